@@ -10,15 +10,17 @@ import (
 	"syscall"
 
 	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/health"
 	"github.com/itcaat/teamcity-mcp/internal/logging"
 	"github.com/itcaat/teamcity-mcp/internal/metrics"
 	"github.com/itcaat/teamcity-mcp/internal/server"
 )
 
 var (
-	transport   = flag.String("transport", "http", "Transport mode: http or stdio")
-	versionFlag = flag.Bool("version", false, "Show version information")
-	envHelp     = flag.Bool("help", false, "Show environment variable help")
+	transport    = flag.String("transport", "http", "Transport mode: http, sse or stdio")
+	stdioFraming = flag.String("stdio-framing", "newline", "STDIO message framing: newline or content-length")
+	versionFlag  = flag.Bool("version", false, "Show version information")
+	envHelp      = flag.Bool("help", false, "Show environment variable help")
 
 	// Build-time variables set by GoReleaser
 	version = "dev"
@@ -75,10 +77,13 @@ func main() {
 	metrics.Init()
 
 	// Create server
-	srv, err := server.New(cfg, logger)
+	srv, err := server.New(cfg, logger, health.BuildInfo{Version: version, Commit: commit})
 	if err != nil {
 		logger.Fatal("Failed to create server", "error", err)
 	}
+	if err := srv.SetSTDIOFraming(*stdioFraming); err != nil {
+		logger.Fatalw("Invalid STDIO framing", "error", err)
+	}
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
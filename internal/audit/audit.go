@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Statuses of audited tool invocations
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+	StatusDenied  = "denied"
+	// StatusConfirmationRequired is a destructive call that returned a confirmation token
+	StatusConfirmationRequired = "confirmation_required"
+)
+
+// Event is the audit record of a tool invocation
+type Event struct {
+	Time time.Time `json:"time"`
+	// Subject is the inbound client identity, e.g. a secret name, JWT user or certificate subject
+	Subject    string `json:"subject"`
+	AuthMethod string `json:"authMethod,omitempty"`
+	Tool       string `json:"tool"`
+	// Arguments are the tool arguments with secrets redacted
+	Arguments interface{} `json:"arguments,omitempty"`
+	Status    string      `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	// Entities are the TeamCity entities the invocation names, e.g. buildType:App_Build or build:123
+	Entities   []string `json:"entities,omitempty"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// Config configures the audit log destination
+type Config struct {
+	// Destination is a file path, "syslog" for the local syslog daemon, or syslog://host:port
+	// (UDP) or syslog+tcp://host:port for a remote one
+	Destination string
+	// MaxSize is the size in bytes at which a log file is rotated; 0 disables rotation
+	MaxSize int64
+	// MaxBackups is the number of rotated log files kept
+	MaxBackups int
+}
+
+// Logger writes audit events as JSON lines
+type Logger struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// Open opens the configured audit log destination
+func Open(cfg Config) (*Logger, error) {
+	var w io.WriteCloser
+	var err error
+	switch {
+	case cfg.Destination == "syslog":
+		w, err = dialSyslog("", "")
+	case strings.HasPrefix(cfg.Destination, "syslog://"):
+		w, err = dialSyslog("udp", strings.TrimPrefix(cfg.Destination, "syslog://"))
+	case strings.HasPrefix(cfg.Destination, "syslog+tcp://"):
+		w, err = dialSyslog("tcp", strings.TrimPrefix(cfg.Destination, "syslog+tcp://"))
+	default:
+		w, err = openRotatingFile(cfg.Destination, cfg.MaxSize, cfg.MaxBackups)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log %s: %w", cfg.Destination, err)
+	}
+	return New(w), nil
+}
+
+// New creates a logger writing to w
+func New(w io.WriteCloser) *Logger {
+	return &Logger{w: w}
+}
+
+// Record writes an event
+func (l *Logger) Record(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the destination
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// dialSyslog connects to a syslog daemon; an empty network and address use the local one
+func dialSyslog(network, address string) (io.WriteCloser, error) {
+	return syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, "teamcity-mcp")
+}
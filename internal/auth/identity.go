@@ -0,0 +1,46 @@
+package auth
+
+import "context"
+
+// Authentication methods of an Identity
+const (
+	MethodHMAC = "hmac"
+	MethodJWT  = "jwt"
+	MethodMTLS = "mtls"
+)
+
+// Identity is the authenticated client of an MCP request
+type Identity struct {
+	// Subject names the user or client, e.g. the JWT subject
+	Subject string
+	// Scopes are the scopes granted to the client, if the authentication method carries any
+	Scopes []string
+	// Method is how the client authenticated
+	Method string
+}
+
+// HasScope reports whether the identity was granted scope
+func (id *Identity) HasScope(scope string) bool {
+	for _, s := range id.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity attaches the authenticated client to the context of its requests
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	if identity == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the authenticated client of a request, or nil if authentication is disabled
+func IdentityFrom(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
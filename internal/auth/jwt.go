@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // hashes used by the supported signature algorithms
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerance applied to the exp and nbf claims
+const clockSkew = time.Minute
+
+// JWTConfig configures JWT bearer token validation
+type JWTConfig struct {
+	// JWKSURL publishes the keys tokens are signed with
+	JWKSURL string
+	// Issuer is the required iss claim; empty accepts any issuer
+	Issuer string
+	// Audience must be one of the aud claim values; empty accepts any audience
+	Audience string
+	// UserClaim names the claim identifying the user (default: sub)
+	UserClaim string
+}
+
+// JWTValidator validates JWT bearer tokens signed with the keys of a JWKS URL
+type JWTValidator struct {
+	cfg  JWTConfig
+	keys *jwks
+	now  func() time.Time
+}
+
+// NewJWTValidator creates a validator; client is used to fetch the JWKS
+func NewJWTValidator(cfg JWTConfig, client *http.Client) (*JWTValidator, error) {
+	if cfg.JWKSURL == "" {
+		return nil, errors.New("JWKS URL is required")
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	return &JWTValidator{
+		cfg:  cfg,
+		keys: &jwks{url: cfg.JWKSURL, client: client},
+		now:  time.Now,
+	}, nil
+}
+
+// LooksLikeJWT reports whether a bearer token has the three-part JWS compact form
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Validate verifies a token's signature and claims and returns the identity it carries
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("token algorithm %s does not match key algorithm %s", header.Alg, key.alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid token signature encoding")
+	}
+	if err := verifySignature(header.Alg, key.key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	return v.identity(claims)
+}
+
+// identity checks the registered claims and extracts the user and scopes
+func (v *JWTValidator) identity(claims map[string]interface{}) (*Identity, error) {
+	now := v.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return nil, errors.New("token has no expiry")
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return nil, errors.New("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(nbf) {
+		return nil, errors.New("token not valid yet")
+	}
+
+	if v.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+			return nil, fmt.Errorf("unexpected token issuer %q", iss)
+		}
+	}
+	if v.cfg.Audience != "" && !containsString(claims["aud"], v.cfg.Audience) {
+		return nil, errors.New("token not issued for this audience")
+	}
+
+	subject, _ := claims[v.cfg.UserClaim].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("token has no %s claim", v.cfg.UserClaim)
+	}
+
+	return &Identity{
+		Subject: subject,
+		Scopes:  scopes(claims),
+		Method:  MethodJWT,
+	}, nil
+}
+
+// verifySignature verifies a JWS signature with the algorithms used by identity providers.
+// Symmetric algorithms and "none" are rejected.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("token algorithm does not match key type")
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case strings.HasPrefix(alg, "PS"):
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("token algorithm does not match key type")
+		}
+		if err := rsa.VerifyPSS(publicKey, hash, digest, signature, nil); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case strings.HasPrefix(alg, "ES"):
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("token algorithm does not match key type")
+		}
+		// ES signatures are the fixed-size concatenation of r and s
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON token segment
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericClaim returns a NumericDate claim such as exp as a time
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// containsString reports whether a string or string array claim contains value
+func containsString(claim interface{}, value string) bool {
+	switch claim := claim.(type) {
+	case string:
+		return claim == value
+	case []interface{}:
+		for _, v := range claim {
+			if s, ok := v.(string); ok && s == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopes returns the scopes of the space-separated scope claim or the scp claim
+func scopes(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []interface{}:
+		result := make([]string, 0, len(scp))
+		for _, v := range scp {
+			if s, ok := v.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
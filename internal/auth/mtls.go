@@ -0,0 +1,15 @@
+package auth
+
+import "crypto/tls"
+
+// CertificateIdentity returns the identity of a connection's verified client certificate,
+// or nil if the client presented none
+func CertificateIdentity(state *tls.ConnectionState) *Identity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return &Identity{
+		Subject: state.VerifiedChains[0][0].Subject.String(),
+		Method:  MethodMTLS,
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Anonymous is the subject policy rules use for requests without an authenticated client,
+// e.g. over STDIO or with authentication disabled
+const Anonymous = "anonymous"
+
+// Policy maps client identities to the tools and TeamCity projects they may use
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule grants tools and projects to a set of subjects
+type PolicyRule struct {
+	// Subjects are the identity subjects the rule applies to; "*" matches every client
+	Subjects []string `json:"subjects"`
+	// Tools are the allowed tool names; omitted or "*" allows all tools
+	Tools []string `json:"tools"`
+	// Projects are the allowed project IDs, including their Parent_Child subprojects; omitted or
+	// "*" allows all projects
+	Projects []string `json:"projects"`
+}
+
+// Grant is what a policy allows a client
+type Grant struct {
+	Subject  string
+	tools    []string
+	projects []string
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+	for i, rule := range policy.Rules {
+		if len(rule.Subjects) == 0 {
+			return nil, fmt.Errorf("policy rule %d has no subjects", i+1)
+		}
+	}
+	return &policy, nil
+}
+
+// Grant returns what the first rule matching the identity allows; a client no rule matches
+// is allowed nothing. A nil identity is matched as Anonymous.
+func (p *Policy) Grant(identity *Identity) *Grant {
+	subject := Anonymous
+	if identity != nil {
+		subject = identity.Subject
+	}
+
+	for _, rule := range p.Rules {
+		if contains(rule.Subjects, subject) || contains(rule.Subjects, "*") {
+			return &Grant{Subject: subject, tools: wildcard(rule.Tools), projects: wildcard(rule.Projects)}
+		}
+	}
+	return &Grant{Subject: subject, tools: []string{}, projects: []string{}}
+}
+
+// AllowsTool reports whether the grant includes a tool
+func (g *Grant) AllowsTool(name string) bool {
+	return g.tools == nil || contains(g.tools, name)
+}
+
+// ProjectScoped reports whether the grant is restricted to some projects
+func (g *Grant) ProjectScoped() bool {
+	return g.projects != nil
+}
+
+// AllowsProject reports whether a project ID is a granted project or, following TeamCity's
+// Parent_Child ID convention, one of its subprojects; Payments allows Payments_Backend but not
+// PaymentsLegacy
+func (g *Grant) AllowsProject(projectID string) bool {
+	if g.projects == nil {
+		return true
+	}
+	for _, project := range g.projects {
+		if projectID == project || strings.HasPrefix(projectID, project+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard returns nil, meaning everything, for an omitted list or one containing "*"
+func wildcard(values []string) []string {
+	if values == nil || contains(values, "*") {
+		return nil
+	}
+	return values
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,16 +12,35 @@ import (
 
 // Cache provides in-memory caching with TTL
 type Cache struct {
-	data map[string]*cacheItem
-	ttl  time.Duration
-	mu   sync.RWMutex
+	data  map[string]*cacheItem
+	stats map[string]*Stats
+	ttl   time.Duration
+	mu    sync.RWMutex
 }
 
 type cacheItem struct {
 	value      interface{}
+	size       int
 	expiration time.Time
 }
 
+// Stats describes the cache usage of one resource type
+type Stats struct {
+	Entries   int
+	Bytes     int
+	Hits      int
+	Misses    int
+	Evictions int
+}
+
+// HitRatio returns the share of lookups that were served from the cache
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
 // New creates a new cache instance
 func New(cfg config.CacheConfig) (*Cache, error) {
 	ttl, err := time.ParseDuration(cfg.TTL)
@@ -28,8 +49,9 @@ func New(cfg config.CacheConfig) (*Cache, error) {
 	}
 
 	cache := &Cache{
-		data: make(map[string]*cacheItem),
-		ttl:  ttl,
+		data:  make(map[string]*cacheItem),
+		stats: make(map[string]*Stats),
+		ttl:   ttl,
 	}
 
 	// Start cleanup goroutine
@@ -38,23 +60,45 @@ func New(cfg config.CacheConfig) (*Cache, error) {
 	return cache, nil
 }
 
+// ResourceType derives the metrics label of a cache key. Keys are resource URIs such as
+// teamcity://builds/123 (resource type "builds") or "type:id" strings.
+func ResourceType(key string) string {
+	rest, isURI := strings.CutPrefix(key, "teamcity://")
+	if !isURI {
+		if name, _, ok := strings.Cut(key, ":"); ok && name != "" {
+			return name
+		}
+		return "unknown"
+	}
+
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		rest = rest[:i]
+	}
+	if rest == "" {
+		return "unknown"
+	}
+	return rest
+}
+
 // Get retrieves a cached value
 func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	item, exists := c.data[key]
-	if !exists {
-		metrics.RecordCacheMiss("unknown")
-		return nil, false
-	}
+	resourceType := ResourceType(key)
+	stats := c.statsFor(resourceType)
 
-	if time.Now().After(item.expiration) {
-		metrics.RecordCacheMiss("expired")
+	item, exists := c.data[key]
+	if !exists || time.Now().After(item.expiration) {
+		stats.Misses++
+		metrics.RecordCacheMiss(resourceType)
+		metrics.RecordCacheHitRatio(resourceType, stats.HitRatio())
 		return nil, false
 	}
 
-	metrics.RecordCacheHit("hit")
+	stats.Hits++
+	metrics.RecordCacheHit(resourceType)
+	metrics.RecordCacheHitRatio(resourceType, stats.HitRatio())
 	return item.value, true
 }
 
@@ -63,10 +107,20 @@ func (c *Cache) Set(key string, value interface{}) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data[key] = &cacheItem{
+	c.remove(key)
+
+	item := &cacheItem{
 		value:      value,
+		size:       sizeOf(value),
 		expiration: time.Now().Add(c.ttl),
 	}
+	c.data[key] = item
+
+	resourceType := ResourceType(key)
+	stats := c.statsFor(resourceType)
+	stats.Entries++
+	stats.Bytes += item.size
+	metrics.RecordCacheSize(resourceType, stats.Entries, stats.Bytes)
 }
 
 // Delete removes a value from the cache
@@ -74,7 +128,7 @@ func (c *Cache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.data, key)
+	c.remove(key)
 }
 
 // Clear removes all cached values
@@ -83,6 +137,23 @@ func (c *Cache) Clear() {
 	defer c.mu.Unlock()
 
 	c.data = make(map[string]*cacheItem)
+	for resourceType, stats := range c.stats {
+		stats.Entries = 0
+		stats.Bytes = 0
+		metrics.RecordCacheSize(resourceType, 0, 0)
+	}
+}
+
+// Stats returns the cache usage per resource type
+func (c *Cache) Stats() map[string]Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]Stats, len(c.stats))
+	for resourceType, stats := range c.stats {
+		result[resourceType] = *stats
+	}
+	return result
 }
 
 // cleanup removes expired items periodically
@@ -91,13 +162,64 @@ func (c *Cache) cleanup() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		c.mu.Lock()
-		now := time.Now()
-		for key, item := range c.data {
-			if now.After(item.expiration) {
-				delete(c.data, key)
-			}
+		c.evictExpired()
+	}
+}
+
+// evictExpired removes all expired items
+func (c *Cache) evictExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, item := range c.data {
+		if now.After(item.expiration) {
+			c.remove(key)
+
+			resourceType := ResourceType(key)
+			c.statsFor(resourceType).Evictions++
+			metrics.RecordCacheEviction(resourceType)
 		}
-		c.mu.Unlock()
 	}
 }
+
+// remove deletes an item and updates the size statistics; the caller must hold the write lock
+func (c *Cache) remove(key string) {
+	item, exists := c.data[key]
+	if !exists {
+		return
+	}
+	delete(c.data, key)
+
+	resourceType := ResourceType(key)
+	stats := c.statsFor(resourceType)
+	stats.Entries--
+	stats.Bytes -= item.size
+	metrics.RecordCacheSize(resourceType, stats.Entries, stats.Bytes)
+}
+
+// statsFor returns the statistics of a resource type; the caller must hold the write lock
+func (c *Cache) statsFor(resourceType string) *Stats {
+	stats, exists := c.stats[resourceType]
+	if !exists {
+		stats = &Stats{}
+		c.stats[resourceType] = stats
+	}
+	return stats
+}
+
+// sizeOf approximates the memory used by a cached value with the size of its JSON encoding
+func sizeOf(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +22,19 @@ type TeamCityConfig struct {
 	URL     string
 	Token   string
 	Timeout string
+
+	// ArtifactDir is where downloaded artifacts are saved (empty disables saving to disk)
+	ArtifactDir string
+	// ArtifactMaxInlineSize is the maximum artifact size in bytes returned inline to the client
+	ArtifactMaxInlineSize string
+	// MaxConcurrentRequests limits the in-flight requests to TeamCity ("0" disables the limit)
+	MaxConcurrentRequests string
+
+	// Builds resource window: number of builds listed and optional default filters
+	BuildsResourceCount   string
+	BuildsResourceState   string
+	BuildsResourceBranch  string
+	BuildsResourceProject string
 }
 
 // ServerConfig holds server settings
@@ -27,6 +43,59 @@ type ServerConfig struct {
 	TLSCert      string
 	TLSKey       string
 	ServerSecret string
+	// NamedSecrets is SERVER_SECRETS: name:secret[:expiry] entries accepted in addition to ServerSecret
+	NamedSecrets string
+
+	// ClientCA is a PEM bundle of the CAs client certificates are verified against (mutual TLS)
+	ClientCA string
+	// RequireClientCert makes the TLS handshake fail without a valid client certificate
+	RequireClientCert string
+
+	// JWT bearer token validation, enabled by JWTJWKSURL
+	JWTJWKSURL   string
+	JWTIssuer    string
+	JWTAudience  string
+	JWTUserClaim string
+
+	// ConfirmDestructiveTools requires destructive tool calls to be confirmed with a token
+	ConfirmDestructiveTools string
+
+	// PolicyFile is a JSON access policy mapping client identities to allowed tools and projects
+	PolicyFile string
+
+	// HTTPShutdownTimeout bounds how long in-flight HTTP requests are drained on shutdown
+	HTTPShutdownTimeout string
+	// STDIOShutdownTimeout bounds how long a pending STDIO request is drained on shutdown
+	STDIOShutdownTimeout string
+
+	// HTTP server timeouts guarding against slow clients; "0" disables a timeout
+	ReadHeaderTimeout string
+	ReadTimeout       string
+	WriteTimeout      string
+	IdleTimeout       string
+
+	// MaxRequestSize is the maximum size in bytes of an HTTP request body or STDIO message
+	MaxRequestSize string
+
+	// SubscriptionPollInterval is how often subscribed resources are checked for changes
+	SubscriptionPollInterval string
+
+	// HTTPSessionIdleTimeout is how long an HTTP session (Mcp-Session-Id) is kept without requests
+	HTTPSessionIdleTimeout string
+
+	// Capabilities is a comma-separated list of the MCP capabilities advertised to clients
+	Capabilities string
+
+	// WebSocket connection lifecycle; "0" disables pings or the idle timeout
+	WSPingInterval   string
+	WSIdleTimeout    string
+	WSMaxMessageSize string
+
+	// AuditLog is the audit log destination: a file path, syslog, syslog://host:port or
+	// syslog+tcp://host:port; auditing is disabled when empty
+	AuditLog           string
+	AuditLogMaxSize    string
+	AuditLogMaxBackups string
 }
 
 // LoggingConfig holds logging settings
@@ -45,10 +114,31 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		// Default values
 		TeamCity: TeamCityConfig{
-			Timeout: getEnvOrDefault("TC_TIMEOUT", "30s"),
+			Timeout:               getEnvOrDefault("TC_TIMEOUT", "30s"),
+			ArtifactMaxInlineSize: getEnvOrDefault("ARTIFACT_MAX_INLINE_SIZE", "1048576"),
+			MaxConcurrentRequests: getEnvOrDefault("TC_MAX_CONCURRENT_REQUESTS", "10"),
+			BuildsResourceCount:   getEnvOrDefault("BUILDS_RESOURCE_COUNT", "100"),
+			BuildsResourceState:   getEnvOrDefault("BUILDS_RESOURCE_STATE", "finished"),
 		},
 		Server: ServerConfig{
-			ListenAddr: getEnvOrDefault("LISTEN_ADDR", ":8123"),
+			ListenAddr:               getEnvOrDefault("LISTEN_ADDR", ":8123"),
+			HTTPShutdownTimeout:      getEnvOrDefault("HTTP_SHUTDOWN_TIMEOUT", "30s"),
+			STDIOShutdownTimeout:     getEnvOrDefault("STDIO_SHUTDOWN_TIMEOUT", "10s"),
+			ReadHeaderTimeout:        getEnvOrDefault("HTTP_READ_HEADER_TIMEOUT", "10s"),
+			ReadTimeout:              getEnvOrDefault("HTTP_READ_TIMEOUT", "60s"),
+			WriteTimeout:             getEnvOrDefault("HTTP_WRITE_TIMEOUT", "5m"),
+			IdleTimeout:              getEnvOrDefault("HTTP_IDLE_TIMEOUT", "120s"),
+			MaxRequestSize:           getEnvOrDefault("MAX_REQUEST_SIZE", "1048576"),
+			SubscriptionPollInterval: getEnvOrDefault("SUBSCRIPTION_POLL_INTERVAL", "15s"),
+			HTTPSessionIdleTimeout:   getEnvOrDefault("HTTP_SESSION_IDLE_TIMEOUT", "30m"),
+			Capabilities:             getEnvOrDefault("MCP_CAPABILITIES", "resources,tools,prompts,logging"),
+			JWTUserClaim:             getEnvOrDefault("JWT_USER_CLAIM", "sub"),
+			RequireClientCert:        getEnvOrDefault("REQUIRE_CLIENT_CERT", "false"),
+			WSPingInterval:           getEnvOrDefault("WS_PING_INTERVAL", "30s"),
+			WSIdleTimeout:            getEnvOrDefault("WS_IDLE_TIMEOUT", "90s"),
+			AuditLogMaxSize:          getEnvOrDefault("AUDIT_LOG_MAX_SIZE", "104857600"),
+			AuditLogMaxBackups:       getEnvOrDefault("AUDIT_LOG_MAX_BACKUPS", "5"),
+			ConfirmDestructiveTools:  getEnvOrDefault("CONFIRM_DESTRUCTIVE_TOOLS", "false"),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnvOrDefault("LOG_LEVEL", "info"),
@@ -81,11 +171,24 @@ func loadFromEnv(cfg *Config) {
 	// TeamCity configuration
 	cfg.TeamCity.URL = os.Getenv("TC_URL")
 	cfg.TeamCity.Token = os.Getenv("TC_TOKEN")
+	cfg.TeamCity.ArtifactDir = os.Getenv("ARTIFACT_DIR")
+	cfg.TeamCity.BuildsResourceBranch = os.Getenv("BUILDS_RESOURCE_BRANCH")
+	cfg.TeamCity.BuildsResourceProject = os.Getenv("BUILDS_RESOURCE_PROJECT")
 
 	// Server configuration
 	cfg.Server.TLSCert = os.Getenv("TLS_CERT")
 	cfg.Server.TLSKey = os.Getenv("TLS_KEY")
+	cfg.Server.ClientCA = os.Getenv("CLIENT_CA")
 	cfg.Server.ServerSecret = os.Getenv("SERVER_SECRET")
+	cfg.Server.NamedSecrets = os.Getenv("SERVER_SECRETS")
+	cfg.Server.JWTJWKSURL = os.Getenv("JWT_JWKS_URL")
+	cfg.Server.JWTIssuer = os.Getenv("JWT_ISSUER")
+	cfg.Server.JWTAudience = os.Getenv("JWT_AUDIENCE")
+	cfg.Server.PolicyFile = os.Getenv("RBAC_POLICY_FILE")
+	cfg.Server.AuditLog = os.Getenv("AUDIT_LOG")
+
+	// WebSocket messages are limited like HTTP request bodies unless configured separately
+	cfg.Server.WSMaxMessageSize = getEnvOrDefault("WS_MAX_MESSAGE_SIZE", cfg.Server.MaxRequestSize)
 }
 
 func validate(cfg *Config) error {
@@ -104,6 +207,127 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("invalid TC_TIMEOUT format: %w", err)
 	}
 
+	// Validate artifact inline size
+	if size, err := strconv.ParseInt(cfg.TeamCity.ArtifactMaxInlineSize, 10, 64); err != nil || size <= 0 {
+		return fmt.Errorf("invalid ARTIFACT_MAX_INLINE_SIZE: must be a positive number of bytes")
+	}
+
+	// Validate TeamCity concurrency limit
+	if limit, err := strconv.Atoi(cfg.TeamCity.MaxConcurrentRequests); err != nil || limit < 0 {
+		return fmt.Errorf("invalid TC_MAX_CONCURRENT_REQUESTS: must be a non-negative number")
+	}
+
+	// Validate builds resource window
+	if count, err := strconv.Atoi(cfg.TeamCity.BuildsResourceCount); err != nil || count <= 0 {
+		return fmt.Errorf("invalid BUILDS_RESOURCE_COUNT: must be a positive number")
+	}
+	switch cfg.TeamCity.BuildsResourceState {
+	case "finished", "running", "queued", "any":
+	default:
+		return fmt.Errorf("invalid BUILDS_RESOURCE_STATE: must be finished, running, queued or any")
+	}
+
+	// Validate shutdown drain timeouts
+	if _, err := time.ParseDuration(cfg.Server.HTTPShutdownTimeout); err != nil {
+		return fmt.Errorf("invalid HTTP_SHUTDOWN_TIMEOUT format: %w", err)
+	}
+	if _, err := time.ParseDuration(cfg.Server.STDIOShutdownTimeout); err != nil {
+		return fmt.Errorf("invalid STDIO_SHUTDOWN_TIMEOUT format: %w", err)
+	}
+
+	// Validate HTTP server timeouts
+	httpTimeouts := []struct{ name, value string }{
+		{"HTTP_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", cfg.Server.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", cfg.Server.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", cfg.Server.IdleTimeout},
+	}
+	for _, t := range httpTimeouts {
+		if timeout, err := time.ParseDuration(t.value); err != nil || timeout < 0 {
+			return fmt.Errorf("invalid %s format: must be a non-negative duration", t.name)
+		}
+	}
+
+	// Validate request size limit
+	if size, err := strconv.ParseInt(cfg.Server.MaxRequestSize, 10, 64); err != nil || size <= 0 {
+		return fmt.Errorf("invalid MAX_REQUEST_SIZE: must be a positive number of bytes")
+	}
+
+	// Validate subscription poll interval
+	if interval, err := time.ParseDuration(cfg.Server.SubscriptionPollInterval); err != nil || interval <= 0 {
+		return fmt.Errorf("invalid SUBSCRIPTION_POLL_INTERVAL format: must be a positive duration")
+	}
+
+	// Validate HTTP session idle timeout
+	if timeout, err := time.ParseDuration(cfg.Server.HTTPSessionIdleTimeout); err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid HTTP_SESSION_IDLE_TIMEOUT format: must be a positive duration")
+	}
+
+	// Validate named server secrets
+	if _, err := cfg.Server.ServerSecrets(); err != nil {
+		return fmt.Errorf("invalid SERVER_SECRETS: %w", err)
+	}
+
+	// Validate mutual TLS settings
+	requireClientCert, err := strconv.ParseBool(cfg.Server.RequireClientCert)
+	if err != nil {
+		return fmt.Errorf("invalid REQUIRE_CLIENT_CERT: must be true or false")
+	}
+	if cfg.Server.ClientCA != "" && (cfg.Server.TLSCert == "" || cfg.Server.TLSKey == "") {
+		return fmt.Errorf("CLIENT_CA requires TLS_CERT and TLS_KEY")
+	}
+	if requireClientCert && cfg.Server.ClientCA == "" {
+		return fmt.Errorf("REQUIRE_CLIENT_CERT requires CLIENT_CA")
+	}
+
+	// Validate JWT settings
+	if cfg.Server.JWTJWKSURL != "" {
+		if u, err := url.Parse(cfg.Server.JWTJWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid JWT_JWKS_URL: must be an http or https URL")
+		}
+	} else if cfg.Server.JWTIssuer != "" || cfg.Server.JWTAudience != "" {
+		return fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE require JWT_JWKS_URL")
+	}
+
+	// Validate audit log rotation
+	if size, err := strconv.ParseInt(cfg.Server.AuditLogMaxSize, 10, 64); err != nil || size < 0 {
+		return fmt.Errorf("invalid AUDIT_LOG_MAX_SIZE: must be a number of bytes (0 disables rotation)")
+	}
+	if backups, err := strconv.Atoi(cfg.Server.AuditLogMaxBackups); err != nil || backups < 0 {
+		return fmt.Errorf("invalid AUDIT_LOG_MAX_BACKUPS: must be a non-negative number")
+	}
+
+	// Validate destructive tool confirmation
+	if _, err := strconv.ParseBool(cfg.Server.ConfirmDestructiveTools); err != nil {
+		return fmt.Errorf("invalid CONFIRM_DESTRUCTIVE_TOOLS: must be true or false")
+	}
+
+	// Validate advertised capabilities
+	for _, capability := range strings.Split(cfg.Server.Capabilities, ",") {
+		switch strings.TrimSpace(capability) {
+		case "resources", "tools", "prompts", "logging", "":
+		default:
+			return fmt.Errorf("invalid MCP_CAPABILITIES: unknown capability %q (use resources, tools, prompts or logging)", strings.TrimSpace(capability))
+		}
+	}
+
+	// Validate WebSocket lifecycle settings
+	pingInterval, err := time.ParseDuration(cfg.Server.WSPingInterval)
+	if err != nil || pingInterval < 0 {
+		return fmt.Errorf("invalid WS_PING_INTERVAL format: must be a non-negative duration")
+	}
+	idleTimeout, err := time.ParseDuration(cfg.Server.WSIdleTimeout)
+	if err != nil || idleTimeout < 0 {
+		return fmt.Errorf("invalid WS_IDLE_TIMEOUT format: must be a non-negative duration")
+	}
+	if pingInterval > 0 && idleTimeout > 0 && idleTimeout <= pingInterval {
+		// Pongs answering the keepalive pings are what keeps a quiet connection open
+		return fmt.Errorf("invalid WS_IDLE_TIMEOUT: must be longer than WS_PING_INTERVAL")
+	}
+	if size, err := strconv.ParseInt(cfg.Server.WSMaxMessageSize, 10, 64); err != nil || size <= 0 {
+		return fmt.Errorf("invalid WS_MAX_MESSAGE_SIZE: must be a positive number of bytes")
+	}
+
 	// Validate cache TTL format
 	if _, err := time.ParseDuration(cfg.Cache.TTL); err != nil {
 		return fmt.Errorf("invalid CACHE_TTL format: %w", err)
@@ -123,14 +347,46 @@ func PrintEnvHelp() {
 	fmt.Println("  TC_TOKEN        TeamCity API token")
 	fmt.Println()
 	fmt.Println("Optional:")
-	fmt.Println("  SERVER_SECRET   Server secret for HMAC token validation (if not set, auth is disabled)")
+	fmt.Println("  SERVER_SECRET   Server secret for HMAC token validation (if no secret or JWT_JWKS_URL is set, auth is disabled)")
+	fmt.Println("  SERVER_SECRETS  Named secrets accepted as well, e.g. ci:secret1,alice:secret2:2026-12-31 (expiry optional)")
+	fmt.Println("  JWT_JWKS_URL    JWKS URL of the keys JWT bearer tokens are signed with; enables JWT validation")
+	fmt.Println("  JWT_ISSUER      Required iss claim of JWT bearer tokens (default: any)")
+	fmt.Println("  JWT_AUDIENCE    Required aud claim of JWT bearer tokens (default: any)")
+	fmt.Println("  JWT_USER_CLAIM  JWT claim identifying the user (default: sub)")
+	fmt.Println("  RBAC_POLICY_FILE  JSON policy restricting each client identity to tools and project ID prefixes")
 	fmt.Println("  LISTEN_ADDR     Address to listen on (default: :8123)")
 	fmt.Println("  TC_TIMEOUT      HTTP timeout for TeamCity API calls (default: 30s)")
+	fmt.Println("  TC_MAX_CONCURRENT_REQUESTS  Maximum concurrent requests to TeamCity (default: 10, 0 disables)")
 	fmt.Println("  TLS_CERT        Path to TLS certificate file")
 	fmt.Println("  TLS_KEY         Path to TLS private key file")
+	fmt.Println("  CLIENT_CA       PEM bundle of CAs that client certificates are verified against (mutual TLS)")
+	fmt.Println("  REQUIRE_CLIENT_CERT  Reject TLS connections without a valid client certificate (default: false)")
 	fmt.Println("  LOG_LEVEL       Log level: debug, info, warn, error (default: info)")
 	fmt.Println("  LOG_FORMAT      Log format: json, console (default: json)")
 	fmt.Println("  CACHE_TTL       Cache TTL for TeamCity API responses (default: 10s)")
+	fmt.Println("  ARTIFACT_DIR    Directory where downloaded artifacts are saved (default: disabled)")
+	fmt.Println("  ARTIFACT_MAX_INLINE_SIZE  Maximum artifact size in bytes returned inline (default: 1048576)")
+	fmt.Println("  BUILDS_RESOURCE_COUNT     Number of builds listed by the builds resource (default: 100)")
+	fmt.Println("  BUILDS_RESOURCE_STATE     Build state listed: finished, running, queued or any (default: finished)")
+	fmt.Println("  BUILDS_RESOURCE_BRANCH    Branch locator, e.g. main or default:any (default: default branch only)")
+	fmt.Println("  BUILDS_RESOURCE_PROJECT   Only list builds of this project and its subprojects (default: all)")
+	fmt.Println("  HTTP_SHUTDOWN_TIMEOUT     Time to drain in-flight HTTP requests on shutdown (default: 30s)")
+	fmt.Println("  STDIO_SHUTDOWN_TIMEOUT    Time to finish a pending STDIO request on shutdown (default: 10s)")
+	fmt.Println("  HTTP_READ_HEADER_TIMEOUT  Time allowed to read HTTP request headers (default: 10s, 0 disables)")
+	fmt.Println("  HTTP_READ_TIMEOUT         Time allowed to read an entire HTTP request (default: 60s, 0 disables)")
+	fmt.Println("  HTTP_WRITE_TIMEOUT        Time allowed to handle a request and write the response (default: 5m, 0 disables)")
+	fmt.Println("  HTTP_IDLE_TIMEOUT         Time an idle keep-alive connection is kept open (default: 120s, 0 disables)")
+	fmt.Println("  MAX_REQUEST_SIZE          Maximum size in bytes of an HTTP request body (default: 1048576)")
+	fmt.Println("  SUBSCRIPTION_POLL_INTERVAL  How often subscribed resources are checked for changes (default: 15s)")
+	fmt.Println("  HTTP_SESSION_IDLE_TIMEOUT   How long an idle HTTP session (Mcp-Session-Id) is kept (default: 30m)")
+	fmt.Println("  MCP_CAPABILITIES          Advertised capabilities: resources, tools, prompts, logging (default: all)")
+	fmt.Println("  WS_PING_INTERVAL          How often WebSocket keepalive pings are sent (default: 30s, 0 disables)")
+	fmt.Println("  WS_IDLE_TIMEOUT           Close WebSocket connections without messages or pongs for this long (default: 90s, 0 disables)")
+	fmt.Println("  WS_MAX_MESSAGE_SIZE       Maximum size in bytes of a WebSocket message (default: MAX_REQUEST_SIZE)")
+	fmt.Println("  CONFIRM_DESTRUCTIVE_TOOLS Require destructive tool calls to be confirmed with a returned token (default: false)")
+	fmt.Println("  AUDIT_LOG                 Audit log of tool invocations: file path, syslog, syslog://host:port or syslog+tcp://host:port")
+	fmt.Println("  AUDIT_LOG_MAX_SIZE        Size in bytes at which the audit log file is rotated (default: 104857600, 0 disables)")
+	fmt.Println("  AUDIT_LOG_MAX_BACKUPS     Number of rotated audit log files kept (default: 5)")
 	fmt.Println()
 	fmt.Println("Example:")
 	fmt.Println("  export TC_URL=https://your-teamcity-server.com")
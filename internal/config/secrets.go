@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NamedSecret is a server secret clients derive their HMAC token from. The name identifies
+// the client in logs; an expired secret is no longer accepted.
+type NamedSecret struct {
+	Name    string
+	Secret  string
+	Expires time.Time
+}
+
+// Expired reports whether the secret has expired at now
+func (s NamedSecret) Expired(now time.Time) bool {
+	return !s.Expires.IsZero() && !now.Before(s.Expires)
+}
+
+// ServerSecrets returns the accepted server secrets: the entries of SERVER_SECRETS and
+// SERVER_SECRET under the name "default"
+func (c ServerConfig) ServerSecrets() ([]NamedSecret, error) {
+	secrets, err := parseNamedSecrets(c.NamedSecrets)
+	if err != nil {
+		return nil, err
+	}
+	if c.ServerSecret != "" {
+		secrets = append(secrets, NamedSecret{Name: "default", Secret: c.ServerSecret})
+	}
+	return secrets, nil
+}
+
+// parseNamedSecrets parses a comma-separated list of name:secret or name:secret:expiry entries.
+// The expiry is a date (2006-01-02, UTC) or an RFC 3339 time.
+func parseNamedSecrets(value string) ([]NamedSecret, error) {
+	secrets := make([]NamedSecret, 0)
+	names := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rest, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || rest == "" {
+			return nil, fmt.Errorf("entry %q must be name:secret or name:secret:expiry", entry)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate secret name %q", name)
+		}
+		names[name] = true
+
+		secret := NamedSecret{Name: name, Secret: rest}
+		if s, expiry, ok := strings.Cut(rest, ":"); ok {
+			expires, err := parseExpiry(expiry)
+			if err != nil {
+				return nil, fmt.Errorf("secret %q: invalid expiry %q: use 2006-01-02 or RFC 3339", name, expiry)
+			}
+			secret.Secret, secret.Expires = s, expires
+		}
+		if secret.Secret == "" {
+			return nil, fmt.Errorf("secret %q is empty", name)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// parseExpiry parses a date or RFC 3339 time
+func parseExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
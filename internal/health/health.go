@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,17 +12,29 @@ import (
 	"github.com/itcaat/teamcity-mcp/internal/teamcity"
 )
 
+// BuildInfo identifies the running MCP server build
+type BuildInfo struct {
+	Version string
+	Commit  string
+}
+
 // Checker provides health check functionality
 type Checker struct {
 	tc     *teamcity.Client
 	logger *zap.SugaredLogger
+	build  BuildInfo
+
+	// teamcityVersion is the TeamCity server version detected by the last readiness check
+	mu              sync.RWMutex
+	teamcityVersion string
 }
 
 // New creates a new health checker
-func New(tc *teamcity.Client, logger *zap.SugaredLogger) *Checker {
+func New(tc *teamcity.Client, logger *zap.SugaredLogger, build BuildInfo) *Checker {
 	return &Checker{
 		tc:     tc,
 		logger: logger,
+		build:  build,
 	}
 }
 
@@ -32,6 +45,13 @@ func (h *Checker) LivenessHandler(w http.ResponseWriter, r *http.Request) {
 		"status":    "ok",
 		"timestamp": time.Now().UTC(),
 		"service":   "teamcity-mcp",
+		"version":   h.build.Version,
+		"commit":    h.build.Commit,
+	}
+
+	// Liveness never calls TeamCity; report the version seen by the last readiness check
+	if version := h.detectedTeamCityVersion(); version != "" {
+		response["teamcityVersion"] = version
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -50,23 +70,35 @@ func (h *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
 	checks := make(map[string]interface{})
 
 	// Check TeamCity connectivity
-	if err := h.checkTeamCity(ctx); err != nil {
+	start := time.Now()
+	err := h.checkTeamCity(ctx)
+	latency := time.Since(start)
+
+	if err != nil {
 		status = "error"
 		statusCode = http.StatusServiceUnavailable
 		checks["teamcity"] = map[string]interface{}{
-			"status": "error",
-			"error":  err.Error(),
+			"status":    "error",
+			"error":     err.Error(),
+			"latencyMs": latency.Milliseconds(),
 		}
 	} else {
-		checks["teamcity"] = map[string]interface{}{
-			"status": "ok",
+		teamcityCheck := map[string]interface{}{
+			"status":    "ok",
+			"latencyMs": latency.Milliseconds(),
+		}
+		if version := h.detectTeamCityVersion(ctx); version != "" {
+			teamcityCheck["version"] = version
 		}
+		checks["teamcity"] = teamcityCheck
 	}
 
 	response := map[string]interface{}{
 		"status":    status,
 		"timestamp": time.Now().UTC(),
 		"service":   "teamcity-mcp",
+		"version":   h.build.Version,
+		"commit":    h.build.Commit,
 		"checks":    checks,
 	}
 
@@ -77,7 +109,30 @@ func (h *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
 
 // checkTeamCity verifies TeamCity connectivity
 func (h *Checker) checkTeamCity(ctx context.Context) error {
-	// Try to list projects as a connectivity test
-	_, err := h.tc.ListProjects(ctx)
+	// Try to list a project as a connectivity test
+	_, _, err := h.tc.ListProjects(ctx, teamcity.Page{Count: 1})
 	return err
 }
+
+// detectTeamCityVersion fetches the TeamCity server version, falling back to the last detected one
+func (h *Checker) detectTeamCityVersion(ctx context.Context) string {
+	version, err := h.tc.GetServerVersion(ctx)
+	if err != nil {
+		// The version is informational and must not fail the readiness check
+		h.logger.Warn("Failed to detect TeamCity version", "error", err)
+		return h.detectedTeamCityVersion()
+	}
+
+	h.mu.Lock()
+	h.teamcityVersion = version
+	h.mu.Unlock()
+
+	return version
+}
+
+// detectedTeamCityVersion returns the TeamCity server version detected by the last readiness check
+func (h *Checker) detectedTeamCityVersion() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.teamcityVersion
+}
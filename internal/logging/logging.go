@@ -1,6 +1,8 @@
 package logging
 
 import (
+	"context"
+
 	"github.com/itcaat/teamcity-mcp/internal/config"
 
 	"go.uber.org/zap"
@@ -49,3 +51,19 @@ func WithRequestID(logger *zap.SugaredLogger, requestID string) *zap.SugaredLogg
 func WithTraceID(logger *zap.SugaredLogger, traceID, spanID string) *zap.SugaredLogger {
 	return logger.With("trace_id", traceID, "span_id", spanID)
 }
+
+type loggerKey struct{}
+
+// WithLogger attaches a request-scoped logger to the context, e.g. one that also forwards
+// log messages to the MCP client of the request
+func WithLogger(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger of the context, or fallback if there is none
+func FromContext(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces sensitive values in logs
+const redactedValue = "[REDACTED]"
+
+// maskedValue replaces sensitive values in tool and resource output
+const maskedValue = "*****"
+
+// sensitiveKeys are substrings of argument, parameter and property names whose values must never be shown
+var sensitiveKeys = []string{"token", "password", "passwd", "secret", "credential", "apikey", "api_key", "authorization", "privatekey", "private_key"}
+
+// isSensitiveKey reports whether an argument, parameter or property name refers to a secret
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	if strings.HasPrefix(lower, "secure:") {
+		return true
+	}
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitiveValue reports whether a value looks like a TeamCity secret reference
+func isSensitiveValue(value string) bool {
+	return strings.HasPrefix(value, "credentialsJSON:") || strings.HasPrefix(value, "zxx")
+}
+
+// secretPatterns match values that are secrets wherever they appear: private keys, cloud and
+// VCS hosting tokens, JWTs (including TeamCity access tokens) and TeamCity secret references
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`\bcredentialsJSON:[0-9A-Za-z-]+`),
+	regexp.MustCompile(`\bzxx[0-9a-f]{8,}\b`),
+}
+
+// secretAssignments match a secret following a prefix that is kept, e.g. the credentials of
+// an Authorization header, of a URL or of a "db.password = ..." line
+var secretAssignments = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(\b(?:proxy-)?authorization\s*[:=]\s*(?:bearer|basic|token)\s+)[^\s"',]+`),
+	regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+(@)`),
+	regexp.MustCompile(`(?i)([\w.-]*(?:password|passwd|secret|token|api[_-]?key)[\w.-]*\s*[=:]\s*)[^\s"',]+`),
+}
+
+// RedactArguments decodes tool arguments for structured logging, replacing secrets with [REDACTED].
+// Values are redacted when their key names a secret, or when they belong to a {"name": ..., "value": ...}
+// pair whose name does (e.g. a parameter named env.DB_PASSWORD).
+func RedactArguments(args json.RawMessage) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(args, &decoded); err != nil {
+		// Undecodable arguments are not logged verbatim as they may contain anything
+		return redactedValue
+	}
+	return redact(decoded, redactedValue)
+}
+
+// RedactText masks secrets in tool and resource output text
+func RedactText(text string) string {
+	return redactText(text, maskedValue)
+}
+
+// RedactJSON masks secrets in a JSON document, e.g. a TeamCity REST response. Text that is not
+// valid JSON is redacted as plain text.
+func RedactJSON(text string) string {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return RedactText(text)
+	}
+
+	data, err := marshalUnescaped(redact(value, maskedValue))
+	if err != nil {
+		return RedactText(text)
+	}
+	return string(data)
+}
+
+// RedactValue returns a JSON-equivalent copy of v with secrets masked: values of password
+// parameters, of properties and fields with secure names, and secrets in strings
+func RedactValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return v
+	}
+	return redact(value, maskedValue)
+}
+
+// redactText replaces secrets in text with mask
+func redactText(text, mask string) string {
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllLiteralString(text, mask)
+	}
+	for _, pattern := range secretAssignments {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			suffix := ""
+			if len(groups) > 2 {
+				suffix = groups[2]
+			}
+			return groups[1] + mask + suffix
+		})
+	}
+	return text
+}
+
+// redact walks a decoded JSON value and replaces sensitive values with mask
+func redact(value interface{}, mask string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// TeamCity parameters and properties are {"name": ..., "value": ..., "type": {"rawValue": ...}}
+		name, _ := v["name"].(string)
+		pairValue, _ := v["value"].(string)
+		secretPair := isSensitiveKey(name) || isSensitiveValue(pairValue) || isPasswordType(v["type"])
+
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if s, isString := item.(string); (!isString || s != "") && (isSensitiveKey(key) || (secretPair && key == "value")) {
+				result[key] = mask
+				continue
+			}
+			result[key] = redact(item, mask)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redact(item, mask)
+		}
+		return result
+	case string:
+		if isSensitiveValue(v) {
+			return mask
+		}
+		return redactText(v, mask)
+	default:
+		return v
+	}
+}
+
+// isPasswordType reports whether a parameter type ({"rawValue": "password display='hidden'"})
+// is the password type
+func isPasswordType(paramType interface{}) bool {
+	t, ok := paramType.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	raw, _ := t["rawValue"].(string)
+	kind, _, _ := strings.Cut(strings.TrimSpace(raw), " ")
+	return kind == "password"
+}
+
+// marshalUnescaped marshals v without escaping HTML characters, which TeamCity values often contain
+func marshalUnescaped(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
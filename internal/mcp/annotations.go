@@ -0,0 +1,106 @@
+package mcp
+
+// toolAnnotation holds the MCP behavior hints of a tool. Clients use them to auto-approve
+// read-only tools and to ask for confirmation before destructive ones.
+type toolAnnotation struct {
+	// ReadOnly tools do not modify TeamCity
+	ReadOnly bool
+	// Destructive tools may cancel, delete or overwrite something, or start builds that can deploy
+	Destructive bool
+	// Idempotent tools have no additional effect when called again with the same arguments
+	Idempotent bool
+}
+
+// readOnlyTool is the annotation of tools that only read from TeamCity
+var readOnlyTool = toolAnnotation{ReadOnly: true, Idempotent: true}
+
+// toolAnnotations lists the behavior hints of every tool in tools/list
+var toolAnnotations = map[string]toolAnnotation{
+	"trigger_build":                 {Destructive: true},
+	"cancel_build":                  {Destructive: true, Idempotent: true},
+	"pin_build":                     {Idempotent: true},
+	"set_build_tag":                 {Destructive: true, Idempotent: true},
+	"download_artifact":             readOnlyTool,
+	"search_builds":                 readOnlyTool,
+	"fetch_build_log":               readOnlyTool,
+	"search_build_configurations":   readOnlyTool,
+	"get_current_time":              readOnlyTool,
+	"get_test_results":              readOnlyTool,
+	"manage_notification_rules":     {Destructive: true},
+	"run_personal_build":            {Destructive: true},
+	"get_compatible_agents":         readOnlyTool,
+	"get_artifact_size_report":      readOnlyTool,
+	"download_artifact_archive":     readOnlyTool,
+	"get_builds_for_change":         readOnlyTool,
+	"find_build_by_revision":        readOnlyTool,
+	"promote_build":                 {Destructive: true},
+	"get_deployments":               readOnlyTool,
+	"search_tests":                  readOnlyTool,
+	"manage_failure_conditions":     {Destructive: true},
+	"manage_build_settings":         {Destructive: true, Idempotent: true},
+	"set_checkout_rules":            {Destructive: true},
+	"get_resolved_build_steps":      readOnlyTool,
+	"export_settings":               readOnlyTool,
+	"list_project_parameters":       readOnlyTool,
+	"get_build_parameters":          readOnlyTool,
+	"get_build_details":             readOnlyTool,
+	"list_queued_builds":            readOnlyTool,
+	"manage_build_queue":            {Destructive: true},
+	"rerun_build":                   {Destructive: true},
+	"set_build_status":              {Destructive: true, Idempotent: true},
+	"list_build_artifacts":          readOnlyTool,
+	"compare_artifacts":             readOnlyTool,
+	"get_build_dependencies":        readOnlyTool,
+	"get_build_chain":               readOnlyTool,
+	"get_running_build_progress":    readOnlyTool,
+	"get_test_history":              readOnlyTool,
+	"manage_investigations":         {Destructive: true},
+	"compare_test_results":          readOnlyTool,
+	"create_project":                {},
+	"create_build_configuration":    {},
+	"set_build_config_parameter":    {Destructive: true, Idempotent: true},
+	"delete_build_config_parameter": {Destructive: true, Idempotent: true},
+	"pause_build_configuration":     {Destructive: true, Idempotent: true},
+	"manage_dependencies":           {Destructive: true},
+	"manage_build_steps":            {Destructive: true},
+	"manage_project_parameters":     {Destructive: true},
+	"delete_project":                {Destructive: true},
+	"delete_build_configuration":    {Destructive: true},
+	"manage_templates":              {Destructive: true, Idempotent: true},
+	"manage_project_features":       {Destructive: true},
+	"search_projects":               readOnlyTool,
+	"list_vcs_roots":                readOnlyTool,
+	"manage_vcs_roots":              {Destructive: true},
+	"check_vcs_root":                {Idempotent: true},
+	"search_changes":                readOnlyTool,
+	"get_change":                    readOnlyTool,
+	"list_branches":                 readOnlyTool,
+	"get_branch_health":             readOnlyTool,
+	"find_pull_request_builds":      readOnlyTool,
+	"manage_agent":                  {Destructive: true, Idempotent: true},
+	"manage_agent_pools":            {Destructive: true, Idempotent: true},
+	"get_agent_details":             readOnlyTool,
+	"search_agents":                 readOnlyTool,
+}
+
+// isDestructive reports whether a tool is annotated as destructive; unknown tools are treated as such
+func isDestructive(name string) bool {
+	annotation, ok := toolAnnotations[name]
+	return !ok || annotation.Destructive
+}
+
+// annotateTools adds the annotations of each tool to its definition
+func annotateTools(tools []map[string]interface{}) {
+	for _, tool := range tools {
+		annotation, ok := toolAnnotations[tool["name"].(string)]
+		if !ok {
+			// Unknown tools are treated as potentially destructive
+			annotation = toolAnnotation{Destructive: true}
+		}
+		tool["annotations"] = map[string]interface{}{
+			"readOnlyHint":    annotation.ReadOnly,
+			"destructiveHint": annotation.Destructive,
+			"idempotentHint":  annotation.Idempotent,
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/audit"
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+)
+
+// SetAuditLogger records every tool invocation to the audit log; nil disables auditing
+func (h *Handler) SetAuditLogger(logger *audit.Logger) {
+	h.audit = logger
+}
+
+// auditToolCall records a tool invocation with the identity of its client
+func (h *Handler) auditToolCall(ctx context.Context, name string, args json.RawMessage, start time.Time, status string, err error) {
+	if h.audit == nil {
+		return
+	}
+
+	event := audit.Event{
+		Time:       start.UTC(),
+		Subject:    auth.Anonymous,
+		Tool:       name,
+		Arguments:  logging.RedactArguments(args),
+		Status:     status,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if identity := auth.IdentityFrom(ctx); identity != nil {
+		event.Subject, event.AuthMethod = identity.Subject, identity.Method
+	}
+	if err != nil {
+		// Errors may quote TeamCity responses, e.g. a rejected password
+		event.Error = logging.RedactText(err.Error())
+	}
+	// Arguments that do not decode have already failed the call; they name no entities
+	refs, _ := entityReferences(name, args)
+	for _, ref := range refs {
+		event.Entities = append(event.Entities, ref.String())
+	}
+
+	if err := h.audit.Record(event); err != nil {
+		h.logger.Errorw("Failed to write audit event", "tool", name, "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// isBatch reports whether a JSON-RPC message is a batch array
+func isBatch(req json.RawMessage) bool {
+	trimmed := bytes.TrimLeft(req, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles a JSON-RPC batch. Entries are dispatched concurrently and their
+// responses are returned in request order; a batch of only notifications has no response.
+func (h *Handler) handleBatch(ctx context.Context, req json.RawMessage) (interface{}, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(req, &entries); err != nil {
+		return h.errorResponse(nil, -32700, "Parse error", nil), nil
+	}
+	if len(entries) == 0 {
+		return h.errorResponse(nil, -32600, "Invalid Request", "empty batch"), nil
+	}
+
+	// Batching was removed from MCP in 2025-06-18
+	if version := protocolVersionFrom(ctx); version >= "2025-06-18" {
+		return h.errorResponse(nil, -32600, "Invalid Request", fmt.Sprintf("batch requests are not supported in protocol version %s", version)), nil
+	}
+
+	responses := make([]interface{}, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry json.RawMessage) {
+			defer wg.Done()
+			responses[i] = h.handleBatchEntry(ctx, entry)
+		}(i, entry)
+	}
+	wg.Wait()
+
+	batch := make([]interface{}, 0, len(responses))
+	for _, resp := range responses {
+		if resp != nil {
+			batch = append(batch, resp)
+		}
+	}
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	return batch, nil
+}
+
+// handleBatchEntry handles a single request or notification of a batch
+func (h *Handler) handleBatchEntry(ctx context.Context, entry json.RawMessage) interface{} {
+	var base struct {
+		ID     interface{} `json:"id,omitempty"`
+		Method string      `json:"method"`
+	}
+	if isBatch(entry) || json.Unmarshal(entry, &base) != nil {
+		return h.errorResponse(nil, -32600, "Invalid Request", nil)
+	}
+	if base.Method == "initialize" {
+		return h.errorResponse(base.ID, -32600, "Invalid Request", "initialize must not be part of a batch")
+	}
+
+	resp, err := h.HandleRequest(ctx, entry)
+	if err != nil {
+		h.logger.Error("Failed to handle batch entry", "method", base.Method, "error", err)
+		return h.errorResponse(base.ID, -32603, "Internal error", err.Error())
+	}
+	return resp
+}
@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities the server can advertise; operators can hide any of them
+const (
+	capabilityResources = "resources"
+	capabilityTools     = "tools"
+	capabilityPrompts   = "prompts"
+	capabilityLogging   = "logging"
+)
+
+// capabilityNames lists the capability names accepted by SetCapabilities
+var capabilityNames = []string{capabilityResources, capabilityTools, capabilityPrompts, capabilityLogging}
+
+// SetCapabilities restricts the advertised capabilities to names. Methods of capabilities
+// that are not advertised are rejected as unknown.
+func (h *Handler) SetCapabilities(names []string) error {
+	enabled := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !isCapability(name) {
+			return fmt.Errorf("unknown capability: %s", name)
+		}
+		enabled[name] = true
+	}
+	h.capabilities = enabled
+	return nil
+}
+
+// isCapability reports whether name is a known capability
+func isCapability(name string) bool {
+	for _, capability := range capabilityNames {
+		if capability == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled reports whether a capability is advertised; all are unless restricted with SetCapabilities
+func (h *Handler) enabled(capability string) bool {
+	return h.capabilities == nil || h.capabilities[capability]
+}
+
+// methodCapability returns the capability a method belongs to, or "" for methods that are always available
+func methodCapability(method string) string {
+	switch {
+	case strings.HasPrefix(method, "resources/"):
+		return capabilityResources
+	case strings.HasPrefix(method, "tools/"):
+		return capabilityTools
+	case strings.HasPrefix(method, "prompts/"):
+		return capabilityPrompts
+	case strings.HasPrefix(method, "logging/"):
+		return capabilityLogging
+	default:
+		return ""
+	}
+}
+
+// serverCapabilities returns the capabilities advertised in the initialize result
+func (h *Handler) serverCapabilities() map[string]interface{} {
+	capabilities := make(map[string]interface{})
+	if h.enabled(capabilityResources) {
+		capabilities[capabilityResources] = map[string]interface{}{
+			"subscribe":   true,
+			"listChanged": false,
+		}
+	}
+	if h.enabled(capabilityTools) {
+		capabilities[capabilityTools] = map[string]interface{}{}
+	}
+	if h.enabled(capabilityPrompts) {
+		capabilities[capabilityPrompts] = map[string]interface{}{
+			"listChanged": false,
+		}
+	}
+	if h.enabled(capabilityLogging) {
+		capabilities[capabilityLogging] = map[string]interface{}{}
+	}
+	return capabilities
+}
+
+// instructions describes how to use the server in the initialize result. Only the advertised
+// capabilities are described.
+func (h *Handler) instructions() string {
+	sections := []string{
+		"This server connects to a TeamCity instance. Identifiers follow TeamCity conventions: " +
+			"build IDs are numeric internal IDs (not the build number shown in the UI), while project " +
+			"and build configuration IDs are strings such as MyProject_Build.",
+	}
+
+	if h.enabled(capabilityTools) {
+		sections = append(sections,
+			"Use search_build_configurations to discover build configuration IDs and search_builds to find "+
+				"build IDs before calling tools that need them. search_builds filters are combined into a "+
+				"TeamCity locator; branch accepts locator values such as default:any to include all branches, "+
+				"and dates use the YYYYMMDDTHHMMSS+HHMM format. Use get_current_time for the current date "+
+				"instead of assuming one.",
+			"Search tools return at most count results (100 by default); narrow the filters or raise count "+
+				"rather than expecting everything in one call. Tools annotated as destructive change TeamCity "+
+				"state and should be confirmed with the user first.")
+	}
+
+	if h.enabled(capabilityResources) {
+		sections = append(sections,
+			"Resources use teamcity:// URIs, e.g. teamcity://projects, teamcity://buildTypes and "+
+				"teamcity://builds. resources/list returns at most 100 resources per response; pass the "+
+				"returned nextCursor back as cursor to get the next page.")
+	}
+
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+// confirmationTTL is how long a confirmation token can be used to execute a tool call
+const confirmationTTL = 5 * time.Minute
+
+// confirmationTokenArgument is the tool argument a confirmation token is echoed back in
+const confirmationTokenArgument = "confirmationToken"
+
+// dryRunTools support the dryRun argument; their dry run is the preview of a confirmation request
+var dryRunTools = map[string]bool{
+	"trigger_build":              true,
+	"cancel_build":               true,
+	"manage_build_queue":         true,
+	"rerun_build":                true,
+	"delete_project":             true,
+	"delete_build_configuration": true,
+}
+
+// readActions are the actions of destructive tools that change nothing, by tool; "" is the
+// action taken when none is given
+var readActions = map[string]map[string]bool{
+	"manage_failure_conditions": {"": true, "list": true},
+	"manage_build_settings":     {"": true, "get": true},
+	"manage_investigations":     {"": true, "list": true},
+	"manage_dependencies":       {"": true, "list": true},
+	"manage_build_steps":        {"": true, "list": true},
+	"manage_project_parameters": {"": true, "get": true},
+	"manage_templates":          {"": true, "list": true},
+	"manage_project_features":   {"": true, "list": true},
+	"manage_notification_rules": {"": true, "list": true},
+	"manage_agent_pools":        {"": true, "list": true},
+}
+
+// pendingConfirmation is a destructive tool call awaiting confirmation
+type pendingConfirmation struct {
+	tool    string
+	digest  string
+	subject string
+	expires time.Time
+}
+
+// confirmations holds the issued confirmation tokens
+type confirmations struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+// issue returns a single-use token confirming a tool call of subject with the given arguments
+func (c *confirmations) issue(tool, digest, subject string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for t, pending := range c.pending {
+		if now.After(pending.expires) {
+			delete(c.pending, t)
+		}
+	}
+	c.pending[token] = pendingConfirmation{tool: tool, digest: digest, subject: subject, expires: now.Add(confirmationTTL)}
+	return token, nil
+}
+
+// consume redeems a token; it is valid for the call it was issued for until it expires
+func (c *confirmations) consume(token, tool, digest, subject string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending, ok := c.pending[token]
+	if !ok {
+		return false
+	}
+	delete(c.pending, token)
+	return pending.tool == tool && pending.digest == digest && pending.subject == subject && time.Now().Before(pending.expires)
+}
+
+// SetConfirmDestructive enables the two-step confirmation of destructive tool calls: a call
+// without a confirmation token returns a preview and a token that must be echoed back to execute it
+func (h *Handler) SetConfirmDestructive(enabled bool) {
+	if enabled {
+		h.confirm = &confirmations{pending: make(map[string]pendingConfirmation)}
+	} else {
+		h.confirm = nil
+	}
+}
+
+// requiresConfirmation reports whether a tool call must be confirmed before it is executed.
+// Dry runs and read actions change nothing and are executed directly.
+func (h *Handler) requiresConfirmation(name string, args json.RawMessage) bool {
+	if h.confirm == nil || !isDestructive(name) {
+		return false
+	}
+	var req struct {
+		DryRun bool   `json:"dryRun"`
+		Action string `json:"action"`
+	}
+	json.Unmarshal(args, &req)
+	if readActions[name][req.Action] {
+		return false
+	}
+	return !(dryRunTools[name] && req.DryRun)
+}
+
+// splitConfirmationToken removes the confirmation token from tool arguments and returns the
+// remaining arguments in a canonical form
+func splitConfirmationToken(args json.RawMessage) (string, json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &fields); err != nil {
+			return "", nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	var token string
+	if raw, ok := fields[confirmationTokenArgument]; ok {
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return "", nil, fmt.Errorf("%s must be a string", confirmationTokenArgument)
+		}
+		delete(fields, confirmationTokenArgument)
+	}
+
+	// Object keys are marshalled in sorted order, so equal arguments have equal digests
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, canonical, nil
+}
+
+// argumentsDigest identifies the arguments a confirmation token is issued for
+func argumentsDigest(args json.RawMessage) string {
+	sum := sha256.Sum256(args)
+	return hex.EncodeToString(sum[:])
+}
+
+// confirmationSubject identifies the client a confirmation token is issued to
+func confirmationSubject(ctx context.Context) string {
+	if identity := auth.IdentityFrom(ctx); identity != nil {
+		return identity.Subject
+	}
+	return auth.Anonymous
+}
+
+// requestConfirmation previews a destructive tool call and issues the token that executes it
+func (h *Handler) requestConfirmation(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var preview string
+	if dryRunTools[name] {
+		var fields map[string]interface{}
+		json.Unmarshal(args, &fields)
+		fields["dryRun"] = true
+		dryRunArgs, err := json.Marshal(fields)
+		if err != nil {
+			return "", err
+		}
+		result, err := h.callTool(ctx, name, dryRunArgs)
+		if err != nil {
+			return "", err
+		}
+		preview, err = previewText(result)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		redacted, _ := json.Marshal(logging.RedactArguments(args))
+		preview = fmt.Sprintf("Would call %s with arguments %s", name, redacted)
+	}
+
+	token, err := h.confirm.issue(name, argumentsDigest(args), confirmationSubject(ctx))
+	if err != nil {
+		return "", fmt.Errorf("issuing confirmation token: %w", err)
+	}
+
+	return fmt.Sprintf("%s\n\nConfirmation required: to execute, call %s again within %s with the same arguments and \"%s\": \"%s\".",
+		preview, name, confirmationTTL, confirmationTokenArgument, token), nil
+}
+
+// previewText renders the dry run result of a tool call: text as-is, anything else as JSON
+func previewText(result interface{}) (string, error) {
+	switch r := result.(type) {
+	case string:
+		return r, nil
+	case *teamcity.StructuredResult:
+		return r.Text, nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("rendering preview: %w", err)
+	}
+	return string(data), nil
+}
+
+// addConfirmationTokenArgument adds the confirmation token argument to destructive tools
+func addConfirmationTokenArgument(tools []map[string]interface{}) {
+	for _, tool := range tools {
+		if !isDestructive(tool["name"].(string)) {
+			continue
+		}
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		properties[confirmationTokenArgument] = map[string]interface{}{
+			"type":        "string",
+			"description": "Token returned by the first call of this tool, confirming its execution",
+		}
+	}
+}
@@ -2,35 +2,85 @@ package mcp
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/itcaat/teamcity-mcp/internal/audit"
+	"github.com/itcaat/teamcity-mcp/internal/auth"
 	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/logging"
 	"github.com/itcaat/teamcity-mcp/internal/metrics"
 	"github.com/itcaat/teamcity-mcp/internal/teamcity"
 )
 
 // Handler handles MCP protocol messages
 type Handler struct {
-	tc     *teamcity.Client
-	cache  *cache.Cache
-	logger *zap.SugaredLogger
+	tc            *teamcity.Client
+	cache         *cache.Cache
+	logger        *zap.SugaredLogger
+	subscriptions *subscriptionManager
+	inflight      *inflightRequests
+
+	// capabilities are the advertised capabilities; nil advertises all of them
+	capabilities map[string]bool
+
+	// policy restricts clients to the tools and projects granted to them; nil allows everything
+	policy atomic.Pointer[auth.Policy]
+
+	// audit records tool invocations; nil if AUDIT_LOG is not configured
+	audit *audit.Logger
+
+	// confirm holds the confirmation tokens of destructive tool calls; nil if confirmation is disabled
+	confirm *confirmations
 }
 
 // NewHandler creates a new MCP handler
 func NewHandler(tc *teamcity.Client, cache *cache.Cache, logger *zap.SugaredLogger) *Handler {
-	return &Handler{
+	h := &Handler{
 		tc:     tc,
 		cache:  cache,
 		logger: logger,
+		inflight: &inflightRequests{
+			requests: make(map[requestKey]*inflightRequest),
+		},
+	}
+	h.subscriptions = &subscriptionManager{
+		interval: defaultPollInterval,
+		subs:     make(map[string]*subscription),
+		snapshot: h.subscriberFingerprint,
+		onError: func(uri string, err error) {
+			logger.Warnw("Failed to check subscribed resource", "uri", uri, "error", err)
+		},
 	}
+	return h
+}
+
+// SetPollInterval sets how often subscribed resources are polled for changes
+func (h *Handler) SetPollInterval(interval time.Duration) {
+	h.subscriptions.mu.Lock()
+	defer h.subscriptions.mu.Unlock()
+	h.subscriptions.interval = interval
+}
+
+// CloseSession removes the subscriptions of a closed client session
+func (h *Handler) CloseSession(session *Session) {
+	h.subscriptions.removeSession(session)
 }
 
-// HandleRequest handles an MCP JSON-RPC request
+// HandleRequest handles an MCP JSON-RPC request or batch
 func (h *Handler) HandleRequest(ctx context.Context, req json.RawMessage) (interface{}, error) {
+	if isBatch(req) {
+		return h.handleBatch(ctx, req)
+	}
+
 	start := time.Now()
 
 	// Parse basic JSON-RPC structure
@@ -56,33 +106,80 @@ func (h *Handler) HandleRequest(ctx context.Context, req json.RawMessage) (inter
 		metrics.RecordMCPRequest(baseReq.Method, "success", duration)
 	}()
 
-	// Route to appropriate handler
-	switch baseReq.Method {
+	ctx = h.withSessionLogger(ctx)
+
+	// A token supplied with the request (e.g. the X-TeamCity-Token HTTP header) takes precedence
+	// over the one the client supplied for its session in initialize
+	if session := sessionFrom(ctx); session != nil && !teamcity.HasToken(ctx) {
+		ctx = teamcity.WithToken(ctx, session.token())
+	}
+
+	// Requests (not notifications) can be cancelled by the client with notifications/cancelled
+	if baseReq.ID != nil {
+		key := newRequestKey(sessionFrom(ctx), baseReq.ID)
+		var inflight *inflightRequest
+		ctx, inflight = h.inflight.track(ctx, key)
+
+		resp, err := h.route(ctx, baseReq.ID, baseReq.Method, baseReq.Params)
+		if h.inflight.done(key, inflight) {
+			// No response is sent for cancelled requests
+			h.logger.Debugw("Request cancelled by client", "id", baseReq.ID, "method", baseReq.Method)
+			return nil, nil
+		}
+		return resp, err
+	}
+
+	return h.route(ctx, baseReq.ID, baseReq.Method, baseReq.Params)
+}
+
+// route dispatches a request or notification to its handler
+func (h *Handler) route(ctx context.Context, id interface{}, method string, params json.RawMessage) (interface{}, error) {
+	if capability := methodCapability(method); capability != "" && !h.enabled(capability) {
+		h.logger.Debugw("Method of disabled capability called", "method", method, "capability", capability)
+		if id != nil {
+			return h.errorResponse(id, -32601, "Method not found", nil), nil
+		}
+		return nil, nil
+	}
+
+	switch method {
 	case "initialize":
-		return h.handleInitialize(baseReq.ID, baseReq.Params)
+		return h.handleInitialize(ctx, id, params)
 	case "initialized":
-		return h.handleInitialized(baseReq.ID)
+		return h.handleInitialized(id)
 	case "notifications/initialized":
-		return h.handleInitialized(baseReq.ID)
+		return h.handleInitialized(id)
 	case "notifications/cancelled":
-		// Handle cancellation notifications - just log and return nil (no response for notifications)
-		h.logger.Debug("Received cancellation notification")
+		// Cancellation notifications abort the in-flight request; no response is sent for notifications
+		h.handleCancelled(ctx, params)
 		return nil, nil
 	case "resources/list":
-		return h.handleResourcesList(ctx, baseReq.ID, baseReq.Params)
+		return h.handleResourcesList(ctx, id, params)
 	case "resources/read":
-		return h.handleResourcesRead(ctx, baseReq.ID, baseReq.Params)
+		return h.handleResourcesRead(ctx, id, params)
+	case "resources/subscribe":
+		return h.handleResourcesSubscribe(ctx, id, params)
+	case "resources/unsubscribe":
+		return h.handleResourcesUnsubscribe(ctx, id, params)
+	case "resources/templates/list":
+		return h.handleResourceTemplatesList(id)
 	case "tools/list":
-		return h.handleToolsList(baseReq.ID)
+		return h.handleToolsList(ctx, id, params)
 	case "tools/call":
-		return h.handleToolsCall(ctx, baseReq.ID, baseReq.Params)
+		return h.handleToolsCall(ctx, id, params)
+	case "prompts/list":
+		return h.handlePromptsList(id)
+	case "prompts/get":
+		return h.handlePromptsGet(id, params)
+	case "logging/setLevel":
+		return h.handleSetLevel(ctx, id, params)
 	case "ping":
-		return h.handlePing(baseReq.ID)
+		return h.handlePing(id)
 	default:
-		h.logger.Warn("Unknown method called", "method", baseReq.Method, "id", baseReq.ID)
+		h.logger.Warn("Unknown method called", "method", method, "id", id)
 		// Only return an error response if this is a request (has an ID), not a notification
-		if baseReq.ID != nil {
-			return h.errorResponse(baseReq.ID, -32601, "Method not found", nil), nil
+		if id != nil {
+			return h.errorResponse(id, -32601, "Method not found", nil), nil
 		}
 		// For notifications, just return nil (no response)
 		return nil, nil
@@ -90,18 +187,40 @@ func (h *Handler) HandleRequest(ctx context.Context, req json.RawMessage) (inter
 }
 
 // handleInitialize handles the initialize request
-func (h *Handler) handleInitialize(id interface{}, params json.RawMessage) (interface{}, error) {
+func (h *Handler) handleInitialize(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		// TeamCityToken is the client's own TeamCity token used for the requests of its session
+		TeamCityToken string `json:"teamcityToken"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return h.errorResponse(id, -32602, "Invalid params", nil), nil
+		}
+	}
+
+	version, ok := negotiateProtocolVersion(req.ProtocolVersion)
+	if !ok {
+		h.logger.Warn("Client requested unsupported protocol version", "requested", req.ProtocolVersion)
+		return h.errorResponse(id, -32602, "Unsupported protocol version", map[string]interface{}{
+			"supported": supportedProtocolVersions,
+			"requested": req.ProtocolVersion,
+		}), nil
+	}
+	if session := sessionFrom(ctx); session != nil {
+		session.setProtocolVersion(version)
+		if req.TeamCityToken != "" {
+			session.setToken(req.TeamCityToken)
+			h.logger.Debug("Client supplied its own TeamCity token for the session")
+		}
+	}
+	h.logger.Debugw("Negotiated protocol version", "requested", req.ProtocolVersion, "version", version)
+
 	currentTime := time.Now()
 	return h.successResponse(id, map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"resources": map[string]interface{}{
-				"subscribe":   false,
-				"listChanged": false,
-			},
-			"tools":   map[string]interface{}{},
-			"logging": map[string]interface{}{},
-		},
+		"protocolVersion": version,
+		"capabilities":    h.serverCapabilities(),
+		"instructions":    h.instructions(),
 		"serverInfo": map[string]interface{}{
 			"name":        "teamcity-mcp",
 			"version":     "1.0.0",
@@ -112,6 +231,23 @@ func (h *Handler) handleInitialize(id interface{}, params json.RawMessage) (inte
 	}), nil
 }
 
+// handleCancelled cancels the in-flight request named by a notifications/cancelled notification
+func (h *Handler) handleCancelled(ctx context.Context, params json.RawMessage) {
+	var req struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil || req.RequestID == nil {
+		h.logger.Debug("Received invalid cancellation notification")
+		return
+	}
+
+	// Cancellation may race with the response; unknown or finished requests are ignored
+	cancelled := h.inflight.cancel(newRequestKey(sessionFrom(ctx), req.RequestID))
+	h.logger.Debugw("Received cancellation notification", "requestId", req.RequestID, "reason", req.Reason, "cancelled", cancelled)
+}
+
 // handleInitialized handles the initialized notification
 func (h *Handler) handleInitialized(id interface{}) (interface{}, error) {
 	// Notification - no response needed
@@ -121,7 +257,8 @@ func (h *Handler) handleInitialized(id interface{}) (interface{}, error) {
 // handleResourcesList handles resources/list requests
 func (h *Handler) handleResourcesList(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		URI string `json:"uri"`
+		URI    string `json:"uri"`
+		Cursor string `json:"cursor"`
 	}
 
 	// Params can be empty or null for resources/list
@@ -131,13 +268,144 @@ func (h *Handler) handleResourcesList(ctx context.Context, id interface{}, param
 		}
 	}
 
-	resources, err := h.listResources(ctx, req.URI)
+	page := teamcity.Page{Count: resourcesPageSize}
+	if req.Cursor != "" {
+		start, err := decodeCursor(req.Cursor, req.URI)
+		if err != nil {
+			return h.errorResponse(id, -32602, "Invalid params", err.Error()), nil
+		}
+		page.Start = start
+	}
+
+	grant := h.grant(ctx)
+	if req.URI != "" {
+		if err := h.authorizeResource(ctx, grant, req.URI); err != nil {
+			return h.errorResponse(id, errCodeForbidden, "Forbidden", err.Error()), nil
+		}
+	}
+
+	resources, more, err := h.listResources(ctx, req.URI, page)
 	if err != nil {
 		return h.errorResponse(id, -32603, "Internal error", err.Error()), nil
 	}
+	next := page.Start + len(resources)
 
-	return h.successResponse(id, map[string]interface{}{
+	// Filtering happens after paging so cursors keep addressing TeamCity's unfiltered list
+	if resources, err = h.filterResources(ctx, grant, req.URI, resources); err != nil {
+		return h.errorResponse(id, -32603, "Internal error", err.Error()), nil
+	}
+
+	for i, resource := range resources {
+		resources[i] = redactResource(resource)
+	}
+
+	result := map[string]interface{}{
 		"resources": resources,
+	}
+	if more {
+		result["nextCursor"] = encodeCursor(req.URI, next)
+	}
+	return h.successResponse(id, result), nil
+}
+
+// handleResourcesSubscribe handles resources/subscribe requests. Subscribed resources are polled
+// and notifications/resources/updated is sent on the client session when their content changes.
+func (h *Handler) handleResourcesSubscribe(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		URI string `json:"uri"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil || req.URI == "" {
+		return h.errorResponse(id, -32602, "Invalid params", nil), nil
+	}
+
+	session := sessionFrom(ctx)
+	if session == nil {
+		return h.errorResponse(id, -32600, "Invalid Request", "subscriptions require a WebSocket, SSE or STDIO connection or an HTTP session"), nil
+	}
+
+	grant := h.grant(ctx)
+	if err := h.authorizeResource(ctx, grant, req.URI); err != nil {
+		return h.errorResponse(id, errCodeForbidden, "Forbidden", err.Error()), nil
+	}
+
+	// The current content is the baseline for change detection; it also validates the URI
+	fingerprint, err := h.resourceFingerprint(ctx, grant, req.URI)
+	if err != nil {
+		return h.errorResponse(id, -32603, "Internal error", err.Error()), nil
+	}
+
+	h.subscriptions.subscribe(session, req.URI, &subscriber{
+		token:       teamcity.TokenFrom(ctx),
+		grant:       grant,
+		fingerprint: fingerprint,
+	})
+	return h.successResponse(id, map[string]interface{}{}), nil
+}
+
+// handleResourcesUnsubscribe handles resources/unsubscribe requests
+func (h *Handler) handleResourcesUnsubscribe(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		URI string `json:"uri"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil || req.URI == "" {
+		return h.errorResponse(id, -32602, "Invalid params", nil), nil
+	}
+
+	if session := sessionFrom(ctx); session != nil {
+		h.subscriptions.unsubscribe(session, req.URI)
+	}
+	return h.successResponse(id, map[string]interface{}{}), nil
+}
+
+// handleResourceTemplatesList handles resources/templates/list requests
+func (h *Handler) handleResourceTemplatesList(id interface{}) (interface{}, error) {
+	return h.successResponse(id, map[string]interface{}{
+		"resourceTemplates": []interface{}{
+			map[string]interface{}{
+				"uriTemplate": "teamcity://projects/{projectId}",
+				"name":        "Project",
+				"description": "A TeamCity project",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://projects/{projectId}/buildTypes",
+				"name":        "Project Build Types",
+				"description": "Build configurations defined directly in a TeamCity project",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://buildTypes/{buildTypeId}",
+				"name":        "Build Type",
+				"description": "A TeamCity build configuration",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://builds/{buildId}",
+				"name":        "Build",
+				"description": "A TeamCity build",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://builds{?locator}",
+				"name":        "Builds View",
+				"description": "Live view of the builds matching a TeamCity build locator, e.g. buildType:X,status:FAILURE,count:10",
+				"mimeType":    "text/plain",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://agents/{agentId}",
+				"name":        "Agent",
+				"description": "A TeamCity build agent",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uriTemplate": "teamcity://mutes/{projectId}",
+				"name":        "Project Mutes",
+				"description": "Muted tests and build problems of a TeamCity project and its subprojects",
+				"mimeType":    "application/json",
+			},
+		},
 	}), nil
 }
 
@@ -151,18 +419,32 @@ func (h *Handler) handleResourcesRead(ctx context.Context, id interface{}, param
 		return h.errorResponse(id, -32602, "Invalid params", nil), nil
 	}
 
+	if err := h.authorizeResource(ctx, h.grant(ctx), req.URI); err != nil {
+		return h.errorResponse(id, errCodeForbidden, "Forbidden", err.Error()), nil
+	}
+
 	resource, err := h.readResource(ctx, req.URI)
 	if err != nil {
 		return h.errorResponse(id, -32603, "Internal error", err.Error()), nil
 	}
 
 	return h.successResponse(id, map[string]interface{}{
-		"contents": []interface{}{resource},
+		"contents": []interface{}{redactResource(resource)},
 	}), nil
 }
 
 // handleToolsList handles tools/list requests
-func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
+func (h *Handler) handleToolsList(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cursor string `json:"cursor"`
+	}
+
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return h.errorResponse(id, -32602, "Invalid params", nil), nil
+		}
+	}
+
 	tools := []map[string]interface{}{
 		{
 			"name":        "trigger_build",
@@ -182,6 +464,46 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 						"type":        "object",
 						"description": "Build properties",
 					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Build comment",
+					},
+					"agentId": map[string]interface{}{
+						"type":        "string",
+						"description": "Run the build on this agent",
+					},
+					"agentPoolId": map[string]interface{}{
+						"type":        "string",
+						"description": "Run the build on an agent of this pool",
+					},
+					"personal": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Queue a personal build (default: false)",
+					},
+					"cleanSources": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Clean all files in the checkout directory before the build (default: false)",
+					},
+					"queueAtTop": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Put the build at the top of the queue (default: false)",
+					},
+					"rebuildDependencies": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Rebuild all snapshot dependencies instead of reusing suitable builds (default: false)",
+					},
+					"changeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build this TeamCity change instead of the latest one",
+					},
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "Build this VCS revision (commit hash) instead of the latest one",
+					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe the build that would be queued",
+					},
 				},
 				"required": []string{"buildTypeId"},
 			},
@@ -200,6 +522,10 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 						"type":        "string",
 						"description": "Cancellation comment",
 					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe what cancelling would do",
+					},
 				},
 				"required": []string{"buildId"},
 			},
@@ -252,7 +578,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 		},
 		{
 			"name":        "download_artifact",
-			"description": "Download build artifacts",
+			"description": "Download a single build artifact. Text artifacts are returned as text (truncated to maxBytes); binary artifacts are returned inline as a base64 resource blob, or saved to the server's artifact directory (ARTIFACT_DIR) when they are too large",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -262,7 +588,16 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 					},
 					"artifactPath": map[string]interface{}{
 						"type":        "string",
-						"description": "Artifact path",
+						"description": "Artifact path (e.g., 'dist/app.zip' or 'reports/summary.txt')",
+					},
+					"maxBytes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of bytes returned inline (default and maximum: the server's inline limit)",
+						"minimum":     1,
+					},
+					"saveToDisk": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Always save the artifact to the artifact directory instead of returning it inline (default: false)",
 					},
 				},
 				"required": []string{"buildId", "artifactPath"},
@@ -270,7 +605,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 		},
 		{
 			"name":        "search_builds",
-			"description": "Search for builds with various filters",
+			"description": "Search for builds with various filters. Composite builds include a roll-up of their parts and the failing constituent build",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -323,6 +658,10 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 						"type":        "boolean",
 						"description": "Filter by pinned status",
 					},
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "Find builds that included this VCS revision (full commit hash); searches all branches unless branch is set",
+					},
 					"count": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum number of builds to return (default: 100)",
@@ -331,6 +670,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 					},
 				},
 			},
+			"outputSchema": buildSearchOutputSchema,
 		},
 		{
 			"name":        "fetch_build_log",
@@ -433,6 +773,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 					},
 				},
 			},
+			"outputSchema": buildConfigurationSearchOutputSchema,
 		},
 		{
 			"name":        "get_current_time",
@@ -455,7 +796,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 		},
 		{
 			"name":        "get_test_results",
-			"description": "Get test results for a specific build. Returns test names, status, duration, and optionally error details/stack traces for failed tests. Use includeDetails=true to get full error messages and stack traces for debugging test failures.",
+			"description": "Get test results for a specific build. Returns test names, status, duration, and optionally error details/stack traces for failed tests. Use includeDetails=true to get full error messages and stack traces for debugging test failures. Results are paginated with count and start.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -465,7 +806,7 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 					},
 					"status": map[string]interface{}{
 						"type":        "string",
-						"description": "Filter by test status (optional). Use 'FAILURE' to see only failed tests.",
+						"description": "Filter by test status (optional). Use 'FAILURE' to see only failed tests, 'IGNORED' for ignored tests.",
 						"enum":        []string{"SUCCESS", "FAILURE", "UNKNOWN", "IGNORED"},
 					},
 					"includeDetails": map[string]interface{}{
@@ -480,82 +821,1914 @@ func (h *Handler) handleToolsList(id interface{}) (interface{}, error) {
 						"maximum":     1000,
 						"default":     100,
 					},
+					"start": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of tests to skip, for fetching the next page (optional, default: 0)",
+						"minimum":     0,
+						"default":     0,
+					},
+					"maxStacktraceLines": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of stack trace lines per test with includeDetails, after the failure message (optional, default: 30, 0 for no limit)",
+						"minimum":     0,
+						"default":     30,
+					},
+					"stacktraceFilter": map[string]interface{}{
+						"type":        "string",
+						"description": "Only keep stack trace lines containing this text, e.g. a package name like 'com.example' (optional)",
+					},
+					"newFailuresOnly": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return tests that started failing in this build, not long-standing failures (optional, default: false)",
+					},
+					"currentlyFailing": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return tests that are still failing in the latest build of the configuration (optional, default: false)",
+					},
 				},
 				"required": []string{"buildId"},
 			},
+			"outputSchema": testResultsOutputSchema,
 		},
-	}
-
-	return h.successResponse(id, map[string]interface{}{
-		"tools": tools,
-	}), nil
-}
-
-// handleToolsCall handles tools/call requests
-func (h *Handler) handleToolsCall(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
-	var req struct {
-		Name      string          `json:"name"`
-		Arguments json.RawMessage `json:"arguments"`
-	}
-
-	if err := json.Unmarshal(params, &req); err != nil {
-		return h.errorResponse(id, -32602, "Invalid params", nil), nil
-	}
-
-	result, err := h.callTool(ctx, req.Name, req.Arguments)
-	if err != nil {
-		h.logger.Error("Tool execution failed", "tool", req.Name, "error", err.Error())
-		return h.errorResponse(id, -32603, "Tool execution failed", err.Error()), nil
-	}
-
-	return h.successResponse(id, map[string]interface{}{
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": result,
+		{
+			"name":        "manage_notification_rules",
+			"description": "List, add or delete a user's notification rules (email or Slack notifications on build events for specific projects or build configurations)",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Action to perform (default: list)",
+						"enum":        []string{"list", "add", "delete"},
+					},
+					"user": map[string]interface{}{
+						"type":        "string",
+						"description": "Username or user ID (default: current user)",
+					},
+					"ruleId": map[string]interface{}{
+						"type":        "string",
+						"description": "Notification rule ID (required for delete)",
+					},
+					"notifier": map[string]interface{}{
+						"type":        "string",
+						"description": "Notifier to use when adding a rule (default: email)",
+						"enum":        []string{"email", "slack"},
+					},
+					"slackChannel": map[string]interface{}{
+						"type":        "string",
+						"description": "Slack channel or user ID (required for slack notifier)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Watch all build configurations of this project",
+					},
+					"buildTypeIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Watch these build configurations",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch filter for watched builds (e.g., '+:<default>')",
+					},
+					"events": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"buildStarted", "buildFailedToStart", "buildFailed", "firstFailureAfterSuccess", "buildFinishedSuccessfully", "firstSuccessAfterFailure", "buildProbablyHanging", "responsibilityChanged"},
+						},
+						"description": "Build events to notify on (default: buildFailed)",
+					},
+				},
 			},
 		},
-	}), nil
-}
-
-// handlePing handles ping requests
-func (h *Handler) handlePing(id interface{}) (interface{}, error) {
-	return h.successResponse(id, map[string]interface{}{}), nil
-}
-
-// successResponse creates a JSON-RPC success response
-func (h *Handler) successResponse(id interface{}, result interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"result":  result,
-	}
-}
-
-// errorResponse creates a JSON-RPC error response
-func (h *Handler) errorResponse(id interface{}, code int, message string, data interface{}) map[string]interface{} {
-	error := map[string]interface{}{
-		"code":    code,
-		"message": message,
-	}
-	if data != nil {
-		error["data"] = data
-	}
-
-	return map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"error":   error,
-	}
-}
-
-// listResources lists available resources
-func (h *Handler) listResources(ctx context.Context, uri string) ([]interface{}, error) {
-	// When uri is empty, return the list of available resource types (not the actual data)
-	if uri == "" {
-		return []interface{}{
-			map[string]interface{}{
-				"uri":         "teamcity://projects",
+		{
+			"name":        "run_personal_build",
+			"description": "Upload a unified diff as a personal change and queue a personal build (remote run) with it on the chosen build configuration",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "Unified diff to apply on top of the VCS sources (e.g., output of 'git diff')",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Description of the personal change",
+					},
+					"branchName": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch name (optional)",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Build properties",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Build comment",
+					},
+				},
+				"required": []string{"buildTypeId", "patch"},
+			},
+		},
+		{
+			"name":        "get_compatible_agents",
+			"description": "List agents compatible with a queued build and the incompatible ones with the unmet requirements that exclude them - use this to troubleshoot why a build is stuck in the queue",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Queued build ID",
+					},
+					"includeReasons": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include unmet requirements for incompatible agents (default: true)",
+					},
+					"maxIncompatible": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of incompatible agents to list (default: 20)",
+						"minimum":     1,
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "get_artifact_size_report",
+			"description": "Summarize artifact sizes over recent builds of a build configuration, showing growth between builds and flagging the builds responsible for storage bloat",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch name to filter by",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of recent finished builds to analyze (default: 20)",
+						"minimum":     2,
+						"maximum":     1000,
+					},
+					"growthThreshold": map[string]interface{}{
+						"type":        "number",
+						"description": "Flag builds whose artifacts grew by at least this percentage compared to the previous build (default: 20)",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "download_artifact_archive",
+			"description": "Download all artifacts of a build as a single zip archive. Small archives are returned inline as a base64 resource blob; larger ones are saved to the server's artifact directory (ARTIFACT_DIR)",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Artifact path pattern to include (optional, e.g., '**/*.log')",
+					},
+					"saveToDisk": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Always save the archive to the artifact directory instead of returning it inline (default: false)",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "get_builds_for_change",
+			"description": "List every build across all configurations and branches that included a VCS change or commit hash, with their statuses and the deployment configurations that built it, to tell whether a commit has been built and deployed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"changeId": map[string]interface{}{
+						"type":        "string",
+						"description": "TeamCity change ID",
+					},
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS revision (full commit hash), used when changeId is not given; builds checked out at the revision are found even when TeamCity has no change for it",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of builds to return (default: 100)",
+					},
+					"includeDownstream": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list the builds depending on them through snapshot dependencies, such as chained deployments without a VCS root of their own, to tell whether the change reached production (default: false)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "find_build_by_revision",
+			"description": "Find the builds across all configurations and branches that were checked out at a commit hash or VCS revision, with their statuses and the deployment configurations that built it, to tell whether a commit has been built and deployed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS revision, e.g. a full commit hash",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of builds to return (default: 100)",
+					},
+				},
+				"required": []string{"revision"},
+			},
+		},
+		{
+			"name":        "promote_build",
+			"description": "Promote a successful build in one call: pin it, apply release tags, set a comment and optionally trigger a deployment configuration using the build's artifacts",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the build to promote",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Tags to apply (e.g., 'released-1.2.3')",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment set on the build and used as the pin comment",
+					},
+					"deployBuildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Deployment build configuration to trigger with this build's artifacts (optional)",
+					},
+					"deployProperties": map[string]interface{}{
+						"type":        "object",
+						"description": "Build parameters for the deployment build (optional)",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "get_deployments",
+			"description": "Show what is currently deployed per environment: the latest successful build of each deployment build configuration and the builds whose artifacts it deployed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Limit to deployment configurations in this project and its subprojects (optional)",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Only consider deployments from this branch (default: all branches)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "search_tests",
+			"description": "Search tests by (partial) name across a project's builds, showing where each test runs, its current status and recent durations",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (subprojects are included)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Test name or part of it (case-insensitive)",
+					},
+					"maxTests": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of matching tests to return (default: 10)",
+					},
+					"occurrences": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of recent runs to analyze per test (default: 10)",
+					},
+				},
+				"required": []string{"projectId", "name"},
+			},
+		},
+		{
+			"name":        "manage_failure_conditions",
+			"description": "List or edit a build configuration's failure conditions: common conditions (exit code, failed tests, error messages, OOM, timeout) and metric or build log text conditions that can leave builds green when they should fail",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Action to perform (default: list)",
+						"enum":        []string{"list", "set", "add", "delete"},
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"settings": map[string]interface{}{
+						"type":        "object",
+						"description": "Common conditions to change for set (keys: failOnExitCode, failOnTestFailure, failOnErrorMessage, failOnOOMOrCrash as 'true'/'false'; executionTimeoutMin in minutes)",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Condition type to add",
+						"enum":        []string{"metric", "text"},
+					},
+					"metric": map[string]interface{}{
+						"type":        "string",
+						"description": "Metric key for metric conditions (e.g., 'testCount', 'buildDurationSecs', 'artifactsSize')",
+					},
+					"threshold": map[string]interface{}{
+						"type":        "string",
+						"description": "Metric threshold value for metric conditions",
+					},
+					"moreOrLess": map[string]interface{}{
+						"type":        "string",
+						"description": "Fail when the metric is more or less than the threshold (default: more)",
+						"enum":        []string{"more", "less"},
+					},
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Build log text or regular expression for text conditions",
+					},
+					"regex": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Treat text as a regular expression (default: false)",
+					},
+					"failIfFound": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Fail when the text is found (true, default) or when it is missing (false)",
+					},
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "Failure message to report when a text condition triggers",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Additional raw feature properties for the added condition (optional)",
+					},
+					"conditionId": map[string]interface{}{
+						"type":        "string",
+						"description": "Condition (build feature) ID to delete",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_build_settings",
+			"description": "Show or edit the general settings of a build configuration: checkout rules per VCS root, artifact paths, build number format, checkout mode and directory, clean build",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Action to perform (default: get)",
+						"enum":        []string{"get", "set"},
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"settings": map[string]interface{}{
+						"type":        "object",
+						"description": "Settings to change for set (keys: artifactRules, buildNumberFormat, checkoutMode (ON_AGENT, ON_SERVER, MANUAL), checkoutDirectory, cleanBuild)",
+					},
+					"checkoutRules": map[string]interface{}{
+						"type":        "object",
+						"description": "New checkout rules for set, keyed by VCS root ID (rules separated by newlines, e.g., '+:src/service => .')",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "set_checkout_rules",
+			"description": "Replace or extend the checkout rules of a VCS root attached to a build configuration (e.g., to limit a monorepo configuration to a subdirectory)",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"vcsRootId": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS root ID (optional when the configuration has a single VCS root)",
+					},
+					"rules": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Checkout rules, one per item (e.g., '+:services/api => .', '-:docs'); an empty list checks out the whole repository",
+					},
+					"append": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Add the rules to the existing ones instead of replacing them (default: false)",
+					},
+				},
+				"required": []string{"buildTypeId", "rules"},
+			},
+		},
+		{
+			"name":        "get_resolved_build_steps",
+			"description": "Show a build's steps with parameter references (%param%) resolved to the values the build actually used, to see what command actually ran",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+					"includeDisabled": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include disabled steps (default: false)",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "export_settings",
+			"description": "Export the settings of a project or build configuration as Kotlin DSL (pipeline as code) or XML, to review them or propose changes",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID; Kotlin DSL shows its declaration, or the settings of its project when the declaration is not found",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Settings format (default: kotlin)",
+						"enum":        []string{"kotlin", "xml"},
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_project_parameters",
+			"description": "List a project's own and inherited parameters, showing which ancestor project each inherited parameter comes from and which own parameters override a parent's value",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"includeInherited": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include parameters inherited from parent projects (default: true)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only show parameters whose name contains this text (optional)",
+					},
+				},
+				"required": []string{"projectId"},
+			},
+		},
+		{
+			"name":        "get_build_parameters",
+			"description": "Get the parameter values a build actually used (resulting properties), with secrets masked; these often differ from the configuration's declared parameters",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+					"prefix": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return parameters starting with this prefix (e.g., 'env.', 'system.')",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return parameters whose name contains this text",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "get_build_details",
+			"description": "Get the full details of a single build: status text, trigger, agent, revisions, tags, dependencies, artifact count, statistics summary and web URL. Composite builds include a roll-up of their parts and the failing constituent build",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "list_queued_builds",
+			"description": "List the build queue in order with each build's queue position, estimated start time, planned agent and wait reason, optionally filtered by build configuration or project",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list queued builds of this build configuration",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list queued builds of this project and its subprojects",
+					},
+				},
+			},
+		},
+		{
+			"name":        "manage_build_queue",
+			"description": "Manage the build queue: remove a queued build, move a queued build to the top of the queue, or move several queued builds to the top in a given order",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Action to perform",
+						"enum":        []string{"remove", "move_to_top", "reorder"},
+					},
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the queued build to remove or move to the top",
+					},
+					"buildIds": map[string]interface{}{
+						"type":        "array",
+						"description": "IDs of the queued builds to move to the top of the queue, in the new order (reorder)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment for removing a build from the queue",
+					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe what the action would do to the queue",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		{
+			"name":        "rerun_build",
+			"description": "Re-run a finished build with the same settings: same branch, VCS revisions and custom parameters, reusing its snapshot dependency builds unless rebuildDependencies is set. Returns the new queued build ID.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the finished build to re-run",
+					},
+					"rebuildDependencies": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Rebuild the snapshot dependencies instead of reusing the original build's dependency builds (default: false)",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment for the new build (default: names the re-run build)",
+					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe the build that would be queued",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "set_build_status",
+			"description": "Change the status of a finished build to successful or failed with a comment, e.g. to mark a known infrastructure failure as successful. The comment is kept in the build history.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the finished build",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "New build status",
+						"enum":        []string{"SUCCESS", "FAILURE"},
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the status is changed",
+					},
+				},
+				"required": []string{"buildId", "status", "comment"},
+			},
+		},
+		{
+			"name":        "list_build_artifacts",
+			"description": "List the artifacts of a build as a recursive tree with file sizes and modification times, optionally including the files inside archives, to decide what to fetch with download_artifact",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Artifact directory to list (default: all artifacts)",
+					},
+					"browseArchives": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list the files inside zip, jar and tar archives (default: false)",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "compare_artifacts",
+			"description": "Compare the artifact trees of two builds and list added, removed and changed files with their sizes, optionally comparing same-sized files by checksum, to investigate why a release artifact changed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"fromBuildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the baseline build",
+					},
+					"toBuildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the build compared to the baseline",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Artifact directory to compare (default: all artifacts)",
+					},
+					"browseArchives": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also compare the files inside zip, jar and tar archives (default: false)",
+					},
+					"compareChecksums": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Download same-sized files of both builds and compare their SHA-256 checksums (at most 50 files; default: false)",
+					},
+				},
+				"required": []string{"fromBuildId", "toBuildId"},
+			},
+		},
+		{
+			"name":        "get_build_dependencies",
+			"description": "List the snapshot and artifact dependencies of a build: the upstream builds it consumed, with their numbers, states and statuses, highlighting failed dependencies",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build ID",
+					},
+				},
+				"required": []string{"buildId"},
+			},
+		},
+		{
+			"name":        "get_build_chain",
+			"description": "Walk the snapshot dependency chain of a build or build configuration upstream (what it depends on) and downstream (what depends on it) and return it as trees with build statuses, listing the failed, running and queued parts, to find which part of a deployment chain is blocked",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build whose chain to walk",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration whose chain to walk, showing the latest build of each configuration",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch of the latest builds for buildTypeId (default: the default branch)",
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction to walk (default: both)",
+						"enum":        []string{"upstream", "downstream", "both"},
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_running_build_progress",
+			"description": "Get the live progress of a running build (percentage complete, elapsed vs estimated time, current stage and the last log lines), or of all running builds",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildId": map[string]interface{}{
+						"type":        "string",
+						"description": "Running build to report on (default: all running builds)",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only report the running builds of this build configuration",
+					},
+					"logLines": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of last log lines returned for buildId (default: 10)",
+						"minimum":     0,
+						"maximum":     200,
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_test_history",
+			"description": "Show the pass/fail history of a test across the latest builds of a build configuration with durations, and tell a new regression from an ongoing or flaky failure",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"testName": map[string]interface{}{
+						"type":        "string",
+						"description": "Full test name, e.g. as returned by get_test_results or search_tests",
+					},
+					"testId": map[string]interface{}{
+						"type":        "string",
+						"description": "TeamCity test ID (nameId), used instead of testName",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of latest runs to return (default: 20)",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_investigations",
+			"description": "List the current investigations of a project, build configuration, test or user, or assign or remove an investigation with a responsible user and comment so triage shows up in the TeamCity UI",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "Action to perform (default: list)",
+						"enum":        []string{"list", "assign", "remove"},
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID; filters listed investigations and is the scope of an investigation of a test",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID; filters listed investigations or is the target of an investigation of any problem",
+					},
+					"testName": map[string]interface{}{
+						"type":        "string",
+						"description": "Full test name to list or assign investigations of",
+					},
+					"testId": map[string]interface{}{
+						"type":        "string",
+						"description": "TeamCity test ID, used instead of testName",
+					},
+					"assignee": map[string]interface{}{
+						"type":        "string",
+						"description": "Username of the responsible user (required for assign; filters listed investigations)",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment of the assigned investigation",
+					},
+					"resolution": map[string]interface{}{
+						"type":        "string",
+						"description": "When the assigned investigation is resolved (default: whenFixed)",
+						"enum":        []string{"whenFixed", "manually"},
+					},
+					"investigationId": map[string]interface{}{
+						"type":        "string",
+						"description": "Investigation ID as returned by list (required for remove)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "compare_test_results",
+			"description": "Compare the test results of two builds and list the newly failed, fixed, newly ignored, added and removed tests, e.g. to qualify a release candidate against the last release",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"fromBuildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the baseline build",
+					},
+					"toBuildId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the build to compare with the baseline",
+					},
+				},
+				"required": []string{"fromBuildId", "toBuildId"},
+			},
+		},
+		{
+			"name":        "create_project",
+			"description": "Create a new TeamCity project under a parent project",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Project name",
+					},
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (default: generated by TeamCity from the parent's ID and the name)",
+					},
+					"parentProjectId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the parent project (default: _Root)",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Project description",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+		{
+			"name":        "create_build_configuration",
+			"description": "Create a build configuration in a project, optionally from a template, with initial parameters and an attached VCS root, to bootstrap CI for a new service",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the project to create the build configuration in",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration name",
+					},
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID (default: generated by TeamCity from the project's ID and the name)",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration description",
+					},
+					"templateId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a template to base the build configuration on",
+					},
+					"parameters": map[string]interface{}{
+						"type":        "object",
+						"description": "Initial parameters as name/value pairs, e.g. {\"env.SERVICE\": \"payments\"}",
+					},
+					"vcsRootId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a VCS root to attach",
+					},
+					"checkoutRules": map[string]interface{}{
+						"type":        "string",
+						"description": "Checkout rules of the attached VCS root, one per line",
+					},
+				},
+				"required": []string{"projectId", "name"},
+			},
+		},
+		{
+			"name":        "set_build_config_parameter",
+			"description": "Create or update a build configuration parameter, optionally with a type spec (password, checkbox, select with options, label, display, required). Without a type the parameter keeps its current spec.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter name, e.g. env.DEPLOY_TARGET",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter value",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter type; replaces the current spec when given",
+						"enum":        []string{"text", "password", "checkbox", "select"},
+					},
+					"options": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Options of a select parameter",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Label shown in the run dialog",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Description shown in the run dialog",
+					},
+					"display": map[string]interface{}{
+						"type":        "string",
+						"description": "Display mode (default: normal)",
+						"enum":        []string{"normal", "prompt", "hidden"},
+					},
+					"required": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether the value must not be empty",
+					},
+					"checkedValue": map[string]interface{}{
+						"type":        "string",
+						"description": "Value of a checked checkbox parameter",
+					},
+					"uncheckedValue": map[string]interface{}{
+						"type":        "string",
+						"description": "Value of an unchecked checkbox parameter",
+					},
+				},
+				"required": []string{"buildTypeId", "name", "value"},
+			},
+		},
+		{
+			"name":        "delete_build_config_parameter",
+			"description": "Delete a parameter of a build configuration; a parameter inherited from a template or project falls back to its inherited value",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter name",
+					},
+				},
+				"required": []string{"buildTypeId", "name"},
+			},
+		},
+		{
+			"name":        "pause_build_configuration",
+			"description": "Pause or unpause a build configuration with a comment; a paused configuration is not triggered automatically, while running and queued builds are not affected",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"paused": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Pause (true) or unpause (false) the build configuration (default: true)",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the build configuration is paused or unpaused, shown in the TeamCity UI",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_dependencies",
+			"description": "List, add or remove the snapshot and artifact dependencies of a build configuration, including revision rules and artifact path rules",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "add", "remove"},
+						"description": "Action to perform (default: list)",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"snapshot", "artifact"},
+						"description": "Dependency kind; required for add and remove, filters list",
+					},
+					"sourceBuildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration depended on (add action)",
+					},
+					"onDependencyFailure": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"RUN_ADD_PROBLEM", "RUN", "MAKE_FAILED_TO_START", "CANCEL"},
+						"description": "What the build does when the dependency fails, for snapshot dependencies (default: RUN_ADD_PROBLEM)",
+					},
+					"sameAgent": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Run the build on the same agent as the dependency, for snapshot dependencies (default: false)",
+					},
+					"revisionRule": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"lastSuccessful", "lastPinned", "lastFinished", "sameChainOrLastFinished", "buildNumber", "buildTag"},
+						"description": "Which build to take artifacts from, for artifact dependencies (default: lastSuccessful)",
+					},
+					"revisionValue": map[string]interface{}{
+						"type":        "string",
+						"description": "Build number or tag for the buildNumber and buildTag revision rules",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch to take artifacts from, for artifact dependencies",
+					},
+					"pathRules": map[string]interface{}{
+						"type":        "string",
+						"description": "Artifact rules, one per line (e.g. 'app.zip!** => app'); required for artifact dependencies",
+					},
+					"cleanDestination": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Clean the destination paths before downloading artifacts (default: false)",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Additional dependency properties, overriding the ones set from the arguments above",
+					},
+					"dependencyId": map[string]interface{}{
+						"type":        "string",
+						"description": "Dependency ID, as shown by the list action (remove action)",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_build_steps",
+			"description": "List, add, update (properties, name, enable/disable), reorder or delete the build steps of a build configuration",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "add", "update", "reorder", "delete"},
+						"description": "Action to perform (default: list)",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"stepId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build step ID, as shown by the list action (update and delete actions)",
+					},
+					"stepIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "All build step IDs in the new order (reorder action)",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Runner type of a new step, e.g. simpleRunner, Maven2, gradle-runner (default: simpleRunner when script is set)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Step name",
+					},
+					"script": map[string]interface{}{
+						"type":        "string",
+						"description": "Script of a command line step",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Runner properties to set; on update an empty value removes a property",
+					},
+					"disabled": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Disable (true) or enable (false) the step",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_project_parameters",
+			"description": "Read, set or delete a project parameter. get shows the effective value and every project up the hierarchy that defines it; set optionally takes a type spec like set_build_config_parameter.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"get", "set", "delete"},
+						"description": "Action to perform (default: get)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter name, e.g. env.DEPLOY_TARGET",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter value (set action)",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Parameter type; replaces the current spec when given",
+						"enum":        []string{"text", "password", "checkbox", "select"},
+					},
+					"options": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Options of a select parameter",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Label shown in the run dialog",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Description shown in the run dialog",
+					},
+					"display": map[string]interface{}{
+						"type":        "string",
+						"description": "Display mode (default: normal)",
+						"enum":        []string{"normal", "prompt", "hidden"},
+					},
+					"required": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether the value must not be empty",
+					},
+					"checkedValue": map[string]interface{}{
+						"type":        "string",
+						"description": "Value of a checked checkbox parameter",
+					},
+					"uncheckedValue": map[string]interface{}{
+						"type":        "string",
+						"description": "Value of an unchecked checkbox parameter",
+					},
+				},
+				"required": []string{"projectId", "name"},
+			},
+		},
+		{
+			"name":        "delete_project",
+			"description": "Delete a project with its subprojects, build configurations, templates and VCS roots; the result summarizes everything that is removed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe what would be deleted",
+					},
+				},
+				"required": []string{"projectId"},
+			},
+		},
+		{
+			"name":        "delete_build_configuration",
+			"description": "Delete a build configuration or template with its build history; the result summarizes the builds removed and the build configurations depending on it",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration or template ID",
+					},
+					"dryRun": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only describe what would be deleted",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "manage_templates",
+			"description": "List the build configuration templates of a project (with the configurations using each) or of a build configuration, and attach or detach a template to one or more build configurations",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "attach", "detach"},
+						"description": "Action to perform (default: list)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "List the templates of this project and its subprojects",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration to list the templates of, or to attach or detach the template to",
+					},
+					"buildTypeIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Build configurations to attach or detach the template to",
+					},
+					"templateId": map[string]interface{}{
+						"type":        "string",
+						"description": "Template ID (attach and detach actions)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "manage_project_features",
+			"description": "List, add, update or delete the features of a project: issue tracker connections (IssueTracker), custom report tabs (ReportTab), cloud profiles (CloudProfile), connections (OAuthProvider) and other project-level settings. Secure property values are masked",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "add", "update", "delete"},
+						"description": "Action to perform (default: list)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Feature type, e.g. IssueTracker, ReportTab, CloudProfile or OAuthProvider (required for add; filters the list)",
+					},
+					"featureId": map[string]interface{}{
+						"type":        "string",
+						"description": "Feature ID, as shown by the list action (update and delete actions)",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Feature properties (add action) or the properties to change (update action)",
+					},
+				},
+				"required": []string{"projectId"},
+			},
+		},
+		{
+			"name":        "search_projects",
+			"description": "Search for projects by name, parent project and archived state",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Search by project name (partial matching, case-insensitive)",
+					},
+					"parentProjectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return subprojects of this project",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include all nested subprojects of parentProjectId, not only its direct children (default: false)",
+					},
+					"archived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Filter by archived status",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of projects to return (default: 100)",
+						"minimum":     1,
+						"maximum":     1000,
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_vcs_roots",
+			"description": "List and search VCS roots by project, VCS type and fetch URL with their URL, default branch, branch spec and auth method, optionally with the build configurations using each, e.g. to find configurations still pointing at an old Git server",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list VCS roots of this project and its subprojects",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by VCS type (partial matching, e.g. 'git', 'svn', 'perforce')",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by fetch URL (partial matching, case-insensitive)",
+					},
+					"includeUsage": map[string]interface{}{
+						"type":        "boolean",
+						"description": "List the build configurations using each VCS root (default: false)",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of VCS roots to return (default: 100)",
+						"minimum":     1,
+						"maximum":     1000,
+					},
+				},
+			},
+		},
+		{
+			"name":        "manage_vcs_roots",
+			"description": "Create a VCS root, change its name and properties (fetch URL, default branch, branch spec, auth method), or attach it to or detach it from a build configuration",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"create", "update", "attach", "detach"},
+						"description": "Action to perform",
+					},
+					"vcsRootId": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS root ID (required for update, attach and detach; generated from the project and name on create when omitted)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project to create the VCS root in (create action)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS root name (create and update actions)",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS type, e.g. jetbrains.git, svn or perforce (create action, default: jetbrains.git)",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Fetch URL",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Default branch, e.g. refs/heads/main",
+					},
+					"branchSpec": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch specification, one +:/-: rule per line",
+					},
+					"authMethod": map[string]interface{}{
+						"type":        "string",
+						"description": "Authentication method, e.g. ANONYMOUS, PASSWORD, TEAMCITY_SSH_KEY or ACCESS_TOKEN; set the key or token reference with properties",
+					},
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "Username",
+					},
+					"properties": map[string]interface{}{
+						"type":        "object",
+						"description": "Other VCS root properties, e.g. teamcitySshKey or tokenId; an empty value removes the property on update",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration to attach the VCS root to or detach it from (attach and detach actions)",
+					},
+					"checkoutRules": map[string]interface{}{
+						"type":        "string",
+						"description": "Checkout rules of the attached VCS root (attach action)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		{
+			"name":        "check_vcs_root",
+			"description": "Verify that TeamCity can reach the repository of a VCS root: show when it last checked for changes and the last revision it saw, queue a check for changes, or send a commit hook notification to collect changes when a push webhook was missed",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"vcsRootId": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS root ID",
+					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"status", "checkForChanges", "commitHook"},
+						"description": "status shows the last check for changes (default); checkForChanges queues a check; commitHook sends a commit hook notification, after which TeamCity relies on commit hooks and polls the repository less often",
+					},
+				},
+				"required": []string{"vcsRootId"},
+			},
+		},
+		{
+			"name":        "search_changes",
+			"description": "Search VCS changes (commits) by committer, project, build configuration, VCS root and date range, newest first, with their revision, author, date and commit message",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by VCS username of the committer",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by project ID",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by build configuration ID",
+					},
+					"vcsRootId": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by VCS root ID",
+					},
+					"sinceDate": map[string]interface{}{
+						"type":        "string",
+						"description": "Changes committed since this date (YYYYMMDDTHHMMSS+HHMM)",
+					},
+					"untilDate": map[string]interface{}{
+						"type":        "string",
+						"description": "Changes committed until this date (YYYYMMDDTHHMMSS+HHMM)",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of changes to return (default: 100)",
+						"minimum":     1,
+						"maximum":     1000,
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_change",
+			"description": "Get the details of a VCS change or commit: author, full commit message, changed files and the builds that included it with their statuses, to trace a commit to its CI outcomes",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"changeId": map[string]interface{}{
+						"type":        "string",
+						"description": "TeamCity change ID",
+					},
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "VCS revision (full commit hash), used when changeId is not given",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of builds to return (default: 100)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_branches",
+			"description": "List the active branches of a build configuration, the default branch first, with the status of the latest build on each branch",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Build configuration ID",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Filter by branch name (partial matching, case-insensitive)",
+					},
+					"includeInactive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list branches without recent builds or commits (default: false)",
+					},
+				},
+				"required": []string{"buildTypeId"},
+			},
+		},
+		{
+			"name":        "get_branch_health",
+			"description": "Report which branches are currently failing across the build configurations of a project and since which build, from the latest build on each active branch",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID; its subprojects are included",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Only check branches whose name contains this (case-insensitive), e.g. 'release/'",
+					},
+				},
+				"required": []string{"projectId"},
+			},
+		},
+		{
+			"name":        "find_pull_request_builds",
+			"description": "Find the builds of a pull or merge request by its number or source branch, resolving the branch names TeamCity uses for pull requests (pull/N, refs/pull/N/head, refs/pull/N/merge, GitLab and Bitbucket variants) and the Pull Requests build feature parameters",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pullRequest": map[string]interface{}{
+						"type":        "string",
+						"description": "Pull or merge request number, e.g. '123'",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Source branch of the pull request, e.g. 'feature/login' (instead of pullRequest)",
+					},
+					"buildTypeId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only find builds of this build configuration",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Only find builds of this project and its subprojects",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of builds to return (default: 20)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "manage_agent",
+			"description": "Enable or disable a build agent with a comment, optionally re-enabling it automatically after a number of minutes, or authorize or unauthorize it",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"enable", "disable", "authorize", "unauthorize"},
+						"description": "Action to perform",
+					},
+					"agent": map[string]interface{}{
+						"type":        "string",
+						"description": "Agent ID or name",
+					},
+					"comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Comment shown with the agent's state, e.g. the reason for disabling it",
+					},
+					"reenableAfterMinutes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Enable the agent again automatically after this many minutes (disable action)",
+						"minimum":     1,
+					},
+				},
+				"required": []string{"action", "agent"},
+			},
+		},
+		{
+			"name":        "manage_agent_pools",
+			"description": "List agent pools with their agents and assigned projects, move an agent to another pool, or assign or unassign a project to a pool",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "moveAgent", "assignProject", "unassignProject"},
+						"description": "Action to perform (default: list)",
+					},
+					"pool": map[string]interface{}{
+						"type":        "string",
+						"description": "Agent pool ID or name; required except for list, where it limits the output to that pool",
+					},
+					"agent": map[string]interface{}{
+						"type":        "string",
+						"description": "Agent ID or name to move into the pool (moveAgent)",
+					},
+					"projectId": map[string]interface{}{
+						"type":        "string",
+						"description": "Project to assign to or unassign from the pool (assignProject, unassignProject)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "get_agent_details",
+			"description": "Get a build agent's full details: connected, enabled and authorized state with comments, pool, running build, OS, CPU count, configuration parameters, system properties and environment variables, with secrets masked",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"agent": map[string]interface{}{
+						"type":        "string",
+						"description": "Agent ID or name",
+					},
+					"prefix": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list parameters whose name starts with this prefix, e.g. env. or teamcity.agent.",
+					},
+				},
+				"required": []string{"agent"},
+			},
+		},
+		{
+			"name":        "search_agents",
+			"description": "Search build agents by connected, enabled and authorized state, pool and name substring. Returns each agent's state, pool and running build, at most count agents",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"connected": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only agents that are (true) or are not (false) connected",
+					},
+					"enabled": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only agents that are (true) or are not (false) enabled",
+					},
+					"authorized": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only agents that are (true) or are not (false) authorized",
+					},
+					"pool": map[string]interface{}{
+						"type":        "string",
+						"description": "Agent pool ID or name",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Case-insensitive substring of the agent name",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of agents to return (default: 100)",
+						"minimum":     1,
+						"maximum":     1000,
+					},
+				},
+			},
+			"outputSchema": agentSearchOutputSchema,
+		},
+	}
+	if supports(ctx, featureToolAnnotations) {
+		annotateTools(tools)
+	}
+	if !supports(ctx, featureStructuredContent) {
+		for _, tool := range tools {
+			delete(tool, "outputSchema")
+		}
+	}
+	if h.confirm != nil {
+		addConfirmationTokenArgument(tools)
+	}
+	if grant := h.grant(ctx); grant != nil {
+		allowed := tools[:0]
+		for _, tool := range tools {
+			if allowsTool(grant, tool["name"].(string)) {
+				allowed = append(allowed, tool)
+			}
+		}
+		tools = allowed
+	}
+
+	start := 0
+	if req.Cursor != "" {
+		var err error
+		if start, err = decodeCursor(req.Cursor, "tools/list"); err != nil || start > len(tools) {
+			return h.errorResponse(id, -32602, "Invalid params", errInvalidCursor.Error()), nil
+		}
+	}
+	end := min(start+toolsPageSize, len(tools))
+
+	result := map[string]interface{}{
+		"tools": tools[start:end],
+	}
+	if end < len(tools) {
+		result["nextCursor"] = encodeCursor("tools/list", end)
+	}
+	return h.successResponse(id, result), nil
+}
+
+// handleToolsCall handles tools/call requests
+func (h *Handler) handleToolsCall(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil {
+		return h.errorResponse(id, -32602, "Invalid params", nil), nil
+	}
+
+	// Arguments are logged with secrets redacted so failed interactions can be reconstructed
+	logger := logging.FromContext(ctx, h.logger)
+	logger.Debugw("Calling tool", "tool", req.Name, "arguments", logging.RedactArguments(req.Arguments))
+
+	start := time.Now()
+	if err := h.authorizeTool(ctx, h.grant(ctx), req.Name, req.Arguments); err != nil {
+		logger.Warnw("Tool call denied by access policy", "tool", req.Name, "error", err.Error())
+		h.auditToolCall(ctx, req.Name, req.Arguments, start, audit.StatusDenied, err)
+		return h.errorResponse(id, errCodeForbidden, "Forbidden", err.Error()), nil
+	}
+
+	if h.requiresConfirmation(req.Name, req.Arguments) {
+		token, args, err := splitConfirmationToken(req.Arguments)
+		if err != nil {
+			h.auditToolCall(ctx, req.Name, req.Arguments, start, audit.StatusError, err)
+			return h.errorResponse(id, -32602, "Invalid params", err.Error()), nil
+		}
+		if token == "" {
+			preview, err := h.requestConfirmation(ctx, req.Name, args)
+			if err != nil {
+				h.auditToolCall(ctx, req.Name, args, start, audit.StatusError, err)
+				return h.errorResponse(id, -32603, "Tool execution failed", logging.RedactText(err.Error())), nil
+			}
+			h.auditToolCall(ctx, req.Name, args, start, audit.StatusConfirmationRequired, nil)
+			return h.successResponse(id, map[string]interface{}{
+				"content": toolContent(logging.RedactText(preview)),
+			}), nil
+		}
+		if !h.confirm.consume(token, req.Name, argumentsDigest(args), confirmationSubject(ctx)) {
+			err := fmt.Errorf("invalid or expired confirmation token; call %s without %s for a new one", req.Name, confirmationTokenArgument)
+			h.auditToolCall(ctx, req.Name, args, start, audit.StatusDenied, err)
+			return h.errorResponse(id, -32602, "Invalid params", err.Error()), nil
+		}
+		req.Arguments = args
+	}
+
+	result, err := h.callTool(ctx, req.Name, req.Arguments)
+	if err != nil {
+		logger.Errorw("Tool execution failed", "tool", req.Name, "error", err.Error())
+		h.auditToolCall(ctx, req.Name, req.Arguments, start, audit.StatusError, err)
+		return h.errorResponse(id, -32603, "Tool execution failed", logging.RedactText(err.Error())), nil
+	}
+	h.auditToolCall(ctx, req.Name, req.Arguments, start, audit.StatusSuccess, nil)
+
+	// Secrets are masked in every tool result, e.g. password parameters or tokens in build logs
+	result = redactToolResult(result)
+
+	response := map[string]interface{}{
+		"content": toolContent(result),
+	}
+	if structured, ok := result.(*teamcity.StructuredResult); ok && supports(ctx, featureStructuredContent) {
+		response["structuredContent"] = structured.Data
+	}
+	return h.successResponse(id, response), nil
+}
+
+// handlePromptsList handles prompts/list requests
+func (h *Handler) handlePromptsList(id interface{}) (interface{}, error) {
+	list := make([]interface{}, 0, len(prompts))
+	for _, p := range prompts {
+		arguments := make([]interface{}, 0, len(p.Arguments))
+		for _, arg := range p.Arguments {
+			arguments = append(arguments, map[string]interface{}{
+				"name":        arg.Name,
+				"description": arg.Description,
+				"required":    arg.Required,
+			})
+		}
+
+		list = append(list, map[string]interface{}{
+			"name":        p.Name,
+			"description": p.Description,
+			"arguments":   arguments,
+		})
+	}
+
+	return h.successResponse(id, map[string]interface{}{
+		"prompts": list,
+	}), nil
+}
+
+// handlePromptsGet handles prompts/get requests by rendering a built-in prompt with its arguments
+func (h *Handler) handlePromptsGet(id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil {
+		return h.errorResponse(id, -32602, "Invalid params", nil), nil
+	}
+
+	p, ok := findPrompt(req.Name)
+	if !ok {
+		return h.errorResponse(id, -32602, "Invalid params", fmt.Sprintf("unknown prompt: %s", req.Name)), nil
+	}
+
+	if req.Arguments == nil {
+		req.Arguments = make(map[string]string)
+	}
+	for _, arg := range p.Arguments {
+		if arg.Required && req.Arguments[arg.Name] == "" {
+			return h.errorResponse(id, -32602, "Invalid params", fmt.Sprintf("missing required argument: %s", arg.Name)), nil
+		}
+	}
+
+	return h.successResponse(id, map[string]interface{}{
+		"description": p.Description,
+		"messages": []interface{}{
+			map[string]interface{}{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": p.Render(req.Arguments),
+				},
+			},
+		},
+	}), nil
+}
+
+// toolContent converts a tool result into MCP content items
+func toolContent(result interface{}) []interface{} {
+	switch r := result.(type) {
+	case *teamcity.BinaryContent:
+		content := []interface{}{
+			map[string]interface{}{
+				"type": "text",
+				"text": r.Summary,
+			},
+		}
+		if r.Data != nil {
+			content = append(content, map[string]interface{}{
+				"type": "resource",
+				"resource": map[string]interface{}{
+					"uri":      r.URI,
+					"mimeType": r.MimeType,
+					"blob":     base64.StdEncoding.EncodeToString(r.Data),
+				},
+			})
+		}
+		return content
+	case *teamcity.StructuredResult:
+		return []interface{}{
+			map[string]interface{}{
+				"type": "text",
+				"text": r.Text,
+			},
+		}
+	default:
+		return []interface{}{
+			map[string]interface{}{
+				"type": "text",
+				"text": result,
+			},
+		}
+	}
+}
+
+// handlePing handles ping requests
+func (h *Handler) handlePing(id interface{}) (interface{}, error) {
+	return h.successResponse(id, map[string]interface{}{}), nil
+}
+
+// successResponse creates a JSON-RPC success response
+func (h *Handler) successResponse(id interface{}, result interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"result":  result,
+	}
+}
+
+// RequestTooLargeResponse returns the error sent for requests exceeding the maximum request size.
+// Oversized requests are rejected before they are parsed, so the request ID is unknown.
+func (h *Handler) RequestTooLargeResponse(limit int64) map[string]interface{} {
+	return h.errorResponse(nil, -32600, "Request too large", fmt.Sprintf("request exceeds the maximum size of %d bytes", limit))
+}
+
+// InvalidMessageResponse returns the error sent for messages whose framing cannot be read, so
+// their request ID is unknown
+func (h *Handler) InvalidMessageResponse(reason string) map[string]interface{} {
+	return h.errorResponse(nil, -32700, "Parse error", reason)
+}
+
+// errorResponse creates a JSON-RPC error response
+func (h *Handler) errorResponse(id interface{}, code int, message string, data interface{}) map[string]interface{} {
+	error := map[string]interface{}{
+		"code":    code,
+		"message": message,
+	}
+	if data != nil {
+		error["data"] = data
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   error,
+	}
+}
+
+// listResources lists a page of available resources; more reports whether resources after the page exist.
+// Only the TeamCity entity collections are paginated; other listings are returned in full.
+func (h *Handler) listResources(ctx context.Context, uri string, page teamcity.Page) (resources []interface{}, more bool, err error) {
+	// When uri is empty, return the list of available resource types (not the actual data)
+	if uri == "" {
+		return []interface{}{
+			map[string]interface{}{
+				"uri":         "teamcity://projects",
 				"name":        "Projects",
 				"description": "TeamCity projects",
 				"mimeType":    "application/json",
@@ -584,26 +2757,62 @@ func (h *Handler) listResources(ctx context.Context, uri string) ([]interface{},
 				"description": "Current server date, time, and runtime information",
 				"mimeType":    "application/json",
 			},
-		}, nil
+			map[string]interface{}{
+				"uri":         "teamcity://queueStats",
+				"name":        "Queue Statistics",
+				"description": "Current build queue length, oldest queued build age and per-pool breakdown",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uri":         "teamcity://mutes",
+				"name":        "Mutes",
+				"description": "Currently muted tests and build problems with who muted them and why",
+				"mimeType":    "application/json",
+			},
+			map[string]interface{}{
+				"uri":         "teamcity://projects/tree",
+				"name":        "Project Tree",
+				"description": "The full project hierarchy with the build configurations, templates and archived flag of each project",
+				"mimeType":    "application/json",
+			},
+		}, false, nil
 	}
 
 	// When a specific URI is requested, fetch the actual data
-	switch uri {
+	base, query, _ := strings.Cut(uri, "?")
+	switch base {
 	case "teamcity://projects":
-		return h.listProjects(ctx)
+		return h.tc.ListProjects(ctx, page)
 	case "teamcity://buildTypes":
-		return h.listBuildTypes(ctx)
+		return h.tc.ListBuildTypes(ctx, page)
 	case "teamcity://builds":
-		return h.listBuilds(ctx)
+		return h.tc.ListBuilds(ctx, page)
 	case "teamcity://agents":
-		return h.listAgents(ctx)
+		return h.listAgents(ctx, query, page)
 	case "teamcity://runtime":
-		return h.listRuntimeInfo(ctx)
+		resources, err = h.listRuntimeInfo(ctx)
+		return resources, false, err
+	case "teamcity://queueStats":
+		resources, err = h.listQueueStats(ctx)
+		return resources, false, err
+	case "teamcity://mutes":
+		resources, err = h.listMutes(ctx)
+		return resources, false, err
+	case "teamcity://projects/tree":
+		resources, err = h.listProjectTree(ctx)
+		return resources, false, err
 	default:
-		return nil, fmt.Errorf("unsupported resource URI: %s", uri)
+		return nil, false, fmt.Errorf("unsupported resource URI: %s", uri)
 	}
 }
 
+type liveReadKey struct{}
+
+// withLiveReads makes the resource reads of ctx skip cached content; what they fetch still refreshes the cache
+func withLiveReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, liveReadKey{}, true)
+}
+
 // readResource reads a specific resource
 func (h *Handler) readResource(ctx context.Context, uri string) (interface{}, error) {
 	// Handle runtime resource separately
@@ -611,12 +2820,51 @@ func (h *Handler) readResource(ctx context.Context, uri string) (interface{}, er
 		return h.getRuntimeInfo(ctx)
 	}
 
+	// Queue statistics are fetched live on every read
+	if uri == "teamcity://queueStats" {
+		return h.tc.GetQueueStats(ctx)
+	}
+
+	// Mutes are fetched live on every read, of all projects or of one project
+	if uri == "teamcity://mutes" {
+		return h.tc.GetMutes(ctx, "")
+	}
+	if projectID, ok := strings.CutPrefix(uri, "teamcity://mutes/"); ok && projectID != "" {
+		return h.tc.GetMutes(ctx, projectID)
+	}
+
+	// The project tree is built from a single listing of all projects
+	if uri == "teamcity://projects/tree" {
+		return h.tc.GetProjectTree(ctx)
+	}
+
+	// Builds can be pinned as live views with a TeamCity locator, e.g. teamcity://builds?locator=status:FAILURE
+	if base, rawQuery, ok := strings.Cut(uri, "?"); ok && base == "teamcity://builds" {
+		return h.readBuildsView(ctx, uri, rawQuery)
+	}
+
+	// Entity reads are cached for CACHE_TTL; reads with a per-request token bypass the cache so
+	// that one token's data is never served to another
+	shared := !teamcity.HasToken(ctx)
+	if shared && ctx.Value(liveReadKey{}) == nil {
+		if resource, ok := h.cache.Get(uri); ok {
+			return resource, nil
+		}
+	}
+
 	// Parse URI and delegate to appropriate handler
-	return h.tc.GetResource(ctx, uri)
+	resource, err := h.tc.GetResource(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		h.cache.Set(uri, resource)
+	}
+	return resource, nil
 }
 
 // callTool executes a tool
-func (h *Handler) callTool(ctx context.Context, name string, args json.RawMessage) (string, error) {
+func (h *Handler) callTool(ctx context.Context, name string, args json.RawMessage) (interface{}, error) {
 	switch name {
 	case "trigger_build":
 		return h.tc.TriggerBuild(ctx, args)
@@ -638,26 +2886,145 @@ func (h *Handler) callTool(ctx context.Context, name string, args json.RawMessag
 		return h.getCurrentTime(ctx, args)
 	case "get_test_results":
 		return h.tc.GetTestResults(ctx, args)
+	case "manage_notification_rules":
+		return h.tc.ManageNotificationRules(ctx, args)
+	case "run_personal_build":
+		return h.tc.RunPersonalBuild(ctx, args)
+	case "get_compatible_agents":
+		return h.tc.GetCompatibleAgents(ctx, args)
+	case "get_artifact_size_report":
+		return h.tc.GetArtifactSizeReport(ctx, args)
+	case "download_artifact_archive":
+		return h.tc.DownloadArtifactArchive(ctx, args)
+	case "get_builds_for_change":
+		return h.tc.GetBuildsForChange(ctx, args)
+	case "find_build_by_revision":
+		return h.tc.FindBuildsByRevision(ctx, args)
+	case "promote_build":
+		return h.tc.PromoteBuild(ctx, args)
+	case "get_deployments":
+		return h.tc.GetDeployments(ctx, args)
+	case "search_tests":
+		return h.tc.SearchTests(ctx, args)
+	case "manage_failure_conditions":
+		return h.tc.ManageFailureConditions(ctx, args)
+	case "manage_build_settings":
+		return h.tc.ManageBuildSettings(ctx, args)
+	case "set_checkout_rules":
+		return h.tc.SetCheckoutRules(ctx, args)
+	case "get_resolved_build_steps":
+		return h.tc.GetResolvedBuildSteps(ctx, args)
+	case "export_settings":
+		return h.tc.ExportSettings(ctx, args)
+	case "list_project_parameters":
+		return h.tc.ListProjectParameters(ctx, args)
+	case "get_build_parameters":
+		return h.tc.GetBuildParameters(ctx, args)
+	case "get_build_details":
+		return h.tc.GetBuildDetails(ctx, args)
+	case "list_queued_builds":
+		return h.tc.ListQueuedBuilds(ctx, args)
+	case "manage_build_queue":
+		return h.tc.ManageBuildQueue(ctx, args)
+	case "rerun_build":
+		return h.tc.RerunBuild(ctx, args)
+	case "set_build_status":
+		return h.tc.SetBuildStatus(ctx, args)
+	case "list_build_artifacts":
+		return h.tc.ListBuildArtifacts(ctx, args)
+	case "compare_artifacts":
+		return h.tc.CompareArtifacts(ctx, args)
+	case "get_build_dependencies":
+		return h.tc.GetBuildDependencies(ctx, args)
+	case "get_build_chain":
+		return h.tc.GetBuildChain(ctx, args)
+	case "get_running_build_progress":
+		return h.tc.GetRunningBuildProgress(ctx, args)
+	case "get_test_history":
+		return h.tc.GetTestHistory(ctx, args)
+	case "manage_investigations":
+		return h.tc.ManageInvestigations(ctx, args)
+	case "compare_test_results":
+		return h.tc.CompareTestResults(ctx, args)
+	case "create_project":
+		return h.tc.CreateProject(ctx, args)
+	case "create_build_configuration":
+		return h.tc.CreateBuildConfiguration(ctx, args)
+	case "set_build_config_parameter":
+		return h.tc.SetBuildConfigParameter(ctx, args)
+	case "delete_build_config_parameter":
+		return h.tc.DeleteBuildConfigParameter(ctx, args)
+	case "pause_build_configuration":
+		return h.tc.PauseBuildConfiguration(ctx, args)
+	case "manage_dependencies":
+		return h.tc.ManageDependencies(ctx, args)
+	case "manage_build_steps":
+		return h.tc.ManageBuildSteps(ctx, args)
+	case "manage_project_parameters":
+		return h.tc.ManageProjectParameters(ctx, args)
+	case "delete_project":
+		return h.tc.DeleteProject(ctx, args)
+	case "delete_build_configuration":
+		return h.tc.DeleteBuildConfiguration(ctx, args)
+	case "manage_templates":
+		return h.tc.ManageTemplates(ctx, args)
+	case "manage_project_features":
+		return h.tc.ManageProjectFeatures(ctx, args)
+	case "search_projects":
+		return h.tc.SearchProjects(ctx, args)
+	case "list_vcs_roots":
+		return h.tc.ListVCSRoots(ctx, args)
+	case "manage_vcs_roots":
+		return h.tc.ManageVCSRoots(ctx, args)
+	case "check_vcs_root":
+		return h.tc.CheckVCSRoot(ctx, args)
+	case "search_changes":
+		return h.tc.SearchChanges(ctx, args)
+	case "get_change":
+		return h.tc.GetChange(ctx, args)
+	case "list_branches":
+		return h.tc.ListBranches(ctx, args)
+	case "get_branch_health":
+		return h.tc.GetBranchHealth(ctx, args)
+	case "find_pull_request_builds":
+		return h.tc.FindPullRequestBuilds(ctx, args)
+	case "manage_agent":
+		return h.tc.ManageAgent(ctx, args)
+	case "manage_agent_pools":
+		return h.tc.ManageAgentPools(ctx, args)
+	case "get_agent_details":
+		return h.tc.GetAgentDetails(ctx, args)
+	case "search_agents":
+		return h.tc.SearchAgents(ctx, args)
 	default:
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
 }
 
-// Placeholder implementations - to be expanded
-func (h *Handler) listProjects(ctx context.Context) ([]interface{}, error) {
-	return h.tc.ListProjects(ctx)
-}
-
-func (h *Handler) listBuildTypes(ctx context.Context) ([]interface{}, error) {
-	return h.tc.ListBuildTypes(ctx)
-}
+// listAgents lists agents, filtered by the connected, enabled, authorized and pool query parameters
+func (h *Handler) listAgents(ctx context.Context, rawQuery string, page teamcity.Page) ([]interface{}, bool, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid agents query: %w", err)
+	}
 
-func (h *Handler) listBuilds(ctx context.Context) ([]interface{}, error) {
-	return h.tc.ListBuilds(ctx)
-}
+	var filter teamcity.AgentFilter
+	for name, target := range map[string]**bool{
+		"connected":  &filter.Connected,
+		"enabled":    &filter.Enabled,
+		"authorized": &filter.Authorized,
+	} {
+		if value := query.Get(name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid agents query: %s must be true or false", name)
+			}
+			*target = &b
+		}
+	}
+	filter.Pool = query.Get("pool")
 
-func (h *Handler) listAgents(ctx context.Context) ([]interface{}, error) {
-	return h.tc.ListAgents(ctx)
+	return h.tc.ListAgents(ctx, filter, page)
 }
 
 // listRuntimeInfo lists runtime information resources
@@ -672,6 +3039,66 @@ func (h *Handler) listRuntimeInfo(ctx context.Context) ([]interface{}, error) {
 	}, nil
 }
 
+// readBuildsView reads the builds matching the locator query parameter of a builds resource URI
+func (h *Handler) readBuildsView(ctx context.Context, uri, rawQuery string) (interface{}, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid builds query: %w", err)
+	}
+
+	locator := query.Get("locator")
+	if locator == "" {
+		return nil, fmt.Errorf("builds query requires a locator parameter")
+	}
+
+	text, err := h.tc.SearchBuildsByLocator(ctx, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"uri":      uri,
+		"mimeType": "text/plain",
+		"text":     text,
+	}, nil
+}
+
+// listQueueStats lists the queue statistics resource
+func (h *Handler) listQueueStats(ctx context.Context) ([]interface{}, error) {
+	return []interface{}{
+		map[string]interface{}{
+			"uri":         "teamcity://queueStats",
+			"name":        "Queue Statistics",
+			"description": "Current build queue length, oldest queued build age and per-pool breakdown",
+			"mimeType":    "application/json",
+		},
+	}, nil
+}
+
+// listMutes lists the mutes resource
+func (h *Handler) listMutes(ctx context.Context) ([]interface{}, error) {
+	return []interface{}{
+		map[string]interface{}{
+			"uri":         "teamcity://mutes",
+			"name":        "Mutes",
+			"description": "Currently muted tests and build problems with who muted them and why",
+			"mimeType":    "application/json",
+		},
+	}, nil
+}
+
+// listProjectTree lists the project tree resource
+func (h *Handler) listProjectTree(ctx context.Context) ([]interface{}, error) {
+	return []interface{}{
+		map[string]interface{}{
+			"uri":         "teamcity://projects/tree",
+			"name":        "Project Tree",
+			"description": "The full project hierarchy with the build configurations, templates and archived flag of each project",
+			"mimeType":    "application/json",
+		},
+	}, nil
+}
+
 // getRuntimeInfo returns current runtime information
 func (h *Handler) getRuntimeInfo(ctx context.Context) (interface{}, error) {
 	currentTime := time.Now()
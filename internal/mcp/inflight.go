@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// requestKey identifies an in-flight request; IDs are only unique within a client session
+type requestKey struct {
+	session *Session
+	id      string
+}
+
+// inflightRequest is a request being handled that the client may cancel
+type inflightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// inflightRequests tracks in-flight requests so notifications/cancelled can abort them
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[requestKey]*inflightRequest
+}
+
+// newRequestKey returns the key of a request ID; JSON numbers and strings are both accepted
+func newRequestKey(session *Session, id interface{}) requestKey {
+	return requestKey{session: session, id: fmt.Sprint(id)}
+}
+
+// track registers a request and returns a context that is cancelled when the client cancels it
+func (r *inflightRequests) track(ctx context.Context, key requestKey) (context.Context, *inflightRequest) {
+	ctx, cancel := context.WithCancel(ctx)
+	req := &inflightRequest{cancel: cancel}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[key] = req
+	return ctx, req
+}
+
+// done unregisters a finished request and reports whether the client cancelled it
+func (r *inflightRequests) done(key requestKey, req *inflightRequest) bool {
+	req.cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.requests[key] == req {
+		delete(r.requests, key)
+	}
+	return req.cancelled
+}
+
+// cancel aborts an in-flight request, reporting false if it is not (or no longer) in flight
+func (r *inflightRequests) cancel(key requestKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	req, ok := r.requests[key]
+	if !ok {
+		return false
+	}
+	req.cancelled = true
+	req.cancel()
+	return true
+}
@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+)
+
+// mcpLogLevels maps MCP (syslog) log levels to zap levels
+var mcpLogLevels = map[string]zapcore.Level{
+	"debug":     zapcore.DebugLevel,
+	"info":      zapcore.InfoLevel,
+	"notice":    zapcore.InfoLevel,
+	"warning":   zapcore.WarnLevel,
+	"error":     zapcore.ErrorLevel,
+	"critical":  zapcore.DPanicLevel,
+	"alert":     zapcore.PanicLevel,
+	"emergency": zapcore.FatalLevel,
+}
+
+// mcpLogLevel returns the MCP log level of a zap level
+func mcpLogLevel(level zapcore.Level) string {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return "debug"
+	case level == zapcore.InfoLevel:
+		return "info"
+	case level == zapcore.WarnLevel:
+		return "warning"
+	case level == zapcore.ErrorLevel:
+		return "error"
+	case level == zapcore.DPanicLevel:
+		return "critical"
+	case level == zapcore.PanicLevel:
+		return "alert"
+	default:
+		return "emergency"
+	}
+}
+
+// notificationCore is a zap core that forwards log entries to a session as
+// notifications/message at or above the level the client set with logging/setLevel
+type notificationCore struct {
+	session *Session
+	fields  []zapcore.Field
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *notificationCore) Enabled(level zapcore.Level) bool {
+	minLevel, ok := c.session.logLevel()
+	return ok && level >= minLevel
+}
+
+// With implements zapcore.Core
+func (c *notificationCore) With(fields []zapcore.Field) zapcore.Core {
+	return &notificationCore{
+		session: c.session,
+		fields:  append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check implements zapcore.Core
+func (c *notificationCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write implements zapcore.Core
+func (c *notificationCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	data := encoder.Fields
+	data["message"] = entry.Message
+
+	return c.session.notify(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/message",
+		"params": map[string]interface{}{
+			"level":  mcpLogLevel(entry.Level),
+			"logger": "teamcity-mcp",
+			"data":   data,
+		},
+	})
+}
+
+// Sync implements zapcore.Core
+func (c *notificationCore) Sync() error {
+	return nil
+}
+
+// withSessionLogger attaches a logger to the context that writes to the server log and
+// forwards messages to the client session of the request. Messages name the authenticated
+// client so that actions can be audited.
+func (h *Handler) withSessionLogger(ctx context.Context) context.Context {
+	session := sessionFrom(ctx)
+	identity := auth.IdentityFrom(ctx)
+	if session == nil && identity == nil {
+		return ctx
+	}
+
+	logger := h.logger
+	if session != nil {
+		logger = logger.Desugar().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, &notificationCore{session: session})
+		})).Sugar()
+	}
+	if identity != nil {
+		logger = logger.With("user", identity.Subject, "auth", identity.Method)
+	}
+	return logging.WithLogger(ctx, logger)
+}
+
+// handleSetLevel handles logging/setLevel requests
+func (h *Handler) handleSetLevel(ctx context.Context, id interface{}, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Level string `json:"level"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil {
+		return h.errorResponse(id, -32602, "Invalid params", nil), nil
+	}
+
+	level, ok := mcpLogLevels[req.Level]
+	if !ok {
+		return h.errorResponse(id, -32602, "Invalid params", "unknown log level: "+req.Level), nil
+	}
+
+	session := sessionFrom(ctx)
+	if session == nil {
+		// Stateless HTTP requests have no connection to send log messages on
+		return h.errorResponse(id, -32602, "Invalid params", "log messages require a WebSocket, SSE or STDIO connection or an HTTP session"), nil
+	}
+
+	session.setLogLevel(level)
+	h.logger.Debugw("Client set log level", "level", req.Level)
+	return h.successResponse(id, map[string]interface{}{}), nil
+}
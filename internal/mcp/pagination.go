@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// resourcesPageSize is the number of resources returned per resources/list page
+const resourcesPageSize = 100
+
+// toolsPageSize is the number of tools returned per tools/list page
+const toolsPageSize = 100
+
+// errInvalidCursor is returned for cursors that were not issued for the listing they are used with
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position of the next page of a listing. Clients treat it as an opaque string.
+type cursor struct {
+	// List names the paginated listing: a resource URI or a method such as tools/list
+	List  string `json:"list"`
+	Start int    `json:"start"`
+}
+
+// encodeCursor returns the cursor of the page of list starting at start
+func encodeCursor(list string, start int) string {
+	data, _ := json.Marshal(cursor{List: list, Start: start})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the start position of a cursor issued for list
+func decodeCursor(value, list string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.List != list || c.Start < 0 {
+		return 0, errInvalidCursor
+	}
+	return c.Start, nil
+}
@@ -0,0 +1,273 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+)
+
+// errCodeForbidden is the JSON-RPC error code of requests the access policy denies
+const errCodeForbidden = -32001
+
+// projectlessTools touch no TeamCity project and are available to project-scoped clients
+var projectlessTools = map[string]bool{
+	"get_current_time": true,
+}
+
+// serverTools act on server-wide entities such as agents, agent pools and users' notification
+// rules, which belong to no project, and are denied to project-scoped clients
+var serverTools = map[string]bool{
+	"manage_agent":              true,
+	"manage_agent_pools":        true,
+	"get_agent_details":         true,
+	"search_agents":             true,
+	"manage_notification_rules": true,
+}
+
+// entityArguments are the tool arguments naming the TeamCity entities a call touches; tools
+// limits an argument to the listed tools, e.g. because others use it only within an entity
+// named by another argument
+var entityArguments = []struct {
+	name  string
+	kind  string
+	tools map[string]bool
+}{
+	{"projectId", "project", nil},
+	{"parentProjectId", "project", nil},
+	{"buildTypeId", "buildType", nil},
+	{"deployBuildTypeId", "buildType", nil},
+	{"sourceBuildTypeId", "buildType", nil},
+	{"templateId", "buildType", nil},
+	{"buildTypeIds", "buildType", nil},
+	{"vcsRootId", "vcsRoot", nil},
+	{"buildId", "build", nil},
+	{"buildIds", "build", nil},
+	{"fromBuildId", "build", nil},
+	{"toBuildId", "build", nil},
+	{"changeId", "change", nil},
+	{"investigationId", "investigation", nil},
+	// A revision alone looks changes up across all VCS roots
+	{"revision", "revision", map[string]bool{"get_change": true, "get_builds_for_change": true, "find_build_by_revision": true}},
+}
+
+// externalID matches TeamCity external IDs of projects, build configurations and VCS roots.
+// Other IDs are rejected, as a path such as Payments_x/../Billing would name another project
+// than the one authorized.
+var externalID = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// externalIDKinds are the entity kinds identified by external IDs
+var externalIDKinds = map[string]bool{
+	"project":   true,
+	"buildType": true,
+	"vcsRoot":   true,
+}
+
+// SetPolicy restricts each client to the tools and projects the policy grants its identity;
+// nil allows every client everything
+func (h *Handler) SetPolicy(policy *auth.Policy) {
+	h.policy.Store(policy)
+}
+
+// grant returns what the policy allows the client of a request; nil means no policy is configured
+func (h *Handler) grant(ctx context.Context) *auth.Grant {
+	policy := h.policy.Load()
+	if policy == nil {
+		return nil
+	}
+	return policy.Grant(auth.IdentityFrom(ctx))
+}
+
+// authorizeTool checks that a client may call a tool with the projects its arguments name
+func (h *Handler) authorizeTool(ctx context.Context, grant *auth.Grant, name string, args json.RawMessage) error {
+	if grant == nil {
+		return nil
+	}
+	if !allowsTool(grant, name) {
+		return fmt.Errorf("tool %s is not allowed for %s", name, grant.Subject)
+	}
+	if !grant.ProjectScoped() || projectlessTools[name] {
+		return nil
+	}
+
+	refs, err := entityReferences(name, args)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := h.authorizeEntity(ctx, grant, ref.kind, ref.id); err != nil {
+			return err
+		}
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("tool %s must name a projectId, buildTypeId or buildId for %s", name, grant.Subject)
+	}
+	return nil
+}
+
+// allowsTool reports whether a grant includes a tool; project-scoped grants never include server tools
+func allowsTool(grant *auth.Grant, name string) bool {
+	return grant.AllowsTool(name) && !(grant.ProjectScoped() && serverTools[name])
+}
+
+// entityRef is a TeamCity entity named by tool arguments
+type entityRef struct {
+	kind string
+	id   string
+}
+
+// String returns the reference as kind:id, e.g. buildType:App_Build
+func (r entityRef) String() string {
+	return r.kind + ":" + r.id
+}
+
+// entityReferences returns the entities named by the arguments of a call to a tool
+func entityReferences(tool string, args json.RawMessage) ([]entityRef, error) {
+	var fields map[string]json.RawMessage
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &fields); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	var refs []entityRef
+	for _, arg := range entityArguments {
+		raw, ok := fields[arg.name]
+		if !ok || (arg.tools != nil && !arg.tools[tool]) {
+			continue
+		}
+		var ids []string
+		if err := json.Unmarshal(raw, &ids); err != nil {
+			var id string
+			if err := json.Unmarshal(raw, &id); err != nil {
+				return nil, fmt.Errorf("invalid %s argument", arg.name)
+			}
+			ids = []string{id}
+		}
+		for _, id := range ids {
+			if id != "" {
+				refs = append(refs, entityRef{kind: arg.kind, id: id})
+			}
+		}
+	}
+	return refs, nil
+}
+
+// authorizeEntity checks that a project, build configuration, VCS root, build, change or
+// investigation belongs to granted projects
+func (h *Handler) authorizeEntity(ctx context.Context, grant *auth.Grant, kind, id string) error {
+	if externalIDKinds[kind] && !externalID.MatchString(id) {
+		return fmt.Errorf("invalid %s ID %q", kind, id)
+	}
+
+	projects, err := h.projectsOf(ctx, kind, id)
+	if err != nil {
+		return fmt.Errorf("resolving project of %s %s: %w", kind, id, err)
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("%s %s belongs to no project allowed for %s", kind, id, grant.Subject)
+	}
+	for _, projectID := range projects {
+		if !grant.AllowsProject(projectID) {
+			return fmt.Errorf("project %s is not allowed for %s", projectID, grant.Subject)
+		}
+	}
+	return nil
+}
+
+// projectsOf returns the projects an entity belongs to
+func (h *Handler) projectsOf(ctx context.Context, kind, id string) ([]string, error) {
+	var projectID string
+	var err error
+	switch kind {
+	case "buildType":
+		projectID, err = h.tc.ProjectOfBuildType(ctx, id)
+	case "build":
+		projectID, err = h.tc.ProjectOfBuild(ctx, id)
+	case "vcsRoot":
+		projectID, err = h.tc.ProjectOfVCSRoot(ctx, id)
+	case "change":
+		return h.tc.ProjectsOfChanges(ctx, "id:"+id)
+	case "revision":
+		return h.tc.ProjectsOfChanges(ctx, "version:"+id)
+	case "investigation":
+		return h.tc.ProjectsOfInvestigation(ctx, id)
+	default:
+		projectID = id
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{projectID}, nil
+}
+
+// authorizeResource checks that a client may read a resource. Project-scoped clients can read
+// the projects, build configurations and builds of their projects and the server-wide agents,
+// runtime and queue statistics resources.
+func (h *Handler) authorizeResource(ctx context.Context, grant *auth.Grant, uri string) error {
+	if grant == nil || !grant.ProjectScoped() {
+		return nil
+	}
+
+	path, _ := strings.CutPrefix(uri, "teamcity://")
+	base, _, hasQuery := strings.Cut(path, "?")
+	parts := strings.Split(base, "/")
+	switch {
+	case parts[0] == "agents" || base == "runtime" || base == "queueStats":
+		return nil
+	case base == "projects" || base == "buildTypes":
+		// Lists are filtered to the granted projects
+		return nil
+	case hasQuery:
+	case base == "projects/tree":
+		// The project tree spans all projects
+	case len(parts) >= 2 && parts[0] == "projects":
+		return h.authorizeEntity(ctx, grant, "project", parts[1])
+	case len(parts) == 2 && parts[0] == "mutes":
+		return h.authorizeEntity(ctx, grant, "project", parts[1])
+	case len(parts) == 2 && parts[0] == "buildTypes":
+		return h.authorizeEntity(ctx, grant, "buildType", parts[1])
+	case len(parts) == 2 && parts[0] == "builds":
+		return h.authorizeEntity(ctx, grant, "build", parts[1])
+	}
+	return fmt.Errorf("resource %s is not available to %s", uri, grant.Subject)
+}
+
+// filterResources removes the resources of projects a client is not granted from a resource list
+func (h *Handler) filterResources(ctx context.Context, grant *auth.Grant, uri string, resources []interface{}) ([]interface{}, error) {
+	if grant == nil || !grant.ProjectScoped() {
+		return resources, nil
+	}
+
+	base, _, _ := strings.Cut(uri, "?")
+	var projectOf func(id string) string
+	switch base {
+	case "teamcity://projects":
+		projectOf = func(id string) string { return id }
+	case "teamcity://buildTypes":
+		projects, err := h.tc.BuildTypeProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		projectOf = func(id string) string { return projects[id] }
+	default:
+		return resources, nil
+	}
+
+	filtered := make([]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		r, ok := resource.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resourceURI, _ := r["uri"].(string)
+		id := resourceURI[strings.LastIndex(resourceURI, "/")+1:]
+		if grant.AllowsProject(projectOf(id)) {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// promptArgument describes an argument of a prompt template
+type promptArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// prompt is a built-in prompt template that pre-wires the tool calls for a CI debugging task
+type prompt struct {
+	Name        string
+	Description string
+	Arguments   []promptArgument
+	// Render builds the prompt text; required arguments are guaranteed to be set
+	Render func(args map[string]string) string
+}
+
+// prompts is the library of built-in prompts, in the order they are listed
+var prompts = []prompt{
+	{
+		Name:        "diagnose_failed_build",
+		Description: "Find the root cause of a failed build from its log, failed tests and resolved build steps",
+		Arguments: []promptArgument{
+			{Name: "buildId", Description: "ID of the failed build", Required: true},
+		},
+		Render: func(args map[string]string) string {
+			buildID := args["buildId"]
+			return fmt.Sprintf(`Diagnose why TeamCity build %[1]s failed.
+
+1. Call fetch_build_log with {"buildId": "%[1]s", "severity": "error", "tailLines": 200} to find the first error.
+2. Call get_test_results with {"buildId": "%[1]s", "status": "FAILURE", "includeDetails": true} to list failed tests and their stack traces.
+3. Call get_resolved_build_steps with {"buildId": "%[1]s"} to see the commands that actually ran.
+4. If the cause is still unclear, call search_builds with the build configuration of this build and {"status": "SUCCESS", "count": 1} to find the last good build, then compare its parameters using get_build_parameters for both builds.
+
+Report the most likely root cause first, quote the relevant log lines or test failures, and suggest a concrete fix. Say whether the failure looks like a code problem, a flaky test or an infrastructure issue.`, buildID)
+		},
+	},
+	{
+		Name:        "summarize_pipeline_health",
+		Description: "Summarize the recent health of a build configuration or project: failure rate, streaks, queue and deployments",
+		Arguments: []promptArgument{
+			{Name: "buildTypeId", Description: "Build configuration to summarize (optional if projectId is set)"},
+			{Name: "projectId", Description: "Project whose deployments are included (optional)"},
+			{Name: "count", Description: "Number of recent builds to consider (default: 20)"},
+		},
+		Render: func(args map[string]string) string {
+			count := args["count"]
+			if count == "" {
+				count = "20"
+			}
+
+			var b strings.Builder
+			b.WriteString("Summarize the recent health of the TeamCity pipeline")
+			switch {
+			case args["buildTypeId"] != "":
+				fmt.Fprintf(&b, " for build configuration %s.\n\n", args["buildTypeId"])
+				fmt.Fprintf(&b, "1. Call search_builds with {\"buildTypeId\": \"%s\", \"state\": \"finished\", \"count\": %s}.\n", args["buildTypeId"], count)
+			case args["projectId"] != "":
+				fmt.Fprintf(&b, " of project %s.\n\n", args["projectId"])
+				fmt.Fprintf(&b, "1. Call search_build_configurations with {\"projectId\": \"%s\"}, then search_builds with {\"state\": \"finished\", \"count\": %s} for the most important configurations.\n", args["projectId"], count)
+			default:
+				b.WriteString(".\n\n")
+				fmt.Fprintf(&b, "1. Call search_builds with {\"state\": \"finished\", \"count\": %s}.\n", count)
+			}
+			b.WriteString("2. Read the teamcity://queueStats resource to see whether builds are waiting for agents.\n")
+			if args["projectId"] != "" {
+				fmt.Fprintf(&b, "3. Call get_deployments with {\"projectId\": \"%s\"} to see what is currently deployed.\n", args["projectId"])
+			}
+			b.WriteString("\nReport the success rate, the current failure streak if any, when the last successful build finished, noticeable changes in build duration, and queue wait times. Finish with the one issue that most deserves attention.")
+			return b.String()
+		},
+	},
+	{
+		Name:        "investigate_flaky_test",
+		Description: "Decide whether a test is flaky by comparing its recent runs across builds and configurations",
+		Arguments: []promptArgument{
+			{Name: "projectId", Description: "Project the test runs in", Required: true},
+			{Name: "testName", Description: "Full or partial test name", Required: true},
+		},
+		Render: func(args map[string]string) string {
+			return fmt.Sprintf(`Investigate whether test "%[2]s" in TeamCity project %[1]s is flaky.
+
+1. Call search_tests with {"projectId": "%[1]s", "name": "%[2]s", "occurrences": 20} to get the recent runs of the test.
+2. For two or three failed runs, call get_test_results with {"buildId": "<failed build ID>", "status": "FAILURE", "includeDetails": true} and compare the failure messages.
+3. If the failures differ, call fetch_build_log with {"buildId": "<failed build ID>", "filterPattern": "<test class or method name>"} to look for timing, ordering or environment problems.
+
+Conclude whether the test is flaky (passes and fails on the same code), consistently broken, or failing only in some configurations or on some agents. Include the failure rate and the evidence, and suggest whether to fix, quarantine (mute) or investigate the environment.`, args["projectId"], args["testName"])
+		},
+	},
+}
+
+// findPrompt returns the built-in prompt with the given name
+func findPrompt(name string) (prompt, bool) {
+	for _, p := range prompts {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return prompt{}, false
+}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+// redactToolResult masks secrets in a tool result. Binary content is passed through unchanged.
+func redactToolResult(result interface{}) interface{} {
+	switch r := result.(type) {
+	case string:
+		return logging.RedactText(r)
+	case *teamcity.StructuredResult:
+		return &teamcity.StructuredResult{Text: logging.RedactText(r.Text), Data: logging.RedactValue(r.Data)}
+	case *teamcity.BinaryContent:
+		redacted := *r
+		redacted.Summary = logging.RedactText(r.Summary)
+		return &redacted
+	default:
+		return logging.RedactValue(result)
+	}
+}
+
+// redactResource masks secrets in resource contents; the JSON text of TeamCity entities is
+// redacted structurally so that password parameters are recognized by their type
+func redactResource(resource interface{}) interface{} {
+	if r, ok := resource.(map[string]interface{}); ok {
+		if text, ok := r["text"].(string); ok && r["mimeType"] == "application/json" {
+			redacted := make(map[string]interface{}, len(r))
+			for key, value := range r {
+				redacted[key] = value
+			}
+			redacted["text"] = logging.RedactJSON(text)
+			return redacted
+		}
+	}
+	return logging.RedactValue(resource)
+}
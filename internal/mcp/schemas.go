@@ -0,0 +1,184 @@
+package mcp
+
+// Output schemas of tools that return structuredContent. They describe the JSON encoding of
+// the matching result types in the teamcity package and must be kept in sync with them.
+
+// buildSearchOutputSchema describes teamcity.BuildSearchResult
+var buildSearchOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"count": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of builds matching the search",
+		},
+		"builds": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":            map[string]interface{}{"type": "integer"},
+					"number":        map[string]interface{}{"type": "string"},
+					"status":        map[string]interface{}{"type": "string"},
+					"state":         map[string]interface{}{"type": "string"},
+					"buildTypeId":   map[string]interface{}{"type": "string"},
+					"buildTypeName": map[string]interface{}{"type": "string"},
+					"branch":        map[string]interface{}{"type": "string"},
+					"composite":     map[string]interface{}{"type": "boolean"},
+					"queuedDate":    map[string]interface{}{"type": "string", "description": "TeamCity timestamp, e.g. 20241226T143022+0300"},
+					"startDate":     map[string]interface{}{"type": "string", "description": "TeamCity timestamp"},
+					"finishDate":    map[string]interface{}{"type": "string", "description": "TeamCity timestamp"},
+					"webUrl":        map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"id", "number", "status", "state", "buildTypeId", "composite"},
+			},
+		},
+	},
+	"required": []string{"count", "builds"},
+}
+
+// testResultsOutputSchema describes teamcity.TestResults
+var testResultsOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"buildId": map[string]interface{}{"type": "string"},
+		"count": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of returned tests",
+		},
+		"start": map[string]interface{}{
+			"type":        "integer",
+			"description": "Offset of the first returned test",
+		},
+		"hasMore": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Whether more tests are available after this page",
+		},
+		"tests": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":         map[string]interface{}{"type": "string"},
+					"name":       map[string]interface{}{"type": "string"},
+					"status":     map[string]interface{}{"type": "string"},
+					"durationMs": map[string]interface{}{"type": "integer"},
+					"muted":      map[string]interface{}{"type": "boolean"},
+					"ignored":    map[string]interface{}{"type": "boolean"},
+					"newFailure": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether the test failed in this build but not in the previous one",
+					},
+					"details": map[string]interface{}{
+						"type":        "string",
+						"description": "Error message and stack trace, only set with includeDetails",
+					},
+				},
+				"required": []string{"name", "status", "durationMs", "muted", "ignored"},
+			},
+		},
+	},
+	"required": []string{"buildId", "count", "start", "hasMore", "tests"},
+}
+
+// buildConfigurationSearchOutputSchema describes teamcity.BuildConfigurationSearchResult
+var buildConfigurationSearchOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"count": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of matching build configurations",
+		},
+		"buildConfigurations": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":          map[string]interface{}{"type": "string"},
+					"name":        map[string]interface{}{"type": "string"},
+					"projectId":   map[string]interface{}{"type": "string"},
+					"projectName": map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"parameters": map[string]interface{}{
+						"type":        "array",
+						"description": "Only set with includeDetails",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":      map[string]interface{}{"type": "string"},
+								"value":     map[string]interface{}{"type": "string"},
+								"origin":    map[string]interface{}{"type": "string"},
+								"overrides": map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"name", "value"},
+						},
+					},
+					"steps": map[string]interface{}{
+						"type":        "array",
+						"description": "Only set with includeDetails",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":     map[string]interface{}{"type": "string"},
+								"type":     map[string]interface{}{"type": "string"},
+								"disabled": map[string]interface{}{"type": "boolean"},
+							},
+							"required": []string{"name", "type", "disabled"},
+						},
+					},
+					"vcsRoots": map[string]interface{}{
+						"type":        "array",
+						"description": "Only set with includeDetails",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":    map[string]interface{}{"type": "string"},
+								"vcsName": map[string]interface{}{"type": "string"},
+							},
+							"required": []string{"name", "vcsName"},
+						},
+					},
+				},
+				"required": []string{"id", "name", "projectId"},
+			},
+		},
+	},
+	"required": []string{"count", "buildConfigurations"},
+}
+
+// agentSearchOutputSchema describes teamcity.AgentSearchResult
+var agentSearchOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"count": map[string]interface{}{
+			"type":        "integer",
+			"description": "Number of returned agents",
+		},
+		"hasMore": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Whether more agents match than were returned",
+		},
+		"agents": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":         map[string]interface{}{"type": "integer"},
+					"name":       map[string]interface{}{"type": "string"},
+					"connected":  map[string]interface{}{"type": "boolean"},
+					"enabled":    map[string]interface{}{"type": "boolean"},
+					"authorized": map[string]interface{}{"type": "boolean"},
+					"poolId":     map[string]interface{}{"type": "integer"},
+					"poolName":   map[string]interface{}{"type": "string"},
+					"runningBuildId": map[string]interface{}{
+						"type":        "integer",
+						"description": "ID of the build the agent is running, unset when idle",
+					},
+					"runningBuildTypeId": map[string]interface{}{"type": "string"},
+					"webUrl":             map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"id", "name", "connected", "enabled", "authorized"},
+			},
+		},
+	},
+	"required": []string{"count", "hasMore", "agents"},
+}
@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+// defaultPollInterval is how often subscribed resources are polled for changes
+const defaultPollInterval = 15 * time.Second
+
+// Session is a client connection that can receive server notifications, such as a
+// WebSocket, SSE or STDIO connection or an HTTP session (Mcp-Session-Id). Stateless HTTP
+// requests have no session.
+type Session struct {
+	notify func(notification interface{}) error
+
+	mu              sync.RWMutex
+	protocolVersion string
+	// minLogLevel is the level set with logging/setLevel; no log messages are sent before
+	minLogLevel zapcore.Level
+	logLevelSet bool
+	// teamCityToken is the client's own TeamCity token from initialize; "" uses TC_TOKEN
+	teamCityToken string
+}
+
+// NewSession creates a session that delivers notifications with notify.
+// notify may be called concurrently with responses and must serialize writes itself.
+func NewSession(notify func(notification interface{}) error) *Session {
+	return &Session{notify: notify}
+}
+
+// ProtocolVersion returns the protocol version negotiated by the session, or "" before initialize
+func (s *Session) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocolVersion
+}
+
+// setProtocolVersion records the protocol version negotiated by initialize
+func (s *Session) setProtocolVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = version
+}
+
+// logLevel returns the minimum level of log messages sent to the session, if the client set one
+func (s *Session) logLevel() (zapcore.Level, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.minLogLevel, s.logLevelSet
+}
+
+// setLogLevel sets the minimum level of log messages sent to the session
+func (s *Session) setLogLevel(level zapcore.Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minLogLevel = level
+	s.logLevelSet = true
+}
+
+// token returns the TeamCity token the client supplied in initialize, if any
+func (s *Session) token() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.teamCityToken
+}
+
+// setToken records the TeamCity token the client supplied in initialize
+func (s *Session) setToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teamCityToken = token
+}
+
+type sessionKey struct{}
+
+// WithSession attaches the client session to the context of its requests
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// sessionFrom returns the client session of a request, or nil for requests without one
+func sessionFrom(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// subscription is a subscribed resource and the sessions subscribed to it
+type subscription struct {
+	sessions map[*Session]*subscriber
+}
+
+// subscriber is a session's subscription to a resource. The resource is polled with the TeamCity
+// token and policy grant of the subscribe request, so that a subscriber only learns of changes it
+// could read itself.
+type subscriber struct {
+	token string
+	grant *auth.Grant
+	// fingerprint is the content the subscriber last saw
+	fingerprint string
+}
+
+// subscriptionManager tracks resource subscriptions and polls TeamCity for changes
+// while at least one subscription exists
+type subscriptionManager struct {
+	mu       sync.Mutex
+	interval time.Duration
+	subs     map[string]*subscription
+	polling  bool
+
+	// snapshot returns the fingerprint of a resource's current content as seen with a grant
+	snapshot func(ctx context.Context, grant *auth.Grant, uri string) (string, error)
+	// onError is called when polling a resource fails
+	onError func(uri string, err error)
+}
+
+// subscribe registers a session for updates of uri
+func (m *subscriptionManager) subscribe(session *Session, uri string, sub *subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.subs[uri]
+	if !ok {
+		entry = &subscription{sessions: make(map[*Session]*subscriber)}
+		m.subs[uri] = entry
+	}
+	entry.sessions[session] = sub
+
+	if !m.polling {
+		m.polling = true
+		go m.poll(m.interval)
+	}
+}
+
+// unsubscribe removes a session's subscription to uri
+func (m *subscriptionManager) unsubscribe(session *Session, uri string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if sub, ok := m.subs[uri]; ok {
+		delete(sub.sessions, session)
+		if len(sub.sessions) == 0 {
+			delete(m.subs, uri)
+		}
+	}
+}
+
+// removeSession removes all subscriptions of a closed session
+func (m *subscriptionManager) removeSession(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for uri, sub := range m.subs {
+		delete(sub.sessions, session)
+		if len(sub.sessions) == 0 {
+			delete(m.subs, uri)
+		}
+	}
+}
+
+// poll checks subscribed resources for changes until no subscriptions are left
+func (m *subscriptionManager) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	type pending struct {
+		uri     string
+		session *Session
+		sub     *subscriber
+	}
+	for range ticker.C {
+		m.mu.Lock()
+		if len(m.subs) == 0 {
+			m.polling = false
+			m.mu.Unlock()
+			return
+		}
+		var checks []pending
+		for uri, entry := range m.subs {
+			for session, sub := range entry.sessions {
+				checks = append(checks, pending{uri: uri, session: session, sub: sub})
+			}
+		}
+		m.mu.Unlock()
+
+		for _, c := range checks {
+			m.check(c.uri, c.session, c.sub, interval)
+		}
+	}
+}
+
+// check notifies a subscriber of uri if the content it can see changed since the last check
+func (m *subscriptionManager) check(uri string, session *Session, sub *subscriber, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(teamcity.WithToken(context.Background(), sub.token), timeout)
+	defer cancel()
+
+	fingerprint, err := m.snapshot(ctx, sub.grant, uri)
+	if err != nil {
+		m.onError(uri, err)
+		return
+	}
+
+	m.mu.Lock()
+	entry, ok := m.subs[uri]
+	if !ok || entry.sessions[session] != sub || sub.fingerprint == fingerprint {
+		m.mu.Unlock()
+		return
+	}
+	sub.fingerprint = fingerprint
+	m.mu.Unlock()
+
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/resources/updated",
+		"params": map[string]interface{}{
+			"uri": uri,
+		},
+	}
+	if err := session.notify(notification); err != nil {
+		m.onError(uri, err)
+	}
+}
+
+// subscriberFingerprint returns the fingerprint of a subscribed resource if the grant still
+// allows reading it
+func (h *Handler) subscriberFingerprint(ctx context.Context, grant *auth.Grant, uri string) (string, error) {
+	if err := h.authorizeResource(ctx, grant, uri); err != nil {
+		return "", err
+	}
+	return h.resourceFingerprint(ctx, grant, uri)
+}
+
+// resourceFingerprint returns a hash of a resource's current content. Collections such as
+// teamcity://builds are fingerprinted by the first page of their listing filtered to the
+// grant, everything else by its read content, bypassing the cache.
+func (h *Handler) resourceFingerprint(ctx context.Context, grant *auth.Grant, uri string) (string, error) {
+	var content interface{}
+	var err error
+	switch base, _, _ := strings.Cut(uri, "?"); {
+	case base == "teamcity://projects", base == "teamcity://buildTypes", base == "teamcity://agents", uri == "teamcity://builds":
+		var resources []interface{}
+		resources, _, err = h.listResources(ctx, uri, teamcity.Page{Count: resourcesPageSize})
+		if err == nil {
+			content, err = h.filterResources(ctx, grant, uri, resources)
+		}
+	default:
+		content, err = h.readResource(withLiveReads(ctx), uri)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
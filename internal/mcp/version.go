@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+)
+
+// supportedProtocolVersions are the MCP protocol versions this server implements, newest first
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// latestProtocolVersion is the newest supported protocol version
+var latestProtocolVersion = supportedProtocolVersions[0]
+
+// defaultProtocolVersion is assumed when an initialize request does not name a version
+const defaultProtocolVersion = "2024-11-05"
+
+// HTTPDefaultProtocolVersion is assumed for HTTP requests without an MCP-Protocol-Version header
+const HTTPDefaultProtocolVersion = "2025-03-26"
+
+// protocolVersionFormat matches MCP protocol version identifiers
+var protocolVersionFormat = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// protocolFeature is a protocol feature that is only used when the negotiated version supports it
+type protocolFeature string
+
+const (
+	// featureToolAnnotations adds behavior hints to tool definitions
+	featureToolAnnotations protocolFeature = "toolAnnotations"
+	// featureStructuredContent adds outputSchema to tool definitions and structuredContent to tool results
+	featureStructuredContent protocolFeature = "structuredContent"
+)
+
+// featureVersions maps protocol features to the version that introduced them
+var featureVersions = map[protocolFeature]string{
+	featureToolAnnotations:   "2025-03-26",
+	featureStructuredContent: "2025-06-18",
+}
+
+// IsSupportedProtocolVersion reports whether version is a supported MCP protocol version
+func IsSupportedProtocolVersion(version string) bool {
+	for _, supported := range supportedProtocolVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateProtocolVersion picks the protocol version for a client that requested the given one.
+// Supported versions are accepted as is; clients newer than this server are offered the latest
+// supported version to accept or disconnect. Older and malformed versions are rejected.
+func negotiateProtocolVersion(requested string) (string, bool) {
+	switch {
+	case requested == "":
+		return defaultProtocolVersion, true
+	case IsSupportedProtocolVersion(requested):
+		return requested, true
+	case protocolVersionFormat.MatchString(requested) && requested > latestProtocolVersion:
+		// Versions are dates, so they compare lexically
+		return latestProtocolVersion, true
+	default:
+		return "", false
+	}
+}
+
+type protocolVersionKey struct{}
+
+// WithProtocolVersion attaches the protocol version of a request without a session, such as
+// the MCP-Protocol-Version header of an HTTP request, to its context
+func WithProtocolVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, protocolVersionKey{}, version)
+}
+
+// protocolVersionFrom returns the protocol version a request is handled with: the version
+// negotiated by its session, then the version attached to its context, then the latest version
+func protocolVersionFrom(ctx context.Context) string {
+	if session := sessionFrom(ctx); session != nil {
+		if version := session.ProtocolVersion(); version != "" {
+			return version
+		}
+	}
+	if version, ok := ctx.Value(protocolVersionKey{}).(string); ok && version != "" {
+		return version
+	}
+	return latestProtocolVersion
+}
+
+// supports reports whether the protocol version of a request includes a feature
+func supports(ctx context.Context, feature protocolFeature) bool {
+	return protocolVersionFrom(ctx) >= featureVersions[feature]
+}
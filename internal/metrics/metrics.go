@@ -59,6 +59,38 @@ var (
 		[]string{"resource_type"},
 	)
 
+	CacheHitRatio = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_hit_ratio",
+			Help: "Ratio of cache lookups served from the cache",
+		},
+		[]string{"resource_type"},
+	)
+
+	CacheEntries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_entries",
+			Help: "Number of entries in the cache",
+		},
+		[]string{"resource_type"},
+	)
+
+	CacheBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_bytes",
+			Help: "Approximate size of cached values in bytes",
+		},
+		[]string{"resource_type"},
+	)
+
+	CacheEvictionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Total number of expired cache entries removed",
+		},
+		[]string{"resource_type"},
+	)
+
 	// Server health metrics
 	ServerConnections = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -102,3 +134,19 @@ func RecordCacheHit(resourceType string) {
 func RecordCacheMiss(resourceType string) {
 	CacheMissesTotal.WithLabelValues(resourceType).Inc()
 }
+
+// RecordCacheHitRatio records the share of cache lookups that were hits
+func RecordCacheHitRatio(resourceType string, ratio float64) {
+	CacheHitRatio.WithLabelValues(resourceType).Set(ratio)
+}
+
+// RecordCacheSize records the number of cached entries and their size
+func RecordCacheSize(resourceType string, entries, bytes int) {
+	CacheEntries.WithLabelValues(resourceType).Set(float64(entries))
+	CacheBytes.WithLabelValues(resourceType).Set(float64(bytes))
+}
+
+// RecordCacheEviction records the removal of an expired cache entry
+func RecordCacheEviction(resourceType string) {
+	CacheEvictionsTotal.WithLabelValues(resourceType).Inc()
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// STDIO message framings
+const (
+	// stdioFramingNewline is newline-delimited JSON, the MCP default
+	stdioFramingNewline = "newline"
+	// stdioFramingContentLength frames each message with LSP-style Content-Length headers
+	stdioFramingContentLength = "content-length"
+)
+
+// SetSTDIOFraming selects how STDIO messages are framed: "newline" (default) or "content-length"
+func (s *Server) SetSTDIOFraming(framing string) error {
+	switch framing {
+	case stdioFramingNewline, stdioFramingContentLength:
+		s.stdioFraming = framing
+		return nil
+	default:
+		return fmt.Errorf("unsupported STDIO framing: %s (use %s or %s)", framing, stdioFramingNewline, stdioFramingContentLength)
+	}
+}
+
+// readSTDIOFramed reads Content-Length framed messages and sends them to requests until input
+// ends; messages that cannot be read are answered with an error through reply, as their ID is unknown
+func (s *Server) readSTDIOFramed(r io.Reader, requests chan<- json.RawMessage, reply func(interface{}) error) {
+	defer close(requests)
+
+	headers := textproto.NewReader(bufio.NewReader(r))
+	for {
+		header, err := headers.ReadMIMEHeader()
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Error("Failed to read message headers", "error", err)
+			}
+			return
+		}
+
+		length, err := strconv.ParseInt(strings.TrimSpace(header.Get("Content-Length")), 10, 64)
+		if err != nil || length < 0 {
+			// Without a length the next message cannot be found; skip to the next header block
+			s.logger.Error("Message without valid Content-Length header", "content_length", header.Get("Content-Length"))
+			if err := reply(s.mcp.InvalidMessageResponse("missing or invalid Content-Length header")); err != nil {
+				s.logger.Error("Failed to write response", "error", err)
+			}
+			continue
+		}
+
+		if limit := s.maxRequestSize(); length > limit {
+			s.logger.Warn("Rejected oversized STDIO message", "size", length, "limit", limit)
+			if _, err := io.CopyN(io.Discard, headers.R, length); err != nil {
+				return
+			}
+			if err := reply(s.mcp.RequestTooLargeResponse(limit)); err != nil {
+				s.logger.Error("Failed to write response", "error", err)
+			}
+			continue
+		}
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(headers.R, body); err != nil {
+			s.logger.Error("Failed to read message body", "error", err)
+			return
+		}
+		requests <- json.RawMessage(body)
+	}
+}
+
+// contentLengthWriter returns a function writing messages to w with Content-Length framing.
+// The caller must serialize calls.
+func contentLengthWriter(w io.Writer) func(interface{}) error {
+	return func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
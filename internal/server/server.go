@@ -1,23 +1,32 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 
+	"github.com/itcaat/teamcity-mcp/internal/audit"
+	"github.com/itcaat/teamcity-mcp/internal/auth"
 	"github.com/itcaat/teamcity-mcp/internal/cache"
 	"github.com/itcaat/teamcity-mcp/internal/config"
 	"github.com/itcaat/teamcity-mcp/internal/health"
@@ -34,12 +43,34 @@ type Server struct {
 	cache    *cache.Cache
 	health   *health.Checker
 	mcp      *mcp.Handler
+	sessions *httpSessions
 	upgrader websocket.Upgrader
 	mu       sync.RWMutex
+
+	// stdioFraming is the STDIO message framing, see SetSTDIOFraming
+	stdioFraming string
+
+	// secrets are the accepted server secrets (SERVER_SECRET and SERVER_SECRETS); guarded by mu
+	// as they are replaced on configuration reload
+	secrets []config.NamedSecret
+
+	// jwt validates JWT bearer tokens; nil if JWT_JWKS_URL is not configured
+	jwt *auth.JWTValidator
+
+	// clientCAs verify client certificates (mutual TLS); nil if CLIENT_CA is not configured
+	clientCAs         *x509.CertPool
+	requireClientCert bool
+
+	// audit records tool invocations; nil if AUDIT_LOG is not configured
+	audit *audit.Logger
+
+	// connections is the parent context of WebSocket connections; closeConnections ends them on shutdown
+	connections      context.Context
+	closeConnections context.CancelFunc
 }
 
-// New creates a new MCP server instance
-func New(cfg *config.Config, logger *zap.SugaredLogger) (*Server, error) {
+// New creates a new MCP server instance; build identifies the running binary in health responses
+func New(cfg *config.Config, logger *zap.SugaredLogger, build health.BuildInfo) (*Server, error) {
 	// Create TeamCity client
 	tc, err := teamcity.NewClient(cfg.TeamCity, logger)
 	if err != nil {
@@ -53,10 +84,46 @@ func New(cfg *config.Config, logger *zap.SugaredLogger) (*Server, error) {
 	}
 
 	// Create health checker
-	health := health.New(tc, logger)
+	health := health.New(tc, logger, build)
 
 	// Create MCP handler
 	mcpHandler := mcp.NewHandler(tc, cache, logger)
+	if interval, err := time.ParseDuration(cfg.Server.SubscriptionPollInterval); err == nil && interval > 0 {
+		mcpHandler.SetPollInterval(interval)
+	}
+	if err := mcpHandler.SetCapabilities(strings.Split(cfg.Server.Capabilities, ",")); err != nil {
+		return nil, fmt.Errorf("configuring capabilities: %w", err)
+	}
+	if cfg.Server.PolicyFile != "" {
+		policy, err := auth.LoadPolicy(cfg.Server.PolicyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading access policy: %w", err)
+		}
+		mcpHandler.SetPolicy(policy)
+	}
+
+	confirmDestructive, _ := strconv.ParseBool(cfg.Server.ConfirmDestructiveTools)
+	mcpHandler.SetConfirmDestructive(confirmDestructive)
+
+	var auditLog *audit.Logger
+	if cfg.Server.AuditLog != "" {
+		maxSize, _ := strconv.ParseInt(cfg.Server.AuditLogMaxSize, 10, 64)
+		maxBackups, _ := strconv.Atoi(cfg.Server.AuditLogMaxBackups)
+		auditLog, err = audit.Open(audit.Config{
+			Destination: cfg.Server.AuditLog,
+			MaxSize:     maxSize,
+			MaxBackups:  maxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+		mcpHandler.SetAuditLogger(auditLog)
+	}
+
+	idleTimeout, err := time.ParseDuration(cfg.Server.HTTPSessionIdleTimeout)
+	if err != nil || idleTimeout <= 0 {
+		idleTimeout = defaultSessionIdleTimeout
+	}
 
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -64,31 +131,88 @@ func New(cfg *config.Config, logger *zap.SugaredLogger) (*Server, error) {
 		},
 	}
 
+	secrets, err := cfg.Server.ServerSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("parsing server secrets: %w", err)
+	}
+	logSecretExpiry(logger, secrets)
+
+	var jwt *auth.JWTValidator
+	if cfg.Server.JWTJWKSURL != "" {
+		jwt, err = auth.NewJWTValidator(auth.JWTConfig{
+			JWKSURL:   cfg.Server.JWTJWKSURL,
+			Issuer:    cfg.Server.JWTIssuer,
+			Audience:  cfg.Server.JWTAudience,
+			UserClaim: cfg.Server.JWTUserClaim,
+		}, &http.Client{Timeout: 10 * time.Second})
+		if err != nil {
+			return nil, fmt.Errorf("creating JWT validator: %w", err)
+		}
+	}
+
+	var clientCAs *x509.CertPool
+	if cfg.Server.ClientCA != "" {
+		clientCAs, err = loadCertPool(cfg.Server.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("loading client CA: %w", err)
+		}
+	}
+	requireClientCert, _ := strconv.ParseBool(cfg.Server.RequireClientCert)
+
+	connections, closeConnections := context.WithCancel(context.Background())
+
 	return &Server{
-		cfg:      cfg,
-		logger:   logger,
-		tc:       tc,
-		cache:    cache,
-		health:   health,
-		mcp:      mcpHandler,
-		upgrader: upgrader,
+		cfg:               cfg,
+		logger:            logger,
+		tc:                tc,
+		cache:             cache,
+		health:            health,
+		mcp:               mcpHandler,
+		sessions:          newHTTPSessions(idleTimeout, mcpHandler.CloseSession),
+		upgrader:          upgrader,
+		secrets:           secrets,
+		jwt:               jwt,
+		clientCAs:         clientCAs,
+		requireClientCert: requireClientCert,
+		audit:             auditLog,
+		connections:       connections,
+		closeConnections:  closeConnections,
 	}, nil
 }
 
 // Start starts the server with the specified transport
 func (s *Server) Start(ctx context.Context, transport string) error {
+	if s.audit != nil {
+		defer s.audit.Close()
+	}
+
+	if transport == "stdio" {
+		return s.startSTDIO(ctx)
+	}
+
+	handler, err := s.Handler(ctx, transport)
+	if err != nil {
+		return err
+	}
+	return s.serveHTTP(ctx, handler)
+}
+
+// Handler returns the authenticated HTTP handler of the "http" or "sse" transport; SSE event
+// streams are ended when the context is cancelled
+func (s *Server) Handler(ctx context.Context, transport string) (http.Handler, error) {
+	mux := s.newMux()
 	switch transport {
 	case "http":
-		return s.startHTTP(ctx)
-	case "stdio":
-		return s.startSTDIO(ctx)
+	case "sse":
+		s.registerSSE(ctx, mux)
 	default:
-		return fmt.Errorf("unsupported transport: %s", transport)
+		return nil, fmt.Errorf("unsupported transport: %s", transport)
 	}
+	return s.authMiddleware(mux), nil
 }
 
-// startHTTP starts the HTTP server
-func (s *Server) startHTTP(ctx context.Context) error {
+// newMux registers the MCP, health and metrics endpoints shared by the HTTP based transports
+func (s *Server) newMux() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// MCP endpoint
@@ -99,18 +223,12 @@ func (s *Server) startHTTP(ctx context.Context) error {
 	mux.HandleFunc("/readyz", s.health.ReadinessHandler)
 	mux.HandleFunc("/metrics", s.handleMetrics)
 
-	server := &http.Server{
-		Addr:    s.cfg.Server.ListenAddr,
-		Handler: s.authMiddleware(mux),
-	}
+	return mux
+}
 
-	// Configure TLS if certificates are provided
-	if s.cfg.Server.TLSCert != "" && s.cfg.Server.TLSKey != "" {
-		tlsConfig := &tls.Config{
-			MinVersion: tls.VersionTLS13,
-		}
-		server.TLSConfig = tlsConfig
-	}
+// serveHTTP serves handler until the context is cancelled, then drains in-flight requests
+func (s *Server) serveHTTP(ctx context.Context, handler http.Handler) error {
+	server := s.HTTPServer(handler)
 
 	// Start server in goroutine
 	errChan := make(chan error, 1)
@@ -123,11 +241,21 @@ func (s *Server) startHTTP(ctx context.Context) error {
 		}
 	}()
 
+	stopExpiry := make(chan struct{})
+	defer close(stopExpiry)
+	go s.expireSessions(stopExpiry)
+
 	// Wait for context cancellation or server error
 	select {
 	case <-ctx.Done():
-		s.logger.Info("Shutting down HTTP server")
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// Session event streams and WebSocket connections never finish on their own, and hijacked
+		// WebSocket connections are not tracked by Shutdown; end them so other requests can drain
+		s.sessions.closeAll()
+		s.closeConnections()
+
+		timeout := s.shutdownTimeout(s.cfg.Server.HTTPShutdownTimeout)
+		s.logger.Info("Shutting down HTTP server", "drain_timeout", timeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		return server.Shutdown(shutdownCtx)
 	case err := <-errChan:
@@ -135,42 +263,179 @@ func (s *Server) startHTTP(ctx context.Context) error {
 	}
 }
 
-// startSTDIO starts the STDIO transport
+// HTTPServer returns the HTTP server serving handler on the listen address, with the configured
+// timeouts and TLS settings
+func (s *Server) HTTPServer(handler http.Handler) *http.Server {
+	// Timeouts protect exposed deployments from slow clients holding connections open.
+	// WebSocket connections are not affected: their deadlines are cleared on upgrade and
+	// they are governed by WS_PING_INTERVAL and WS_IDLE_TIMEOUT instead.
+	server := &http.Server{
+		Addr:              s.cfg.Server.ListenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: parseTimeout(s.cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       parseTimeout(s.cfg.Server.ReadTimeout),
+		WriteTimeout:      parseTimeout(s.cfg.Server.WriteTimeout),
+		IdleTimeout:       parseTimeout(s.cfg.Server.IdleTimeout),
+	}
+
+	// Configure TLS if certificates are provided
+	if s.cfg.Server.TLSCert != "" && s.cfg.Server.TLSKey != "" {
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS13,
+		}
+		if s.clientCAs != nil {
+			tlsConfig.ClientCAs = s.clientCAs
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			if s.requireClientCert {
+				tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+		server.TLSConfig = tlsConfig
+	}
+	return server
+}
+
+// startSTDIO starts the STDIO transport on standard input and output
 func (s *Server) startSTDIO(ctx context.Context) error {
+	return s.ServeSTDIO(ctx, os.Stdin, os.Stdout)
+}
+
+// ServeSTDIO handles the MCP messages read from in and writes the responses to out until in
+// ends or the context is cancelled, then drains pending requests
+func (s *Server) ServeSTDIO(ctx context.Context, in io.Reader, out io.Writer) error {
 	s.logger.Info("Starting STDIO transport")
 
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
+	write := json.NewEncoder(out).Encode
+	if s.stdioFraming == stdioFramingContentLength {
+		s.logger.Info("Using Content-Length framing for STDIO messages")
+		write = contentLengthWriter(out)
+	}
+
+	// Responses and subscription notifications are written from different goroutines
+	var writeMu sync.Mutex
+	writeJSON := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return write(v)
+	}
+
+	requests := make(chan json.RawMessage)
+	if s.stdioFraming == stdioFramingContentLength {
+		go s.readSTDIOFramed(in, requests, writeJSON)
+	} else {
+		go s.readSTDIO(in, requests)
+	}
+
+	session := mcp.NewSession(writeJSON)
+	defer s.mcp.CloseSession(session)
 
+	// Requests are handled with a context that survives shutdown so that a pending
+	// request can finish and its response can be written before exit
+	handleCtx, cancelHandle := context.WithCancel(mcp.WithSession(context.Background(), session))
+	defer cancelHandle()
+
+	// Requests are handled concurrently so that notifications/cancelled can reach a running request
+	var pending sync.WaitGroup
 	for {
 		select {
 		case <-ctx.Done():
+			done := make(chan struct{})
+			go func() {
+				pending.Wait()
+				close(done)
+			}()
+
+			timeout := s.shutdownTimeout(s.cfg.Server.STDIOShutdownTimeout)
+			s.logger.Info("Draining pending STDIO requests", "drain_timeout", timeout)
+			select {
+			case <-done:
+			case <-time.After(timeout):
+				s.logger.Warn("Pending STDIO requests did not finish before shutdown")
+				cancelHandle()
+			}
 			return nil
-		default:
-			var req json.RawMessage
-			if err := decoder.Decode(&req); err != nil {
-				if err == io.EOF {
-					return nil
-				}
-				s.logger.Error("Failed to decode request", "error", err)
-				continue
+		case req, ok := <-requests:
+			if !ok {
+				// Input is closed; finish pending requests so their responses are written
+				pending.Wait()
+				return nil
 			}
 
-			resp, err := s.mcp.HandleRequest(ctx, req)
-			if err != nil {
-				s.logger.Error("Failed to handle request", "error", err)
-				continue
-			}
+			pending.Add(1)
+			go func() {
+				defer pending.Done()
+				s.handleSTDIORequest(handleCtx, writeJSON, req)
+			}()
+		}
+	}
+}
 
-			if resp != nil {
-				if err := encoder.Encode(resp); err != nil {
-					s.logger.Error("Failed to encode response", "error", err)
+// readSTDIO decodes requests from r until EOF and sends them to requests
+func (s *Server) readSTDIO(r io.Reader, requests chan<- json.RawMessage) {
+	defer close(requests)
+
+	input := bufio.NewReader(r)
+	decoder := json.NewDecoder(input)
+	for {
+		var req json.RawMessage
+		if err := decoder.Decode(&req); err != nil {
+			if err == io.EOF {
+				return
+			}
+			s.logger.Error("Failed to decode request", "error", err)
+
+			// The decoder cannot recover from malformed input; skip the rest of the line
+			rest, _ := io.ReadAll(decoder.Buffered())
+			rest = bytes.TrimLeft(rest, " \t\r\n")
+			if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+				rest = rest[i+1:]
+			} else {
+				rest = nil
+				if _, err := input.ReadBytes('\n'); err != nil {
+					return
 				}
 			}
+			decoder = json.NewDecoder(io.MultiReader(bytes.NewReader(rest), input))
+			continue
+		}
+		requests <- req
+	}
+}
+
+// handleSTDIORequest handles a single STDIO request and writes its response
+func (s *Server) handleSTDIORequest(ctx context.Context, writeJSON func(interface{}) error, req json.RawMessage) {
+	resp, err := s.mcp.HandleRequest(ctx, req)
+	if err != nil {
+		s.logger.Error("Failed to handle request", "error", err)
+		return
+	}
+
+	if resp != nil {
+		if err := writeJSON(resp); err != nil {
+			s.logger.Error("Failed to encode response", "error", err)
 		}
 	}
 }
 
+// parseTimeout parses a configured HTTP server timeout; invalid values disable the timeout
+// as they are rejected when the configuration is loaded
+func parseTimeout(value string) time.Duration {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// shutdownTimeout parses a configured drain timeout, falling back to 30 seconds
+func (s *Server) shutdownTimeout(value string) time.Duration {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 30 * time.Second
+	}
+	return timeout
+}
+
 // handleMCP handles MCP requests over HTTP/WebSocket
 func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 	if websocket.IsWebSocketUpgrade(r) {
@@ -178,31 +443,122 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle regular HTTP MCP request
-	if r.Method != http.MethodPost {
+	switch {
+	case r.Method == http.MethodGet && r.Header.Get(sessionIDHeader) != "":
+		s.handleSessionStream(w, r)
+		return
+	case r.Method == http.MethodDelete:
+		s.handleSessionDelete(w, r)
+		return
+	case r.Method != http.MethodPost:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	// Requests outside a session name the negotiated protocol version in a header
+	protocolVersion := r.Header.Get("MCP-Protocol-Version")
+	if protocolVersion == "" {
+		protocolVersion = mcp.HTTPDefaultProtocolVersion
+	} else if !mcp.IsSupportedProtocolVersion(protocolVersion) {
+		http.Error(w, "Unsupported MCP-Protocol-Version", http.StatusBadRequest)
+		return
+	}
+
+	limit := s.maxRequestSize()
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
 	var req json.RawMessage
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.logger.Warn("Rejected oversized MCP request", "limit", limit)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(s.mcp.RequestTooLargeResponse(limit))
+			return
+		}
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	resp, err := s.mcp.HandleRequest(r.Context(), req)
+	ctx := withTeamCityToken(mcp.WithProtocolVersion(r.Context(), protocolVersion), r)
+
+	// Requests naming a session must belong to a live one; initialize without a session starts one.
+	// Requests without a session ID are still handled statelessly.
+	var session *httpSession
+	newSession := false
+	if id := r.Header.Get(sessionIDHeader); id != "" {
+		if session = s.sessions.get(id); session == nil {
+			http.Error(w, "Unknown session", http.StatusNotFound)
+			return
+		}
+	} else if isInitializeRequest(req) {
+		session = newHTTPSession()
+		newSession = true
+	}
+	if session != nil {
+		ctx = mcp.WithSession(ctx, session.mcp)
+	}
+
+	resp, err := s.mcp.HandleRequest(ctx, req)
 	if err != nil {
 		s.logger.Error("Failed to handle MCP request", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	if newSession && isSuccessResponse(resp) {
+		id, err := s.sessions.register(session)
+		if err != nil {
+			s.logger.Warn("Failed to start HTTP session", "error", err)
+		} else {
+			s.logger.Info("HTTP session started", "session_id", id)
+			w.Header().Set(sessionIDHeader, id)
+		}
+	}
+
+	// Notifications, and batches of only notifications, have no response
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		s.logger.Error("Failed to encode response", "error", err)
 	}
 }
 
+// isInitializeRequest reports whether a JSON-RPC message is an initialize request
+func isInitializeRequest(req json.RawMessage) bool {
+	var base struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(req, &base) == nil && base.Method == "initialize"
+}
+
+// isSuccessResponse reports whether a JSON-RPC response carries a result rather than an error
+func isSuccessResponse(resp interface{}) bool {
+	response, ok := resp.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasResult := response["result"]
+	return hasResult
+}
+
+// wsWriteWait bounds how long a WebSocket control frame may take to write
+const wsWriteWait = 10 * time.Second
+
+// teamCityTokenHeader lets HTTP clients act in TeamCity with their own token instead of TC_TOKEN
+const teamCityTokenHeader = "X-TeamCity-Token"
+
+// withTeamCityToken makes the TeamCity requests of an MCP request use the token of its
+// X-TeamCity-Token header, if there is one
+func withTeamCityToken(ctx context.Context, r *http.Request) context.Context {
+	return teamcity.WithToken(ctx, r.Header.Get(teamCityTokenHeader))
+}
+
 // handleWebSocket handles WebSocket connections
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
@@ -215,37 +571,183 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	metrics.ServerConnections.WithLabelValues("websocket").Inc()
 	defer metrics.ServerConnections.WithLabelValues("websocket").Dec()
 
-	s.logger.Info("WebSocket connection established")
+	pingInterval, idleTimeout, limit := s.webSocketSettings()
+	s.logger.Info("WebSocket connection established", "ping_interval", pingInterval, "idle_timeout", idleTimeout)
+
+	// Responses and subscription notifications are written from different goroutines
+	var writeMu sync.Mutex
+	writeJSON := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	session := mcp.NewSession(writeJSON)
+	defer s.mcp.CloseSession(session)
+
+	// Requests are handled concurrently so that notifications/cancelled can reach a running request;
+	// they are aborted and awaited when the connection ends. The context derives from the server
+	// rather than the upgrade request so that shutdown cancels it; the client identity and
+	// TeamCity token of the upgrade request apply to the whole connection.
+	connCtx := auth.WithIdentity(mcp.WithSession(s.connections, session), auth.IdentityFrom(r.Context()))
+	ctx, cancel := context.WithCancel(withTeamCityToken(connCtx, r))
+	var pending sync.WaitGroup
+	defer pending.Wait()
+	defer cancel()
+
+	// On server shutdown tell the client and close the connection, which ends the read loop
+	stopShutdown := context.AfterFunc(s.connections, func() {
+		closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+		conn.Close()
+	})
+	defer stopShutdown()
+
+	// Any message or pong from the client extends the idle deadline
+	extendDeadline := func() {
+		if idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		}
+	}
+	extendDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+
+	if pingInterval > 0 {
+		go s.pingWebSocket(ctx, conn, pingInterval)
+	}
 
 	for {
-		var req json.RawMessage
-		if err := conn.ReadJSON(&req); err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+		req, err := readWebSocketMessage(conn, limit)
+		if err == errMessageTooLarge {
+			extendDeadline()
+			s.logger.Warn("Rejected oversized WebSocket message", "limit", limit)
+			if err := writeJSON(s.mcp.RequestTooLargeResponse(limit)); err != nil {
+				s.logger.Error("Failed to write WebSocket response", "error", err)
+				break
+			}
+			continue
+		}
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.As(err, &netErr) && netErr.Timeout():
+				s.logger.Info("Closing idle WebSocket connection", "idle_timeout", idleTimeout)
+				closeWebSocket(conn, websocket.CloseGoingAway, "idle timeout")
+			case s.connections.Err() != nil:
+				// Closed on shutdown
+			case websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure):
 				s.logger.Error("WebSocket error", "error", err)
 			}
 			break
 		}
+		extendDeadline()
 
-		resp, err := s.mcp.HandleRequest(r.Context(), req)
-		if err != nil {
-			s.logger.Error("Failed to handle WebSocket request", "error", err)
-			continue
-		}
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
 
-		if resp != nil {
-			if err := conn.WriteJSON(resp); err != nil {
-				s.logger.Error("Failed to write WebSocket response", "error", err)
-				break
+			resp, err := s.mcp.HandleRequest(ctx, req)
+			if err != nil {
+				s.logger.Error("Failed to handle WebSocket request", "error", err)
+				return
+			}
+
+			if resp != nil {
+				if err := writeJSON(resp); err != nil {
+					s.logger.Error("Failed to write WebSocket response", "error", err)
+				}
 			}
+		}()
+	}
+
+	s.logger.Info("WebSocket connection closed")
+}
+
+// pingWebSocket sends keepalive pings on conn until ctx is cancelled or a ping cannot be written
+func (s *Server) pingWebSocket(ctx context.Context, conn *websocket.Conn, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				s.logger.Debug("Failed to send WebSocket ping", "error", err)
+				return
+			}
+		}
+	}
+}
+
+// closeWebSocket sends a close frame; errors are ignored as the connection is being closed anyway
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+}
+
+// webSocketSettings returns the configured keepalive ping interval, idle timeout and maximum message size
+func (s *Server) webSocketSettings() (pingInterval, idleTimeout time.Duration, maxMessageSize int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Like WS_MAX_MESSAGE_SIZE itself, the limit defaults to the maximum request size
+	maxMessageSize, err := strconv.ParseInt(s.cfg.Server.WSMaxMessageSize, 10, 64)
+	if err != nil || maxMessageSize <= 0 {
+		maxMessageSize, err = strconv.ParseInt(s.cfg.Server.MaxRequestSize, 10, 64)
+	}
+	if err != nil || maxMessageSize <= 0 {
+		maxMessageSize = 1 << 20
+	}
+	return parseTimeout(s.cfg.Server.WSPingInterval), parseTimeout(s.cfg.Server.WSIdleTimeout), maxMessageSize
+}
+
+// errMessageTooLarge is returned for WebSocket messages exceeding the maximum request size
+var errMessageTooLarge = errors.New("message exceeds the maximum request size")
+
+// readWebSocketMessage reads the next WebSocket message, buffering at most limit bytes.
+// The remainder of an oversized message is discarded so the connection stays usable.
+func readWebSocketMessage(conn *websocket.Conn, limit int64) (json.RawMessage, error) {
+	_, reader, err := conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return nil, err
 		}
+		return nil, errMessageTooLarge
+	}
+
+	var req json.RawMessage
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// maxRequestSize returns the configured maximum request size in bytes
+func (s *Server) maxRequestSize() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	size, err := strconv.ParseInt(s.cfg.Server.MaxRequestSize, 10, 64)
+	if err != nil || size <= 0 {
+		return 1 << 20
 	}
+	return size
 }
 
 // handleMetrics handles Prometheus metrics endpoint
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	// This will be implemented by importing prometheus handler
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("# Metrics endpoint placeholder\n"))
+	promhttp.Handler().ServeHTTP(w, r)
 }
 
 // authMiddleware provides HMAC-based authentication (optional)
@@ -257,14 +759,21 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// If no server secret is configured, skip authentication
-		if s.cfg.Server.ServerSecret == "" {
-			next.ServeHTTP(w, r)
+		// A verified client certificate identifies the client without a bearer token
+		certIdentity := auth.CertificateIdentity(r.TLS)
+
+		// If neither server secrets nor JWT validation are configured, skip authentication
+		if len(s.serverSecrets()) == 0 && s.jwt == nil {
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), certIdentity)))
 			return
 		}
 
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
+			if certIdentity != nil {
+				next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), certIdentity)))
+				return
+			}
 			http.Error(w, "Authorization header required", http.StatusUnauthorized)
 			return
 		}
@@ -275,30 +784,103 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
-		if !s.validateToken(token) {
+		identity, err := s.authenticate(r.Context(), token)
+		if err != nil {
+			s.logger.Debug("Rejected client token", "error", err)
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
 	})
 }
 
-// validateToken validates the HMAC token
-func (s *Server) validateToken(token string) bool {
-	// Simple HMAC validation - in production, implement proper token validation
-	mac := hmac.New(sha256.New, []byte(s.cfg.Server.ServerSecret))
-	mac.Write([]byte("teamcity-mcp"))
-	expectedMAC := mac.Sum(nil)
-	expectedToken := hex.EncodeToString(expectedMAC)
+// loadCertPool reads a PEM bundle of CA certificates
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// authenticate validates a bearer token: a JWT if JWT validation is configured, otherwise
+// the HMAC token derived from one of the server secrets
+func (s *Server) authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	if s.jwt != nil && auth.LooksLikeJWT(token) {
+		return s.jwt.Validate(ctx, token)
+	}
 
-	return hmac.Equal([]byte(token), []byte(expectedToken))
+	secret, ok := s.validateToken(token)
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	if secret.Expired(time.Now()) {
+		s.logger.Warn("Rejected token of expired server secret", "secret", secret.Name, "expired", secret.Expires)
+		return nil, fmt.Errorf("server secret %q expired", secret.Name)
+	}
+	return &auth.Identity{Subject: secret.Name, Method: auth.MethodHMAC}, nil
+}
+
+// validateToken returns the server secret the HMAC token was derived from
+func (s *Server) validateToken(token string) (config.NamedSecret, bool) {
+	for _, secret := range s.serverSecrets() {
+		mac := hmac.New(sha256.New, []byte(secret.Secret))
+		mac.Write([]byte("teamcity-mcp"))
+		expectedToken := hex.EncodeToString(mac.Sum(nil))
+
+		if hmac.Equal([]byte(token), []byte(expectedToken)) {
+			return secret, true
+		}
+	}
+	return config.NamedSecret{}, false
+}
+
+// serverSecrets returns the accepted server secrets
+func (s *Server) serverSecrets() []config.NamedSecret {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.secrets
+}
+
+// logSecretExpiry warns about server secrets that expired or expire within a week
+func logSecretExpiry(logger *zap.SugaredLogger, secrets []config.NamedSecret) {
+	now := time.Now()
+	for _, secret := range secrets {
+		switch {
+		case secret.Expired(now):
+			logger.Warn("Server secret has expired", "secret", secret.Name, "expired", secret.Expires)
+		case secret.Expired(now.Add(7 * 24 * time.Hour)):
+			logger.Warn("Server secret expires soon", "secret", secret.Name, "expires", secret.Expires)
+		}
+	}
 }
 
 // UpdateConfig updates the server configuration (for SIGHUP)
 func (s *Server) UpdateConfig(cfg *config.Config) {
+	// Secrets were validated when the configuration was loaded
+	secrets, err := cfg.Server.ServerSecrets()
+	if err != nil {
+		s.logger.Error("Ignoring invalid server secrets", "error", err)
+		secrets = s.serverSecrets()
+	}
+	logSecretExpiry(s.logger, secrets)
+
+	if cfg.Server.PolicyFile == "" {
+		s.mcp.SetPolicy(nil)
+	} else if policy, err := auth.LoadPolicy(cfg.Server.PolicyFile); err != nil {
+		s.logger.Error("Keeping the current access policy", "error", err)
+	} else {
+		s.mcp.SetPolicy(policy)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.cfg = cfg
+	s.secrets = secrets
 	s.logger.Info("Configuration updated")
 }
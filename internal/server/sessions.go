@@ -0,0 +1,268 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// sessionIDHeader carries the HTTP session ID issued on initialize
+const sessionIDHeader = "Mcp-Session-Id"
+
+// defaultSessionIdleTimeout is how long an HTTP session is kept without requests if not configured
+const defaultSessionIdleTimeout = 30 * time.Minute
+
+// errNotificationDropped is returned when a notification cannot be queued for an HTTP session,
+// e.g. because the client has no event stream open to receive it
+var errNotificationDropped = errors.New("notification dropped: no event stream is receiving")
+
+// httpSession is a Streamable HTTP session. Requests name it with the Mcp-Session-Id header;
+// server notifications are delivered on event streams the client opens with GET /mcp.
+type httpSession struct {
+	mcp           *mcp.Session
+	notifications chan []byte
+	done          chan struct{}
+
+	mu         sync.Mutex
+	lastActive time.Time
+}
+
+// httpSessions tracks the open HTTP sessions and expires idle ones
+type httpSessions struct {
+	mu          sync.Mutex
+	sessions    map[string]*httpSession
+	idleTimeout time.Duration
+	closed      bool
+
+	// onClose is called when a session ends
+	onClose func(session *mcp.Session)
+}
+
+// newHTTPSessions creates a session store that expires sessions idle for longer than idleTimeout
+func newHTTPSessions(idleTimeout time.Duration, onClose func(session *mcp.Session)) *httpSessions {
+	return &httpSessions{
+		sessions:    make(map[string]*httpSession),
+		idleTimeout: idleTimeout,
+		onClose:     onClose,
+	}
+}
+
+// newHTTPSession creates a session that is not yet registered
+func newHTTPSession() *httpSession {
+	session := &httpSession{
+		notifications: make(chan []byte, 64),
+		done:          make(chan struct{}),
+		lastActive:    time.Now(),
+	}
+	session.mcp = mcp.NewSession(session.notify)
+	return session
+}
+
+// notify queues a server notification for the session's event streams without blocking
+func (session *httpSession) notify(notification interface{}) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case session.notifications <- data:
+		return nil
+	case <-session.done:
+		return errSessionClosed
+	default:
+		return errNotificationDropped
+	}
+}
+
+// touch records activity on the session
+func (session *httpSession) touch() {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.lastActive = time.Now()
+}
+
+// idleSince returns when the session was last active
+func (session *httpSession) idleSince() time.Time {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.lastActive
+}
+
+// register stores a session under a new random ID
+func (hs *httpSessions) register(session *httpSession) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if hs.closed {
+		return "", errors.New("server is shutting down")
+	}
+	hs.sessions[id] = session
+	metrics.ServerConnections.WithLabelValues("http_session").Inc()
+	return id, nil
+}
+
+// get returns the session with the given ID and records activity, or nil if it does not exist
+func (hs *httpSessions) get(id string) *httpSession {
+	hs.mu.Lock()
+	session := hs.sessions[id]
+	hs.mu.Unlock()
+
+	if session != nil {
+		session.touch()
+	}
+	return session
+}
+
+// remove ends a session; it reports whether the session existed
+func (hs *httpSessions) remove(id string) bool {
+	hs.mu.Lock()
+	session, ok := hs.sessions[id]
+	delete(hs.sessions, id)
+	hs.mu.Unlock()
+
+	if ok {
+		hs.end(session)
+	}
+	return ok
+}
+
+// end closes a removed session's event streams and drops its subscriptions
+func (hs *httpSessions) end(session *httpSession) {
+	close(session.done)
+	hs.onClose(session.mcp)
+	metrics.ServerConnections.WithLabelValues("http_session").Dec()
+}
+
+// expireIdle ends the sessions that have been idle for longer than the idle timeout
+func (hs *httpSessions) expireIdle(now time.Time) int {
+	hs.mu.Lock()
+	expired := make([]*httpSession, 0)
+	for id, session := range hs.sessions {
+		if now.Sub(session.idleSince()) > hs.idleTimeout {
+			expired = append(expired, session)
+			delete(hs.sessions, id)
+		}
+	}
+	hs.mu.Unlock()
+
+	for _, session := range expired {
+		hs.end(session)
+	}
+	return len(expired)
+}
+
+// closeAll ends all sessions and rejects new ones
+func (hs *httpSessions) closeAll() {
+	hs.mu.Lock()
+	sessions := hs.sessions
+	hs.sessions = make(map[string]*httpSession)
+	hs.closed = true
+	hs.mu.Unlock()
+
+	for _, session := range sessions {
+		hs.end(session)
+	}
+}
+
+// expireSessions periodically ends idle HTTP sessions until done is closed
+func (s *Server) expireSessions(done <-chan struct{}) {
+	interval := s.sessions.idleTimeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if n := s.sessions.expireIdle(now); n > 0 {
+				s.logger.Info("Expired idle HTTP sessions", "count", n)
+			}
+		}
+	}
+}
+
+// handleSessionStream sends the server notifications of an HTTP session as an event stream
+func (s *Server) handleSessionStream(w http.ResponseWriter, r *http.Request) {
+	session := s.sessions.get(r.Header.Get(sessionIDHeader))
+	if session == nil {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream is long-lived and must not be cut by the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Warn("Failed to clear event stream write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-session.done:
+			return
+		case message := <-session.notifications:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", message); err != nil {
+				s.logger.Error("Failed to write session notification", "error", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			// An open event stream keeps the session alive
+			session.touch()
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleSessionDelete ends the HTTP session named by the request
+func (s *Server) handleSessionDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		http.Error(w, "Missing "+sessionIDHeader+" header", http.StatusBadRequest)
+		return
+	}
+	if !s.sessions.remove(id) {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Info("HTTP session terminated by client", "session_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
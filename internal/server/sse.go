@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// sseKeepAliveInterval is how often a comment is sent on idle event streams so proxies keep them open
+const sseKeepAliveInterval = 30 * time.Second
+
+// sseSession is an open SSE event stream; responses to messages posted for the session are sent on it
+type sseSession struct {
+	messages chan []byte
+	done     chan struct{}
+	mcp      *mcp.Session
+}
+
+// sseSessions tracks the open SSE event streams
+type sseSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+	closed   bool
+}
+
+// registerSSE adds the SSE transport endpoints to mux: clients open an event stream with
+// GET /sse and post requests to the endpoint announced in the stream's first event
+func (s *Server) registerSSE(ctx context.Context, mux *http.ServeMux) {
+	sessions := &sseSessions{sessions: make(map[string]*sseSession)}
+
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		s.handleSSEStream(w, r, sessions)
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		s.handleSSEMessage(w, r, sessions)
+	})
+
+	// Event streams never finish on their own; end them so shutdown can drain other requests
+	go func() {
+		<-ctx.Done()
+		sessions.closeAll()
+	}()
+}
+
+// handleSSEStream opens an event stream and sends the responses of the session until the client disconnects
+func (s *Server) handleSSEStream(w http.ResponseWriter, r *http.Request, sessions *sseSessions) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	id, session, err := sessions.open()
+	if err != nil {
+		s.logger.Error("Failed to open SSE session", "error", err)
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer sessions.remove(id)
+	defer s.mcp.CloseSession(session.mcp)
+
+	// The stream is long-lived and must not be cut by the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Warn("Failed to clear SSE write deadline", "error", err)
+	}
+
+	metrics.ServerConnections.WithLabelValues("sse").Inc()
+	defer metrics.ServerConnections.WithLabelValues("sse").Dec()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx so events are delivered immediately
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "event: endpoint\ndata: /messages?sessionId=%s\n\n", id)
+	flusher.Flush()
+
+	s.logger.Info("SSE session established", "session_id", id)
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-session.done:
+			return
+		case message := <-session.messages:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", message); err != nil {
+				s.logger.Error("Failed to write SSE message", "error", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleSSEMessage handles a request posted for an SSE session and sends the response on its event stream
+func (s *Server) handleSSEMessage(w http.ResponseWriter, r *http.Request, sessions *sseSessions) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := sessions.get(r.URL.Query().Get("sessionId"))
+	if session == nil {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+
+	limit := s.maxRequestSize()
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	var req json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.logger.Warn("Rejected oversized SSE message", "limit", limit)
+			if err := session.send(s.mcp.RequestTooLargeResponse(limit)); err != nil {
+				s.logger.Error("Failed to send SSE response", "error", err)
+			}
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.mcp.HandleRequest(withTeamCityToken(mcp.WithSession(r.Context(), session.mcp), r), req)
+	if err != nil {
+		s.logger.Error("Failed to handle SSE request", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if resp != nil {
+		if err := session.send(resp); err != nil {
+			s.logger.Error("Failed to send SSE response", "error", err)
+			http.Error(w, "Session closed", http.StatusGone)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// errSessionClosed is returned when sending on an SSE session whose event stream has ended
+var errSessionClosed = errors.New("SSE session closed")
+
+// send queues a message on the session's event stream
+func (session *sseSession) send(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case session.messages <- data:
+		return nil
+	case <-session.done:
+		return errSessionClosed
+	}
+}
+
+// open registers a new session with a random ID
+func (ss *sseSessions) open() (string, *sseSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(buf)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.closed {
+		return "", nil, errors.New("server is shutting down")
+	}
+
+	session := &sseSession{
+		messages: make(chan []byte, 16),
+		done:     make(chan struct{}),
+	}
+	session.mcp = mcp.NewSession(session.send)
+	ss.sessions[id] = session
+	return id, session, nil
+}
+
+// get returns the session with the given ID, or nil if it is not open
+func (ss *sseSessions) get(id string) *sseSession {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.sessions[id]
+}
+
+// remove closes and unregisters a session
+func (ss *sseSessions) remove(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if session, ok := ss.sessions[id]; ok {
+		close(session.done)
+		delete(ss.sessions, id)
+	}
+}
+
+// closeAll ends all event streams and rejects new ones
+func (ss *sseSessions) closeAll() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.closed = true
+	for id, session := range ss.sessions {
+		close(session.done)
+		delete(ss.sessions, id)
+	}
+}
@@ -0,0 +1,776 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// agentFields is the field selection used when listing agents
+const agentFields = "nextHref,agent(id,name,connected,enabled,authorized,webUrl,pool(id,name),build(id,number,buildTypeId,state,status))"
+
+// AgentFilter selects agents by state and pool; nil fields are not filtered on
+type AgentFilter struct {
+	Connected  *bool
+	Enabled    *bool
+	Authorized *bool
+	Pool       string
+}
+
+// locator converts the filter to a TeamCity agent locator
+func (f AgentFilter) locator() string {
+	// Without defaultFilter:false TeamCity hides disconnected and unauthorized agents
+	parts := []string{"defaultFilter:false"}
+	if f.Connected != nil {
+		parts = append(parts, fmt.Sprintf("connected:%t", *f.Connected))
+	}
+	if f.Enabled != nil {
+		parts = append(parts, fmt.Sprintf("enabled:%t", *f.Enabled))
+	}
+	if f.Authorized != nil {
+		parts = append(parts, fmt.Sprintf("authorized:%t", *f.Authorized))
+	}
+	if f.Pool != "" {
+		if _, err := strconv.Atoi(f.Pool); err == nil {
+			parts = append(parts, fmt.Sprintf("pool:(id:%s)", f.Pool))
+		} else {
+			parts = append(parts, fmt.Sprintf("pool:(name:%s)", f.Pool))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// describeAgent summarizes an agent's state, pool and running build
+func describeAgent(agent Agent) string {
+	desc := fmt.Sprintf("Connected: %t, Enabled: %t, Authorized: %t", agent.Connected, agent.Enabled, agent.Authorized)
+	if agent.Pool != nil {
+		desc += fmt.Sprintf(", Pool: %s", agent.Pool.Name)
+	}
+	if agent.Build != nil {
+		desc += fmt.Sprintf(", Running: %s #%s (ID: %d)", agent.Build.BuildTypeID, agent.Build.Number, agent.Build.ID)
+	} else {
+		desc += ", Idle"
+	}
+	return desc
+}
+
+// listAgentsByLocator lists agents matching a TeamCity agent locator
+func (c *Client) listAgentsByLocator(ctx context.Context, locator string) ([]Agent, error) {
+	agents, _, err := c.listAgentsPage(ctx, locator)
+	return agents, err
+}
+
+// listAgentsPage fetches agents matching a locator; more reports whether TeamCity has
+// agents after the returned ones
+func (c *Client) listAgentsPage(ctx context.Context, locator string) ([]Agent, bool, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/agents?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(agentFields)), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var response struct {
+		pagedResponse
+		Agent []Agent `json:"agent"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse agents response: %w", err)
+	}
+
+	return response.Agent, response.NextHref != "", nil
+}
+
+// agentAvailability returns why an otherwise compatible agent cannot run builds, or "" if it can
+func agentAvailability(agent Agent) string {
+	reasons := make([]string, 0)
+	if !agent.Authorized {
+		reasons = append(reasons, "unauthorized")
+	}
+	if !agent.Connected {
+		reasons = append(reasons, "disconnected")
+	}
+	if !agent.Enabled {
+		reasons = append(reasons, "disabled")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// GetCompatibleAgents lists agents compatible with a queued build and the excluded ones with reasons
+func (c *Client) GetCompatibleAgents(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID         string `json:"buildId"`
+		IncludeReasons  *bool  `json:"includeReasons"`
+		MaxIncompatible int    `json:"maxIncompatible"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := validateBuildID(req.BuildID); err != nil {
+		return "", err
+	}
+
+	includeReasons := true
+	if req.IncludeReasons != nil {
+		includeReasons = *req.IncludeReasons
+	}
+	maxIncompatible := req.MaxIncompatible
+	if maxIncompatible == 0 {
+		maxIncompatible = 20
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_compatible_agents", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildQueue/id:%s?fields=id,buildTypeId,state,waitReason", url.PathEscape(req.BuildID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("queued build not found: %w", err)
+	}
+
+	var queued struct {
+		ID          int    `json:"id"`
+		BuildTypeID string `json:"buildTypeId"`
+		State       string `json:"state"`
+		WaitReason  string `json:"waitReason"`
+	}
+	if err := json.Unmarshal(respBody, &queued); err != nil {
+		return "", fmt.Errorf("failed to parse queued build: %w", err)
+	}
+
+	compatible, err := c.listAgentsByLocator(ctx, fmt.Sprintf("compatible:(build:(id:%s)),defaultFilter:false", req.BuildID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get compatible agents: %w", err)
+	}
+
+	incompatible, err := c.listAgentsByLocator(ctx, fmt.Sprintf("incompatible:(build:(id:%s)),defaultFilter:false", req.BuildID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get incompatible agents: %w", err)
+	}
+
+	result := fmt.Sprintf("Agents for queued build %d (%s)\n", queued.ID, queued.BuildTypeID)
+	if queued.WaitReason != "" {
+		result += fmt.Sprintf("Wait Reason: %s\n", queued.WaitReason)
+	}
+
+	available := 0
+	for _, agent := range compatible {
+		if agentAvailability(agent) == "" {
+			available++
+		}
+	}
+
+	result += fmt.Sprintf("\nCompatible agents (%d, %d available):\n", len(compatible), available)
+	if len(compatible) == 0 {
+		result += "  (none)\n"
+	}
+	for _, agent := range compatible {
+		result += fmt.Sprintf("  - %s (ID: %d)", agent.Name, agent.ID)
+		if agent.Pool != nil {
+			result += fmt.Sprintf(" [pool: %s]", agent.Pool.Name)
+		}
+		if reason := agentAvailability(agent); reason != "" {
+			result += fmt.Sprintf(" - not available: %s", reason)
+		}
+		result += "\n"
+	}
+
+	result += fmt.Sprintf("\nIncompatible agents (%d):\n", len(incompatible))
+	if len(incompatible) == 0 {
+		result += "  (none)\n"
+	}
+	for i, agent := range incompatible {
+		if i >= maxIncompatible {
+			result += fmt.Sprintf("  ... and %d more\n", len(incompatible)-maxIncompatible)
+			break
+		}
+
+		result += fmt.Sprintf("  - %s (ID: %d)", agent.Name, agent.ID)
+		if agent.Pool != nil {
+			result += fmt.Sprintf(" [pool: %s]", agent.Pool.Name)
+		}
+		result += "\n"
+
+		if includeReasons {
+			reasons, err := c.getIncompatibilityReasons(ctx, agent.ID, queued.BuildTypeID)
+			if err != nil {
+				c.logger.Warnw("Failed to get incompatibility reasons", "agentId", agent.ID, "error", err)
+				continue
+			}
+			for _, reason := range reasons {
+				result += fmt.Sprintf("      %s\n", reason)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// getIncompatibilityReasons returns the unmet requirements that prevent an agent from running a build configuration
+func (c *Client) getIncompatibilityReasons(ctx context.Context, agentID int, buildTypeID string) ([]string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/agents/id:%d/incompatibleBuildTypes?fields=compatibility(buildType(id),unmetRequirements(description,requirement(id,type,properties(property(name,value)))))", agentID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Compatibility []struct {
+			BuildType         BuildType `json:"buildType"`
+			UnmetRequirements struct {
+				Description string `json:"description"`
+				Requirement []struct {
+					ID         string `json:"id"`
+					Type       string `json:"type"`
+					Properties struct {
+						Property []Parameter `json:"property"`
+					} `json:"properties"`
+				} `json:"requirement"`
+			} `json:"unmetRequirements"`
+		} `json:"compatibility"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse incompatibility response: %w", err)
+	}
+
+	reasons := make([]string, 0)
+	for _, compat := range response.Compatibility {
+		if compat.BuildType.ID != buildTypeID {
+			continue
+		}
+		if compat.UnmetRequirements.Description != "" {
+			reasons = append(reasons, compat.UnmetRequirements.Description)
+		}
+		for _, requirement := range compat.UnmetRequirements.Requirement {
+			props := make([]string, 0, len(requirement.Properties.Property))
+			for _, prop := range requirement.Properties.Property {
+				props = append(props, fmt.Sprintf("%s=%s", prop.Name, prop.Value))
+			}
+			reasons = append(reasons, fmt.Sprintf("Unmet requirement: %s (%s)", requirement.Type, strings.Join(props, ", ")))
+		}
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "Agent pool is not associated with the project or agent is not allowed to run this configuration")
+	}
+
+	return reasons, nil
+}
+
+// agentLocator returns the locator of an agent given by ID or name
+func agentLocator(agent string) string {
+	if _, err := strconv.Atoi(agent); err == nil {
+		return "id:" + agent
+	}
+	return "name:" + agent
+}
+
+// ManageAgent enables or disables an agent, optionally until a given time, or authorizes or
+// unauthorizes it
+func (c *Client) ManageAgent(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action               string `json:"action"`
+		Agent                string `json:"agent"`
+		Comment              string `json:"comment"`
+		ReenableAfterMinutes int    `json:"reenableAfterMinutes"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Agent == "" {
+		return "", fmt.Errorf("agent is required")
+	}
+	var setting string
+	var status bool
+	switch req.Action {
+	case "enable", "disable":
+		setting, status = "enabledInfo", req.Action == "enable"
+	case "authorize", "unauthorize":
+		setting, status = "authorizedInfo", req.Action == "authorize"
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected enable, disable, authorize or unauthorize)", req.Action)
+	}
+	if req.ReenableAfterMinutes < 0 {
+		return "", fmt.Errorf("reenableAfterMinutes must be positive")
+	}
+	if req.ReenableAfterMinutes > 0 && req.Action != "disable" {
+		return "", fmt.Errorf("reenableAfterMinutes is only supported by the disable action")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_agent", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := "/agents/" + url.PathEscape(agentLocator(req.Agent))
+	respBody, err := c.makeRequest(ctx, "GET", endpoint+"?fields=id,name", nil)
+	if err != nil {
+		return "", fmt.Errorf("agent not found: %w", err)
+	}
+	var agent Agent
+	if err := json.Unmarshal(respBody, &agent); err != nil {
+		return "", fmt.Errorf("failed to parse agent response: %w", err)
+	}
+
+	info := map[string]interface{}{"status": status}
+	if req.Comment != "" {
+		info["comment"] = map[string]string{"text": req.Comment}
+	}
+	// TeamCity switches the agent back to enabled at statusSwitchTime
+	var reenableAt time.Time
+	if req.ReenableAfterMinutes > 0 {
+		reenableAt = time.Now().Add(time.Duration(req.ReenableAfterMinutes) * time.Minute)
+		info["statusSwitchTime"] = reenableAt.Format("20060102T150405-0700")
+	}
+
+	reqBody, err := json.Marshal(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal agent state: %w", err)
+	}
+	if _, err := c.makeRequest(ctx, "PUT", fmt.Sprintf("/agents/id:%d/%s", agent.ID, setting), reqBody); err != nil {
+		return "", fmt.Errorf("failed to %s agent: %w", req.Action, err)
+	}
+
+	result := fmt.Sprintf("Agent %s (ID: %d) %sd", agent.Name, agent.ID, req.Action)
+	if req.Comment != "" {
+		result += ": " + req.Comment
+	}
+	if !reenableAt.IsZero() {
+		result += fmt.Sprintf("\nIt will be enabled again at %s", reenableAt.Format(time.RFC3339))
+	}
+	return result, nil
+}
+
+// poolLocator returns the locator of an agent pool given by ID or name
+func poolLocator(pool string) string {
+	if _, err := strconv.Atoi(pool); err == nil {
+		return "id:" + pool
+	}
+	return "name:" + pool
+}
+
+// ManageAgentPools lists agent pools with their agents and projects, moves an agent to a pool, or
+// assigns or unassigns a project to a pool
+func (c *Client) ManageAgentPools(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action    string `json:"action"`
+		Pool      string `json:"pool"`
+		Agent     string `json:"agent"`
+		ProjectID string `json:"projectId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_agent_pools", "success", time.Since(start).Seconds())
+	}()
+
+	if req.Action == "list" {
+		return c.listAgentPools(ctx, req.Pool)
+	}
+
+	if req.Pool == "" {
+		return "", fmt.Errorf("pool is required for %s action", req.Action)
+	}
+	poolEndpoint := "/agentPools/" + url.PathEscape(poolLocator(req.Pool))
+	respBody, err := c.makeRequest(ctx, "GET", poolEndpoint+"?fields=id,name", nil)
+	if err != nil {
+		return "", fmt.Errorf("agent pool not found: %w", err)
+	}
+	var pool AgentPool
+	if err := json.Unmarshal(respBody, &pool); err != nil {
+		return "", fmt.Errorf("failed to parse agent pool response: %w", err)
+	}
+
+	switch req.Action {
+	case "moveAgent":
+		if req.Agent == "" {
+			return "", fmt.Errorf("agent is required for moveAgent action")
+		}
+		respBody, err := c.makeRequest(ctx, "GET", "/agents/"+url.PathEscape(agentLocator(req.Agent))+"?fields=id,name,pool(id,name)", nil)
+		if err != nil {
+			return "", fmt.Errorf("agent not found: %w", err)
+		}
+		var agent Agent
+		if err := json.Unmarshal(respBody, &agent); err != nil {
+			return "", fmt.Errorf("failed to parse agent response: %w", err)
+		}
+
+		reqBody, err := json.Marshal(map[string]int{"id": agent.ID})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal agent: %w", err)
+		}
+		if _, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/agentPools/id:%d/agents", pool.ID), reqBody); err != nil {
+			return "", fmt.Errorf("failed to move agent: %w", err)
+		}
+		result := fmt.Sprintf("Agent %s (ID: %d) moved to pool %s (ID: %d)", agent.Name, agent.ID, pool.Name, pool.ID)
+		if agent.Pool != nil {
+			result += fmt.Sprintf(" from pool %s (ID: %d)", agent.Pool.Name, agent.Pool.ID)
+		}
+		return result, nil
+
+	case "assignProject":
+		if req.ProjectID == "" {
+			return "", fmt.Errorf("projectId is required for assignProject action")
+		}
+		reqBody, err := json.Marshal(map[string]string{"id": req.ProjectID})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal project: %w", err)
+		}
+		if _, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/agentPools/id:%d/projects", pool.ID), reqBody); err != nil {
+			return "", fmt.Errorf("failed to assign project: %w", err)
+		}
+		return fmt.Sprintf("Project %s assigned to pool %s (ID: %d)", req.ProjectID, pool.Name, pool.ID), nil
+
+	case "unassignProject":
+		if req.ProjectID == "" {
+			return "", fmt.Errorf("projectId is required for unassignProject action")
+		}
+		if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/agentPools/id:%d/projects/id:%s", pool.ID, url.PathEscape(req.ProjectID)), nil); err != nil {
+			return "", fmt.Errorf("failed to unassign project: %w", err)
+		}
+		return fmt.Sprintf("Project %s unassigned from pool %s (ID: %d)", req.ProjectID, pool.Name, pool.ID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, moveAgent, assignProject or unassignProject)", req.Action)
+	}
+}
+
+// listAgentPools renders the agent pools, or the pool given by ID or name, with their agents and projects
+func (c *Client) listAgentPools(ctx context.Context, pool string) (string, error) {
+	fields := "agentPool(id,name,maxAgents,agents(agent(id,name,connected,enabled,authorized)),projects(project(id,name)))"
+	endpoint := "/agentPools?fields=" + url.QueryEscape(fields)
+	if pool != "" {
+		endpoint += "&locator=" + url.QueryEscape(poolLocator(pool))
+	}
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get agent pools: %w", err)
+	}
+
+	var response struct {
+		AgentPool []struct {
+			AgentPool
+			MaxAgents *int `json:"maxAgents"`
+			Agents    struct {
+				Agent []Agent `json:"agent"`
+			} `json:"agents"`
+			Projects struct {
+				Project []Project `json:"project"`
+			} `json:"projects"`
+		} `json:"agentPool"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse agent pools response: %w", err)
+	}
+	if len(response.AgentPool) == 0 {
+		return "No agent pools found.", nil
+	}
+
+	result := fmt.Sprintf("Agent pools (%d):\n", len(response.AgentPool))
+	for _, p := range response.AgentPool {
+		result += fmt.Sprintf("\n%s (ID: %d)", p.Name, p.ID)
+		if p.MaxAgents != nil && *p.MaxAgents >= 0 {
+			result += fmt.Sprintf(", at most %d agents", *p.MaxAgents)
+		}
+		result += "\n"
+
+		result += fmt.Sprintf("  Agents (%d):\n", len(p.Agents.Agent))
+		for _, agent := range p.Agents.Agent {
+			result += fmt.Sprintf("    - %s (ID: %d)", agent.Name, agent.ID)
+			if reasons := agentAvailability(agent); reasons != "" {
+				result += " [" + reasons + "]"
+			}
+			result += "\n"
+		}
+		result += fmt.Sprintf("  Projects (%d):\n", len(p.Projects.Project))
+		for i, project := range p.Projects.Project {
+			if i == maxListedEntities {
+				result += fmt.Sprintf("    ... and %d more\n", len(p.Projects.Project)-maxListedEntities)
+				break
+			}
+			result += fmt.Sprintf("    - %s (%s)\n", project.Name, project.ID)
+		}
+	}
+	return result, nil
+}
+
+// agentDetailsFields is the field selection used by get_agent_details
+const agentDetailsFields = "id,name,connected,enabled,authorized,uptodate,ip,version,lastActivityTime,webUrl," +
+	"pool(id,name),build(id,number,buildTypeId,state,status)," +
+	"enabledInfo(comment(text,timestamp,user(username,name)),statusSwitchTime)," +
+	"authorizedInfo(comment(text,timestamp,user(username,name)))," +
+	"environment(osName,osType),properties(property(name,value))"
+
+// agentStateInfo is the enabled or authorized state of an agent with the comment of its last change
+type agentStateInfo struct {
+	Comment *struct {
+		Text      string   `json:"text"`
+		Timestamp string   `json:"timestamp"`
+		User      *userRef `json:"user"`
+	} `json:"comment"`
+	StatusSwitchTime string `json:"statusSwitchTime"`
+}
+
+// isSecretEnvironmentVariable reports whether an environment variable must be masked in tool
+// output; besides secure-looking names and values it covers keys, secrets and credentials
+func isSecretEnvironmentVariable(name, value string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range []string{"secret", "key", "credential", "pass", "auth", "private"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return isSecretValue(name, value)
+}
+
+// GetAgentDetails returns a single agent's state with comments, OS, hardware, running build,
+// configuration parameters, system properties and environment variables, with secrets masked
+func (c *Client) GetAgentDetails(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Agent  string `json:"agent"`
+		Prefix string `json:"prefix"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Agent == "" {
+		return "", fmt.Errorf("agent is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_agent_details", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/agents/%s?fields=%s",
+		url.PathEscape(agentLocator(req.Agent)), url.QueryEscape(agentDetailsFields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("agent not found: %w", err)
+	}
+
+	var agent struct {
+		Agent
+		UpToDate         *bool           `json:"uptodate"`
+		IP               string          `json:"ip"`
+		Version          string          `json:"version"`
+		LastActivityTime string          `json:"lastActivityTime"`
+		EnabledInfo      *agentStateInfo `json:"enabledInfo"`
+		AuthorizedInfo   *agentStateInfo `json:"authorizedInfo"`
+		Environment      *struct {
+			OSName string `json:"osName"`
+			OSType string `json:"osType"`
+		} `json:"environment"`
+		Properties Properties `json:"properties"`
+	}
+	if err := json.Unmarshal(respBody, &agent); err != nil {
+		return "", fmt.Errorf("failed to parse agent response: %w", err)
+	}
+
+	result := fmt.Sprintf("Agent %s (ID: %d)\n", agent.Name, agent.ID)
+	result += fmt.Sprintf("  Connected: %t\n", agent.Connected)
+	result += "  Enabled: " + c.formatAgentState(agent.Enabled, agent.EnabledInfo) + "\n"
+	result += "  Authorized: " + c.formatAgentState(agent.Authorized, agent.AuthorizedInfo) + "\n"
+	if agent.Pool != nil {
+		result += fmt.Sprintf("  Pool: %s (ID: %d)\n", agent.Pool.Name, agent.Pool.ID)
+	}
+	if agent.Build != nil {
+		result += fmt.Sprintf("  Running: %s #%s (ID: %d)", agent.Build.BuildTypeID, agent.Build.Number, agent.Build.ID)
+		if agent.Build.Status != "" {
+			result += ", status: " + agent.Build.Status
+		}
+		result += "\n"
+	} else {
+		result += "  Running: idle\n"
+	}
+
+	osName := agent.Properties["teamcity.agent.jvm.os.name"]
+	if agent.Environment != nil && agent.Environment.OSName != "" {
+		osName = agent.Environment.OSName
+	}
+	if osName != "" {
+		if arch := agent.Properties["teamcity.agent.jvm.os.arch"]; arch != "" {
+			osName += " (" + arch + ")"
+		}
+		result += fmt.Sprintf("  OS: %s\n", osName)
+	}
+	if cpus := agent.Properties["teamcity.agent.hardware.cpuCount"]; cpus != "" {
+		result += fmt.Sprintf("  CPU count: %s\n", cpus)
+	}
+	if memory := agent.Properties["teamcity.agent.hardware.memorySizeMb"]; memory != "" {
+		result += fmt.Sprintf("  Memory: %s MB\n", memory)
+	}
+	if agent.Version != "" {
+		result += "  Version: " + agent.Version
+		if agent.UpToDate != nil && !*agent.UpToDate {
+			result += " (outdated)"
+		}
+		result += "\n"
+	}
+	if agent.IP != "" {
+		result += fmt.Sprintf("  IP: %s\n", agent.IP)
+	}
+	if agent.LastActivityTime != "" {
+		result += fmt.Sprintf("  Last activity: %s\n", c.formatTeamCityDate(agent.LastActivityTime))
+	}
+	if agent.WebURL != "" {
+		result += fmt.Sprintf("  URL: %s\n", agent.WebURL)
+	}
+
+	// TeamCity reports configuration parameters, system properties and environment variables together
+	var configuration, system, environment []string
+	names := make([]string, 0, len(agent.Properties))
+	for name := range agent.Properties {
+		if strings.HasPrefix(name, req.Prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := agent.Properties[name]
+		switch {
+		case strings.HasPrefix(name, "env."):
+			if isSecretEnvironmentVariable(strings.TrimPrefix(name, "env."), value) {
+				value = maskedValue
+			}
+			environment = append(environment, fmt.Sprintf("%s = %s", name, value))
+		case strings.HasPrefix(name, "system."):
+			if isSecretValue(name, value) {
+				value = maskedValue
+			}
+			system = append(system, fmt.Sprintf("%s = %s", name, value))
+		default:
+			if isSecretValue(name, value) {
+				value = maskedValue
+			}
+			configuration = append(configuration, fmt.Sprintf("%s = %s", name, value))
+		}
+	}
+	for _, group := range []struct {
+		title string
+		lines []string
+	}{
+		{"Configuration parameters", configuration},
+		{"System properties", system},
+		{"Environment variables", environment},
+	} {
+		result += fmt.Sprintf("\n%s (%d):\n", group.title, len(group.lines))
+		for _, line := range group.lines {
+			result += "  " + line + "\n"
+		}
+	}
+	return result, nil
+}
+
+// formatAgentState renders an enabled or authorized state with the comment of its last change
+func (c *Client) formatAgentState(status bool, info *agentStateInfo) string {
+	result := fmt.Sprintf("%t", status)
+	if info == nil {
+		return result
+	}
+	if comment := info.Comment; comment != nil && (comment.Text != "" || comment.User != nil) {
+		result += " ("
+		if comment.Text != "" {
+			result += comment.Text
+		} else {
+			result += "no comment"
+		}
+		if comment.User != nil && comment.User.Username != "" {
+			result += " by " + comment.User.Username
+		}
+		if comment.Timestamp != "" {
+			result += " at " + c.formatTeamCityDate(comment.Timestamp)
+		}
+		result += ")"
+	}
+	if info.StatusSwitchTime != "" {
+		result += fmt.Sprintf(", switches back at %s", c.formatTeamCityDate(info.StatusSwitchTime))
+	}
+	return result
+}
+
+// SearchAgents searches build agents by state, pool and name substring and returns at most count of them
+func (c *Client) SearchAgents(ctx context.Context, args json.RawMessage) (*StructuredResult, error) {
+	var req struct {
+		Connected  *bool  `json:"connected"`
+		Enabled    *bool  `json:"enabled"`
+		Authorized *bool  `json:"authorized"`
+		Pool       string `json:"pool"`
+		Name       string `json:"name"`
+		Count      int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Count == 0 {
+		req.Count = 100
+	}
+	if req.Count < 1 || req.Count > 1000 {
+		return nil, fmt.Errorf("count must be between 1 and 1000")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("search_agents", "success", time.Since(start).Seconds())
+	}()
+
+	filter := AgentFilter{Connected: req.Connected, Enabled: req.Enabled, Authorized: req.Authorized, Pool: req.Pool}
+	locator := filter.locator()
+	if req.Name == "" {
+		// One agent more than requested tells whether the result is truncated
+		locator += fmt.Sprintf(",count:%d", req.Count+1)
+	}
+	agents, err := c.listAgentsByLocator(ctx, locator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search agents: %w", err)
+	}
+
+	// TeamCity only matches agent names exactly, so substrings are matched here
+	matching := make([]Agent, 0, len(agents))
+	for _, agent := range agents {
+		if strings.Contains(strings.ToLower(agent.Name), strings.ToLower(req.Name)) {
+			matching = append(matching, agent)
+		}
+	}
+	hasMore := len(matching) > req.Count
+	if hasMore {
+		matching = matching[:req.Count]
+	}
+
+	data := AgentSearchResult{Count: len(matching), HasMore: hasMore, Agents: make([]AgentResult, 0, len(matching))}
+	for _, agent := range matching {
+		data.Agents = append(data.Agents, newAgentResult(agent))
+	}
+	if len(matching) == 0 {
+		return &StructuredResult{Text: "No agents found.", Data: data}, nil
+	}
+
+	result := fmt.Sprintf("Found %d agent(s):\n\n", len(matching))
+	for _, agent := range matching {
+		result += fmt.Sprintf("- %s (ID: %d)\n  %s\n", agent.Name, agent.ID, describeAgent(agent))
+	}
+	if hasMore {
+		result += fmt.Sprintf("\nMore agents match; only the first %d are shown. Narrow the filters or raise count.\n", req.Count)
+	}
+	return &StructuredResult{Text: result, Data: data}, nil
+}
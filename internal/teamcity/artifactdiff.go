@@ -0,0 +1,175 @@
+package teamcity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// maxChecksumFiles bounds the number of same-sized file pairs compare_artifacts downloads to compare checksums
+const maxChecksumFiles = 50
+
+// CompareArtifacts compares the artifact trees of two builds and lists the added, removed and
+// changed files. Files are compared by size and, with compareChecksums, same-sized files by SHA-256.
+func (c *Client) CompareArtifacts(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		FromBuildID      string `json:"fromBuildId"`
+		ToBuildID        string `json:"toBuildId"`
+		Path             string `json:"path"`
+		BrowseArchives   bool   `json:"browseArchives"`
+		CompareChecksums bool   `json:"compareChecksums"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.FromBuildID == "" || req.ToBuildID == "" {
+		return "", fmt.Errorf("fromBuildId and toBuildId are required")
+	}
+	root := strings.Trim(req.Path, "/")
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("compare_artifacts", "success", time.Since(start).Seconds())
+	}()
+
+	from, err := c.artifactSizes(ctx, req.FromBuildID, root, req.BrowseArchives)
+	if err != nil {
+		return "", err
+	}
+	to, err := c.artifactSizes(ctx, req.ToBuildID, root, req.BrowseArchives)
+	if err != nil {
+		return "", err
+	}
+
+	var added, removed, changed, sameSize []string
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for name, size := range from {
+		toSize, ok := to[name]
+		switch {
+		case !ok:
+			removed = append(removed, name)
+		case toSize != size:
+			changed = append(changed, name)
+		default:
+			sameSize = append(sameSize, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(sameSize)
+
+	// Same-sized files may still differ; compare the checksums of their contents
+	var contentChanged, unchecked int
+	if req.CompareChecksums {
+		for i, name := range sameSize {
+			if i >= maxChecksumFiles {
+				unchecked = len(sameSize) - maxChecksumFiles
+				break
+			}
+			fromSum, err := c.artifactChecksum(ctx, req.FromBuildID, name)
+			if err != nil {
+				return "", err
+			}
+			toSum, err := c.artifactChecksum(ctx, req.ToBuildID, name)
+			if err != nil {
+				return "", err
+			}
+			if fromSum != toSum {
+				changed = append(changed, name)
+				contentChanged++
+			}
+		}
+	}
+	sort.Strings(changed)
+
+	where := ""
+	if root != "" {
+		where = " under " + root
+	}
+	result := fmt.Sprintf("Artifacts of build %s compared to build %s%s: %d added, %d removed, %d changed\n",
+		req.ToBuildID, req.FromBuildID, where, len(added), len(removed), len(changed))
+
+	if len(added) > 0 {
+		result += fmt.Sprintf("\nAdded (%d):\n", len(added))
+		for _, name := range added {
+			result += fmt.Sprintf("  + %s  %s\n", name, formatBytes(to[name]))
+		}
+	}
+	if len(removed) > 0 {
+		result += fmt.Sprintf("\nRemoved (%d):\n", len(removed))
+		for _, name := range removed {
+			result += fmt.Sprintf("  - %s  %s\n", name, formatBytes(from[name]))
+		}
+	}
+	if len(changed) > 0 {
+		result += fmt.Sprintf("\nChanged (%d):\n", len(changed))
+		for _, name := range changed {
+			delta := to[name] - from[name]
+			switch {
+			case delta > 0:
+				result += fmt.Sprintf("  ~ %s  %s -> %s (+%s)\n", name, formatBytes(from[name]), formatBytes(to[name]), formatBytes(delta))
+			case delta < 0:
+				result += fmt.Sprintf("  ~ %s  %s -> %s (-%s)\n", name, formatBytes(from[name]), formatBytes(to[name]), formatBytes(-delta))
+			default:
+				result += fmt.Sprintf("  ~ %s  %s, content differs\n", name, formatBytes(to[name]))
+			}
+		}
+	}
+
+	result += fmt.Sprintf("\nUnchanged: %d files", len(sameSize)-contentChanged)
+	switch {
+	case !req.CompareChecksums:
+		result += " with the same size (contents not compared; set compareChecksums to compare them)"
+	case unchecked > 0:
+		result += fmt.Sprintf(" (%d of them compared by size only)", unchecked)
+	}
+	return result + "\n", nil
+}
+
+// artifactSizes returns the size of every artifact file of a build under root by full path
+func (c *Client) artifactSizes(ctx context.Context, buildID, root string, browseArchives bool) (map[string]int64, error) {
+	files, err := c.listArtifactFiles(ctx, buildID, root, browseArchives)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64, len(files))
+	for _, file := range files {
+		// Directories have no size; archives have both a size and children and are compared as files
+		if file.Size == nil {
+			continue
+		}
+		sizes[file.FullName] = *file.Size
+	}
+	return sizes, nil
+}
+
+// artifactChecksum returns the SHA-256 checksum of an artifact's content
+func (c *Client) artifactChecksum(ctx context.Context, buildID, artifactPath string) (string, error) {
+	resp, err := c.makeStreamRequest(ctx, fmt.Sprintf("/builds/id:%s/artifacts/content/%s", url.PathEscape(buildID), escapeArtifactPath(artifactPath)))
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s of build %s: %w", artifactPath, buildID, err)
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", fmt.Errorf("reading %s of build %s: %w", artifactPath, buildID, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
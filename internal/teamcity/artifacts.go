@@ -0,0 +1,558 @@
+package teamcity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// validateBuildID checks that a build ID is numeric; build IDs end up in file names under the
+// artifact directory, so anything else could escape it
+func validateBuildID(buildID string) error {
+	if buildID == "" {
+		return fmt.Errorf("buildId is required")
+	}
+	if _, err := strconv.ParseInt(buildID, 10, 64); err != nil {
+		return fmt.Errorf("buildId must be a numeric build ID: %q", buildID)
+	}
+	return nil
+}
+
+// formatBytes formats a byte count in human readable form
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit && size > -unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	exp := 0
+	for absFloat(value) >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exp-1])
+}
+
+func absFloat(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// GetArtifactSizeReport summarizes artifact sizes over recent builds of a configuration and flags size jumps
+func (c *Client) GetArtifactSizeReport(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID     string  `json:"buildTypeId"`
+		Branch          string  `json:"branch"`
+		Count           int     `json:"count"`
+		GrowthThreshold float64 `json:"growthThreshold"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 20
+	}
+	threshold := req.GrowthThreshold
+	if threshold == 0 {
+		threshold = 20
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_artifact_size_report", "success", time.Since(start).Seconds())
+	}()
+
+	locator := fmt.Sprintf("buildType:%s,state:finished,count:%d", req.BuildTypeID, count)
+	if req.Branch != "" {
+		locator += fmt.Sprintf(",branch:%s", req.Branch)
+	}
+
+	endpoint := fmt.Sprintf("/builds?locator=%s&fields=build(id,number,status,branchName,finishDate,statistics(property(name,value)))", locator)
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get builds: %w", err)
+	}
+
+	var response struct {
+		Build []struct {
+			Build
+			Statistics struct {
+				Property []Parameter `json:"property"`
+			} `json:"statistics"`
+		} `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse builds response: %w", err)
+	}
+
+	type buildSize struct {
+		build Build
+		size  int64
+		delta int64
+		known bool
+	}
+
+	// TeamCity returns newest builds first
+	sizes := make([]buildSize, 0, len(response.Build))
+	for _, b := range response.Build {
+		entry := buildSize{build: b.Build}
+		for _, prop := range b.Statistics.Property {
+			if prop.Name == "ArtifactsSize" {
+				if v, err := strconv.ParseInt(prop.Value, 10, 64); err == nil {
+					entry.size = v
+					entry.known = true
+				}
+			}
+		}
+		sizes = append(sizes, entry)
+	}
+
+	if len(sizes) == 0 {
+		return fmt.Sprintf("No finished builds found for %s.", req.BuildTypeID), nil
+	}
+
+	result := fmt.Sprintf("Artifact size report for %s (last %d finished builds):\n\n", req.BuildTypeID, len(sizes))
+
+	flagged := make([]buildSize, 0)
+	for i := range sizes {
+		entry := &sizes[i]
+		result += fmt.Sprintf("Build #%s (ID: %d)", entry.build.Number, entry.build.ID)
+		if !entry.known {
+			result += ": size unknown\n"
+			continue
+		}
+		result += fmt.Sprintf(": %s", formatBytes(entry.size))
+
+		// Compare against the previous (older) build with a known size
+		for j := i + 1; j < len(sizes); j++ {
+			if !sizes[j].known {
+				continue
+			}
+			entry.delta = entry.size - sizes[j].size
+			if entry.delta != 0 {
+				sign := "+"
+				if entry.delta < 0 {
+					sign = "-"
+				}
+				result += fmt.Sprintf(" (%s%s", sign, formatBytes(int64(absFloat(float64(entry.delta)))))
+				if sizes[j].size > 0 {
+					result += fmt.Sprintf(", %s%.1f%%", sign, absFloat(float64(entry.delta))*100/float64(sizes[j].size))
+				}
+				result += ")"
+			}
+			if entry.delta > 0 && (sizes[j].size == 0 || float64(entry.delta)*100/float64(sizes[j].size) >= threshold) {
+				result += " [GROWTH]"
+				flagged = append(flagged, *entry)
+			}
+			break
+		}
+		result += "\n"
+	}
+
+	// Overall growth between the oldest and newest builds with known sizes
+	var newest, oldest *buildSize
+	for i := range sizes {
+		if sizes[i].known {
+			if newest == nil {
+				newest = &sizes[i]
+			}
+			oldest = &sizes[i]
+		}
+	}
+	if newest != nil && oldest != nil && newest != oldest {
+		result += fmt.Sprintf("\nOverall: %s -> %s between #%s and #%s\n",
+			formatBytes(oldest.size), formatBytes(newest.size), oldest.build.Number, newest.build.Number)
+	}
+
+	if len(flagged) > 0 {
+		sort.Slice(flagged, func(i, j int) bool { return flagged[i].delta > flagged[j].delta })
+		result += fmt.Sprintf("\nBuilds responsible for storage growth (>= %.0f%%):\n", threshold)
+		for _, entry := range flagged {
+			result += fmt.Sprintf("  - #%s (ID: %d): +%s\n", entry.build.Number, entry.build.ID, formatBytes(entry.delta))
+		}
+	} else {
+		result += fmt.Sprintf("\nNo builds exceeded the %.0f%% growth threshold.\n", threshold)
+	}
+
+	return result, nil
+}
+
+// BinaryContent is tool output carrying binary data, returned to MCP clients as an embedded resource
+type BinaryContent struct {
+	Summary  string
+	URI      string
+	MimeType string
+	Data     []byte
+}
+
+// defaultMaxInlineSize is the default maximum artifact size returned inline (1 MiB)
+const defaultMaxInlineSize = 1 << 20
+
+// DownloadArtifactArchive downloads all artifacts of a build as a single zip archive, either
+// saving it to the configured artifact directory or returning it inline within the size limit
+func (c *Client) DownloadArtifactArchive(ctx context.Context, args json.RawMessage) (*BinaryContent, error) {
+	var req struct {
+		BuildID    string `json:"buildId"`
+		Pattern    string `json:"pattern"`
+		SaveToDisk bool   `json:"saveToDisk"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := validateBuildID(req.BuildID); err != nil {
+		return nil, err
+	}
+	if req.SaveToDisk && c.cfg.ArtifactDir == "" {
+		return nil, fmt.Errorf("saving to disk requires ARTIFACT_DIR to be configured")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("download_artifact_archive", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := fmt.Sprintf("/builds/id:%s/artifacts/archived", url.PathEscape(req.BuildID))
+	if req.Pattern != "" {
+		endpoint += "?locator=" + url.QueryEscape("pattern:"+req.Pattern)
+	}
+
+	resp, err := c.makeStreamRequest(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	saveToDisk := func(archive io.Reader) (*BinaryContent, error) {
+		path := filepath.Join(c.cfg.ArtifactDir, fmt.Sprintf("build-%s-artifacts.zip", req.BuildID))
+		written, err := saveToFile(path, archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save artifact archive: %w", err)
+		}
+		return &BinaryContent{
+			Summary: fmt.Sprintf("Artifacts of build %s saved as zip archive to %s (%s)", req.BuildID, path, formatBytes(written)),
+		}, nil
+	}
+
+	tooLarge := resp.ContentLength > c.maxInlineSize
+	if req.SaveToDisk || (tooLarge && c.cfg.ArtifactDir != "") {
+		return saveToDisk(resp.Body)
+	}
+
+	if tooLarge {
+		return nil, fmt.Errorf("artifact archive is %s, which exceeds the inline limit of %s; configure ARTIFACT_DIR to save it to disk",
+			formatBytes(resp.ContentLength), formatBytes(c.maxInlineSize))
+	}
+
+	// Content length may be unknown for archives generated on the fly, so enforce the limit while reading
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxInlineSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading artifact archive: %w", err)
+	}
+	if int64(len(data)) > c.maxInlineSize {
+		if c.cfg.ArtifactDir != "" {
+			return saveToDisk(io.MultiReader(bytes.NewReader(data), resp.Body))
+		}
+		return nil, fmt.Errorf("artifact archive exceeds the inline limit of %s; configure ARTIFACT_DIR to save it to disk",
+			formatBytes(c.maxInlineSize))
+	}
+
+	return &BinaryContent{
+		Summary:  fmt.Sprintf("Artifacts of build %s downloaded as zip archive (%s)", req.BuildID, formatBytes(int64(len(data)))),
+		URI:      fmt.Sprintf("teamcity://builds/%s/artifacts/archived", req.BuildID),
+		MimeType: "application/zip",
+		Data:     data,
+	}, nil
+}
+
+// DownloadArtifact downloads a single artifact of a build. Text artifacts are returned as text,
+// truncated to maxBytes; binary artifacts are returned inline as base64 within maxBytes, or saved
+// to the artifact directory when they are larger or saveToDisk is set.
+func (c *Client) DownloadArtifact(ctx context.Context, args json.RawMessage) (*BinaryContent, error) {
+	var req struct {
+		BuildID      string `json:"buildId"`
+		ArtifactPath string `json:"artifactPath"`
+		MaxBytes     int64  `json:"maxBytes"`
+		SaveToDisk   bool   `json:"saveToDisk"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := validateBuildID(req.BuildID); err != nil {
+		return nil, err
+	}
+	// Cleaning against the root keeps the path inside the build's artifacts and the artifact directory
+	artifactPath := strings.TrimPrefix(path.Clean("/"+req.ArtifactPath), "/")
+	if artifactPath == "" {
+		return nil, fmt.Errorf("artifactPath is required")
+	}
+	if req.SaveToDisk && c.cfg.ArtifactDir == "" {
+		return nil, fmt.Errorf("saving to disk requires ARTIFACT_DIR to be configured")
+	}
+	maxBytes := c.maxInlineSize
+	if req.MaxBytes > 0 && req.MaxBytes < maxBytes {
+		maxBytes = req.MaxBytes
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("download_artifact", "success", time.Since(start).Seconds())
+	}()
+
+	resp, err := c.makeStreamRequest(ctx, fmt.Sprintf("/builds/id:%s/artifacts/content/%s", url.PathEscape(req.BuildID), escapeArtifactPath(artifactPath)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact: %w", err)
+	}
+	defer resp.Body.Close()
+
+	saveToDisk := func(body io.Reader) (*BinaryContent, error) {
+		filePath := filepath.Join(c.cfg.ArtifactDir, "build-"+req.BuildID, filepath.FromSlash(artifactPath))
+		if rel, err := filepath.Rel(c.cfg.ArtifactDir, filePath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("artifact path %s is outside the artifact directory", artifactPath)
+		}
+		written, err := saveToFile(filePath, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save artifact: %w", err)
+		}
+		return &BinaryContent{
+			Summary: fmt.Sprintf("Artifact %s of build %s saved to %s (%s)", artifactPath, req.BuildID, filePath, formatBytes(written)),
+		}, nil
+	}
+
+	if req.SaveToDisk {
+		return saveToDisk(resp.Body)
+	}
+
+	// Read one byte more than the limit to tell whether the artifact fits
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading artifact: %w", err)
+	}
+	truncated := int64(len(data)) > maxBytes
+
+	if isText(data) {
+		summary := fmt.Sprintf("Artifact %s of build %s", artifactPath, req.BuildID)
+		if truncated {
+			data = data[:maxBytes]
+			// Do not cut a multi-byte character in half
+			for len(data) > 0 && !utf8.Valid(data) {
+				data = data[:len(data)-1]
+			}
+			summary += fmt.Sprintf(" (first %s", formatBytes(int64(len(data))))
+			if resp.ContentLength > 0 {
+				summary += " of " + formatBytes(resp.ContentLength)
+			}
+			summary += ", truncated)"
+		} else {
+			summary += fmt.Sprintf(" (%s)", formatBytes(int64(len(data))))
+		}
+		return &BinaryContent{Summary: summary + ":\n\n" + string(data)}, nil
+	}
+
+	if truncated {
+		if c.cfg.ArtifactDir != "" {
+			return saveToDisk(io.MultiReader(bytes.NewReader(data), resp.Body))
+		}
+		return nil, fmt.Errorf("binary artifact %s exceeds the inline limit of %s; configure ARTIFACT_DIR to save it to disk",
+			artifactPath, formatBytes(maxBytes))
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" || strings.HasPrefix(mimeType, "application/octet-stream") {
+		mimeType = http.DetectContentType(data)
+	}
+	return &BinaryContent{
+		Summary:  fmt.Sprintf("Artifact %s of build %s downloaded (%s, %s)", artifactPath, req.BuildID, mimeType, formatBytes(int64(len(data)))),
+		URI:      fmt.Sprintf("teamcity://builds/%s/artifacts/content/%s", req.BuildID, artifactPath),
+		MimeType: mimeType,
+		Data:     data,
+	}, nil
+}
+
+// isText reports whether data looks like text: valid UTF-8 without NUL bytes. A multi-byte
+// character cut off at the end of a truncated read is tolerated.
+func isText(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	for i := 0; i < utf8.UTFMax && i < len(data); i++ {
+		if utf8.Valid(data[:len(data)-i]) {
+			return true
+		}
+	}
+	return len(data) == 0
+}
+
+// saveToFile streams r into a new file at path, creating parent directories as needed
+func saveToFile(path string, r io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+
+	return written, nil
+}
+
+// maxListedArtifacts bounds the number of entries list_build_artifacts renders
+const maxListedArtifacts = 1000
+
+// artifactFile is an artifact file, directory or archive entry
+type artifactFile struct {
+	Name             string `json:"name"`
+	FullName         string `json:"fullName"`
+	Size             *int64 `json:"size"`
+	ModificationTime string `json:"modificationTime"`
+	Children         *struct {
+		Count int `json:"count"`
+	} `json:"children"`
+}
+
+// ListBuildArtifacts lists the artifacts of a build recursively as a tree with sizes and
+// modification times, optionally including the entries of archives
+func (c *Client) ListBuildArtifacts(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID        string `json:"buildId"`
+		Path           string `json:"path"`
+		BrowseArchives bool   `json:"browseArchives"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildID == "" {
+		return "", fmt.Errorf("buildId is required")
+	}
+	root := strings.Trim(req.Path, "/")
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("list_build_artifacts", "success", time.Since(start).Seconds())
+	}()
+
+	files, err := c.listArtifactFiles(ctx, req.BuildID, root, req.BrowseArchives)
+	if err != nil {
+		return "", err
+	}
+
+	where := ""
+	if root != "" {
+		where = " under " + root
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("Build %s has no artifacts%s.", req.BuildID, where), nil
+	}
+
+	var count int
+	var total int64
+	lines := ""
+	for i, file := range files {
+		inArchive := strings.Contains(file.FullName, "!/")
+		if file.Size != nil && !inArchive {
+			count++
+			total += *file.Size
+		}
+		if i >= maxListedArtifacts {
+			continue
+		}
+
+		relative := strings.TrimPrefix(strings.TrimPrefix(file.FullName, root), "/")
+		line := strings.Repeat("  ", strings.Count(relative, "/")+1) + file.Name
+		switch {
+		case file.Children != nil && file.Size != nil:
+			line += fmt.Sprintf("  (archive, %s)", formatBytes(*file.Size))
+		case file.Children != nil:
+			line += "/"
+		case file.Size != nil:
+			line += "  " + formatBytes(*file.Size)
+		}
+		if file.ModificationTime != "" {
+			line += "  " + c.formatTeamCityDate(file.ModificationTime)
+		}
+		lines += line + "\n"
+	}
+
+	result := fmt.Sprintf("Artifacts of build %s%s: %d files, %s\n\n", req.BuildID, where, count, formatBytes(total))
+	result += lines
+	if len(files) > maxListedArtifacts {
+		result += fmt.Sprintf("\n... %d more entries not shown; list a subdirectory with path\n", len(files)-maxListedArtifacts)
+	}
+	return result, nil
+}
+
+// listArtifactFiles returns the artifact files, directories and, optionally, archive entries of a
+// build under root, sorted by full name so that every entry follows its directory or archive
+func (c *Client) listArtifactFiles(ctx context.Context, buildID, root string, browseArchives bool) ([]artifactFile, error) {
+	locator := "recursive:true"
+	if browseArchives {
+		locator += ",browseArchives:true"
+	}
+	endpoint := fmt.Sprintf("/builds/id:%s/artifacts/children/%s?locator=%s&fields=%s", url.PathEscape(buildID),
+		escapeArtifactPath(root), url.QueryEscape(locator), url.QueryEscape("file(name,fullName,size,modificationTime,children(count))"))
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts of build %s: %w", buildID, err)
+	}
+
+	var response struct {
+		File []artifactFile `json:"file"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse artifacts response: %w", err)
+	}
+
+	sort.Slice(response.File, func(i, j int) bool { return response.File[i].FullName < response.File[j].FullName })
+	return response.File, nil
+}
+
+// escapeArtifactPath escapes each segment of an artifact path for use in a URL path
+func escapeArtifactPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
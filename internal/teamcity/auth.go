@@ -0,0 +1,41 @@
+package teamcity
+
+import (
+	"context"
+	"net/http"
+)
+
+type tokenKey struct{}
+
+// WithToken makes TeamCity requests made with ctx authenticate with token instead of the
+// configured TC_TOKEN, so that actions are attributed to the user the token belongs to
+func WithToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// HasToken reports whether ctx carries a token set with WithToken
+func HasToken(ctx context.Context) bool {
+	_, ok := ctx.Value(tokenKey{}).(string)
+	return ok
+}
+
+// TokenFrom returns the token set on ctx with WithToken, or "" if there is none
+func TokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// authorize sets the Authorization header of a TeamCity request: the token of the request
+// context if there is one, otherwise the configured token
+func (c *Client) authorize(ctx context.Context, req *http.Request) {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	if !ok {
+		token = c.cfg.Token
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
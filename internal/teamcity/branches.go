@@ -0,0 +1,407 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// Branch represents a branch of a build configuration with its latest build
+type Branch struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+	Active  bool   `json:"active"`
+	Builds  struct {
+		Build []Build `json:"build"`
+	} `json:"builds"`
+}
+
+// branchFields is the field selection of branches, with the latest build of each
+const branchFields = "branch(name,default,active,builds($locator(count:1),build(id,number,status,state,finishDate,webUrl)))"
+
+// ListBranches lists the branches of a build configuration, the default branch first, with the
+// status of the latest build of each
+func (c *Client) ListBranches(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID     string `json:"buildTypeId"`
+		Name            string `json:"name"`
+		IncludeInactive bool   `json:"includeInactive"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("list_branches", "success", time.Since(start).Seconds())
+	}()
+
+	branches, err := c.getBranches(ctx, req.BuildTypeID, req.IncludeInactive)
+	if err != nil {
+		return "", err
+	}
+
+	var matching []Branch
+	for _, branch := range branches {
+		if req.Name == "" || strings.Contains(strings.ToLower(branch.Name), strings.ToLower(req.Name)) {
+			matching = append(matching, branch)
+		}
+	}
+	if len(matching) == 0 {
+		return fmt.Sprintf("No branches found for %s.", req.BuildTypeID), nil
+	}
+
+	result := fmt.Sprintf("Branches of %s (%d):\n\n", req.BuildTypeID, len(matching))
+	for _, branch := range matching {
+		result += "- " + branch.Name
+		if branch.Default {
+			result += " [default]"
+		}
+		if req.IncludeInactive && !branch.Active {
+			result += " [inactive]"
+		}
+		result += ": "
+		if len(branch.Builds.Build) == 0 {
+			result += "no builds\n"
+			continue
+		}
+		build := branch.Builds.Build[0]
+		if build.State == "finished" {
+			result += build.Status
+		} else {
+			result += strings.ToUpper(build.State)
+		}
+		result += fmt.Sprintf(" #%s (ID: %d)", build.Number, build.ID)
+		if build.FinishDate != "" {
+			result += ", finished " + c.formatTeamCityDate(build.FinishDate)
+		}
+		result += "\n"
+	}
+	return result, nil
+}
+
+// getBranches returns the active branches of a build configuration, or all branches it has
+// builds or VCS branches for, with the default branch first
+func (c *Client) getBranches(ctx context.Context, buildTypeID string, includeInactive bool) ([]Branch, error) {
+	locator := "policy:ACTIVE_HISTORY_AND_ACTIVE_VCS_BRANCHES"
+	if includeInactive {
+		locator = "policy:ALL_BRANCHES"
+	}
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/branches?locator=%s&fields=%s",
+		url.PathEscape(buildTypeID), url.QueryEscape(locator), url.QueryEscape(branchFields)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branches: %w", err)
+	}
+
+	var response struct {
+		Branch []Branch `json:"branch"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse branches response: %w", err)
+	}
+
+	branches := make([]Branch, 0, len(response.Branch))
+	for _, branch := range response.Branch {
+		if branch.Default {
+			branches = append([]Branch{branch}, branches...)
+		} else {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// maxBranchHealthConfigurations bounds the build configurations get_branch_health checks
+const maxBranchHealthConfigurations = 200
+
+// failingBranch is a build configuration whose latest build on a branch failed
+type failingBranch struct {
+	buildType BuildType
+	latest    Build
+	// since is the first failed build after the last successful one, nil when none succeeded
+	since *Build
+}
+
+// GetBranchHealth reports, for the build configurations of a project, which branches are failing
+// and since which build
+func (c *Client) GetBranchHealth(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID string `json:"projectId"`
+		Branch    string `json:"branch"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" {
+		return "", fmt.Errorf("projectId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_branch_health", "success", time.Since(start).Seconds())
+	}()
+
+	locator := fmt.Sprintf("affectedProject:(id:%s),count:%d", req.ProjectID, maxBranchHealthConfigurations+1)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes?locator=%s&fields=%s",
+		url.QueryEscape(locator), url.QueryEscape("buildType(id,name)")), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build configurations: %w", err)
+	}
+
+	var buildTypes struct {
+		BuildType []BuildType `json:"buildType"`
+	}
+	if err := json.Unmarshal(respBody, &buildTypes); err != nil {
+		return "", fmt.Errorf("failed to parse build configurations response: %w", err)
+	}
+	truncated := len(buildTypes.BuildType) > maxBranchHealthConfigurations
+	if truncated {
+		buildTypes.BuildType = buildTypes.BuildType[:maxBranchHealthConfigurations]
+	}
+
+	failing := make(map[string][]failingBranch)
+	var branchNames []string
+	defaultBranches := make(map[string]bool)
+	checked, passing := 0, 0
+	for _, buildType := range buildTypes.BuildType {
+		branches, err := c.getBranches(ctx, buildType.ID, false)
+		if err != nil {
+			return "", fmt.Errorf("failed to get branches of %s: %w", buildType.ID, err)
+		}
+		for _, branch := range branches {
+			if req.Branch != "" && !strings.Contains(strings.ToLower(branch.Name), strings.ToLower(req.Branch)) {
+				continue
+			}
+			if len(branch.Builds.Build) == 0 || branch.Builds.Build[0].State != "finished" {
+				continue
+			}
+			checked++
+			latest := branch.Builds.Build[0]
+			if latest.Status != "FAILURE" {
+				passing++
+				continue
+			}
+
+			since, err := c.firstFailureSince(ctx, buildType.ID, branch.Name)
+			if err != nil {
+				return "", err
+			}
+			if _, ok := failing[branch.Name]; !ok {
+				branchNames = append(branchNames, branch.Name)
+			}
+			failing[branch.Name] = append(failing[branch.Name], failingBranch{buildType, latest, since})
+			if branch.Default {
+				defaultBranches[branch.Name] = true
+			}
+		}
+	}
+
+	result := fmt.Sprintf("Branch health of project %s: %d of %d branch builds failing in %d branches (%d build configurations checked)\n",
+		req.ProjectID, checked-passing, checked, len(branchNames), len(buildTypes.BuildType))
+	if truncated {
+		result += fmt.Sprintf("Only the first %d build configurations were checked.\n", maxBranchHealthConfigurations)
+	}
+	if len(branchNames) == 0 {
+		return result + "\nNo branch is failing.\n", nil
+	}
+
+	// Default branches come first, then the others by name
+	sort.Slice(branchNames, func(i, j int) bool {
+		if defaultBranches[branchNames[i]] != defaultBranches[branchNames[j]] {
+			return defaultBranches[branchNames[i]]
+		}
+		return branchNames[i] < branchNames[j]
+	})
+	for _, name := range branchNames {
+		result += "\n" + name
+		if defaultBranches[name] {
+			result += " [default]"
+		}
+		result += "\n"
+		for _, f := range failing[name] {
+			result += fmt.Sprintf("  - %s (%s): FAILURE #%s (ID: %d)", f.buildType.Name, f.buildType.ID, f.latest.Number, f.latest.ID)
+			switch {
+			case f.since == nil:
+				result += ", never succeeded"
+			case f.since.ID == f.latest.ID:
+				result += ", first failure"
+			default:
+				result += fmt.Sprintf(", failing since #%s (ID: %d)", f.since.Number, f.since.ID)
+				if f.since.FinishDate != "" {
+					result += " finished " + c.formatTeamCityDate(f.since.FinishDate)
+				}
+			}
+			result += "\n"
+		}
+	}
+	return result, nil
+}
+
+// firstFailureSince returns the first finished build on a branch after its last successful build,
+// or nil when no build on the branch succeeded
+func (c *Client) firstFailureSince(ctx context.Context, buildTypeID, branch string) (*Build, error) {
+	branchLocator := fmt.Sprintf("buildType:(id:%s),branch:(name:%s)", buildTypeID, branch)
+	lastSuccess, err := c.findBuilds(ctx, branchLocator+",status:SUCCESS,count:1", "build(id)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last successful build of %s on %s: %w", buildTypeID, branch, err)
+	}
+	if len(lastSuccess) == 0 {
+		return nil, nil
+	}
+
+	// Builds come newest first, so the first failure is the last one listed
+	builds, err := c.findBuilds(ctx, fmt.Sprintf("%s,sinceBuild:(id:%d),count:1000", branchLocator, lastSuccess[0].ID), "build(id,number,finishDate)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get builds of %s on %s: %w", buildTypeID, branch, err)
+	}
+	if len(builds) == 0 {
+		return nil, nil
+	}
+	return &builds[len(builds)-1], nil
+}
+
+// findBuilds returns the builds matching a locator with the given field selection
+func (c *Client) findBuilds(ctx context.Context, locator, fields string) ([]Build, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Build []Build `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse builds response: %w", err)
+	}
+	return response.Build, nil
+}
+
+// FindPullRequestBuilds finds the builds of a pull or merge request from its number or source
+// branch, trying the branch names TeamCity uses for pull requests and the pull request build parameters
+func (c *Client) FindPullRequestBuilds(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		PullRequest string `json:"pullRequest"`
+		Branch      string `json:"branch"`
+		BuildTypeID string `json:"buildTypeId"`
+		ProjectID   string `json:"projectId"`
+		Count       int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	req.PullRequest = strings.TrimPrefix(strings.TrimSpace(req.PullRequest), "#")
+	if (req.PullRequest == "") == (req.Branch == "") {
+		return "", fmt.Errorf("exactly one of pullRequest or branch is required")
+	}
+	if req.Count == 0 {
+		req.Count = 20
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("find_pull_request_builds", "success", time.Since(start).Seconds())
+	}()
+
+	// Depending on the VCS hosting and branch specification, TeamCity names a pull request branch
+	// pull/N, refs/pull/N/head or refs/pull/N/merge; the Pull Requests build feature also records
+	// the number and source branch as build parameters
+	var subject string
+	var candidates []string
+	if req.PullRequest != "" {
+		n := req.PullRequest
+		subject = "pull request #" + n
+		candidates = []string{
+			fmt.Sprintf("property:(name:teamcity.pullRequest.number,value:%s),branch:default:any", n),
+			fmt.Sprintf("branch:(name:pull/%s)", n),
+			fmt.Sprintf("branch:(name:refs/pull/%s/head)", n),
+			fmt.Sprintf("branch:(name:refs/pull/%s/merge)", n),
+			fmt.Sprintf("branch:(name:merge-requests/%s)", n),
+			fmt.Sprintf("branch:(name:refs/merge-requests/%s/head)", n),
+			fmt.Sprintf("branch:(name:pull-requests/%s)", n),
+			fmt.Sprintf("branch:(name:refs/pull-requests/%s/from)", n),
+		}
+	} else {
+		branch := strings.TrimPrefix(req.Branch, "refs/heads/")
+		subject = "branch " + branch
+		candidates = []string{
+			fmt.Sprintf("property:(name:teamcity.pullRequest.source.branch,value:%s),branch:default:any", branch),
+			fmt.Sprintf("branch:(name:%s)", branch),
+			fmt.Sprintf("branch:(name:refs/heads/%s)", branch),
+		}
+	}
+
+	scope := ""
+	if req.BuildTypeID != "" {
+		scope += fmt.Sprintf("buildType:(id:%s),", req.BuildTypeID)
+	}
+	if req.ProjectID != "" {
+		scope += fmt.Sprintf("affectedProject:(id:%s),", req.ProjectID)
+	}
+
+	fields := "build(id,number,status,state,branchName,buildTypeId,finishDate,webUrl,buildType(name))"
+	seen := make(map[int]bool)
+	var builds []Build
+	var branches []string
+	for _, candidate := range candidates {
+		found, err := c.findBuilds(ctx, fmt.Sprintf("%s%s,state:any,count:%d", scope, candidate, req.Count), fields)
+		if err != nil {
+			return "", fmt.Errorf("failed to search builds: %w", err)
+		}
+		for _, build := range found {
+			if seen[build.ID] {
+				continue
+			}
+			seen[build.ID] = true
+			builds = append(builds, build)
+			if build.BranchName != "" && !containsString(branches, build.BranchName) {
+				branches = append(branches, build.BranchName)
+			}
+		}
+	}
+
+	if len(builds) == 0 {
+		return fmt.Sprintf("No builds found for %s.", subject), nil
+	}
+
+	// Newest first, as TeamCity lists builds
+	sort.Slice(builds, func(i, j int) bool { return builds[i].ID > builds[j].ID })
+	if len(builds) > req.Count {
+		builds = builds[:req.Count]
+	}
+
+	result := fmt.Sprintf("Found %d builds for %s on branches %s:\n\n", len(builds), subject, strings.Join(branches, ", "))
+	for _, build := range builds {
+		result += fmt.Sprintf("- %s (%s) #%s (ID: %d): ", build.BuildType.Name, build.BuildTypeID, build.Number, build.ID)
+		if build.State == "finished" {
+			result += build.Status
+		} else {
+			result += strings.ToUpper(build.State)
+		}
+		if build.BranchName != "" {
+			result += fmt.Sprintf(" [%s]", build.BranchName)
+		}
+		if build.FinishDate != "" {
+			result += ", finished " + c.formatTeamCityDate(build.FinishDate)
+		}
+		result += "\n"
+		if build.WebURL != "" {
+			result += fmt.Sprintf("  URL: %s\n", build.WebURL)
+		}
+	}
+	return result, nil
+}
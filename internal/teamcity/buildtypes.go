@@ -0,0 +1,776 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// BuildFeature represents a build feature of a TeamCity build configuration
+type BuildFeature struct {
+	ID         string `json:"id,omitempty"`
+	Type       string `json:"type"`
+	Disabled   bool   `json:"disabled,omitempty"`
+	Properties struct {
+		Property []Parameter `json:"property,omitempty"`
+	} `json:"properties"`
+}
+
+// Property returns the value of a feature property, or "" if it is not set
+func (f BuildFeature) Property(name string) string {
+	for _, prop := range f.Properties.Property {
+		if prop.Name == name {
+			return prop.Value
+		}
+	}
+	return ""
+}
+
+// Failure condition feature types
+const (
+	failureOnMetricFeature  = "BuildFailureOnMetric"
+	failureOnMessageFeature = "BuildFailureOnMessage"
+)
+
+// failureSettings maps the common failure condition arguments to build configuration settings
+var failureSettings = map[string]string{
+	"failOnExitCode":      "shouldFailBuildOnBadExitCode",
+	"failOnTestFailure":   "shouldFailBuildIfTestsFailed",
+	"failOnErrorMessage":  "shouldFailBuildOnAnyErrorMessage",
+	"failOnOOMOrCrash":    "shouldFailBuildOnOOMEOrCrash",
+	"executionTimeoutMin": "executionTimeoutMin",
+}
+
+// getBuildTypeSettings returns the general settings of a build configuration
+func (c *Client) getBuildTypeSettings(ctx context.Context, buildTypeID string) (map[string]string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/settings", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Property []Parameter `json:"property"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse settings response: %w", err)
+	}
+
+	settings := make(map[string]string, len(response.Property))
+	for _, prop := range response.Property {
+		settings[prop.Name] = prop.Value
+	}
+	return settings, nil
+}
+
+// setBuildTypeSetting updates a single general setting of a build configuration
+func (c *Client) setBuildTypeSetting(ctx context.Context, buildTypeID, name, value string) error {
+	_, err := c.makeRawRequest(ctx, "PUT", fmt.Sprintf("/app/rest/buildTypes/id:%s/settings/%s", url.PathEscape(buildTypeID), url.PathEscape(name)), []byte(value), "text/plain")
+	return err
+}
+
+// getBuildFeatures returns the build features of a build configuration
+func (c *Client) getBuildFeatures(ctx context.Context, buildTypeID string) ([]BuildFeature, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/features", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Feature []BuildFeature `json:"feature"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse features response: %w", err)
+	}
+	return response.Feature, nil
+}
+
+// ManageFailureConditions lists or edits the failure conditions of a build configuration:
+// the common conditions (exit code, failed tests, error messages, timeout) and the
+// metric and build log text conditions defined as build features
+func (c *Client) ManageFailureConditions(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action      string            `json:"action"`
+		BuildTypeID string            `json:"buildTypeId"`
+		Settings    map[string]string `json:"settings"`
+		Type        string            `json:"type"`
+		Metric      string            `json:"metric"`
+		Threshold   string            `json:"threshold"`
+		MoreOrLess  string            `json:"moreOrLess"`
+		Text        string            `json:"text"`
+		Regex       bool              `json:"regex"`
+		FailIfFound *bool             `json:"failIfFound"`
+		Message     string            `json:"message"`
+		Properties  map[string]string `json:"properties"`
+		ConditionID string            `json:"conditionId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_failure_conditions", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "list":
+		settings, err := c.getBuildTypeSettings(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get settings: %w", err)
+		}
+		features, err := c.getBuildFeatures(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get build features: %w", err)
+		}
+		return formatFailureConditions(req.BuildTypeID, settings, features), nil
+
+	case "set":
+		if len(req.Settings) == 0 {
+			return "", fmt.Errorf("settings is required for set action")
+		}
+
+		names := make([]string, 0, len(req.Settings))
+		for name := range req.Settings {
+			if _, ok := failureSettings[name]; !ok {
+				return "", fmt.Errorf("unknown failure condition setting: %s", name)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value := req.Settings[name]
+			if name == "executionTimeoutMin" {
+				if _, err := strconv.Atoi(value); err != nil {
+					return "", fmt.Errorf("executionTimeoutMin must be a number of minutes")
+				}
+			} else if _, err := strconv.ParseBool(value); err != nil {
+				return "", fmt.Errorf("%s must be true or false", name)
+			}
+			if err := c.setBuildTypeSetting(ctx, req.BuildTypeID, failureSettings[name], value); err != nil {
+				return "", fmt.Errorf("failed to update %s: %w", name, err)
+			}
+		}
+
+		return fmt.Sprintf("Failure conditions of %s updated: %d setting(s) changed", req.BuildTypeID, len(names)), nil
+
+	case "add":
+		properties := make(map[string]string)
+		feature := map[string]interface{}{}
+
+		switch req.Type {
+		case "metric":
+			if req.Metric == "" || req.Threshold == "" {
+				return "", fmt.Errorf("metric and threshold are required for metric conditions")
+			}
+			if req.MoreOrLess == "" {
+				req.MoreOrLess = "more"
+			}
+			if req.MoreOrLess != "more" && req.MoreOrLess != "less" {
+				return "", fmt.Errorf("moreOrLess must be more or less")
+			}
+			feature["type"] = failureOnMetricFeature
+			properties["metricKey"] = req.Metric
+			properties["metricThreshold"] = req.Threshold
+			properties["moreOrLess"] = req.MoreOrLess
+			properties["metricUnits"] = "metricUnitsDefault"
+			properties["anchorBuild"] = "-1"
+		case "text":
+			if req.Text == "" {
+				return "", fmt.Errorf("text is required for text conditions")
+			}
+			feature["type"] = failureOnMessageFeature
+			properties["buildFailureOnMessage.conditionText"] = req.Text
+			properties["buildFailureOnMessage.conditionType"] = "contains"
+			if req.Regex {
+				properties["buildFailureOnMessage.conditionType"] = "regexp"
+			}
+			properties["buildFailureOnMessage.reverse"] = "false"
+			if req.FailIfFound != nil && !*req.FailIfFound {
+				properties["buildFailureOnMessage.reverse"] = "true"
+			}
+			if req.Message != "" {
+				properties["buildFailureOnMessage.outputText"] = req.Message
+			}
+		default:
+			return "", fmt.Errorf("type must be metric or text")
+		}
+
+		// Explicit properties take precedence for options not covered by the arguments above
+		for name, value := range req.Properties {
+			properties[name] = value
+		}
+		feature["properties"] = propertiesPayload(properties)
+
+		reqBody, err := json.Marshal(feature)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal failure condition: %w", err)
+		}
+
+		respBody, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/buildTypes/id:%s/features", url.PathEscape(req.BuildTypeID)), reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to add failure condition: %w", err)
+		}
+
+		var created BuildFeature
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse failure condition response: %w", err)
+		}
+
+		return fmt.Sprintf("Failure condition added to %s (ID: %s): %s", req.BuildTypeID, created.ID, describeFailureCondition(created)), nil
+
+	case "delete":
+		if req.ConditionID == "" {
+			return "", fmt.Errorf("conditionId is required for delete action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/buildTypes/id:%s/features/%s", url.PathEscape(req.BuildTypeID), url.PathEscape(req.ConditionID)), nil); err != nil {
+			return "", fmt.Errorf("failed to delete failure condition: %w", err)
+		}
+
+		return fmt.Sprintf("Failure condition %s deleted from %s", req.ConditionID, req.BuildTypeID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, set, add or delete)", req.Action)
+	}
+}
+
+// describeFailureCondition summarizes a metric or build log text failure condition
+func describeFailureCondition(f BuildFeature) string {
+	switch f.Type {
+	case failureOnMetricFeature:
+		return fmt.Sprintf("fail if metric %s is %s than %s", f.Property("metricKey"), f.Property("moreOrLess"), f.Property("metricThreshold"))
+	case failureOnMessageFeature:
+		verb := "contains"
+		if f.Property("buildFailureOnMessage.conditionType") == "regexp" {
+			verb = "matches"
+		}
+		when := "fail if build log"
+		if f.Property("buildFailureOnMessage.reverse") == "true" {
+			when = "fail if build log does not"
+			if verb == "contains" {
+				verb = "contain"
+			} else {
+				verb = "match"
+			}
+		}
+		return fmt.Sprintf("%s %s '%s'", when, verb, f.Property("buildFailureOnMessage.conditionText"))
+	default:
+		return f.Type
+	}
+}
+
+// formatFailureConditions formats the failure conditions of a build configuration
+func formatFailureConditions(buildTypeID string, settings map[string]string, features []BuildFeature) string {
+	onOff := func(name string, defaultValue string) string {
+		value, ok := settings[name]
+		if !ok {
+			value = defaultValue
+		}
+		if value == "true" {
+			return "enabled"
+		}
+		return "disabled"
+	}
+
+	result := fmt.Sprintf("Failure conditions for %s:\n\n", buildTypeID)
+	result += "Common conditions:\n"
+	result += fmt.Sprintf("  Fail on non-zero exit code (failOnExitCode): %s\n", onOff("shouldFailBuildOnBadExitCode", "true"))
+	result += fmt.Sprintf("  Fail if tests failed (failOnTestFailure): %s\n", onOff("shouldFailBuildIfTestsFailed", "true"))
+	result += fmt.Sprintf("  Fail on error message from build runner (failOnErrorMessage): %s\n", onOff("shouldFailBuildOnAnyErrorMessage", "false"))
+	result += fmt.Sprintf("  Fail on OutOfMemoryError or crash (failOnOOMOrCrash): %s\n", onOff("shouldFailBuildOnOOMEOrCrash", "true"))
+	timeout := settings["executionTimeoutMin"]
+	if timeout == "" || timeout == "0" {
+		timeout = "none"
+	} else {
+		timeout += " min"
+	}
+	result += fmt.Sprintf("  Execution timeout (executionTimeoutMin): %s\n", timeout)
+
+	conditions := make([]BuildFeature, 0)
+	for _, f := range features {
+		if f.Type == failureOnMetricFeature || f.Type == failureOnMessageFeature {
+			conditions = append(conditions, f)
+		}
+	}
+
+	result += fmt.Sprintf("\nAdditional conditions (%d):\n", len(conditions))
+	if len(conditions) == 0 {
+		result += "  (none)\n"
+	}
+	for _, f := range conditions {
+		result += fmt.Sprintf("  - [%s] %s", f.ID, describeFailureCondition(f))
+		if f.Disabled {
+			result += " (disabled)"
+		}
+		result += "\n"
+	}
+
+	return result
+}
+
+// VCSRootEntry represents a VCS root attached to a build configuration
+type VCSRootEntry struct {
+	ID            string  `json:"id"`
+	VCSRoot       VCSRoot `json:"vcs-root"`
+	CheckoutRules string  `json:"checkout-rules"`
+}
+
+// generalSettings maps the general setting arguments to build configuration settings
+var generalSettings = map[string]string{
+	"artifactRules":     "artifactRules",
+	"buildNumberFormat": "buildNumberPattern",
+	"checkoutMode":      "checkoutMode",
+	"checkoutDirectory": "checkoutDirectory",
+	"cleanBuild":        "cleanBuild",
+}
+
+// checkoutModes lists the valid values of the checkoutMode setting
+var checkoutModes = []string{"ON_AGENT", "ON_SERVER", "MANUAL"}
+
+// getVCSRootEntries returns the VCS roots attached to a build configuration with their checkout rules
+func (c *Client) getVCSRootEntries(ctx context.Context, buildTypeID string) ([]VCSRootEntry, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/vcs-root-entries?fields=vcs-root-entry(id,checkout-rules,vcs-root(id,name,vcsName))", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		VCSRootEntry []VCSRootEntry `json:"vcs-root-entry"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse VCS root entries response: %w", err)
+	}
+	return response.VCSRootEntry, nil
+}
+
+// setCheckoutRules replaces the checkout rules of a VCS root attached to a build configuration
+func (c *Client) setCheckoutRules(ctx context.Context, buildTypeID, vcsRootID, rules string) error {
+	_, err := c.makeRawRequest(ctx, "PUT", fmt.Sprintf("/app/rest/buildTypes/id:%s/vcs-root-entries/%s/checkout-rules", url.PathEscape(buildTypeID), url.PathEscape(vcsRootID)), []byte(rules), "text/plain")
+	return err
+}
+
+// ManageBuildSettings shows or edits the general settings of a build configuration: checkout rules
+// per VCS root, artifact paths, build number format and checkout mode
+func (c *Client) ManageBuildSettings(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action        string            `json:"action"`
+		BuildTypeID   string            `json:"buildTypeId"`
+		Settings      map[string]string `json:"settings"`
+		CheckoutRules map[string]string `json:"checkoutRules"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	if req.Action == "" {
+		req.Action = "get"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_build_settings", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "get":
+		settings, err := c.getBuildTypeSettings(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get settings: %w", err)
+		}
+		entries, err := c.getVCSRootEntries(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get VCS root entries: %w", err)
+		}
+		return formatBuildSettings(req.BuildTypeID, settings, entries), nil
+
+	case "set":
+		if len(req.Settings) == 0 && len(req.CheckoutRules) == 0 {
+			return "", fmt.Errorf("settings or checkoutRules is required for set action")
+		}
+
+		names := make([]string, 0, len(req.Settings))
+		for name, value := range req.Settings {
+			if _, ok := generalSettings[name]; !ok {
+				return "", fmt.Errorf("unknown setting: %s", name)
+			}
+			if name == "checkoutMode" && !containsString(checkoutModes, value) {
+				return "", fmt.Errorf("checkoutMode must be one of %s", strings.Join(checkoutModes, ", "))
+			}
+			if name == "cleanBuild" {
+				if _, err := strconv.ParseBool(value); err != nil {
+					return "", fmt.Errorf("cleanBuild must be true or false")
+				}
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		changed := make([]string, 0)
+		for _, name := range names {
+			if err := c.setBuildTypeSetting(ctx, req.BuildTypeID, generalSettings[name], req.Settings[name]); err != nil {
+				return "", fmt.Errorf("failed to update %s: %w", name, err)
+			}
+			changed = append(changed, name)
+		}
+
+		rootIDs := make([]string, 0, len(req.CheckoutRules))
+		for id := range req.CheckoutRules {
+			rootIDs = append(rootIDs, id)
+		}
+		sort.Strings(rootIDs)
+
+		for _, id := range rootIDs {
+			if err := c.setCheckoutRules(ctx, req.BuildTypeID, id, req.CheckoutRules[id]); err != nil {
+				return "", fmt.Errorf("failed to update checkout rules of %s: %w", id, err)
+			}
+			changed = append(changed, fmt.Sprintf("checkout rules of %s", id))
+		}
+
+		return fmt.Sprintf("Settings of %s updated: %s", req.BuildTypeID, strings.Join(changed, ", ")), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected get or set)", req.Action)
+	}
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBuildSettings formats the general settings of a build configuration
+func formatBuildSettings(buildTypeID string, settings map[string]string, entries []VCSRootEntry) string {
+	valueOr := func(name, defaultValue string) string {
+		if value := settings[name]; value != "" {
+			return value
+		}
+		return defaultValue
+	}
+
+	result := fmt.Sprintf("General settings for %s:\n\n", buildTypeID)
+	result += fmt.Sprintf("  Build Number Format (buildNumberFormat): %s\n", valueOr("buildNumberPattern", "%build.counter%"))
+	result += fmt.Sprintf("  Checkout Mode (checkoutMode): %s\n", valueOr("checkoutMode", "ON_AGENT"))
+	result += fmt.Sprintf("  Checkout Directory (checkoutDirectory): %s\n", valueOr("checkoutDirectory", "(default)"))
+	result += fmt.Sprintf("  Clean Build (cleanBuild): %s\n", valueOr("cleanBuild", "false"))
+
+	result += "  Artifact Paths (artifactRules):"
+	if rules := settings["artifactRules"]; rules != "" {
+		result += "\n"
+		for _, line := range strings.Split(rules, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				result += fmt.Sprintf("    %s\n", line)
+			}
+		}
+	} else {
+		result += " (none)\n"
+	}
+
+	result += fmt.Sprintf("\nVCS Roots (%d):\n", len(entries))
+	if len(entries) == 0 {
+		result += "  (none)\n"
+	}
+	for _, entry := range entries {
+		result += fmt.Sprintf("  - %s (%s)\n", entry.VCSRoot.Name, entry.ID)
+		result += formatCheckoutRules(entry.CheckoutRules, "      ")
+	}
+
+	return result
+}
+
+// formatCheckoutRules formats checkout rules one per line
+func formatCheckoutRules(rules, indent string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(rules, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return indent + "Checkout Rules: (none, whole repository)\n"
+	}
+
+	result := indent + "Checkout Rules:\n"
+	for _, line := range lines {
+		result += fmt.Sprintf("%s  %s\n", indent, line)
+	}
+	return result
+}
+
+// SetCheckoutRules replaces or extends the checkout rules of a VCS root attached to a build configuration
+func (c *Client) SetCheckoutRules(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string   `json:"buildTypeId"`
+		VCSRootID   string   `json:"vcsRootId"`
+		Rules       []string `json:"rules"`
+		Append      bool     `json:"append"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("set_checkout_rules", "success", time.Since(start).Seconds())
+	}()
+
+	entries, err := c.getVCSRootEntries(ctx, req.BuildTypeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get VCS root entries: %w", err)
+	}
+
+	var entry *VCSRootEntry
+	for i := range entries {
+		if req.VCSRootID == "" || entries[i].ID == req.VCSRootID {
+			entry = &entries[i]
+			break
+		}
+	}
+	switch {
+	case req.VCSRootID == "" && len(entries) > 1:
+		ids := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		return "", fmt.Errorf("vcsRootId is required when a configuration has several VCS roots (%s)", strings.Join(ids, ", "))
+	case entry == nil && req.VCSRootID != "":
+		return "", fmt.Errorf("VCS root %s is not attached to %s", req.VCSRootID, req.BuildTypeID)
+	case entry == nil:
+		return "", fmt.Errorf("%s has no VCS roots attached", req.BuildTypeID)
+	}
+
+	rules := make([]string, 0, len(req.Rules))
+	if req.Append {
+		for _, line := range strings.Split(entry.CheckoutRules, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				rules = append(rules, line)
+			}
+		}
+	}
+	for _, rule := range req.Rules {
+		if rule = strings.TrimSpace(rule); rule != "" && !containsString(rules, rule) {
+			rules = append(rules, rule)
+		}
+	}
+
+	newRules := strings.Join(rules, "\n")
+	if err := c.setCheckoutRules(ctx, req.BuildTypeID, entry.ID, newRules); err != nil {
+		return "", fmt.Errorf("failed to update checkout rules: %w", err)
+	}
+
+	result := fmt.Sprintf("Checkout rules of %s in %s updated\n\n", entry.ID, req.BuildTypeID)
+	result += "Before:\n" + formatCheckoutRules(entry.CheckoutRules, "  ")
+	result += "After:\n" + formatCheckoutRules(newRules, "  ")
+	return result, nil
+}
+
+// CreateBuildConfiguration creates a build configuration in a project, optionally based on a
+// template, with initial parameters and an attached VCS root
+func (c *Client) CreateBuildConfiguration(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID     string            `json:"projectId"`
+		Name          string            `json:"name"`
+		ID            string            `json:"id"`
+		Description   string            `json:"description"`
+		TemplateID    string            `json:"templateId"`
+		Parameters    map[string]string `json:"parameters"`
+		VCSRootID     string            `json:"vcsRootId"`
+		CheckoutRules string            `json:"checkoutRules"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" || req.Name == "" {
+		return "", fmt.Errorf("projectId and name are required")
+	}
+	if req.CheckoutRules != "" && req.VCSRootID == "" {
+		return "", fmt.Errorf("checkoutRules requires vcsRootId")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("create_build_configuration", "success", time.Since(start).Seconds())
+	}()
+
+	// TeamCity generates the ID from the project's ID and the name when none is given
+	buildType := map[string]interface{}{
+		"name":    req.Name,
+		"project": map[string]string{"id": req.ProjectID},
+	}
+	if req.ID != "" {
+		buildType["id"] = req.ID
+	}
+	if req.Description != "" {
+		buildType["description"] = req.Description
+	}
+	if req.TemplateID != "" {
+		buildType["templates"] = map[string]interface{}{"buildType": []map[string]string{{"id": req.TemplateID}}}
+	}
+	if len(req.Parameters) > 0 {
+		buildType["parameters"] = propertiesPayload(req.Parameters)
+	}
+	if req.VCSRootID != "" {
+		entry := map[string]interface{}{"id": req.VCSRootID, "vcs-root": map[string]string{"id": req.VCSRootID}}
+		if req.CheckoutRules != "" {
+			entry["checkout-rules"] = req.CheckoutRules
+		}
+		buildType["vcs-root-entries"] = map[string]interface{}{"vcs-root-entry": []map[string]interface{}{entry}}
+	}
+
+	reqBody, err := json.Marshal(buildType)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal build configuration: %w", err)
+	}
+
+	respBody, err := c.makeRequest(ctx, "POST", "/buildTypes", reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create build configuration: %w", err)
+	}
+
+	var created struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		WebURL string `json:"webUrl"`
+	}
+	if err := json.Unmarshal(respBody, &created); err != nil {
+		return "", fmt.Errorf("failed to parse build configuration response: %w", err)
+	}
+
+	result := fmt.Sprintf("Build configuration %s (%s) created in project %s\n", created.Name, created.ID, req.ProjectID)
+	if req.TemplateID != "" {
+		result += fmt.Sprintf("  Template: %s\n", req.TemplateID)
+	}
+	if len(req.Parameters) > 0 {
+		// Parameter values may be secrets; only their names are echoed
+		names := make([]string, 0, len(req.Parameters))
+		for name := range req.Parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		result += fmt.Sprintf("  Parameters: %s\n", strings.Join(names, ", "))
+	}
+	if req.VCSRootID != "" {
+		result += fmt.Sprintf("  VCS root: %s\n", req.VCSRootID)
+	}
+	if created.WebURL != "" {
+		result += fmt.Sprintf("  URL: %s\n", created.WebURL)
+	}
+	return result, nil
+}
+
+// PauseBuildConfiguration pauses or unpauses a build configuration with a comment. A paused
+// configuration is not triggered automatically; running and queued builds are not affected.
+func (c *Client) PauseBuildConfiguration(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId"`
+		Paused      *bool  `json:"paused"`
+		Comment     string `json:"comment"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	paused := req.Paused == nil || *req.Paused
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("pause_build_configuration", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s?fields=id,name,paused,pauseComment(text,timestamp,user(username))", url.PathEscape(req.BuildTypeID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("build configuration not found: %w", err)
+	}
+
+	var buildType struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Paused       bool   `json:"paused"`
+		PauseComment *struct {
+			Text      string   `json:"text"`
+			Timestamp string   `json:"timestamp"`
+			User      *userRef `json:"user"`
+		} `json:"pauseComment"`
+	}
+	if err := json.Unmarshal(respBody, &buildType); err != nil {
+		return "", fmt.Errorf("failed to parse build configuration: %w", err)
+	}
+
+	if buildType.Paused == paused {
+		result := fmt.Sprintf("%s (%s) is already %s", buildType.Name, buildType.ID, pausedState(paused))
+		if comment := buildType.PauseComment; paused && comment != nil {
+			if comment.User != nil && comment.User.Username != "" {
+				result += " by " + comment.User.Username
+			}
+			if comment.Timestamp != "" {
+				result += " at " + c.formatTeamCityDate(comment.Timestamp)
+			}
+			if comment.Text != "" {
+				result += ": " + comment.Text
+			}
+		}
+		return result, nil
+	}
+
+	if _, err := c.makeRawRequest(ctx, "PUT", fmt.Sprintf("/app/rest/buildTypes/id:%s/paused", url.PathEscape(req.BuildTypeID)), []byte(fmt.Sprint(paused)), "text/plain"); err != nil {
+		return "", fmt.Errorf("failed to %s build configuration: %w", strings.TrimSuffix(pausedState(paused), "d"), err)
+	}
+
+	result := fmt.Sprintf("%s (%s) %s", buildType.Name, buildType.ID, pausedState(paused))
+	if req.Comment != "" {
+		// The state has changed at this point; a comment that cannot be saved does not undo it
+		if _, err := c.makeRawRequest(ctx, "PUT", fmt.Sprintf("/app/rest/buildTypes/id:%s/pauseComment", url.PathEscape(req.BuildTypeID)), []byte(req.Comment), "text/plain"); err != nil {
+			c.logger.Warnw("Failed to set pause comment", "buildTypeId", req.BuildTypeID, "error", err)
+			result += fmt.Sprintf("; the comment could not be saved: %v", err)
+		} else {
+			result += ": " + req.Comment
+		}
+	}
+	if paused {
+		result += "\nAutomatic triggers are suspended; running and queued builds are not affected."
+	}
+	return result, nil
+}
+
+// pausedState describes whether a build configuration is paused
+func pausedState(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "unpaused"
+}
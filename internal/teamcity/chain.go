@@ -0,0 +1,318 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// maxChainSize bounds the number of builds or build configurations get_build_chain walks per direction
+const maxChainSize = 200
+
+// chainNode is a build or build configuration in a build chain
+type chainNode struct {
+	// ref names the node in the summary of blocked nodes; label describes it in the tree
+	ref   string
+	label string
+	// state and status are those of the build, or of the latest build of a build configuration
+	state  string
+	status string
+	deps   []string
+}
+
+// buildChain is the snapshot dependency graph around a build or build configuration
+type buildChain struct {
+	root  string
+	nodes map[string]*chainNode
+}
+
+// GetBuildChain walks the snapshot dependency chain of a build or build configuration upstream
+// (what it depends on) and downstream (what depends on it) and renders it as trees with statuses
+func (c *Client) GetBuildChain(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID     string `json:"buildId"`
+		BuildTypeID string `json:"buildTypeId"`
+		Branch      string `json:"branch"`
+		Direction   string `json:"direction"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if (req.BuildID == "") == (req.BuildTypeID == "") {
+		return "", fmt.Errorf("exactly one of buildId or buildTypeId is required")
+	}
+	if req.Direction == "" {
+		req.Direction = "both"
+	}
+	if req.Direction != "upstream" && req.Direction != "downstream" && req.Direction != "both" {
+		return "", fmt.Errorf("direction must be upstream, downstream or both")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_build_chain", "success", time.Since(start).Seconds())
+	}()
+
+	load := func(direction string) (*buildChain, error) {
+		if req.BuildID != "" {
+			return c.buildChainOfBuild(ctx, req.BuildID, direction)
+		}
+		return c.buildChainOfBuildType(ctx, req.BuildTypeID, req.Branch, direction)
+	}
+
+	result := ""
+	for _, direction := range []string{"upstream", "downstream"} {
+		if req.Direction != "both" && req.Direction != direction {
+			continue
+		}
+		chain, err := load(direction)
+		if err != nil {
+			return "", err
+		}
+		root, ok := chain.nodes[chain.root]
+		if !ok {
+			return "", fmt.Errorf("%s not found in its build chain", chain.root)
+		}
+		if result == "" {
+			result = "Build chain of " + root.label + "\n"
+		}
+
+		edges := chain.dependencies()
+		title := "Upstream (dependencies)"
+		if direction == "downstream" {
+			edges = chain.dependents()
+			title = "Downstream (dependents)"
+		}
+		result += fmt.Sprintf("\n%s (%d):\n", title, len(chain.nodes)-1)
+		if len(edges[chain.root]) == 0 {
+			result += "  (none)\n"
+		} else {
+			result += chain.renderTree(chain.root, edges, "  ", map[string]bool{})
+		}
+		if len(chain.nodes) >= maxChainSize {
+			result += fmt.Sprintf("  ... the chain was cut off at %d nodes\n", maxChainSize)
+		}
+		result += chain.blockers()
+	}
+	return result, nil
+}
+
+// dependencies returns the snapshot dependencies of each node within the chain
+func (b *buildChain) dependencies() map[string][]string {
+	edges := make(map[string][]string, len(b.nodes))
+	for id, node := range b.nodes {
+		for _, dep := range node.deps {
+			if _, ok := b.nodes[dep]; ok {
+				edges[id] = append(edges[id], dep)
+			}
+		}
+		sort.Strings(edges[id])
+	}
+	return edges
+}
+
+// dependents returns the nodes depending on each node within the chain
+func (b *buildChain) dependents() map[string][]string {
+	edges := make(map[string][]string, len(b.nodes))
+	for id, node := range b.nodes {
+		for _, dep := range node.deps {
+			if _, ok := b.nodes[dep]; ok {
+				edges[dep] = append(edges[dep], id)
+			}
+		}
+	}
+	for id := range edges {
+		sort.Strings(edges[id])
+	}
+	return edges
+}
+
+// renderTree renders the children of a node along edges; nodes reached again are not expanded twice
+func (b *buildChain) renderTree(id string, edges map[string][]string, indent string, rendered map[string]bool) string {
+	rendered[id] = true
+	result := ""
+	for _, child := range edges[id] {
+		result += indent + "- " + b.nodes[child].label
+		if rendered[child] && len(edges[child]) > 0 {
+			result += " (see above)\n"
+			continue
+		}
+		result += "\n" + b.renderTree(child, edges, indent+"  ", rendered)
+	}
+	return result
+}
+
+// blockers summarizes the failed, running and queued nodes of the chain other than the root
+func (b *buildChain) blockers() string {
+	groups := map[string][]string{}
+	for id, node := range b.nodes {
+		if id == b.root {
+			continue
+		}
+		switch {
+		case isFailedStatus(node.status):
+			groups["Failed"] = append(groups["Failed"], node.ref)
+		case node.state == "running":
+			groups["Running"] = append(groups["Running"], node.ref)
+		case node.state == "queued":
+			groups["Queued"] = append(groups["Queued"], node.ref)
+		}
+	}
+
+	result := ""
+	for _, group := range []string{"Failed", "Running", "Queued"} {
+		if len(groups[group]) > 0 {
+			sort.Strings(groups[group])
+			result += fmt.Sprintf("  %s: %s\n", group, strings.Join(groups[group], ", "))
+		}
+	}
+	return result
+}
+
+// chainLocator is the snapshot dependency locator of a direction: upstream follows the
+// dependencies of the initial entity, downstream the entities depending on it
+func chainLocator(entity, direction string) string {
+	side := "to"
+	if direction == "downstream" {
+		side = "from"
+	}
+	return fmt.Sprintf("snapshotDependency:(%s:(id:%s),includeInitial:true)", side, entity)
+}
+
+// buildChainOfBuild loads the builds of a build's chain in one direction
+func (c *Client) buildChainOfBuild(ctx context.Context, buildID, direction string) (*buildChain, error) {
+	locator := fmt.Sprintf("%s,defaultFilter:false,count:%d", chainLocator(buildID, direction), maxChainSize)
+	fields := "build(id,number,status,state,branchName,buildTypeId,buildType(name),snapshot-dependencies(build(id)))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s builds: %w", direction, err)
+	}
+
+	var response struct {
+		Build []struct {
+			Build
+			SnapshotDependencies struct {
+				Build []Build `json:"build"`
+			} `json:"snapshot-dependencies"`
+		} `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse builds response: %w", err)
+	}
+
+	chain := &buildChain{root: buildID, nodes: make(map[string]*chainNode, len(response.Build))}
+	for _, build := range response.Build {
+		id := fmt.Sprint(build.ID)
+		node := &chainNode{
+			ref:    fmt.Sprintf("#%s (ID: %d) of %s", build.Number, build.ID, build.BuildTypeID),
+			label:  describeChainBuild(build.Build, true),
+			state:  build.State,
+			status: build.Status,
+		}
+		for _, dep := range build.SnapshotDependencies.Build {
+			node.deps = append(node.deps, fmt.Sprint(dep.ID))
+		}
+		chain.nodes[id] = node
+	}
+	return chain, nil
+}
+
+// buildChainOfBuildType loads the build configurations of a configuration's chain in one
+// direction, each with its latest build on the branch
+func (c *Client) buildChainOfBuildType(ctx context.Context, buildTypeID, branch, direction string) (*buildChain, error) {
+	locator := fmt.Sprintf("%s,count:%d", chainLocator(buildTypeID, direction), maxChainSize)
+	fields := "buildType(id,name,snapshot-dependencies(snapshot-dependency(source-buildType(id))))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s build configurations: %w", direction, err)
+	}
+
+	var response struct {
+		BuildType []struct {
+			ID                   string `json:"id"`
+			Name                 string `json:"name"`
+			SnapshotDependencies struct {
+				SnapshotDependency []struct {
+					SourceBuildType BuildType `json:"source-buildType"`
+				} `json:"snapshot-dependency"`
+			} `json:"snapshot-dependencies"`
+		} `json:"buildType"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse build configurations response: %w", err)
+	}
+
+	chain := &buildChain{root: buildTypeID, nodes: make(map[string]*chainNode, len(response.BuildType))}
+	for _, buildType := range response.BuildType {
+		latest, err := c.latestChainBuild(ctx, buildType.ID, branch)
+		if err != nil {
+			return nil, err
+		}
+
+		node := &chainNode{ref: buildType.ID, label: fmt.Sprintf("%s (%s): ", buildType.Name, buildType.ID)}
+		if latest != nil {
+			node.label += "latest " + describeChainBuild(*latest, false)
+			node.state = latest.State
+			node.status = latest.Status
+		} else {
+			node.label += "no builds"
+		}
+		for _, dep := range buildType.SnapshotDependencies.SnapshotDependency {
+			node.deps = append(node.deps, dep.SourceBuildType.ID)
+		}
+		chain.nodes[buildType.ID] = node
+	}
+	return chain, nil
+}
+
+// latestChainBuild returns the latest queued, running or finished build of a configuration on a
+// branch (the default branch when empty), or nil when there is none
+func (c *Client) latestChainBuild(ctx context.Context, buildTypeID, branch string) (*Build, error) {
+	locator := fmt.Sprintf("buildType:(id:%s),state:any,count:1", buildTypeID)
+	if branch != "" {
+		locator += fmt.Sprintf(",branch:(name:%s)", branch)
+	}
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=build(id,number,status,state,branchName,buildTypeId)", url.QueryEscape(locator)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest build of %s: %w", buildTypeID, err)
+	}
+
+	var response struct {
+		Build []Build `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse builds response: %w", err)
+	}
+	if len(response.Build) == 0 {
+		return nil, nil
+	}
+	return &response.Build[0], nil
+}
+
+// describeChainBuild renders a build of a chain, with its build configuration when withType is set
+func describeChainBuild(build Build, withType bool) string {
+	label := fmt.Sprintf("#%s (ID: %d)", build.Number, build.ID)
+	if withType {
+		name := build.BuildType.Name
+		if name == "" {
+			name = build.BuildTypeID
+		}
+		label += fmt.Sprintf(" %s (%s)", name, build.BuildTypeID)
+	}
+	if build.BranchName != "" {
+		label += ", branch " + build.BranchName
+	}
+	label += ": " + build.State
+	if build.Status != "" && build.State != "queued" {
+		label += ", " + build.Status
+	}
+	return label
+}
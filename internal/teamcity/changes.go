@@ -0,0 +1,545 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// Change represents a TeamCity VCS change
+type Change struct {
+	ID       int    `json:"id"`
+	Version  string `json:"version"`
+	Username string `json:"username"`
+	Date     string `json:"date"`
+	Comment  string `json:"comment"`
+	WebURL   string `json:"webUrl,omitempty"`
+}
+
+// maxDownstreamOrigins bounds the builds of a change whose dependent builds get_builds_for_change looks up
+const maxDownstreamOrigins = 20
+
+// maxChangeFiles bounds the changed files get_change lists
+const maxChangeFiles = 100
+
+// maxScannedChanges bounds the changes search_changes pages through to find those in a date range
+const maxScannedChanges = 5000
+
+// vcsRevision matches VCS revisions: commit hashes, Subversion and Perforce change numbers and
+// similar identifiers. Locator syntax such as commas and parentheses is rejected, as it would add
+// dimensions to the locators a revision is embedded in.
+var vcsRevision = regexp.MustCompile(`^[0-9A-Za-z._-]+$`)
+
+// validateRevision checks that a revision can be embedded in a TeamCity locator
+func validateRevision(revision string) error {
+	if !vcsRevision.MatchString(revision) {
+		return fmt.Errorf("invalid revision %q: only letters, digits, '.', '_' and '-' are allowed", revision)
+	}
+	return nil
+}
+
+// GetBuildsForChange lists every build, across all configurations and branches, that included a change,
+// and which deployment configurations successfully built it
+func (c *Client) GetBuildsForChange(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ChangeID          string `json:"changeId"`
+		Revision          string `json:"revision"`
+		Count             int    `json:"count"`
+		IncludeDownstream bool   `json:"includeDownstream"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ChangeID == "" && req.Revision == "" {
+		return "", fmt.Errorf("changeId or revision is required")
+	}
+	if req.ChangeID == "" {
+		if err := validateRevision(req.Revision); err != nil {
+			return "", err
+		}
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 100
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_builds_for_change", "success", time.Since(start).Seconds())
+	}()
+
+	changeLocator := fmt.Sprintf("version:%s", req.Revision)
+	if req.ChangeID != "" {
+		changeLocator = fmt.Sprintf("id:%s", req.ChangeID)
+	}
+
+	// The same revision may be known under several VCS roots, each as a separate change
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/changes?locator=%s&fields=change(id,version,username,date,comment,webUrl)", changeLocator), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get change: %w", err)
+	}
+
+	var changes struct {
+		Change []Change `json:"change"`
+	}
+	if err := json.Unmarshal(respBody, &changes); err != nil {
+		return "", fmt.Errorf("failed to parse changes response: %w", err)
+	}
+
+	var result, buildLocator string
+	if len(changes.Change) > 0 {
+		change := changes.Change[0]
+		result = fmt.Sprintf("Change %d (%s) by %s\n", change.ID, change.Version, change.Username)
+		if comment := strings.TrimSpace(change.Comment); comment != "" {
+			result += fmt.Sprintf("  %s\n", strings.SplitN(comment, "\n", 2)[0])
+		}
+		buildLocator = fmt.Sprintf("change:(%s)", changeLocator)
+	} else if req.ChangeID == "" {
+		// TeamCity may not have collected the revision as a change, e.g. for the first build of a VCS
+		// root; builds that were checked out at the revision are still found by their revisions
+		result = fmt.Sprintf("No change found for revision %s; builds of this revision:\n", req.Revision)
+		buildLocator = fmt.Sprintf("revision:%s", req.Revision)
+	} else {
+		return fmt.Sprintf("No change found matching %s.", changeLocator), nil
+	}
+
+	builds, found, err := c.changeBuilds(ctx, buildLocator, count, req.IncludeDownstream)
+	if err != nil {
+		return "", err
+	}
+	if found == 0 && len(changes.Change) == 0 {
+		return fmt.Sprintf("No change or build found for revision %s.", req.Revision), nil
+	}
+	return result + builds, nil
+}
+
+// FindBuildsByRevision lists the builds, across all configurations and branches, that were checked out
+// at a VCS revision, with their statuses and the deployment configurations that built it
+func (c *Client) FindBuildsByRevision(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Revision string `json:"revision"`
+		Count    int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Revision == "" {
+		return "", fmt.Errorf("revision is required")
+	}
+	if err := validateRevision(req.Revision); err != nil {
+		return "", err
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 100
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("find_build_by_revision", "success", time.Since(start).Seconds())
+	}()
+
+	builds, found, err := c.changeBuilds(ctx, fmt.Sprintf("revision:%s", req.Revision), count, false)
+	if err != nil {
+		return "", err
+	}
+	if found == 0 {
+		return fmt.Sprintf("No builds found for revision %s.", req.Revision), nil
+	}
+	return fmt.Sprintf("Builds of revision %s:\n", req.Revision) + builds, nil
+}
+
+// changeBuilds renders the builds matching a change or revision build locator grouped by build
+// configuration, with the deployment configurations that built it, and returns how many were found.
+// With downstream, the builds depending on them through snapshot dependencies are included too.
+func (c *Client) changeBuilds(ctx context.Context, buildLocator string, count int, downstream bool) (string, int, error) {
+	buildFields := "build(id,number,status,state,branchName,buildTypeId,finishDate,webUrl,buildType(name,type))"
+
+	locator := fmt.Sprintf("%s,branch:default:any,state:any,count:%d", buildLocator, count)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(buildFields)), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get builds for change: %w", err)
+	}
+
+	var response struct {
+		Build []Build `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", 0, fmt.Errorf("failed to parse builds response: %w", err)
+	}
+
+	if len(response.Build) == 0 {
+		return "\nNo builds included this change.\n", 0, nil
+	}
+
+	// A deployment often has no VCS root of its own and only gets the change through its
+	// dependencies, so the builds depending on the matching ones are looked up separately
+	direct := len(response.Build)
+	upstreamOf := make(map[int]Build)
+	if downstream {
+		seen := make(map[int]bool, len(response.Build))
+		for _, build := range response.Build {
+			seen[build.ID] = true
+		}
+		origins := response.Build
+		if len(origins) > maxDownstreamOrigins {
+			origins = origins[:maxDownstreamOrigins]
+		}
+		for _, origin := range origins {
+			dependents, err := c.findBuilds(ctx, fmt.Sprintf("snapshotDependency:(from:(id:%d),recursive:true,includeInitial:false),defaultFilter:false,count:%d",
+				origin.ID, count), buildFields)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to get builds depending on build %d: %w", origin.ID, err)
+			}
+			for _, dependent := range dependents {
+				if !seen[dependent.ID] {
+					seen[dependent.ID] = true
+					upstreamOf[dependent.ID] = origin
+					response.Build = append(response.Build, dependent)
+				}
+			}
+		}
+	}
+
+	// Group builds by configuration so the impact on each pipeline stage is visible at a glance
+	byType := make(map[string][]Build)
+	statusCounts := make(map[string]int)
+	var deployed []string
+	for _, build := range response.Build {
+		byType[build.BuildTypeID] = append(byType[build.BuildTypeID], build)
+		if build.BuildType.Type == "deployment" && build.State == "finished" && build.Status == "SUCCESS" {
+			deployed = append(deployed, fmt.Sprintf("%s (#%s, ID: %d)", build.BuildType.Name, build.Number, build.ID))
+		}
+		status := build.Status
+		if build.State != "finished" {
+			status = strings.ToUpper(build.State)
+		}
+		statusCounts[status]++
+	}
+
+	typeIDs := make([]string, 0, len(byType))
+	for id := range byType {
+		typeIDs = append(typeIDs, id)
+	}
+	sort.Strings(typeIDs)
+
+	statuses := make([]string, 0, len(statusCounts))
+	for status, n := range statusCounts {
+		statuses = append(statuses, fmt.Sprintf("%s: %d", status, n))
+	}
+	sort.Strings(statuses)
+
+	result := fmt.Sprintf("\nFound %d builds in %d configurations (%s):\n", len(response.Build), len(typeIDs), strings.Join(statuses, ", "))
+	if len(upstreamOf) > 0 {
+		result = fmt.Sprintf("\nFound %d builds, %d of them downstream, in %d configurations (%s):\n", len(response.Build), len(upstreamOf), len(typeIDs), strings.Join(statuses, ", "))
+	}
+	for _, id := range typeIDs {
+		builds := byType[id]
+		result += fmt.Sprintf("\n%s (%s)", builds[0].BuildType.Name, id)
+		if builds[0].BuildType.Type == "deployment" {
+			result += " [deployment]"
+		}
+		result += "\n"
+		for _, build := range builds {
+			result += fmt.Sprintf("  - #%s (ID: %d): ", build.Number, build.ID)
+			if build.State == "finished" {
+				result += build.Status
+			} else {
+				result += build.State
+			}
+			if build.BranchName != "" {
+				result += fmt.Sprintf(" [%s]", build.BranchName)
+			}
+			if build.FinishDate != "" {
+				result += fmt.Sprintf(", finished %s", c.formatTeamCityDate(build.FinishDate))
+			}
+			if upstream, ok := upstreamOf[build.ID]; ok {
+				result += fmt.Sprintf(", downstream of %s #%s (ID: %d)", upstream.BuildType.Name, upstream.Number, upstream.ID)
+			}
+			result += "\n"
+		}
+	}
+
+	if len(deployed) > 0 {
+		result += fmt.Sprintf("\nDeployed by: %s\n", strings.Join(deployed, ", "))
+	} else {
+		result += "\nNo successful build of a deployment configuration included it yet.\n"
+	}
+
+	if direct >= count {
+		result += fmt.Sprintf("\nShowing the first %d builds; increase count to see more.\n", count)
+	}
+
+	return result, len(response.Build), nil
+}
+
+// SearchChanges searches VCS changes by committer, project, build configuration, VCS root and date
+// range, newest first
+func (c *Client) SearchChanges(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Username    string `json:"username"`
+		ProjectID   string `json:"projectId"`
+		BuildTypeID string `json:"buildTypeId"`
+		VCSRootID   string `json:"vcsRootId"`
+		SinceDate   string `json:"sinceDate"`
+		UntilDate   string `json:"untilDate"`
+		Count       int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Count == 0 {
+		req.Count = 100
+	}
+	if req.Count < 0 || req.Count > 1000 {
+		return "", fmt.Errorf("count must be between 1 and 1000")
+	}
+	var since, until time.Time
+	if req.SinceDate != "" {
+		date, err := parseTeamCityDate(req.SinceDate)
+		if err != nil {
+			return "", fmt.Errorf("invalid sinceDate: expected YYYYMMDDTHHMMSS+HHMM")
+		}
+		since = date
+	}
+	if req.UntilDate != "" {
+		date, err := parseTeamCityDate(req.UntilDate)
+		if err != nil {
+			return "", fmt.Errorf("invalid untilDate: expected YYYYMMDDTHHMMSS+HHMM")
+		}
+		until = date
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("search_changes", "success", time.Since(start).Seconds())
+	}()
+
+	var filters []string
+	if req.Username != "" {
+		filters = append(filters, fmt.Sprintf("username:%s", req.Username))
+	}
+	if req.ProjectID != "" {
+		filters = append(filters, fmt.Sprintf("project:(id:%s)", req.ProjectID))
+	}
+	if req.BuildTypeID != "" {
+		filters = append(filters, fmt.Sprintf("buildType:(id:%s)", req.BuildTypeID))
+	}
+	if req.VCSRootID != "" {
+		filters = append(filters, fmt.Sprintf("vcsRoot:(id:%s)", req.VCSRootID))
+	}
+
+	type foundChange struct {
+		Change
+		VCSRootInstance struct {
+			Name string `json:"name"`
+		} `json:"vcsRootInstance"`
+	}
+
+	// Changes come newest first and the change locator has no date dimension, so the date range
+	// is applied while paging
+	const pageSize = 100
+	var changes []foundChange
+	truncated, exhausted := false, false
+	scanned := 0
+	for !truncated && !exhausted && scanned < maxScannedChanges {
+		locator := strings.Join(append(filters, fmt.Sprintf("start:%d", scanned), fmt.Sprintf("count:%d", pageSize)), ",")
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/changes?locator=%s&fields=%s", url.QueryEscape(locator),
+			url.QueryEscape("change(id,version,username,date,comment,webUrl,vcsRootInstance(name))")), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to search changes: %w", err)
+		}
+
+		var response struct {
+			Change []foundChange `json:"change"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return "", fmt.Errorf("failed to parse changes response: %w", err)
+		}
+		scanned += len(response.Change)
+		exhausted = len(response.Change) < pageSize
+
+		for _, change := range response.Change {
+			date, err := parseTeamCityDate(change.Date)
+			if err == nil && !until.IsZero() && date.After(until) {
+				continue
+			}
+			if err == nil && !since.IsZero() && date.Before(since) {
+				exhausted = true
+				break
+			}
+			if len(changes) == req.Count {
+				truncated = true
+				break
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) == 0 {
+		if !exhausted {
+			return fmt.Sprintf("No changes found in the latest %d changes.", scanned), nil
+		}
+		return "No changes found.", nil
+	}
+
+	result := fmt.Sprintf("Found %d changes:\n", len(changes))
+	for _, change := range changes {
+		result += fmt.Sprintf("\nChange %d (%s) by %s", change.ID, change.Version, change.Username)
+		if change.Date != "" {
+			result += ", " + c.formatTeamCityDate(change.Date)
+		}
+		result += "\n"
+		if comment := strings.TrimSpace(change.Comment); comment != "" {
+			result += fmt.Sprintf("  %s\n", strings.SplitN(comment, "\n", 2)[0])
+		}
+		if change.VCSRootInstance.Name != "" {
+			result += fmt.Sprintf("  VCS root: %s\n", change.VCSRootInstance.Name)
+		}
+		if change.WebURL != "" {
+			result += fmt.Sprintf("  URL: %s\n", change.WebURL)
+		}
+	}
+
+	switch {
+	case truncated:
+		result += fmt.Sprintf("\nShowing the first %d changes; narrow the search or increase count to see more.\n", req.Count)
+	case !exhausted:
+		result += fmt.Sprintf("\nOnly the latest %d changes were searched; narrow the search to find older ones.\n", scanned)
+	}
+	return result, nil
+}
+
+// GetChange returns the details of a VCS change: its author, full commit message, changed files
+// and the builds that included it
+func (c *Client) GetChange(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ChangeID string `json:"changeId"`
+		Revision string `json:"revision"`
+		Count    int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ChangeID == "" && req.Revision == "" {
+		return "", fmt.Errorf("changeId or revision is required")
+	}
+	if req.ChangeID == "" {
+		if err := validateRevision(req.Revision); err != nil {
+			return "", err
+		}
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 100
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_change", "success", time.Since(start).Seconds())
+	}()
+
+	changeLocator := fmt.Sprintf("version:%s", req.Revision)
+	if req.ChangeID != "" {
+		changeLocator = fmt.Sprintf("id:%s", req.ChangeID)
+	}
+
+	fields := "change(id,version,username,date,comment,webUrl,user(username,name),vcsRootInstance(name)," +
+		"files(count,file(relative-file,changeType)))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/changes?locator=%s&fields=%s", url.QueryEscape(changeLocator), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get change: %w", err)
+	}
+
+	var changes struct {
+		Change []struct {
+			Change
+			User            *userRef `json:"user"`
+			VCSRootInstance struct {
+				Name string `json:"name"`
+			} `json:"vcsRootInstance"`
+			Files struct {
+				Count int `json:"count"`
+				File  []struct {
+					RelativeFile string `json:"relative-file"`
+					ChangeType   string `json:"changeType"`
+				} `json:"file"`
+			} `json:"files"`
+		} `json:"change"`
+	}
+	if err := json.Unmarshal(respBody, &changes); err != nil {
+		return "", fmt.Errorf("failed to parse changes response: %w", err)
+	}
+	if len(changes.Change) == 0 {
+		return fmt.Sprintf("No change found matching %s.", changeLocator), nil
+	}
+
+	// The same revision may be known under several VCS roots; the first is described
+	change := changes.Change[0]
+	result := fmt.Sprintf("Change %d (%s)\n", change.ID, change.Version)
+	author := change.Username
+	if change.User != nil && change.User.Username != "" {
+		author = fmt.Sprintf("%s (TeamCity user %s)", change.Username, change.User.Username)
+	}
+	result += fmt.Sprintf("  Author: %s\n", author)
+	if change.Date != "" {
+		result += fmt.Sprintf("  Date: %s\n", c.formatTeamCityDate(change.Date))
+	}
+	if change.VCSRootInstance.Name != "" {
+		result += fmt.Sprintf("  VCS root: %s\n", change.VCSRootInstance.Name)
+	}
+	if change.WebURL != "" {
+		result += fmt.Sprintf("  URL: %s\n", change.WebURL)
+	}
+	if len(changes.Change) > 1 {
+		others := make([]string, 0, len(changes.Change)-1)
+		for _, other := range changes.Change[1:] {
+			others = append(others, strconv.Itoa(other.ID))
+		}
+		result += fmt.Sprintf("  Also collected in other VCS roots as changes %s\n", strings.Join(others, ", "))
+	}
+	if comment := strings.TrimSpace(change.Comment); comment != "" {
+		result += "\nCommit message:\n"
+		for _, line := range strings.Split(comment, "\n") {
+			result += "  " + line + "\n"
+		}
+	}
+
+	files := change.Files.File
+	total := max(change.Files.Count, len(files))
+	result += fmt.Sprintf("\nChanged files (%d):\n", total)
+	for i, file := range files {
+		if i == maxChangeFiles {
+			break
+		}
+		result += fmt.Sprintf("  %s %s\n", strings.ToUpper(file.ChangeType), file.RelativeFile)
+	}
+	if total > maxChangeFiles {
+		result += fmt.Sprintf("  ... and %d more\n", total-maxChangeFiles)
+	}
+
+	builds, _, err := c.changeBuilds(ctx, fmt.Sprintf("change:(id:%d)", change.ID), count, false)
+	if err != nil {
+		return "", err
+	}
+	return result + builds, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/logging"
 	"github.com/itcaat/teamcity-mcp/internal/metrics"
 )
 
@@ -24,6 +26,8 @@ type Client struct {
 	baseURL    string
 	logger     *zap.SugaredLogger
 	cfg        config.TeamCityConfig
+
+	maxInlineSize int64
 }
 
 // Project represents a TeamCity project
@@ -41,6 +45,8 @@ type BuildType struct {
 	Description string  `json:"description"`
 	ProjectID   string  `json:"projectId"`
 	Project     Project `json:"project"`
+	// Type is regular, composite or deployment; only returned when requested
+	Type string `json:"type,omitempty"`
 }
 
 // Build represents a TeamCity build
@@ -55,39 +61,82 @@ type Build struct {
 	FinishDate  string    `json:"finishDate"`
 	QueuedDate  string    `json:"queuedDate"`
 	BuildType   BuildType `json:"buildType"`
+	Composite   bool      `json:"composite,omitempty"`
+	WebURL      string    `json:"webUrl,omitempty"`
 }
 
 // Agent represents a TeamCity build agent
 type Agent struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	Connected bool   `json:"connected"`
-	Enabled   bool   `json:"enabled"`
-	WebURL    string `json:"webUrl"`
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Connected  bool       `json:"connected"`
+	Enabled    bool       `json:"enabled"`
+	Authorized bool       `json:"authorized"`
+	WebURL     string     `json:"webUrl"`
+	Pool       *AgentPool `json:"pool,omitempty"`
+	Build      *Build     `json:"build,omitempty"`
+}
+
+// AgentPool represents a TeamCity agent pool
+type AgentPool struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 // Parameter represents a TeamCity build configuration parameter
 type Parameter struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
-	Type  string `json:"type,omitempty"`
+	Name      string         `json:"name"`
+	Value     string         `json:"value"`
+	Type      *ParameterType `json:"type,omitempty"`
+	Inherited bool           `json:"inherited,omitempty"`
+
+	// Origin and Overrides describe where the value comes from; they are filled in by the client
+	Origin    string `json:"origin,omitempty"`
+	Overrides string `json:"overrides,omitempty"`
+}
+
+// Properties is a set of TeamCity name/value properties. TeamCity serializes them as
+// {"property":[{"name":...,"value":...}]}; a plain JSON object is accepted as well.
+type Properties map[string]string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	var wrapped struct {
+		Property []Parameter `json:"property"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Property != nil {
+		*p = make(Properties, len(wrapped.Property))
+		for _, prop := range wrapped.Property {
+			(*p)[prop.Name] = prop.Value
+		}
+		return nil
+	}
+
+	var plain map[string]string
+	if err := json.Unmarshal(data, &plain); err != nil {
+		// Empty property lists come back as {"count":0}
+		*p = Properties{}
+		return nil
+	}
+	*p = plain
+	return nil
 }
 
 // BuildStep represents a TeamCity build step
 type BuildStep struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Type       string            `json:"type"`
-	Disabled   bool              `json:"disabled"`
-	Properties map[string]string `json:"properties,omitempty"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Disabled   bool       `json:"disabled"`
+	Properties Properties `json:"properties,omitempty"`
 }
 
 // VCSRoot represents a TeamCity VCS root
 type VCSRoot struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	VcsName    string            `json:"vcsName"`
-	Properties map[string]string `json:"properties,omitempty"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	VcsName    string     `json:"vcsName"`
+	Properties Properties `json:"properties,omitempty"`
 }
 
 // DetailedBuildType represents a TeamCity build configuration with detailed information
@@ -110,6 +159,12 @@ type TestOccurrence struct {
 	Details  string `json:"details,omitempty"`
 	Href     string `json:"href,omitempty"`
 	Muted    bool   `json:"muted,omitempty"`
+	Ignored  bool   `json:"ignored,omitempty"`
+	// NewFailure is set for tests that failed in this build but not in the previous one
+	NewFailure  bool `json:"newFailure,omitempty"`
+	FirstFailed *struct {
+		Build *Build `json:"build"`
+	} `json:"firstFailed,omitempty"`
 }
 
 // NewClient creates a new TeamCity client
@@ -119,18 +174,46 @@ func NewClient(cfg config.TeamCityConfig, logger *zap.SugaredLogger) (*Client, e
 		return nil, fmt.Errorf("invalid timeout: %w", err)
 	}
 
+	maxInlineSize := int64(defaultMaxInlineSize)
+	if cfg.ArtifactMaxInlineSize != "" {
+		maxInlineSize, err = strconv.ParseInt(cfg.ArtifactMaxInlineSize, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact max inline size: %w", err)
+		}
+	}
+
+	maxConcurrent := 0
+	if cfg.MaxConcurrentRequests != "" {
+		maxConcurrent, err = strconv.Atoi(cfg.MaxConcurrentRequests)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max concurrent requests: %w", err)
+		}
+	}
+
 	httpClient := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: newLimitedTransport(http.DefaultTransport, maxConcurrent),
 	}
 
 	return &Client{
-		httpClient: httpClient,
-		baseURL:    cfg.URL,
-		logger:     logger,
-		cfg:        cfg,
+		httpClient:    httpClient,
+		baseURL:       cfg.URL,
+		logger:        logger,
+		cfg:           cfg,
+		maxInlineSize: maxInlineSize,
 	}, nil
 }
 
+// log returns the logger for a request: the request-scoped logger of the MCP client if there is one
+func (c *Client) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, c.logger)
+}
+
+// logResponse logs a TeamCity API call at debug level
+func (c *Client) logResponse(ctx context.Context, method, endpoint string, status int, start time.Time) {
+	c.log(ctx).Debugw("TeamCity request", "method", method, "endpoint", endpoint, "status", status, "duration", time.Since(start))
+}
+
 // makeRequest makes an authenticated HTTP request to TeamCity
 func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
 	url := c.baseURL + "/app/rest" + endpoint
@@ -146,21 +229,21 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body
 	}
 
 	// Set authentication
-	if c.cfg.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
-
-	}
+	c.authorize(ctx, req)
 
 	req.Header.Set("Accept", "application/json")
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.log(ctx).Debugw("TeamCity request failed", "method", method, "endpoint", endpoint, "error", err)
 		return nil, fmt.Errorf("making request: %w", err)
 	}
 	defer resp.Body.Close()
+	c.logResponse(ctx, method, endpoint, resp.StatusCode, start)
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -174,39 +257,161 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body
 	return respBody, nil
 }
 
-// GetResource gets a resource by URI
+// makeRawRequest makes an authenticated HTTP request to a non-REST TeamCity endpoint
+// (e.g. /uploadDiffChanges.html) and returns the raw response body
+func (c *Client) makeRawRequest(ctx context.Context, method, endpoint string, body []byte, contentType string) ([]byte, error) {
+	url := c.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.authorize(ctx, req)
+	if body != nil && contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.log(ctx).Debugw("TeamCity request failed", "method", method, "endpoint", endpoint, "error", err)
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+	c.logResponse(ctx, method, endpoint, resp.StatusCode, start)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
+
+// makeStreamRequest makes an authenticated GET request to the REST API and returns the
+// response for streaming; the caller must close the response body
+func (c *Client) makeStreamRequest(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/app/rest"+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.authorize(ctx, req)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.log(ctx).Debugw("TeamCity request failed", "method", "GET", "endpoint", endpoint, "error", err)
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	c.logResponse(ctx, "GET", endpoint, resp.StatusCode, start)
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
+
+// GetResource reads a single TeamCity entity by resource URI, e.g. teamcity://builds/123
 func (c *Client) GetResource(ctx context.Context, uri string) (interface{}, error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("get_resource", "success", time.Since(start).Seconds())
 	}()
 
-	// Parse URI and call appropriate method
-	// This is a simplified implementation
+	path, ok := strings.CutPrefix(uri, "teamcity://")
+	if !ok || strings.ContainsAny(path, "?#") {
+		return nil, fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("unsupported resource URI: %s", uri)
+		}
+	}
+
+	var endpoint string
+	switch {
+	case len(parts) == 2 && parts[0] == "projects":
+		endpoint = fmt.Sprintf("/projects/id:%s", url.PathEscape(parts[1]))
+	case len(parts) == 3 && parts[0] == "projects" && parts[2] == "buildTypes":
+		endpoint = fmt.Sprintf("/projects/id:%s/buildTypes", url.PathEscape(parts[1]))
+	case len(parts) == 2 && parts[0] == "buildTypes":
+		endpoint = fmt.Sprintf("/buildTypes/id:%s", url.PathEscape(parts[1]))
+	case len(parts) == 2 && parts[0] == "builds":
+		endpoint = fmt.Sprintf("/builds/id:%s", url.PathEscape(parts[1]))
+	case len(parts) == 2 && parts[0] == "agents":
+		endpoint = fmt.Sprintf("/agents/id:%s", url.PathEscape(parts[1]))
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+
 	return map[string]interface{}{
-		"uri":     uri,
-		"type":    "resource",
-		"content": "Resource content for " + uri,
+		"uri":      uri,
+		"mimeType": "application/json",
+		"text":     string(respBody),
 	}, nil
 }
 
-// ListProjects lists all projects
-func (c *Client) ListProjects(ctx context.Context) ([]interface{}, error) {
+// GetServerVersion returns the version reported by the TeamCity server, e.g. "2024.12 (build 174331)"
+func (c *Client) GetServerVersion(ctx context.Context) (string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/server?fields=version", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server info: %w", err)
+	}
+
+	var server struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(respBody, &server); err != nil {
+		return "", fmt.Errorf("failed to parse server info: %w", err)
+	}
+
+	return server.Version, nil
+}
+
+// ListProjects lists a page of projects; more reports whether projects after the page exist
+func (c *Client) ListProjects(ctx context.Context, page Page) (resources []interface{}, more bool, err error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("list_projects", "success", time.Since(start).Seconds())
 	}()
 
-	respBody, err := c.makeRequest(ctx, "GET", "/projects", nil)
+	endpoint := "/projects"
+	if locator := page.locator(); locator != "" {
+		endpoint += "?locator=" + locator
+	}
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get projects: %w", err)
+		return nil, false, fmt.Errorf("failed to get projects: %w", err)
 	}
 
 	var response struct {
+		pagedResponse
 		Project []Project `json:"project"`
 	}
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse projects response: %w", err)
+		return nil, false, fmt.Errorf("failed to parse projects response: %w", err)
 	}
 
 	result := make([]interface{}, len(response.Project))
@@ -219,26 +424,32 @@ func (c *Client) ListProjects(ctx context.Context) ([]interface{}, error) {
 		}
 	}
 
-	return result, nil
+	return result, response.NextHref != "", nil
 }
 
-// ListBuildTypes lists all build configurations
-func (c *Client) ListBuildTypes(ctx context.Context) ([]interface{}, error) {
+// ListBuildTypes lists a page of build configurations; more reports whether build
+// configurations after the page exist
+func (c *Client) ListBuildTypes(ctx context.Context, page Page) (resources []interface{}, more bool, err error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("list_build_types", "success", time.Since(start).Seconds())
 	}()
 
-	respBody, err := c.makeRequest(ctx, "GET", "/buildTypes", nil)
+	endpoint := "/buildTypes"
+	if locator := page.locator(); locator != "" {
+		endpoint += "?locator=" + locator
+	}
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get build types: %w", err)
+		return nil, false, fmt.Errorf("failed to get build types: %w", err)
 	}
 
 	var response struct {
+		pagedResponse
 		BuildType []BuildType `json:"buildType"`
 	}
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse build types response: %w", err)
+		return nil, false, fmt.Errorf("failed to parse build types response: %w", err)
 	}
 
 	result := make([]interface{}, len(response.BuildType))
@@ -251,26 +462,65 @@ func (c *Client) ListBuildTypes(ctx context.Context) ([]interface{}, error) {
 		}
 	}
 
-	return result, nil
+	return result, response.NextHref != "", nil
 }
 
-// ListBuilds lists recent builds
-func (c *Client) ListBuilds(ctx context.Context) ([]interface{}, error) {
+// buildsResourceWindow returns the number of recent builds listed by the builds resource
+func (c *Client) buildsResourceWindow() int {
+	if n, err := strconv.Atoi(c.cfg.BuildsResourceCount); err == nil && n > 0 {
+		return n
+	}
+	return 100
+}
+
+// buildsResourceLocator returns the locator of the builds listed by the builds resource,
+// selecting count builds from start within the configured window.
+// Without a branch dimension TeamCity only returns builds from the default branch.
+func (c *Client) buildsResourceLocator(start, count int) string {
+	locator := fmt.Sprintf("count:%d", count)
+	if start > 0 {
+		locator = fmt.Sprintf("start:%d,%s", start, locator)
+	}
+	if c.cfg.BuildsResourceState != "" {
+		locator += ",state:" + c.cfg.BuildsResourceState
+	}
+	if c.cfg.BuildsResourceBranch != "" {
+		locator += ",branch:" + c.cfg.BuildsResourceBranch
+	}
+	if c.cfg.BuildsResourceProject != "" {
+		locator += fmt.Sprintf(",affectedProject:(id:%s)", c.cfg.BuildsResourceProject)
+	}
+	return locator
+}
+
+// ListBuilds lists a page of the recent builds within the configured window; more reports
+// whether builds after the page exist within the window
+func (c *Client) ListBuilds(ctx context.Context, page Page) (resources []interface{}, more bool, err error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("list_builds", "success", time.Since(start).Seconds())
 	}()
 
-	respBody, err := c.makeRequest(ctx, "GET", "/builds?locator=count:100", nil)
+	window := c.buildsResourceWindow()
+	count := window - page.Start
+	if page.Count > 0 && page.Count < count {
+		count = page.Count
+	}
+	if count <= 0 {
+		return []interface{}{}, false, nil
+	}
+
+	respBody, err := c.makeRequest(ctx, "GET", "/builds?locator="+url.QueryEscape(c.buildsResourceLocator(page.Start, count)), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get builds: %w", err)
+		return nil, false, fmt.Errorf("failed to get builds: %w", err)
 	}
 
 	var response struct {
+		pagedResponse
 		Build []Build `json:"build"`
 	}
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse builds response: %w", err)
+		return nil, false, fmt.Errorf("failed to parse builds response: %w", err)
 	}
 
 	result := make([]interface{}, len(response.Build))
@@ -283,54 +533,65 @@ func (c *Client) ListBuilds(ctx context.Context) ([]interface{}, error) {
 		}
 	}
 
-	return result, nil
+	return result, response.NextHref != "" && page.Start+len(result) < window, nil
 }
 
-// ListAgents lists all build agents
-func (c *Client) ListAgents(ctx context.Context) ([]interface{}, error) {
+// ListAgents lists a page of build agents matching the filter, including their pool and
+// running build; more reports whether agents after the page exist
+func (c *Client) ListAgents(ctx context.Context, filter AgentFilter, page Page) (resources []interface{}, more bool, err error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("list_agents", "success", time.Since(start).Seconds())
 	}()
 
-	respBody, err := c.makeRequest(ctx, "GET", "/agents", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get agents: %w", err)
-	}
-
-	var response struct {
-		Agent []Agent `json:"agent"`
+	locator := filter.locator()
+	if pageLocator := page.locator(); pageLocator != "" {
+		locator += "," + pageLocator
 	}
-	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse agents response: %w", err)
+	agents, more, err := c.listAgentsPage(ctx, locator)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get agents: %w", err)
 	}
 
-	result := make([]interface{}, len(response.Agent))
-	for i, agent := range response.Agent {
+	result := make([]interface{}, len(agents))
+	for i, agent := range agents {
 		result[i] = map[string]interface{}{
 			"uri":         fmt.Sprintf("teamcity://agents/%d", agent.ID),
 			"name":        agent.Name,
-			"description": fmt.Sprintf("Connected: %t", agent.Connected),
+			"description": describeAgent(agent),
 			"mimeType":    "application/json",
 		}
 	}
 
-	return result, nil
+	return result, more, nil
 }
 
 // TriggerBuild triggers a new build
 func (c *Client) TriggerBuild(ctx context.Context, args json.RawMessage) (string, error) {
 	var req struct {
-		BuildTypeID string            `json:"buildTypeId"`
-		BranchName  string            `json:"branchName,omitempty"`
-		Properties  map[string]string `json:"properties,omitempty"`
-		Comment     string            `json:"comment,omitempty"`
+		BuildTypeID         string            `json:"buildTypeId"`
+		BranchName          string            `json:"branchName,omitempty"`
+		Properties          map[string]string `json:"properties,omitempty"`
+		Comment             string            `json:"comment,omitempty"`
+		AgentID             string            `json:"agentId,omitempty"`
+		AgentPoolID         string            `json:"agentPoolId,omitempty"`
+		Personal            bool              `json:"personal,omitempty"`
+		CleanSources        bool              `json:"cleanSources,omitempty"`
+		QueueAtTop          bool              `json:"queueAtTop,omitempty"`
+		RebuildDependencies bool              `json:"rebuildDependencies,omitempty"`
+		ChangeID            string            `json:"changeId,omitempty"`
+		Revision            string            `json:"revision,omitempty"`
+		DryRun              bool              `json:"dryRun,omitempty"`
 	}
 
 	if err := json.Unmarshal(args, &req); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
+	if req.ChangeID != "" && req.Revision != "" {
+		return "", fmt.Errorf("changeId and revision cannot be combined")
+	}
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordTeamCityRequest("trigger_build", "success", time.Since(start).Seconds())
@@ -366,11 +627,73 @@ func (c *Client) TriggerBuild(ctx context.Context, args json.RawMessage) (string
 		}
 	}
 
+	// Options are also listed for the dry run
+	var options []string
+	if req.AgentID != "" {
+		agentID, err := strconv.Atoi(req.AgentID)
+		if err != nil {
+			return "", fmt.Errorf("invalid agent ID: %w", err)
+		}
+		buildRequest["agent"] = map[string]int{"id": agentID}
+		options = append(options, fmt.Sprintf("run on agent ID %d", agentID))
+	}
+	if req.AgentPoolID != "" {
+		poolID, err := strconv.Atoi(req.AgentPoolID)
+		if err != nil {
+			return "", fmt.Errorf("invalid agent pool ID: %w", err)
+		}
+		buildRequest["agentPool"] = map[string]int{"id": poolID}
+		options = append(options, fmt.Sprintf("run in agent pool ID %d", poolID))
+	}
+	if req.Personal {
+		buildRequest["personal"] = true
+		options = append(options, "personal build")
+	}
+
+	triggeringOptions := map[string]bool{}
+	if req.CleanSources {
+		triggeringOptions["cleanSources"] = true
+		options = append(options, "clean all files in the checkout directory")
+	}
+	if req.QueueAtTop {
+		triggeringOptions["queueAtTop"] = true
+		options = append(options, "put at the top of the queue")
+	}
+	if req.RebuildDependencies {
+		triggeringOptions["rebuildAllDependencies"] = true
+		options = append(options, "rebuild all dependencies")
+	}
+	if len(triggeringOptions) > 0 {
+		buildRequest["triggeringOptions"] = triggeringOptions
+	}
+
+	// The build runs on the given change instead of the latest one
+	switch {
+	case req.ChangeID != "":
+		changeID, err := strconv.Atoi(req.ChangeID)
+		if err != nil {
+			return "", fmt.Errorf("invalid change ID: %w", err)
+		}
+		buildRequest["lastChanges"] = map[string]interface{}{
+			"change": []map[string]int{{"id": changeID}},
+		}
+		options = append(options, fmt.Sprintf("build change ID %d", changeID))
+	case req.Revision != "":
+		buildRequest["lastChanges"] = map[string]interface{}{
+			"change": []map[string]string{{"locator": fmt.Sprintf("version:%s,buildType:(id:%s)", req.Revision, req.BuildTypeID)}},
+		}
+		options = append(options, "build revision "+req.Revision)
+	}
+
 	reqBody, err := json.Marshal(buildRequest)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal build request: %w", err)
 	}
 
+	if req.DryRun {
+		return c.describeTriggerBuild(ctx, req.BuildTypeID, req.BranchName, req.Comment, req.Properties, options, reqBody)
+	}
+
 	respBody, err := c.makeRequest(ctx, "POST", "/buildQueue", reqBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to trigger build: %w", err)
@@ -389,6 +712,7 @@ func (c *Client) CancelBuild(ctx context.Context, args json.RawMessage) (string,
 	var req struct {
 		BuildID string `json:"buildId"`
 		Comment string `json:"comment,omitempty"`
+		DryRun  bool   `json:"dryRun,omitempty"`
 	}
 
 	if err := json.Unmarshal(args, &req); err != nil {
@@ -426,6 +750,10 @@ func (c *Client) CancelBuild(ctx context.Context, args json.RawMessage) (string,
 		return "", fmt.Errorf("failed to marshal cancel request: %w", err)
 	}
 
+	if req.DryRun {
+		return describeCancelBuild(build, reqBody), nil
+	}
+
 	_, err = c.makeRequest(ctx, "POST", fmt.Sprintf("/builds/id:%d/cancelRequest", buildID), reqBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to cancel build: %w", err)
@@ -550,29 +878,8 @@ func (c *Client) SetBuildTag(ctx context.Context, args json.RawMessage) (string,
 	return fmt.Sprintf("Tags updated for build #%s", build.Number), nil
 }
 
-// DownloadArtifact downloads build artifacts
-func (c *Client) DownloadArtifact(ctx context.Context, args json.RawMessage) (string, error) {
-	var req struct {
-		BuildID      string `json:"buildId"`
-		ArtifactPath string `json:"artifactPath"`
-	}
-
-	if err := json.Unmarshal(args, &req); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
-	}
-
-	start := time.Now()
-	defer func() {
-		metrics.RecordTeamCityRequest("download_artifact", "success", time.Since(start).Seconds())
-	}()
-
-	// This is a simplified implementation
-	// In practice, you would stream the artifact content
-	return fmt.Sprintf("Artifact %s from build %s download initiated", req.ArtifactPath, req.BuildID), nil
-}
-
 // SearchBuilds searches for builds with various filters
-func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string, error) {
+func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (*StructuredResult, error) {
 	var req struct {
 		BuildTypeID string   `json:"buildTypeId"`
 		Status      string   `json:"status"`
@@ -586,11 +893,12 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 		Tags        []string `json:"tags"`
 		Personal    *bool    `json:"personal"`
 		Pinned      *bool    `json:"pinned"`
+		Revision    string   `json:"revision"`
 		Count       int      `json:"count"`
 	}
 
 	if err := json.Unmarshal(args, &req); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
 	start := time.Now()
@@ -612,6 +920,9 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 	}
 	if req.Branch != "" {
 		params = append(params, fmt.Sprintf("branch:%s", req.Branch))
+	} else if req.Revision != "" {
+		// A commit may have been built on any branch, not just the default one
+		params = append(params, "branch:default:any")
 	}
 	if req.Agent != "" {
 		params = append(params, fmt.Sprintf("agent:%s", req.Agent))
@@ -619,6 +930,9 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 	if req.User != "" {
 		params = append(params, fmt.Sprintf("user:%s", req.User))
 	}
+	if req.Revision != "" {
+		params = append(params, fmt.Sprintf("change:(version:%s)", req.Revision))
+	}
 	if req.SinceBuild != "" {
 		params = append(params, fmt.Sprintf("sinceBuild:%s", req.SinceBuild))
 	}
@@ -645,21 +959,43 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 		count = 100
 	}
 
-	// Build endpoint with locator
-	endpoint := "/builds"
-	if len(params) > 0 {
-		locator := fmt.Sprintf("count:%d", count)
-		for _, param := range params {
-			locator += "," + param
-		}
-		endpoint += "?locator=" + locator
-	} else {
-		endpoint += fmt.Sprintf("?locator=count:%d", count)
+	// Build locator
+	locator := fmt.Sprintf("count:%d", count)
+	for _, param := range params {
+		locator += "," + param
 	}
 
-	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	return c.searchBuildsByLocator(ctx, locator)
+}
+
+// SearchBuildsByLocator searches builds with a raw TeamCity build locator
+// (e.g. "buildType:X,status:FAILURE,count:10"), returning up to 100 builds unless a count is given
+func (c *Client) SearchBuildsByLocator(ctx context.Context, locator string) (string, error) {
+	if strings.TrimSpace(locator) == "" {
+		return "", fmt.Errorf("locator is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("search_builds_by_locator", "success", time.Since(start).Seconds())
+	}()
+
+	if !regexp.MustCompile(`(^|,)count:`).MatchString(locator) {
+		locator = "count:100," + locator
+	}
+
+	result, err := c.searchBuildsByLocator(ctx, locator)
 	if err != nil {
-		return "", fmt.Errorf("failed to search builds: %w", err)
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// searchBuildsByLocator fetches builds matching a locator and formats them for search output
+func (c *Client) searchBuildsByLocator(ctx context.Context, locator string) (*StructuredResult, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/builds?locator="+url.QueryEscape(locator), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search builds: %w", err)
 	}
 
 	var response struct {
@@ -667,12 +1003,16 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 		Build []Build `json:"build"`
 	}
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return "", fmt.Errorf("failed to parse builds response: %w", err)
+		return nil, fmt.Errorf("failed to parse builds response: %w", err)
 	}
 
+	data := BuildSearchResult{Count: response.Count, Builds: make([]BuildResult, 0, len(response.Build))}
+
 	// Format response
 	result := fmt.Sprintf("Found %d builds:\n\n", response.Count)
 	for _, build := range response.Build {
+		data.Builds = append(data.Builds, newBuildResult(build))
+
 		result += fmt.Sprintf("Build #%s (ID: %d)\n", build.Number, build.ID)
 		result += fmt.Sprintf("  Status: %s\n", build.Status)
 		result += fmt.Sprintf("  State: %s\n", build.State)
@@ -680,6 +1020,9 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 		if build.BranchName != "" {
 			result += fmt.Sprintf("  Branch: %s\n", build.BranchName)
 		}
+		if build.Composite {
+			result += c.describeComposite(ctx, build.ID, "  ")
+		}
 
 		// Enhanced time information with duration calculation
 		if build.QueuedDate != "" {
@@ -716,7 +1059,7 @@ func (c *Client) SearchBuilds(ctx context.Context, args json.RawMessage) (string
 		result = "No builds found matching the specified criteria."
 	}
 
-	return result, nil
+	return &StructuredResult{Text: result, Data: data}, nil
 }
 
 // formatTeamCityDate formats TeamCity date string to a more readable format
@@ -741,6 +1084,15 @@ func (c *Client) formatTeamCityDate(tcDate string) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
+// parseTeamCityDate parses a TeamCity date (e.g. 20241226T143022+0300)
+func parseTeamCityDate(tcDate string) (time.Time, error) {
+	t, err := time.Parse("20060102T150405-0700", tcDate)
+	if err != nil {
+		return time.Parse("20060102T150405", tcDate)
+	}
+	return t, nil
+}
+
 // calculateDuration calculates duration between two TeamCity date strings
 func (c *Client) calculateDuration(startDate, endDate string) string {
 	if startDate == "" || endDate == "" {
@@ -867,9 +1219,7 @@ func (c *Client) FetchBuildLog(ctx context.Context, args json.RawMessage) (strin
 	}
 
 	// Set authentication
-	if c.cfg.Token != "" {
-		reqObj.Header.Set("Authorization", "Bearer "+c.cfg.Token)
-	}
+	c.authorize(ctx, reqObj)
 
 	resp, err := c.httpClient.Do(reqObj)
 	if err != nil {
@@ -1016,7 +1366,7 @@ func (c *Client) applyBuildLogFilters(lines []string, pattern string, severity s
 }
 
 // SearchBuildConfigurations searches for build configurations with comprehensive filters including parameters, steps, and VCS roots
-func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMessage) (string, error) {
+func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMessage) (*StructuredResult, error) {
 	var req struct {
 		// Basic filters
 		ProjectID string `json:"projectId"`
@@ -1036,7 +1386,7 @@ func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMes
 	}
 
 	if err := json.Unmarshal(args, &req); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
 	start := time.Now()
@@ -1047,7 +1397,7 @@ func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMes
 	// First, get basic build configurations matching basic criteria
 	basicConfigs, err := c.getBasicBuildConfigurations(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get basic configurations: %w", err)
+		return nil, fmt.Errorf("failed to get basic configurations: %w", err)
 	}
 
 	var matchingConfigs []DetailedBuildType
@@ -1065,6 +1415,11 @@ func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMes
 
 			// Apply detailed filters
 			if c.matchesDetailedCriteria(detailed, req) {
+				if req.IncludeDetails {
+					if err := c.annotateParameterOrigins(ctx, detailed); err != nil {
+						c.logger.Warnw("Failed to resolve parameter origins", "id", config.ID, "error", err)
+					}
+				}
 				matchingConfigs = append(matchingConfigs, *detailed)
 			}
 		} else {
@@ -1075,8 +1430,16 @@ func (c *Client) SearchBuildConfigurations(ctx context.Context, args json.RawMes
 		}
 	}
 
+	data := BuildConfigurationSearchResult{
+		Count:               len(matchingConfigs),
+		BuildConfigurations: make([]BuildConfigurationResult, 0, len(matchingConfigs)),
+	}
+	for _, config := range matchingConfigs {
+		data.BuildConfigurations = append(data.BuildConfigurations, newBuildConfigurationResult(config, req.IncludeDetails))
+	}
+
 	// Format response
-	return c.formatDetailedSearchResults(matchingConfigs, req.IncludeDetails), nil
+	return &StructuredResult{Text: c.formatDetailedSearchResults(matchingConfigs, req.IncludeDetails), Data: data}, nil
 }
 
 // getBasicBuildConfigurations gets configurations using basic filters
@@ -1161,7 +1524,7 @@ func (c *Client) getBuildConfigurationDetails(ctx context.Context, buildTypeID s
 	}
 
 	// Get parameters
-	paramResp, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/parameters", buildTypeID), nil)
+	paramResp, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/parameters?fields=%s", url.PathEscape(buildTypeID), parameterFields), nil)
 	if err != nil {
 		c.logger.Warn("Failed to get parameters", "buildTypeId", buildTypeID, "error", err)
 	} else {
@@ -1226,7 +1589,7 @@ func (c *Client) matchesDetailedCriteria(config *DetailedBuildType, req struct {
 		paramMatch := false
 		for _, param := range config.Parameters {
 			nameMatch := req.ParameterName == "" || strings.Contains(strings.ToLower(param.Name), strings.ToLower(req.ParameterName))
-			valueMatch := req.ParameterValue == "" || strings.Contains(strings.ToLower(param.Value), strings.ToLower(req.ParameterValue))
+			valueMatch := req.ParameterValue == "" || strings.Contains(strings.ToLower(param.DisplayValue()), strings.ToLower(req.ParameterValue))
 
 			if nameMatch && valueMatch {
 				paramMatch = true
@@ -1293,7 +1656,18 @@ func (c *Client) formatDetailedSearchResults(configs []DetailedBuildType, includ
 			if len(config.Parameters) > 0 {
 				result += "  Parameters:\n"
 				for _, param := range config.Parameters {
-					result += fmt.Sprintf("    %s = %s\n", param.Name, param.Value)
+					result += fmt.Sprintf("    %s = %s", param.Name, param.DisplayValue())
+					if param.Type != nil {
+						result += fmt.Sprintf(" [%s]", param.Spec())
+					}
+					if param.Origin != "" {
+						result += fmt.Sprintf(" (%s", param.Origin)
+						if param.Overrides != "" {
+							result += fmt.Sprintf(", overrides %s", param.Overrides)
+						}
+						result += ")"
+					}
+					result += "\n"
 				}
 			}
 
@@ -1324,72 +1698,40 @@ func (c *Client) formatDetailedSearchResults(configs []DetailedBuildType, includ
 	return result
 }
 
-// GetTestFailures returns failing tests for a specific build
-func (c *Client) GetTestFailures(ctx context.Context, args json.RawMessage) (string, error) {
-	var req struct {
-		BuildID string `json:"buildId"`
-	}
-	if err := json.Unmarshal(args, &req); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
-	}
-	if req.BuildID == "" {
-		return "", fmt.Errorf("buildId is required")
-	}
-
-	start := time.Now()
-	defer func() {
-		metrics.RecordTeamCityRequest("get_test_failures", "success", time.Since(start).Seconds())
-	}()
+// defaultStacktraceLines is the number of stack trace lines get_test_results keeps per test by default
+const defaultStacktraceLines = 30
 
-	endpoint := fmt.Sprintf("/testOccurrences?locator=build:(id:%s),status:FAILURE", req.BuildID)
-	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to get test failures: %w", err)
-	}
+// testResultStatuses is the order in which get_test_results groups tests
+var testResultStatuses = []string{"FAILURE", "UNKNOWN", "IGNORED", "SUCCESS"}
 
-	var response struct {
-		Count          int `json:"count"`
-		TestOccurrence []struct {
-			Name     string `json:"name"`
-			Status   string `json:"status"`
-			Duration int    `json:"duration"`
-			Message  string `json:"details"`
-		} `json:"testOccurrence"`
-	}
-	if err := json.Unmarshal(respBody, &response); err != nil {
-		return "", fmt.Errorf("failed to parse test failures response: %w", err)
-	}
-
-	if response.Count == 0 {
-		return "No failing tests found for this build.", nil
-	}
-
-	result := fmt.Sprintf("%d failing tests:\n", response.Count)
-	for _, test := range response.TestOccurrence {
-		result += fmt.Sprintf("- %s (duration: %d ms)", test.Name, test.Duration)
-		if test.Message != "" {
-			result += fmt.Sprintf(": %s", test.Message)
-		}
-		result += "\n"
-	}
-	return result, nil
-}
-
-// GetTestResults returns test results for a specific build with optional filtering
-func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (string, error) {
+// GetTestResults returns a page of the test results of a build with optional filtering by status
+func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (*StructuredResult, error) {
 	var req struct {
 		BuildID        string `json:"buildId"`
 		Status         string `json:"status,omitempty"`
 		IncludeDetails bool   `json:"includeDetails,omitempty"`
 		Count          int    `json:"count,omitempty"`
+		Start          int    `json:"start,omitempty"`
+		// MaxStacktraceLines and StacktraceFilter keep details within token limits
+		MaxStacktraceLines *int   `json:"maxStacktraceLines,omitempty"`
+		StacktraceFilter   string `json:"stacktraceFilter,omitempty"`
+		NewFailuresOnly    bool   `json:"newFailuresOnly,omitempty"`
+		CurrentlyFailing   bool   `json:"currentlyFailing,omitempty"`
 	}
 
 	if err := json.Unmarshal(args, &req); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
+		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
 	if req.BuildID == "" {
-		return "", fmt.Errorf("buildId is required")
+		return nil, fmt.Errorf("buildId is required")
+	}
+	if req.Start < 0 {
+		return nil, fmt.Errorf("start must not be negative")
+	}
+	maxStacktraceLines := defaultStacktraceLines
+	if req.MaxStacktraceLines != nil {
+		maxStacktraceLines = *req.MaxStacktraceLines
 	}
 
 	start := time.Now()
@@ -1397,10 +1739,27 @@ func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (stri
 		metrics.RecordTeamCityRequest("get_test_results", "success", time.Since(start).Seconds())
 	}()
 
-	// Build the locator string (similar to GetTestFailures)
 	locator := fmt.Sprintf("build:(id:%s)", req.BuildID)
+	switch status := strings.ToUpper(req.Status); status {
+	case "":
+	case "IGNORED":
+		// Ignored tests have no status of their own in TeamCity
+		locator += ",ignored:true"
+	default:
+		locator += ",status:" + status
+	}
+	// Filters are described in the result so that an empty page is not mistaken for a passing build
+	var filters []string
 	if req.Status != "" {
-		locator += fmt.Sprintf(",status:%s", req.Status)
+		filters = append(filters, "status: "+req.Status)
+	}
+	if req.NewFailuresOnly {
+		locator += ",newFailure:true"
+		filters = append(filters, "new failures only")
+	}
+	if req.CurrentlyFailing {
+		locator += ",currentlyFailing:true"
+		filters = append(filters, "currently failing")
 	}
 
 	// Set default count if not specified
@@ -1408,69 +1767,94 @@ func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (stri
 	if count == 0 {
 		count = 100
 	}
-	locator += fmt.Sprintf(",count:%d", count)
-
-	endpoint := fmt.Sprintf("/testOccurrences?locator=%s", locator)
+	locator += fmt.Sprintf(",start:%d,count:%d", req.Start, count)
 
-	// Add fields parameter when details are requested
+	fields := "count,nextHref,testOccurrence(id,name,status,duration,muted,ignored,newFailure,firstFailed(build(id,number))"
 	if req.IncludeDetails {
-		endpoint += "&fields=testOccurrence(id,name,status,duration,muted,details)"
+		fields += ",details"
 	}
+	fields += ")"
 
+	endpoint := fmt.Sprintf("/testOccurrences?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(fields))
 	c.logger.Debug("Fetching test results", "endpoint", endpoint, "buildId", req.BuildID)
 
 	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to get test results: %w", err)
-	}
-
-	c.logger.Debug("Received test results response", "bodyLength", len(respBody))
-
-	// First, try to parse the response to see what structure we have
-	var rawResponse map[string]interface{}
-	if err := json.Unmarshal(respBody, &rawResponse); err != nil {
-		c.logger.Error("Failed to parse test results as map", "error", err, "body", string(respBody))
-		return "", fmt.Errorf("failed to parse test results response: %w", err)
+		return nil, fmt.Errorf("failed to get test results: %w", err)
 	}
 
-	c.logger.Debug("Raw response keys", "keys", fmt.Sprintf("%v", getKeys(rawResponse)))
-
 	var response struct {
 		Count          int              `json:"count"`
+		NextHref       string           `json:"nextHref"`
 		TestOccurrence []TestOccurrence `json:"testOccurrence"`
 	}
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		c.logger.Error("Failed to parse test results", "error", err, "body", string(respBody))
-		return "", fmt.Errorf("failed to parse test results response: %w", err)
+		return nil, fmt.Errorf("failed to parse test results response: %w", err)
 	}
 
-	c.logger.Debug("Parsed test results", "count", response.Count, "occurrences", len(response.TestOccurrence))
+	data := TestResults{
+		BuildID: req.BuildID,
+		Count:   len(response.TestOccurrence),
+		Start:   req.Start,
+		HasMore: response.NextHref != "",
+		Tests:   make([]TestResult, 0, len(response.TestOccurrence)),
+	}
+	for i, test := range response.TestOccurrence {
+		if test.Details != "" {
+			response.TestOccurrence[i].Details = trimStacktrace(test.Details, req.StacktraceFilter, maxStacktraceLines)
+			test = response.TestOccurrence[i]
+		}
+		data.Tests = append(data.Tests, TestResult{
+			ID:         test.ID,
+			Name:       test.Name,
+			Status:     test.Status,
+			Duration:   test.Duration,
+			Muted:      test.Muted,
+			Ignored:    test.Ignored,
+			NewFailure: test.NewFailure,
+			Details:    test.Details,
+		})
+	}
 
-	// Check if we actually have no tests (use occurrence length, not count field)
 	if len(response.TestOccurrence) == 0 {
 		statusMsg := "any status"
-		if req.Status != "" {
-			statusMsg = fmt.Sprintf("status: %s", req.Status)
+		if len(filters) > 0 {
+			statusMsg = strings.Join(filters, ", ")
+		}
+		if req.Start > 0 {
+			return &StructuredResult{Text: fmt.Sprintf("No more tests found for build %s with %s after the first %d.", req.BuildID, statusMsg, req.Start), Data: data}, nil
 		}
-		return fmt.Sprintf("No tests found for build %s with %s.", req.BuildID, statusMsg), nil
+		return &StructuredResult{Text: fmt.Sprintf("No tests found for build %s with %s.", req.BuildID, statusMsg), Data: data}, nil
 	}
 
-	// Format the results (use actual test count, not the count field which may be missing)
-	testCount := len(response.TestOccurrence)
-	result := fmt.Sprintf("Found %d test(s) for build %s", testCount, req.BuildID)
-	if req.Status != "" {
-		result += fmt.Sprintf(" (status: %s)", req.Status)
+	result := fmt.Sprintf("Found %d test(s) for build %s", len(response.TestOccurrence), req.BuildID)
+	if len(filters) > 0 {
+		result += fmt.Sprintf(" (%s)", strings.Join(filters, ", "))
+	}
+	if req.Start > 0 || data.HasMore {
+		result += fmt.Sprintf(", showing tests %d-%d", req.Start+1, req.Start+len(response.TestOccurrence))
 	}
 	result += ":\n\n"
 
 	// Group tests by status for better readability
 	statusGroups := make(map[string][]TestOccurrence)
 	for _, test := range response.TestOccurrence {
-		statusGroups[test.Status] = append(statusGroups[test.Status], test)
+		status := test.Status
+		switch {
+		case test.Ignored:
+			status = "IGNORED"
+		case status != "SUCCESS" && status != "FAILURE":
+			status = "UNKNOWN"
+		}
+		statusGroups[status] = append(statusGroups[status], test)
 	}
 
-	// Display tests grouped by status
-	for status, tests := range statusGroups {
+	for _, status := range testResultStatuses {
+		tests := statusGroups[status]
+		if len(tests) == 0 {
+			continue
+		}
 		result += fmt.Sprintf("%s (%d):\n", status, len(tests))
 		for _, test := range tests {
 			result += fmt.Sprintf("  - %s", test.Name)
@@ -1489,6 +1873,15 @@ func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (stri
 				result += " [MUTED]"
 			}
 
+			// Tell new failures from long-standing ones
+			if test.Status == "FAILURE" {
+				if test.NewFailure {
+					result += " [NEW]"
+				} else if test.FirstFailed != nil && test.FirstFailed.Build != nil {
+					result += fmt.Sprintf(" (failing since build #%s, ID: %d)", test.FirstFailed.Build.Number, test.FirstFailed.Build.ID)
+				}
+			}
+
 			result += "\n"
 
 			// Add details if requested and available
@@ -1505,14 +1898,39 @@ func (c *Client) GetTestResults(ctx context.Context, args json.RawMessage) (stri
 		result += "\n"
 	}
 
-	return result, nil
+	if data.HasMore {
+		result += fmt.Sprintf("More tests are available; call again with start=%d to see the next page.\n", req.Start+len(response.TestOccurrence))
+	}
+
+	return &StructuredResult{Text: result, Data: data}, nil
 }
 
-// getKeys returns the keys of a map for debugging
-func getKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// trimStacktrace shortens the details of a test to its failure message and at most maxLines
+// stack trace lines, keeping only lines containing filter when set; maxLines 0 keeps all lines
+func trimStacktrace(details, filter string, maxLines int) string {
+	lines := strings.Split(strings.TrimRight(details, "\n"), "\n")
+
+	// The first line is the failure message
+	kept := []string{lines[0]}
+	omitted := 0
+	for _, line := range lines[1:] {
+		switch {
+		case strings.TrimSpace(line) == "":
+		case filter != "" && !strings.Contains(line, filter):
+			omitted++
+		case maxLines > 0 && len(kept) > maxLines:
+			omitted++
+		default:
+			kept = append(kept, line)
+		}
+	}
+
+	if omitted > 0 {
+		note := fmt.Sprintf("... %d more lines", omitted)
+		if filter != "" {
+			note += fmt.Sprintf(" (showing lines containing %q)", filter)
+		}
+		kept = append(kept, note)
 	}
-	return keys
+	return strings.Join(kept, "\n")
 }
@@ -0,0 +1,225 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// maxListedEntities bounds the entities named per group in a deletion summary
+const maxListedEntities = 20
+
+// entityGroup is a group of entities affected by a deletion
+type entityGroup struct {
+	title string
+	names []string
+}
+
+// DeleteProject deletes a project with its subprojects, build configurations, templates and VCS
+// roots; a dry run only describes what would be deleted
+func (c *Client) DeleteProject(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID string `json:"projectId"`
+		DryRun    bool   `json:"dryRun,omitempty"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" {
+		return "", fmt.Errorf("projectId is required")
+	}
+	if req.ProjectID == rootProjectID {
+		return "", fmt.Errorf("the root project cannot be deleted")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("delete_project", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/projects/id:%s?fields=id,name", url.PathEscape(req.ProjectID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("project not found: %w", err)
+	}
+
+	var project Project
+	if err := json.Unmarshal(respBody, &project); err != nil {
+		return "", fmt.Errorf("failed to parse project: %w", err)
+	}
+
+	affected := fmt.Sprintf("affectedProject:(id:%s)", req.ProjectID)
+	var groups []entityGroup
+	for _, query := range []struct {
+		title, collection, item, locator string
+	}{
+		{"Subprojects", "projects", "project", affected},
+		{"Build configurations", "buildTypes", "buildType", affected},
+		{"Templates", "buildTypes", "buildType", affected + ",templateFlag:true"},
+		{"VCS roots", "vcs-roots", "vcs-root", affected},
+	} {
+		names, err := c.entityNames(ctx, query.collection, query.item, query.locator)
+		if err != nil {
+			return "", fmt.Errorf("failed to list %s: %w", strings.ToLower(query.title), err)
+		}
+		if query.title == "Subprojects" {
+			// affectedProject includes the project itself
+			names = removeString(names, fmt.Sprintf("%s (%s)", project.Name, project.ID))
+		}
+		groups = append(groups, entityGroup{query.title, names})
+	}
+
+	subject := fmt.Sprintf("project %s (%s)", project.Name, project.ID)
+	if req.DryRun {
+		return "Dry run: nothing was deleted.\n\nWould delete " + subject + ", along with:\n" + formatEntityGroups(groups) +
+			fmt.Sprintf("\nRequest: DELETE /app/rest/projects/id:%s", url.PathEscape(req.ProjectID)), nil
+	}
+
+	if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/projects/id:%s", url.PathEscape(req.ProjectID)), nil); err != nil {
+		return "", fmt.Errorf("failed to delete project: %w", err)
+	}
+	return "Deleted " + subject + ", along with:\n" + formatEntityGroups(groups), nil
+}
+
+// DeleteBuildConfiguration deletes a build configuration or template with its build history and
+// lists the build configurations depending on it; a dry run only describes what would be deleted
+func (c *Client) DeleteBuildConfiguration(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId"`
+		DryRun      bool   `json:"dryRun,omitempty"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("delete_build_configuration", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s?fields=id,name,projectId,templateFlag", url.PathEscape(req.BuildTypeID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("build configuration not found: %w", err)
+	}
+
+	var buildType struct {
+		BuildType
+		TemplateFlag bool `json:"templateFlag"`
+	}
+	if err := json.Unmarshal(respBody, &buildType); err != nil {
+		return "", fmt.Errorf("failed to parse build configuration: %w", err)
+	}
+
+	respBody, err = c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=count",
+		url.QueryEscape(fmt.Sprintf("buildType:(id:%s),defaultFilter:false", req.BuildTypeID))), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to count builds: %w", err)
+	}
+	var builds struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(respBody, &builds); err != nil {
+		return "", fmt.Errorf("failed to parse builds response: %w", err)
+	}
+
+	// Configurations depending on this one lose the dependency or, for a template, their settings
+	queries := []struct{ title, locator string }{
+		{"Snapshot dependencies of", fmt.Sprintf("snapshotDependency:(from:(id:%s),recursive:false)", req.BuildTypeID)},
+		{"Artifact dependencies of", fmt.Sprintf("artifactDependency:(from:(id:%s),recursive:false)", req.BuildTypeID)},
+	}
+	kind := "build configuration"
+	if buildType.TemplateFlag {
+		kind = "template"
+		queries = append(queries, struct{ title, locator string }{"Build configurations based on it", fmt.Sprintf("template:(id:%s)", req.BuildTypeID)})
+	}
+
+	var groups []entityGroup
+	for _, query := range queries {
+		names, err := c.entityNames(ctx, "buildTypes", "buildType", query.locator)
+		if err != nil {
+			return "", fmt.Errorf("failed to list dependent build configurations: %w", err)
+		}
+		groups = append(groups, entityGroup{query.title, names})
+	}
+
+	subject := fmt.Sprintf("%s %s (%s) in project %s", kind, buildType.Name, buildType.ID, buildType.ProjectID)
+	summary := fmt.Sprintf("Build history: %d builds\n", builds.Count)
+	summary += "\nAffected build configurations:\n" + formatEntityGroups(groups)
+	if req.DryRun {
+		return "Dry run: nothing was deleted.\n\nWould delete " + subject + "\n" + summary +
+			fmt.Sprintf("\nRequest: DELETE /app/rest/buildTypes/id:%s", url.PathEscape(req.BuildTypeID)), nil
+	}
+
+	if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/buildTypes/id:%s", url.PathEscape(req.BuildTypeID)), nil); err != nil {
+		return "", fmt.Errorf("failed to delete %s: %w", kind, err)
+	}
+	return "Deleted " + subject + "\n" + summary, nil
+}
+
+// entityNames returns "name (id)" of the entities of a collection matching a locator
+func (c *Client) entityNames(ctx context.Context, collection, item, locator string) ([]string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/%s?locator=%s&fields=%s", collection,
+		url.QueryEscape(locator), url.QueryEscape(item+"(id,name)")), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", collection, err)
+	}
+	// An empty collection may come back as {"count":0}
+	var entities []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if raw, ok := response[item]; ok {
+		if err := json.Unmarshal(raw, &entities); err != nil {
+			return nil, fmt.Errorf("failed to parse %s response: %w", collection, err)
+		}
+	}
+
+	names := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		names = append(names, fmt.Sprintf("%s (%s)", entity.Name, entity.ID))
+	}
+	return names, nil
+}
+
+// formatEntityGroups renders entity groups with their counts, naming at most maxListedEntities each
+func formatEntityGroups(groups []entityGroup) string {
+	result := ""
+	for _, group := range groups {
+		result += fmt.Sprintf("  %s: %d\n", group.title, len(group.names))
+		for i, name := range group.names {
+			if i == maxListedEntities {
+				result += fmt.Sprintf("    ... and %d more\n", len(group.names)-maxListedEntities)
+				break
+			}
+			result += "    - " + name + "\n"
+		}
+	}
+	return result
+}
+
+// removeString returns values without s
+func removeString(values []string, s string) []string {
+	kept := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != s {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
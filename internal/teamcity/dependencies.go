@@ -0,0 +1,361 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// dependencyBuildFields is the field selection of a dependency build
+const dependencyBuildFields = "id,number,status,state,statusText,branchName,buildTypeId,buildType(name),composite,finishDate"
+
+// dependencyBuild is a build with the fields of dependencyBuildFields
+type dependencyBuild struct {
+	Build
+	StatusText string `json:"statusText"`
+}
+
+// GetBuildDependencies lists the snapshot and artifact dependencies of a build: the upstream
+// builds it consumed, with their numbers and statuses
+func (c *Client) GetBuildDependencies(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID string `json:"buildId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildID == "" {
+		return "", fmt.Errorf("buildId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_build_dependencies", "success", time.Since(start).Seconds())
+	}()
+
+	fields := fmt.Sprintf("id,number,status,state,buildTypeId,snapshot-dependencies(build(%s)),artifact-dependencies(build(%s))",
+		dependencyBuildFields, dependencyBuildFields)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s?fields=%s", url.PathEscape(req.BuildID), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("build not found: %w", err)
+	}
+
+	var build struct {
+		Build
+		SnapshotDependencies struct {
+			Build []dependencyBuild `json:"build"`
+		} `json:"snapshot-dependencies"`
+		ArtifactDependencies struct {
+			Build []dependencyBuild `json:"build"`
+		} `json:"artifact-dependencies"`
+	}
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return "", fmt.Errorf("failed to parse build: %w", err)
+	}
+
+	result := fmt.Sprintf("Dependencies of build #%s (ID: %d) of %s (%s", build.Number, build.ID, build.BuildTypeID, build.State)
+	if build.Status != "" {
+		result += ", " + build.Status
+	}
+	result += ")\n"
+
+	if len(build.SnapshotDependencies.Build) == 0 && len(build.ArtifactDependencies.Build) == 0 {
+		return result + "\nThe build has no snapshot or artifact dependencies.", nil
+	}
+
+	var failed []string
+	seen := make(map[int]bool)
+	for _, group := range []struct {
+		title  string
+		builds []dependencyBuild
+	}{
+		{"Snapshot dependencies", build.SnapshotDependencies.Build},
+		{"Artifact dependencies", build.ArtifactDependencies.Build},
+	} {
+		if len(group.builds) == 0 {
+			continue
+		}
+		result += fmt.Sprintf("\n%s (%d):\n", group.title, len(group.builds))
+		for _, dep := range group.builds {
+			result += "  " + c.describeDependency(dep) + "\n"
+			if !isFailedStatus(dep.Status) {
+				continue
+			}
+			if dep.StatusText != "" {
+				result += fmt.Sprintf("    %s\n", dep.StatusText)
+			}
+			// A build can be both a snapshot and an artifact dependency
+			if !seen[dep.ID] {
+				failed = append(failed, fmt.Sprintf("#%s (ID: %d) of %s", dep.Number, dep.ID, dep.BuildTypeID))
+				seen[dep.ID] = true
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		result += fmt.Sprintf("\nFailed dependencies: %s\n", strings.Join(failed, ", "))
+	} else {
+		result += "\nNo dependency failed.\n"
+	}
+	return result, nil
+}
+
+// describeDependency renders a dependency build on one line
+func (c *Client) describeDependency(dep dependencyBuild) string {
+	name := dep.BuildType.Name
+	if name == "" {
+		name = dep.BuildTypeID
+	}
+	line := fmt.Sprintf("#%s (ID: %d) %s (%s)", dep.Number, dep.ID, name, dep.BuildTypeID)
+	if dep.BranchName != "" {
+		line += ", branch " + dep.BranchName
+	}
+	line += ": " + dep.State
+	if dep.Status != "" {
+		line += ", " + dep.Status
+	}
+	if dep.Composite {
+		line += " (composite)"
+	}
+	if dep.FinishDate != "" {
+		line += ", finished " + c.formatTeamCityDate(dep.FinishDate)
+	}
+	return line
+}
+
+// BuildTypeDependency is a snapshot or artifact dependency of a build configuration on another one
+type BuildTypeDependency struct {
+	BuildFeature
+	SourceBuildType BuildType `json:"source-buildType"`
+}
+
+// dependencyKinds maps the kinds of build configuration dependencies to their collection and type
+var dependencyKinds = map[string]struct{ title, collection, item, itemType string }{
+	"snapshot": {"Snapshot", "snapshot-dependencies", "snapshot-dependency", "snapshot_dependency"},
+	"artifact": {"Artifact", "artifact-dependencies", "artifact-dependency", "artifact_dependency"},
+}
+
+// artifactRevisionRules maps the revision rules of artifact dependencies to their revision
+// value; buildNumber and buildTag take the value from the revisionValue argument
+var artifactRevisionRules = map[string]string{
+	"lastSuccessful":          "latest.lastSuccessful",
+	"lastPinned":              "latest.lastPinned",
+	"lastFinished":            "latest.lastFinished",
+	"sameChainOrLastFinished": "latest.sameChainOrLastFinished",
+	"buildNumber":             "",
+	"buildTag":                "",
+}
+
+// snapshotFailureActions lists what a build does when a snapshot dependency fails
+var snapshotFailureActions = []string{"RUN_ADD_PROBLEM", "RUN", "MAKE_FAILED_TO_START", "CANCEL"}
+
+// ManageDependencies lists, adds or removes the snapshot and artifact dependencies of a build
+// configuration
+func (c *Client) ManageDependencies(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action              string            `json:"action"`
+		BuildTypeID         string            `json:"buildTypeId"`
+		Kind                string            `json:"kind"`
+		SourceBuildTypeID   string            `json:"sourceBuildTypeId"`
+		OnDependencyFailure string            `json:"onDependencyFailure"`
+		SameAgent           bool              `json:"sameAgent"`
+		RevisionRule        string            `json:"revisionRule"`
+		RevisionValue       string            `json:"revisionValue"`
+		Branch              string            `json:"branch"`
+		PathRules           string            `json:"pathRules"`
+		CleanDestination    bool              `json:"cleanDestination"`
+		Properties          map[string]string `json:"properties"`
+		DependencyID        string            `json:"dependencyId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	if req.Action == "" {
+		req.Action = "list"
+	}
+	kind, ok := dependencyKinds[req.Kind]
+	if (req.Action != "list" || req.Kind != "") && !ok {
+		return "", fmt.Errorf("kind must be snapshot or artifact")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_dependencies", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "list":
+		result := fmt.Sprintf("Dependencies of %s:\n", req.BuildTypeID)
+		for _, name := range []string{"snapshot", "artifact"} {
+			if req.Kind != "" && req.Kind != name {
+				continue
+			}
+			deps, err := c.getBuildTypeDependencies(ctx, req.BuildTypeID, name)
+			if err != nil {
+				return "", fmt.Errorf("failed to get %s dependencies: %w", name, err)
+			}
+			result += fmt.Sprintf("\n%s dependencies (%d):\n", dependencyKinds[name].title, len(deps))
+			if len(deps) == 0 {
+				result += "  (none)\n"
+			}
+			for _, dep := range deps {
+				result += describeBuildTypeDependency(dep)
+			}
+		}
+		return result, nil
+
+	case "add":
+		if req.SourceBuildTypeID == "" {
+			return "", fmt.Errorf("sourceBuildTypeId is required for add action")
+		}
+
+		properties := make(map[string]string)
+		if req.Kind == "snapshot" {
+			if req.OnDependencyFailure == "" {
+				req.OnDependencyFailure = "RUN_ADD_PROBLEM"
+			}
+			if !containsString(snapshotFailureActions, req.OnDependencyFailure) {
+				return "", fmt.Errorf("onDependencyFailure must be one of %s", strings.Join(snapshotFailureActions, ", "))
+			}
+			properties["run-build-if-dependency-failed"] = req.OnDependencyFailure
+			properties["run-build-on-the-same-agent"] = fmt.Sprint(req.SameAgent)
+		} else {
+			if req.PathRules == "" {
+				return "", fmt.Errorf("pathRules is required for artifact dependencies")
+			}
+			if req.RevisionRule == "" {
+				req.RevisionRule = "lastSuccessful"
+			}
+			value, ok := artifactRevisionRules[req.RevisionRule]
+			if !ok {
+				return "", fmt.Errorf("unknown revisionRule: %s (expected lastSuccessful, lastPinned, lastFinished, sameChainOrLastFinished, buildNumber or buildTag)", req.RevisionRule)
+			}
+			switch req.RevisionRule {
+			case "buildNumber":
+				value = req.RevisionValue
+			case "buildTag":
+				value = req.RevisionValue + ".tcbuildtag"
+			}
+			if value == "" || value == ".tcbuildtag" {
+				return "", fmt.Errorf("revisionValue is required for revisionRule %s", req.RevisionRule)
+			}
+			properties["revisionName"] = req.RevisionRule
+			properties["revisionValue"] = value
+			properties["pathRules"] = req.PathRules
+			properties["cleanDestinationDirectory"] = fmt.Sprint(req.CleanDestination)
+			if req.Branch != "" {
+				properties["revisionBranch"] = req.Branch
+			}
+		}
+		// Explicit properties take precedence for options not covered by the arguments above
+		for name, value := range req.Properties {
+			properties[name] = value
+		}
+
+		reqBody, err := json.Marshal(map[string]interface{}{
+			"type":             kind.itemType,
+			"properties":       propertiesPayload(properties),
+			"source-buildType": map[string]string{"id": req.SourceBuildTypeID},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal dependency: %w", err)
+		}
+
+		respBody, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/buildTypes/id:%s/%s", url.PathEscape(req.BuildTypeID), kind.collection), reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s dependency: %w", req.Kind, err)
+		}
+
+		var created BuildTypeDependency
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse dependency response: %w", err)
+		}
+
+		return fmt.Sprintf("%s dependency of %s on %s added (ID: %s)", kind.title,
+			req.BuildTypeID, req.SourceBuildTypeID, created.ID), nil
+
+	case "remove":
+		if req.DependencyID == "" {
+			return "", fmt.Errorf("dependencyId is required for remove action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/buildTypes/id:%s/%s/%s", req.BuildTypeID, kind.collection, url.PathEscape(req.DependencyID)), nil); err != nil {
+			return "", fmt.Errorf("failed to remove %s dependency: %w", req.Kind, err)
+		}
+
+		return fmt.Sprintf("%s dependency %s removed from %s", kind.title, req.DependencyID, req.BuildTypeID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, add or remove)", req.Action)
+	}
+}
+
+// getBuildTypeDependencies returns the snapshot or artifact dependencies of a build configuration
+func (c *Client) getBuildTypeDependencies(ctx context.Context, buildTypeID, kind string) ([]BuildTypeDependency, error) {
+	collection, item := dependencyKinds[kind].collection, dependencyKinds[kind].item
+	fields := item + "(id,type,disabled,properties(property(name,value)),source-buildType(id,name))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/%s?fields=%s", url.PathEscape(buildTypeID), collection, url.QueryEscape(fields)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response map[string][]BuildTypeDependency
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse dependencies response: %w", err)
+	}
+	return response[item], nil
+}
+
+// describeBuildTypeDependency renders a build configuration dependency with its properties
+func describeBuildTypeDependency(dep BuildTypeDependency) string {
+	name := dep.SourceBuildType.Name
+	if name == "" {
+		name = dep.SourceBuildType.ID
+	}
+	result := fmt.Sprintf("  - %s (%s), ID: %s", name, dep.SourceBuildType.ID, dep.ID)
+	if dep.Disabled {
+		result += " (disabled)"
+	}
+	result += "\n"
+
+	if dep.Type == "artifact_dependency" {
+		result += fmt.Sprintf("      Revision: %s (%s)", dep.Property("revisionName"), dep.Property("revisionValue"))
+		if branch := dep.Property("revisionBranch"); branch != "" {
+			result += ", branch " + branch
+		}
+		result += "\n"
+		if dep.Property("cleanDestinationDirectory") == "true" {
+			result += "      Clean destination paths: true\n"
+		}
+		result += "      Artifact rules:\n"
+		for _, line := range strings.Split(dep.Property("pathRules"), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				result += fmt.Sprintf("        %s\n", line)
+			}
+		}
+		return result
+	}
+
+	properties := make([]string, 0, len(dep.Properties.Property))
+	for _, prop := range dep.Properties.Property {
+		properties = append(properties, fmt.Sprintf("%s=%s", prop.Name, prop.Value))
+	}
+	sort.Strings(properties)
+	for _, prop := range properties {
+		result += fmt.Sprintf("      %s\n", prop)
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// deployedBuild is the latest successful build of a deployment configuration with the builds it deployed
+type deployedBuild struct {
+	Build
+	ArtifactDependencies struct {
+		Build []Build `json:"build"`
+	} `json:"artifact-dependencies"`
+	SnapshotDependencies struct {
+		Build []Build `json:"build"`
+	} `json:"snapshot-dependencies"`
+}
+
+// GetDeployments reports which build is currently deployed per environment, based on the latest
+// successful build of each deployment configuration and the builds it took artifacts from
+func (c *Client) GetDeployments(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID string `json:"projectId"`
+		Branch    string `json:"branch"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_deployments", "success", time.Since(start).Seconds())
+	}()
+
+	locator := "type:deployment"
+	if req.ProjectID != "" {
+		locator += fmt.Sprintf(",affectedProject:(id:%s)", req.ProjectID)
+	}
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes?locator=%s&fields=buildType(id,name,projectName)", locator), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get deployment configurations: %w", err)
+	}
+
+	var buildTypes struct {
+		BuildType []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			ProjectName string `json:"projectName"`
+		} `json:"buildType"`
+	}
+	if err := json.Unmarshal(respBody, &buildTypes); err != nil {
+		return "", fmt.Errorf("failed to parse build configurations response: %w", err)
+	}
+
+	if len(buildTypes.BuildType) == 0 {
+		return "No deployment build configurations found. Mark configurations as deployments in their general settings to track them.", nil
+	}
+
+	branch := "default:any"
+	if req.Branch != "" {
+		branch = req.Branch
+	}
+
+	result := fmt.Sprintf("Current deployments (%d environments):\n\n", len(buildTypes.BuildType))
+	for _, bt := range buildTypes.BuildType {
+		result += fmt.Sprintf("%s / %s (%s)\n", bt.ProjectName, bt.Name, bt.ID)
+
+		buildLocator := fmt.Sprintf("buildType:(id:%s),status:SUCCESS,state:finished,branch:%s,count:1", bt.ID, branch)
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=build(id,number,branchName,finishDate,artifact-dependencies(build(id,number,buildTypeId,branchName,buildType(name))),snapshot-dependencies(build(id,number,buildTypeId,branchName,buildType(name))))", buildLocator), nil)
+		if err != nil {
+			c.logger.Warnw("Failed to get latest deployment", "buildTypeId", bt.ID, "error", err)
+			result += "  Could not determine the latest deployment\n\n"
+			continue
+		}
+
+		var builds struct {
+			Build []deployedBuild `json:"build"`
+		}
+		if err := json.Unmarshal(respBody, &builds); err != nil {
+			return "", fmt.Errorf("failed to parse builds response: %w", err)
+		}
+
+		if len(builds.Build) == 0 {
+			result += "  Nothing deployed yet\n\n"
+			continue
+		}
+
+		deploy := builds.Build[0]
+		result += fmt.Sprintf("  Deployment: #%s (ID: %d)", deploy.Number, deploy.ID)
+		if deploy.BranchName != "" {
+			result += fmt.Sprintf(" [%s]", deploy.BranchName)
+		}
+		if deploy.FinishDate != "" {
+			result += fmt.Sprintf(", finished %s", c.formatTeamCityDate(deploy.FinishDate))
+		}
+		result += "\n"
+
+		// Artifact dependencies identify what was deployed; fall back to snapshot dependencies
+		deployed := deploy.ArtifactDependencies.Build
+		if len(deployed) == 0 {
+			deployed = deploy.SnapshotDependencies.Build
+		}
+		if len(deployed) == 0 {
+			result += "  Deployed builds: unknown (no dependencies)\n"
+		}
+		for _, build := range deployed {
+			result += fmt.Sprintf("  Deployed: %s #%s (ID: %d)", build.BuildType.Name, build.Number, build.ID)
+			if build.BranchName != "" {
+				result += fmt.Sprintf(" [%s]", build.BranchName)
+			}
+			result += "\n"
+		}
+		result += "\n"
+	}
+
+	return result, nil
+}
@@ -0,0 +1,285 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// buildDetailsFields is the field selection of get_build_details
+const buildDetailsFields = "id,number,status,state,statusText,branchName,defaultBranch,personal,pinned,composite,webUrl," +
+	"queuedDate,startDate,finishDate,buildType(id,name,projectId,project(name))," +
+	"triggered(type,details,date,user(username,name),build(id,number,buildTypeId))," +
+	"agent(id,name),revisions(revision(version,vcsBranchName,vcs-root-instance(name))),tags(tag(name))," +
+	"snapshot-dependencies(count,build(id,number,status,state,buildTypeId))," +
+	"artifact-dependencies(count,build(id,number,status,state,buildTypeId)),artifacts(count)," +
+	"canceledInfo(text,user(username)),statistics(property(name,value))"
+
+// userRef is a TeamCity user reference
+type userRef struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// buildDetails is a build with the fields of buildDetailsFields
+type buildDetails struct {
+	Build
+	StatusText    string `json:"statusText"`
+	DefaultBranch bool   `json:"defaultBranch"`
+	Personal      bool   `json:"personal"`
+	Pinned        bool   `json:"pinned"`
+	Triggered     *struct {
+		Type    string   `json:"type"`
+		Details string   `json:"details"`
+		Date    string   `json:"date"`
+		User    *userRef `json:"user"`
+		Build   *Build   `json:"build"`
+	} `json:"triggered"`
+	Agent *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"agent"`
+	Revisions struct {
+		Revision []struct {
+			Version         string `json:"version"`
+			VcsBranchName   string `json:"vcsBranchName"`
+			VcsRootInstance struct {
+				Name string `json:"name"`
+			} `json:"vcs-root-instance"`
+		} `json:"revision"`
+	} `json:"revisions"`
+	Tags struct {
+		Tag []struct {
+			Name string `json:"name"`
+		} `json:"tag"`
+	} `json:"tags"`
+	SnapshotDependencies struct {
+		Build []Build `json:"build"`
+	} `json:"snapshot-dependencies"`
+	ArtifactDependencies struct {
+		Build []Build `json:"build"`
+	} `json:"artifact-dependencies"`
+	Artifacts *struct {
+		Count int `json:"count"`
+	} `json:"artifacts"`
+	CanceledInfo *struct {
+		Text string   `json:"text"`
+		User *userRef `json:"user"`
+	} `json:"canceledInfo"`
+	Statistics struct {
+		Property []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"property"`
+	} `json:"statistics"`
+}
+
+// GetBuildDetails returns the full details of a single build
+func (c *Client) GetBuildDetails(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID string `json:"buildId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildID == "" {
+		return "", fmt.Errorf("buildId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_build_details", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s?fields=%s", url.PathEscape(req.BuildID), url.QueryEscape(buildDetailsFields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build: %w", err)
+	}
+
+	var build buildDetails
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return "", fmt.Errorf("failed to parse build: %w", err)
+	}
+
+	return c.formatBuildDetails(ctx, build), nil
+}
+
+// formatBuildDetails renders the details of a build
+func (c *Client) formatBuildDetails(ctx context.Context, build buildDetails) string {
+	result := fmt.Sprintf("Build #%s (ID: %d)\n", build.Number, build.ID)
+	result += fmt.Sprintf("  Build configuration: %s (%s) in project %s (%s)\n",
+		build.BuildType.Name, build.BuildType.ID, build.BuildType.Project.Name, build.BuildType.ProjectID)
+	result += fmt.Sprintf("  State: %s", build.State)
+	if build.Status != "" {
+		result += fmt.Sprintf(", status: %s", build.Status)
+	}
+	result += "\n"
+	if build.StatusText != "" {
+		result += fmt.Sprintf("  Status text: %s\n", build.StatusText)
+	}
+
+	branch := build.BranchName
+	if branch == "" {
+		branch = "<default>"
+	} else if build.DefaultBranch {
+		branch += " (default)"
+	}
+	result += fmt.Sprintf("  Branch: %s\n", branch)
+
+	var flags []string
+	if build.Personal {
+		flags = append(flags, "personal")
+	}
+	if build.Pinned {
+		flags = append(flags, "pinned")
+	}
+	if build.Composite {
+		flags = append(flags, "composite")
+	}
+	if len(flags) > 0 {
+		result += fmt.Sprintf("  Flags: %s\n", strings.Join(flags, ", "))
+	}
+	if build.Composite {
+		result += c.describeComposite(ctx, build.ID, "  ")
+	}
+
+	if t := build.Triggered; t != nil {
+		result += fmt.Sprintf("  Triggered by: %s\n", describeTrigger(t.Type, t.Details, t.User, t.Build))
+		if t.Date != "" {
+			result += fmt.Sprintf("  Triggered at: %s\n", c.formatTeamCityDate(t.Date))
+		}
+	}
+	if build.QueuedDate != "" {
+		result += fmt.Sprintf("  Queued: %s\n", c.formatTeamCityDate(build.QueuedDate))
+	}
+	if build.StartDate != "" {
+		result += fmt.Sprintf("  Started: %s\n", c.formatTeamCityDate(build.StartDate))
+	}
+	if build.FinishDate != "" {
+		result += fmt.Sprintf("  Finished: %s", c.formatTeamCityDate(build.FinishDate))
+		if duration := c.calculateDuration(build.StartDate, build.FinishDate); duration != "" {
+			result += fmt.Sprintf(" (took %s)", duration)
+		}
+		result += "\n"
+	}
+	if build.Agent != nil && build.Agent.Name != "" {
+		result += fmt.Sprintf("  Agent: %s (ID: %d)\n", build.Agent.Name, build.Agent.ID)
+	}
+	if info := build.CanceledInfo; info != nil {
+		result += "  Canceled"
+		if info.User != nil && info.User.Username != "" {
+			result += " by " + info.User.Username
+		}
+		if info.Text != "" {
+			result += ": " + info.Text
+		}
+		result += "\n"
+	}
+
+	if len(build.Tags.Tag) > 0 {
+		tags := make([]string, 0, len(build.Tags.Tag))
+		for _, tag := range build.Tags.Tag {
+			tags = append(tags, tag.Name)
+		}
+		result += fmt.Sprintf("  Tags: %s\n", strings.Join(tags, ", "))
+	}
+
+	if len(build.Revisions.Revision) > 0 {
+		result += "  Revisions:\n"
+		for _, revision := range build.Revisions.Revision {
+			result += fmt.Sprintf("    %s: %s", revision.VcsRootInstance.Name, revision.Version)
+			if revision.VcsBranchName != "" {
+				result += fmt.Sprintf(" (%s)", revision.VcsBranchName)
+			}
+			result += "\n"
+		}
+	}
+
+	result += formatDependencies("Snapshot dependencies", build.SnapshotDependencies.Build)
+	result += formatDependencies("Artifact dependencies", build.ArtifactDependencies.Build)
+
+	if build.Artifacts != nil {
+		result += fmt.Sprintf("  Artifacts: %d\n", build.Artifacts.Count)
+	}
+
+	if summary := statisticsSummary(build); summary != "" {
+		result += "  Statistics: " + summary + "\n"
+	}
+
+	if build.WebURL != "" {
+		result += fmt.Sprintf("  URL: %s\n", build.WebURL)
+	}
+	return result
+}
+
+// describeTrigger describes what triggered a build, e.g. "user alice" or "vcs (Git: main)"
+func describeTrigger(triggerType, details string, user *userRef, build *Build) string {
+	switch {
+	case triggerType == "user" && user != nil:
+		if user.Name != "" && user.Name != user.Username {
+			return fmt.Sprintf("user %s (%s)", user.Username, user.Name)
+		}
+		return "user " + user.Username
+	case build != nil && build.ID != 0:
+		return fmt.Sprintf("%s of build #%s (ID: %d) of %s", triggerType, build.Number, build.ID, build.BuildTypeID)
+	case details != "":
+		return fmt.Sprintf("%s (%s)", triggerType, details)
+	default:
+		return triggerType
+	}
+}
+
+// formatDependencies lists the dependency builds of a build
+func formatDependencies(title string, builds []Build) string {
+	if len(builds) == 0 {
+		return ""
+	}
+	result := fmt.Sprintf("  %s (%d):\n", title, len(builds))
+	for _, dep := range builds {
+		result += fmt.Sprintf("    #%s (ID: %d) of %s: %s", dep.Number, dep.ID, dep.BuildTypeID, dep.State)
+		if dep.Status != "" {
+			result += ", " + dep.Status
+		}
+		result += "\n"
+	}
+	return result
+}
+
+// statisticsSummary summarizes the most useful build statistics: queue time, tests and artifact size
+func statisticsSummary(build buildDetails) string {
+	stats := make(map[string]string, len(build.Statistics.Property))
+	for _, property := range build.Statistics.Property {
+		stats[property.Name] = property.Value
+	}
+
+	var parts []string
+	if ms, err := strconv.ParseInt(stats["TimeSpentInQueue"], 10, 64); err == nil {
+		parts = append(parts, fmt.Sprintf("%s in queue", (time.Duration(ms)*time.Millisecond).Round(time.Second)))
+	}
+	if ms, err := strconv.ParseInt(stats["BuildDuration"], 10, 64); err == nil {
+		parts = append(parts, fmt.Sprintf("duration %s", (time.Duration(ms)*time.Millisecond).Round(time.Second)))
+	}
+	if total := stats["TotalTestCount"]; total != "" {
+		tests := fmt.Sprintf("%s tests", total)
+		for _, count := range []struct{ stat, label string }{
+			{"PassedTestCount", "passed"}, {"FailedTestCount", "failed"}, {"IgnoredTestCount", "ignored"},
+		} {
+			if value := stats[count.stat]; value != "" && value != "0" {
+				tests += fmt.Sprintf(", %s %s", value, count.label)
+			}
+		}
+		parts = append(parts, tests)
+	}
+	if size, err := strconv.ParseInt(stats["ArtifactsSize"], 10, 64); err == nil {
+		parts = append(parts, fmt.Sprintf("artifacts %s", formatBytes(size)))
+	}
+	return strings.Join(parts, "; ")
+}
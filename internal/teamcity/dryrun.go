@@ -0,0 +1,126 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+)
+
+// describeTriggerBuild describes the build trigger_build would queue, after checking that the
+// build configuration exists
+func (c *Client) describeTriggerBuild(ctx context.Context, buildTypeID, branch, comment string, properties map[string]string, options []string, reqBody []byte) (string, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s?fields=id,name,projectId,project(name)", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("build configuration not found: %w", err)
+	}
+
+	var buildType BuildType
+	if err := json.Unmarshal(respBody, &buildType); err != nil {
+		return "", fmt.Errorf("failed to parse build configuration: %w", err)
+	}
+
+	if branch == "" {
+		branch = "default branch"
+	}
+	result := "Dry run: no build was queued.\n\n"
+	result += fmt.Sprintf("Would queue a build of %s (%s) in project %s (%s)\n", buildType.Name, buildType.ID, buildType.Project.Name, buildType.ProjectID)
+	result += fmt.Sprintf("  Branch: %s\n", branch)
+	if comment != "" {
+		result += fmt.Sprintf("  Comment: %s\n", comment)
+	}
+	if len(properties) > 0 {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		result += "  Properties:\n"
+		for _, name := range names {
+			value := properties[name]
+			if isSecretValue(name, value) {
+				value = maskedValue
+			}
+			result += fmt.Sprintf("    %s = %s\n", name, value)
+		}
+	}
+	if len(options) > 0 {
+		result += "  Options:\n"
+		for _, option := range options {
+			result += fmt.Sprintf("    %s\n", option)
+		}
+	}
+	result += "\nRequest: POST /app/rest/buildQueue " + logging.RedactJSON(string(reqBody))
+
+	return result, nil
+}
+
+// describeCancelBuild describes what cancel_build would do to a build
+func describeCancelBuild(build Build, reqBody []byte) string {
+	var effect string
+	switch build.State {
+	case "queued":
+		effect = "removed from the queue"
+	case "running":
+		effect = "stopped"
+	default:
+		effect = "left unchanged as it has already finished"
+	}
+
+	result := "Dry run: no build was cancelled.\n\n"
+	result += fmt.Sprintf("Build #%s (ID: %d) of %s is %s and would be %s.\n", build.Number, build.ID, build.BuildTypeID, build.State, effect)
+	result += fmt.Sprintf("\nRequest: POST /app/rest/builds/id:%d/cancelRequest %s", build.ID, reqBody)
+	return result
+}
+
+// describeRerunBuild describes the build rerun_build would queue
+func describeRerunBuild(source rerunSource, properties map[string]string, skipped []string, rebuildDependencies bool, reqBody []byte) string {
+	branch := source.BranchName
+	if branch == "" {
+		branch = "default branch"
+	}
+	result := "Dry run: no build was queued.\n\n"
+	result += fmt.Sprintf("Would re-run build #%s (ID: %d) of %s\n", source.Number, source.ID, source.BuildTypeID)
+	result += fmt.Sprintf("  Branch: %s\n", branch)
+	if len(source.Revisions.Revision) > 0 {
+		result += "  Revisions:\n"
+		for _, revision := range source.Revisions.Revision {
+			result += fmt.Sprintf("    %s: %s\n", revision.VcsRootInstance.Name, revision.Version)
+		}
+	}
+	if len(properties) > 0 {
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		result += "  Parameters:\n"
+		for _, name := range names {
+			value := properties[name]
+			if isSecretValue(name, value) {
+				value = maskedValue
+			}
+			result += fmt.Sprintf("    %s = %s\n", name, value)
+		}
+	}
+	if len(skipped) > 0 {
+		result += fmt.Sprintf("  Password parameters (configured values): %s\n", strings.Join(skipped, ", "))
+	}
+	switch {
+	case rebuildDependencies:
+		result += "  Dependencies: rebuilt\n"
+	case len(source.SnapshotDependencies.Build) > 0:
+		result += "  Dependencies: reused\n"
+		for _, dep := range source.SnapshotDependencies.Build {
+			result += fmt.Sprintf("    #%s (ID: %d) of %s\n", dep.Number, dep.ID, dep.BuildTypeID)
+		}
+	}
+	result += "\nRequest: POST /app/rest/buildQueue " + logging.RedactJSON(string(reqBody))
+	return result
+}
@@ -0,0 +1,271 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// investigationFields is the field selection of listed investigations
+const investigationFields = "investigation(id,state,assignee(username,name),assignment(user(username),timestamp,text)," +
+	"resolution(type),scope(project(id,name),buildTypes(buildType(id,name))),target(anyProblem,tests(test(id,name)),problems(problem(id,type,identity))))"
+
+// Investigation represents a TeamCity investigation: a user taking responsibility for a failure
+type Investigation struct {
+	ID         string   `json:"id"`
+	State      string   `json:"state"`
+	Assignee   *userRef `json:"assignee"`
+	Assignment *struct {
+		User      *userRef `json:"user"`
+		Timestamp string   `json:"timestamp"`
+		Text      string   `json:"text"`
+	} `json:"assignment"`
+	Resolution *struct {
+		Type string `json:"type"`
+	} `json:"resolution"`
+	Scope struct {
+		Project    *Project `json:"project"`
+		BuildTypes *struct {
+			BuildType []BuildType `json:"buildType"`
+		} `json:"buildTypes"`
+	} `json:"scope"`
+	Target struct {
+		AnyProblem bool `json:"anyProblem"`
+		Tests      *struct {
+			Test []Test `json:"test"`
+		} `json:"tests"`
+		Problems *struct {
+			Problem []struct {
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Identity string `json:"identity"`
+			} `json:"problem"`
+		} `json:"problems"`
+	} `json:"target"`
+}
+
+// ManageInvestigations lists the investigations of a project, build configuration or test, or
+// assigns or removes an investigation so triage shows up in the TeamCity UI
+func (c *Client) ManageInvestigations(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action          string `json:"action"`
+		ProjectID       string `json:"projectId"`
+		BuildTypeID     string `json:"buildTypeId"`
+		TestName        string `json:"testName"`
+		TestID          string `json:"testId"`
+		Assignee        string `json:"assignee"`
+		Comment         string `json:"comment"`
+		Resolution      string `json:"resolution"`
+		InvestigationID string `json:"investigationId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Action == "" {
+		req.Action = "list"
+	}
+	if req.TestName != "" && req.TestID != "" {
+		return "", fmt.Errorf("testName and testId are mutually exclusive")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_investigations", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "list":
+		var filters []string
+		if req.ProjectID != "" {
+			filters = append(filters, fmt.Sprintf("affectedProject:(id:%s)", req.ProjectID))
+		}
+		if req.BuildTypeID != "" {
+			filters = append(filters, fmt.Sprintf("buildType:(id:%s)", req.BuildTypeID))
+		}
+		if req.Assignee != "" {
+			filters = append(filters, fmt.Sprintf("assignee:(%s)", userLocator(req.Assignee)))
+		}
+		test, err := c.investigationTest(ctx, req.TestID, req.TestName)
+		if err != nil {
+			return "", err
+		}
+		if test != nil {
+			filters = append(filters, fmt.Sprintf("test:(id:%s)", test.ID))
+		}
+		if len(filters) == 0 {
+			return "", fmt.Errorf("projectId, buildTypeId, testName, testId or assignee is required for list action")
+		}
+
+		locator := strings.Join(filters, ",")
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/investigations?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(investigationFields)), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get investigations: %w", err)
+		}
+
+		var response struct {
+			Investigation []Investigation `json:"investigation"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return "", fmt.Errorf("failed to parse investigations response: %w", err)
+		}
+
+		if len(response.Investigation) == 0 {
+			return "No investigations found.", nil
+		}
+		result := fmt.Sprintf("Found %d investigations:\n\n", len(response.Investigation))
+		for _, investigation := range response.Investigation {
+			result += c.formatInvestigation(investigation) + "\n"
+		}
+		return result, nil
+
+	case "assign":
+		if req.Assignee == "" {
+			return "", fmt.Errorf("assignee is required for assign action")
+		}
+		if req.Resolution == "" {
+			req.Resolution = "whenFixed"
+		}
+		if req.Resolution != "whenFixed" && req.Resolution != "manually" {
+			return "", fmt.Errorf("invalid resolution: must be 'whenFixed' or 'manually'")
+		}
+
+		investigation := map[string]interface{}{
+			"state":      "TAKEN",
+			"assignee":   map[string]string{"username": req.Assignee},
+			"assignment": map[string]string{"text": req.Comment},
+			"resolution": map[string]string{"type": req.Resolution},
+		}
+
+		var what string
+		test, err := c.investigationTest(ctx, req.TestID, req.TestName)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case test != nil:
+			// A test is investigated within a project
+			if req.ProjectID == "" {
+				return "", fmt.Errorf("projectId is required to assign an investigation of a test")
+			}
+			investigation["scope"] = map[string]interface{}{"project": map[string]string{"id": req.ProjectID}}
+			investigation["target"] = map[string]interface{}{"tests": map[string]interface{}{"test": []map[string]string{{"id": test.ID}}}}
+			what = fmt.Sprintf("test %s in project %s", test.Name, req.ProjectID)
+			if test.Name == "" {
+				what = fmt.Sprintf("test %s in project %s", test.ID, req.ProjectID)
+			}
+		case req.BuildTypeID != "":
+			investigation["scope"] = map[string]interface{}{"buildTypes": map[string]interface{}{"buildType": []map[string]string{{"id": req.BuildTypeID}}}}
+			investigation["target"] = map[string]bool{"anyProblem": true}
+			what = "build configuration " + req.BuildTypeID
+		default:
+			return "", fmt.Errorf("buildTypeId, testName or testId is required for assign action")
+		}
+
+		reqBody, err := json.Marshal(investigation)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal investigation: %w", err)
+		}
+		if _, err := c.makeRequest(ctx, "POST", "/investigations", reqBody); err != nil {
+			return "", fmt.Errorf("failed to assign investigation: %w", err)
+		}
+
+		result := fmt.Sprintf("Investigation of %s assigned to %s (resolve %s)", what, req.Assignee, req.Resolution)
+		if req.Comment != "" {
+			result += ": " + req.Comment
+		}
+		return result, nil
+
+	case "remove":
+		if req.InvestigationID == "" {
+			return "", fmt.Errorf("investigationId is required for remove action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", "/investigations/"+url.PathEscape(req.InvestigationID), nil); err != nil {
+			return "", fmt.Errorf("failed to remove investigation: %w", err)
+		}
+		return fmt.Sprintf("Investigation %s removed", req.InvestigationID), nil
+
+	default:
+		return "", fmt.Errorf("invalid action: must be 'list', 'assign' or 'remove'")
+	}
+}
+
+// investigationTest resolves the test of an investigation from its ID or full name; it returns nil
+// when neither is given
+func (c *Client) investigationTest(ctx context.Context, testID, testName string) (*Test, error) {
+	if testID != "" {
+		return &Test{ID: testID}, nil
+	}
+	if testName == "" {
+		return nil, nil
+	}
+	test, err := c.findTest(ctx, testName)
+	if err != nil {
+		return nil, err
+	}
+	if test == nil {
+		return nil, fmt.Errorf("no test named '%s' found", testName)
+	}
+	return test, nil
+}
+
+// formatInvestigation renders an investigation with its target, assignee and comment
+func (c *Client) formatInvestigation(investigation Investigation) string {
+	var targets []string
+	if tests := investigation.Target.Tests; tests != nil {
+		for _, test := range tests.Test {
+			targets = append(targets, "test "+test.Name)
+		}
+	}
+	if problems := investigation.Target.Problems; problems != nil {
+		for _, problem := range problems.Problem {
+			targets = append(targets, fmt.Sprintf("problem %s (%s)", problem.Identity, problem.Type))
+		}
+	}
+	if investigation.Target.AnyProblem {
+		targets = append(targets, "any problem")
+	}
+
+	var scope []string
+	if project := investigation.Scope.Project; project != nil {
+		scope = append(scope, fmt.Sprintf("project %s (%s)", project.Name, project.ID))
+	}
+	if buildTypes := investigation.Scope.BuildTypes; buildTypes != nil {
+		for _, buildType := range buildTypes.BuildType {
+			scope = append(scope, fmt.Sprintf("%s (%s)", buildType.Name, buildType.ID))
+		}
+	}
+
+	result := fmt.Sprintf("%s: %s", investigation.State, strings.Join(targets, ", "))
+	if len(scope) > 0 {
+		result += " in " + strings.Join(scope, ", ")
+	}
+	result += "\n"
+	if investigation.Assignee != nil {
+		result += fmt.Sprintf("  Assignee: %s\n", investigation.Assignee.Username)
+	}
+	if assignment := investigation.Assignment; assignment != nil {
+		if assignment.User != nil && assignment.User.Username != "" {
+			result += "  Assigned by: " + assignment.User.Username
+			if assignment.Timestamp != "" {
+				result += " at " + c.formatTeamCityDate(assignment.Timestamp)
+			}
+			result += "\n"
+		}
+		if assignment.Text != "" {
+			result += fmt.Sprintf("  Comment: %s\n", assignment.Text)
+		}
+	}
+	if investigation.Resolution != nil && investigation.Resolution.Type != "" {
+		result += fmt.Sprintf("  Resolve: %s\n", investigation.Resolution.Type)
+	}
+	result += fmt.Sprintf("  ID: %s\n", investigation.ID)
+	return result
+}
@@ -0,0 +1,57 @@
+package teamcity
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// limitedTransport bounds the number of concurrent in-flight requests to TeamCity.
+// A request holds its slot until the response body is closed, so streamed downloads count too.
+type limitedTransport struct {
+	next  http.RoundTripper
+	slots chan struct{}
+}
+
+// newLimitedTransport wraps next so that at most limit requests are in flight; limit <= 0 disables the limit
+func newLimitedTransport(next http.RoundTripper, limit int) http.RoundTripper {
+	if limit <= 0 {
+		return next
+	}
+	return &limitedTransport{
+		next:  next,
+		slots: make(chan struct{}, limit),
+	}
+}
+
+// RoundTrip waits for a free slot, giving up when the request context is cancelled
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		<-t.slots
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { <-t.slots }}
+	return resp, nil
+}
+
+// releasingBody frees the request slot once the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close closes the body and frees the request slot
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,143 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// muteFields is the field selection of the mutes resource
+const muteFields = "mute(id,assignment(user(username),timestamp,text),resolution(type,time)," +
+	"scope(project(id),buildTypes(buildType(id))),target(tests(test(id,name)),problems(problem(id,type,identity))))"
+
+// Mutes lists the currently muted tests and build problems
+type Mutes struct {
+	Type          string       `json:"type"`
+	Timestamp     string       `json:"timestamp"`
+	ProjectID     string       `json:"projectId,omitempty"`
+	MutedTests    int          `json:"mutedTests"`
+	MutedProblems int          `json:"mutedProblems"`
+	Mutes         []MutedEntry `json:"mutes"`
+}
+
+// MutedEntry is a muted test or build problem with who muted it, why and until when
+type MutedEntry struct {
+	MuteID int    `json:"muteId"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	// Scope is the project or build configurations the mute applies to
+	Scope   []string `json:"scope"`
+	MutedBy string   `json:"mutedBy,omitempty"`
+	MutedAt string   `json:"mutedAt,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	// Unmute is whenFixed, manually or the time the mute expires
+	Unmute string `json:"unmute,omitempty"`
+}
+
+// GetMutes returns the currently muted tests and build problems, of a project and its
+// subprojects when projectID is set
+func (c *Client) GetMutes(ctx context.Context, projectID string) (*Mutes, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_mutes", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := "/mutes?fields=" + url.QueryEscape(muteFields)
+	if projectID != "" {
+		endpoint += "&locator=" + url.QueryEscape(fmt.Sprintf("affectedProject:(id:%s)", projectID))
+	}
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mutes: %w", err)
+	}
+
+	var response struct {
+		Mute []struct {
+			ID         int `json:"id"`
+			Assignment *struct {
+				User      *userRef `json:"user"`
+				Timestamp string   `json:"timestamp"`
+				Text      string   `json:"text"`
+			} `json:"assignment"`
+			Resolution *struct {
+				Type string `json:"type"`
+				Time string `json:"time"`
+			} `json:"resolution"`
+			Scope struct {
+				Project    *Project `json:"project"`
+				BuildTypes *struct {
+					BuildType []BuildType `json:"buildType"`
+				} `json:"buildTypes"`
+			} `json:"scope"`
+			Target struct {
+				Tests *struct {
+					Test []Test `json:"test"`
+				} `json:"tests"`
+				Problems *struct {
+					Problem []struct {
+						ID       string `json:"id"`
+						Type     string `json:"type"`
+						Identity string `json:"identity"`
+					} `json:"problem"`
+				} `json:"problems"`
+			} `json:"target"`
+		} `json:"mute"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse mutes response: %w", err)
+	}
+
+	mutes := &Mutes{
+		Type:      "mutes",
+		Timestamp: time.Now().Format(time.RFC3339),
+		ProjectID: projectID,
+		Mutes:     make([]MutedEntry, 0),
+	}
+	for _, mute := range response.Mute {
+		// A mute can cover several tests and problems; list each of them
+		entry := MutedEntry{MuteID: mute.ID, Scope: make([]string, 0)}
+		if mute.Scope.Project != nil {
+			entry.Scope = append(entry.Scope, mute.Scope.Project.ID)
+		}
+		if mute.Scope.BuildTypes != nil {
+			for _, buildType := range mute.Scope.BuildTypes.BuildType {
+				entry.Scope = append(entry.Scope, buildType.ID)
+			}
+		}
+		if assignment := mute.Assignment; assignment != nil {
+			if assignment.User != nil {
+				entry.MutedBy = assignment.User.Username
+			}
+			if date, err := parseTeamCityDate(assignment.Timestamp); err == nil {
+				entry.MutedAt = date.Format(time.RFC3339)
+			}
+			entry.Comment = assignment.Text
+		}
+		if resolution := mute.Resolution; resolution != nil {
+			entry.Unmute = resolution.Type
+			if date, err := parseTeamCityDate(resolution.Time); err == nil {
+				entry.Unmute = date.Format(time.RFC3339)
+			}
+		}
+
+		if tests := mute.Target.Tests; tests != nil {
+			for _, test := range tests.Test {
+				entry.Kind, entry.Name = "test", test.Name
+				mutes.Mutes = append(mutes.Mutes, entry)
+				mutes.MutedTests++
+			}
+		}
+		if problems := mute.Target.Problems; problems != nil {
+			for _, problem := range problems.Problem {
+				entry.Kind, entry.Name = "problem", fmt.Sprintf("%s (%s)", problem.Identity, problem.Type)
+				mutes.Mutes = append(mutes.Mutes, entry)
+				mutes.MutedProblems++
+			}
+		}
+	}
+	return mutes, nil
+}
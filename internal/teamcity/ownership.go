@@ -0,0 +1,166 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// ProjectOfBuildType returns the ID of the project a build configuration belongs to
+func (c *Client) ProjectOfBuildType(ctx context.Context, buildTypeID string) (string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("project_of_build_type", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s?fields=projectId", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build configuration %s: %w", buildTypeID, err)
+	}
+
+	var buildType BuildType
+	if err := json.Unmarshal(respBody, &buildType); err != nil {
+		return "", fmt.Errorf("failed to parse build configuration response: %w", err)
+	}
+	return buildType.ProjectID, nil
+}
+
+// ProjectOfBuild returns the ID of the project a build's configuration belongs to
+func (c *Client) ProjectOfBuild(ctx context.Context, buildID string) (string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("project_of_build", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s?fields=buildType(projectId)", url.PathEscape(buildID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build %s: %w", buildID, err)
+	}
+
+	var build Build
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return "", fmt.Errorf("failed to parse build response: %w", err)
+	}
+	return build.BuildType.ProjectID, nil
+}
+
+// ProjectOfVCSRoot returns the ID of the project a VCS root belongs to
+func (c *Client) ProjectOfVCSRoot(ctx context.Context, vcsRootID string) (string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("project_of_vcs_root", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/vcs-roots/id:%s?fields=project(id)", url.PathEscape(vcsRootID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get VCS root %s: %w", vcsRootID, err)
+	}
+
+	var root struct {
+		Project Project `json:"project"`
+	}
+	if err := json.Unmarshal(respBody, &root); err != nil {
+		return "", fmt.Errorf("failed to parse VCS root response: %w", err)
+	}
+	return root.Project.ID, nil
+}
+
+// BuildTypeProjects returns the project ID of every build configuration by build configuration ID
+func (c *Client) BuildTypeProjects(ctx context.Context) (map[string]string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("build_type_projects", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", "/buildTypes?fields=buildType(id,projectId)", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build configurations: %w", err)
+	}
+
+	var response struct {
+		BuildType []BuildType `json:"buildType"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse build configurations response: %w", err)
+	}
+
+	projects := make(map[string]string, len(response.BuildType))
+	for _, buildType := range response.BuildType {
+		projects[buildType.ID] = buildType.ProjectID
+	}
+	return projects, nil
+}
+
+// ProjectsOfChanges returns the IDs of the projects owning the VCS roots of the changes matching a
+// change locator, e.g. id:42 or version:abc123; a revision may be known under several VCS roots
+func (c *Client) ProjectsOfChanges(ctx context.Context, changeLocator string) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("projects_of_changes", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/changes?locator=%s&fields=%s", url.QueryEscape(changeLocator),
+		url.QueryEscape("change(id,vcsRootInstance(vcs-root(id,project(id))))")), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes %s: %w", changeLocator, err)
+	}
+
+	var response struct {
+		Change []struct {
+			VCSRootInstance struct {
+				VCSRoot struct {
+					Project Project `json:"project"`
+				} `json:"vcs-root"`
+			} `json:"vcsRootInstance"`
+		} `json:"change"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse changes response: %w", err)
+	}
+
+	projects := make([]string, 0, len(response.Change))
+	for _, change := range response.Change {
+		projects = append(projects, change.VCSRootInstance.VCSRoot.Project.ID)
+	}
+	return projects, nil
+}
+
+// ProjectsOfInvestigation returns the IDs of the projects an investigation applies to: its
+// project, or the projects of its build configurations
+func (c *Client) ProjectsOfInvestigation(ctx context.Context, investigationID string) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("projects_of_investigation", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/investigations/%s?fields=%s", url.PathEscape(investigationID),
+		url.QueryEscape("scope(project(id),buildTypes(buildType(id,projectId)))")), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get investigation %s: %w", investigationID, err)
+	}
+
+	var investigation struct {
+		Scope struct {
+			Project    *Project `json:"project"`
+			BuildTypes struct {
+				BuildType []BuildType `json:"buildType"`
+			} `json:"buildTypes"`
+		} `json:"scope"`
+	}
+	if err := json.Unmarshal(respBody, &investigation); err != nil {
+		return nil, fmt.Errorf("failed to parse investigation response: %w", err)
+	}
+
+	var projects []string
+	if investigation.Scope.Project != nil {
+		projects = append(projects, investigation.Scope.Project.ID)
+	}
+	for _, buildType := range investigation.Scope.BuildTypes.BuildType {
+		projects = append(projects, buildType.ProjectID)
+	}
+	return projects, nil
+}
@@ -0,0 +1,23 @@
+package teamcity
+
+import "fmt"
+
+// Page selects a window of a resource listing. The zero Page lists everything.
+type Page struct {
+	Start int
+	Count int
+}
+
+// locator returns the TeamCity locator dimensions selecting the page, or "" for the zero Page
+func (p Page) locator() string {
+	if p.Count <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("start:%d,count:%d", p.Start, p.Count)
+}
+
+// pagedResponse holds the paging information of TeamCity list responses.
+// TeamCity only sets nextHref when entities after the returned page exist.
+type pagedResponse struct {
+	NextHref string `json:"nextHref"`
+}
@@ -0,0 +1,748 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// ParameterType holds the raw TeamCity parameter specification
+// (e.g. "select data_1='dev' data_2='prod' display='prompt'")
+type ParameterType struct {
+	RawValue string `json:"rawValue"`
+}
+
+// ParameterSpec is a parsed TeamCity parameter specification
+type ParameterSpec struct {
+	Kind           string   `json:"kind"`
+	Label          string   `json:"label,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Display        string   `json:"display,omitempty"`
+	Required       bool     `json:"required,omitempty"`
+	ReadOnly       bool     `json:"readOnly,omitempty"`
+	Options        []string `json:"options,omitempty"`
+	Multiple       bool     `json:"multiple,omitempty"`
+	CheckedValue   string   `json:"checkedValue,omitempty"`
+	UncheckedValue string   `json:"uncheckedValue,omitempty"`
+}
+
+// ParseParameterSpec parses a raw TeamCity parameter specification.
+// An empty spec is treated as a plain text parameter.
+func ParseParameterSpec(raw string) ParameterSpec {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ParameterSpec{Kind: "text"}
+	}
+
+	kind := raw
+	rest := ""
+	if i := strings.IndexByte(raw, ' '); i >= 0 {
+		kind = raw[:i]
+		rest = raw[i+1:]
+	}
+
+	spec := ParameterSpec{Kind: kind}
+	attrs := parseSpecAttributes(rest)
+
+	spec.Label = attrs["label"]
+	spec.Description = attrs["description"]
+	spec.Display = attrs["display"]
+	spec.ReadOnly = attrs["readOnly"] == "true"
+	spec.Multiple = attrs["multiple"] == "true"
+	spec.CheckedValue = attrs["checkedValue"]
+	spec.UncheckedValue = attrs["uncheckedValue"]
+	spec.Required = attrs["validationMode"] == "not_empty"
+
+	// Select options are stored as data_1, data_2, ... in display order
+	indexes := make([]int, 0)
+	for key := range attrs {
+		if n, ok := strings.CutPrefix(key, "data_"); ok {
+			if idx, err := strconv.Atoi(n); err == nil {
+				indexes = append(indexes, idx)
+			}
+		}
+	}
+	sort.Ints(indexes)
+	for _, idx := range indexes {
+		spec.Options = append(spec.Options, attrs[fmt.Sprintf("data_%d", idx)])
+	}
+
+	return spec
+}
+
+// parseSpecAttributes parses key='value' pairs, honoring TeamCity's |-escaping inside values
+func parseSpecAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+
+	for {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.Index(s, "='")
+		if eq < 0 {
+			return attrs
+		}
+		key := s[:eq]
+		s = s[eq+2:]
+
+		var value strings.Builder
+		i := 0
+		for ; i < len(s); i++ {
+			if s[i] == '|' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 'r':
+					value.WriteByte('\r')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			if s[i] == '\'' {
+				break
+			}
+			value.WriteByte(s[i])
+		}
+
+		attrs[key] = value.String()
+		if i >= len(s) {
+			return attrs
+		}
+		s = s[i+1:]
+	}
+}
+
+// Spec returns the parsed specification of the parameter
+func (p Parameter) Spec() ParameterSpec {
+	if p.Type == nil {
+		return ParameterSpec{Kind: "text"}
+	}
+	return ParseParameterSpec(p.Type.RawValue)
+}
+
+// String renders the specification as a compact, human readable annotation
+func (s ParameterSpec) String() string {
+	parts := []string{s.Kind}
+
+	if s.Label != "" {
+		parts = append(parts, fmt.Sprintf("label: %q", s.Label))
+	}
+	if len(s.Options) > 0 {
+		options := fmt.Sprintf("options: %s", strings.Join(s.Options, " | "))
+		if s.Multiple {
+			options += " (multiple)"
+		}
+		parts = append(parts, options)
+	}
+	if s.Kind == "checkbox" && (s.CheckedValue != "" || s.UncheckedValue != "") {
+		parts = append(parts, fmt.Sprintf("checked: %q, unchecked: %q", s.CheckedValue, s.UncheckedValue))
+	}
+	if s.Required {
+		parts = append(parts, "required")
+	}
+	if s.Display != "" && s.Display != "normal" {
+		parts = append(parts, "display: "+s.Display)
+	}
+	if s.ReadOnly {
+		parts = append(parts, "read-only")
+	}
+	if s.Description != "" {
+		parts = append(parts, fmt.Sprintf("description: %q", s.Description))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Raw renders the specification in TeamCity's raw format, the inverse of ParseParameterSpec
+func (s ParameterSpec) Raw() string {
+	if s.Kind == "" || (s.Kind == "text" && s.Label == "" && s.Description == "" && s.Display == "" && !s.Required && !s.ReadOnly) {
+		return ""
+	}
+
+	raw := s.Kind
+	attr := func(key, value string) {
+		escaped := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r").Replace(value)
+		raw += fmt.Sprintf(" %s='%s'", key, escaped)
+	}
+	for i, option := range s.Options {
+		attr(fmt.Sprintf("data_%d", i+1), option)
+	}
+	if s.Multiple {
+		attr("multiple", "true")
+	}
+	if s.CheckedValue != "" {
+		attr("checkedValue", s.CheckedValue)
+	}
+	if s.UncheckedValue != "" {
+		attr("uncheckedValue", s.UncheckedValue)
+	}
+	if s.Label != "" {
+		attr("label", s.Label)
+	}
+	if s.Description != "" {
+		attr("description", s.Description)
+	}
+	if s.Display != "" {
+		attr("display", s.Display)
+	}
+	if s.Required {
+		attr("validationMode", "not_empty")
+	}
+	if s.ReadOnly {
+		attr("readOnly", "true")
+	}
+	return raw
+}
+
+// DisplayValue returns the parameter value for output, masking password parameters
+func (p Parameter) DisplayValue() string {
+	if p.Spec().Kind == "password" {
+		return maskedValue
+	}
+	return p.Value
+}
+
+// parameterFields is the field selection used when listing parameters
+const parameterFields = "property(name,value,inherited,type(rawValue))"
+
+// getProjectChain returns a project followed by its ancestors up to the root project
+func (c *Client) getProjectChain(ctx context.Context, projectID string) ([]Project, error) {
+	chain := make([]Project, 0)
+	for id := projectID; id != ""; {
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/projects/id:%s?fields=id,name,parentProjectId", url.PathEscape(id)), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var project struct {
+			Project
+			ParentProjectID string `json:"parentProjectId"`
+		}
+		if err := json.Unmarshal(respBody, &project); err != nil {
+			return nil, fmt.Errorf("failed to parse project: %w", err)
+		}
+
+		chain = append(chain, project.Project)
+		id = project.ParentProjectID
+	}
+	return chain, nil
+}
+
+// getParameters returns the parameters of a project or build configuration ("projects/id:X" or "buildTypes/id:X")
+func (c *Client) getParameters(ctx context.Context, owner string) ([]Parameter, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/%s/parameters?fields=%s", owner, parameterFields), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Property []Parameter `json:"property"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse parameters response: %w", err)
+	}
+	return response.Property, nil
+}
+
+// parameterOrigins maps each parameter name to the nearest project in chain that defines it itself.
+// chain starts at the project the lookup is made for and ends at the root project.
+func (c *Client) parameterOrigins(ctx context.Context, chain []Project) (map[string]Project, map[string][]Project, error) {
+	origins := make(map[string]Project)
+	definedIn := make(map[string][]Project)
+	for _, project := range chain {
+		params, err := c.getParameters(ctx, "projects/id:"+url.PathEscape(project.ID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get parameters of %s: %w", project.ID, err)
+		}
+		for _, param := range params {
+			if param.Inherited {
+				continue
+			}
+			if _, ok := origins[param.Name]; !ok {
+				origins[param.Name] = project
+			}
+			definedIn[param.Name] = append(definedIn[param.Name], project)
+		}
+	}
+	return origins, definedIn, nil
+}
+
+// ListProjectParameters lists a project's own and inherited parameters with the project each one comes from
+func (c *Client) ListProjectParameters(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID        string `json:"projectId"`
+		IncludeInherited *bool  `json:"includeInherited"`
+		Name             string `json:"name"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" {
+		return "", fmt.Errorf("projectId is required")
+	}
+
+	includeInherited := true
+	if req.IncludeInherited != nil {
+		includeInherited = *req.IncludeInherited
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("list_project_parameters", "success", time.Since(start).Seconds())
+	}()
+
+	params, err := c.getParameters(ctx, "projects/id:"+url.PathEscape(req.ProjectID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get project parameters: %w", err)
+	}
+
+	chain, err := c.getProjectChain(ctx, req.ProjectID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get project hierarchy: %w", err)
+	}
+
+	origins, definedIn, err := c.parameterOrigins(ctx, chain[1:])
+	if err != nil {
+		return "", err
+	}
+
+	own := make([]Parameter, 0)
+	inherited := make([]Parameter, 0)
+	for _, param := range params {
+		if req.Name != "" && !strings.Contains(strings.ToLower(param.Name), strings.ToLower(req.Name)) {
+			continue
+		}
+		if param.Inherited {
+			inherited = append(inherited, param)
+		} else {
+			own = append(own, param)
+		}
+	}
+
+	hierarchy := make([]string, 0, len(chain))
+	for i := len(chain) - 1; i >= 0; i-- {
+		hierarchy = append(hierarchy, chain[i].Name)
+	}
+
+	result := fmt.Sprintf("Parameters of project %s\n", req.ProjectID)
+	result += fmt.Sprintf("Hierarchy: %s\n", strings.Join(hierarchy, " > "))
+
+	result += fmt.Sprintf("\nOwn parameters (%d):\n", len(own))
+	if len(own) == 0 {
+		result += "  (none)\n"
+	}
+	for _, param := range own {
+		result += fmt.Sprintf("  %s = %s", param.Name, param.DisplayValue())
+		if parents := definedIn[param.Name]; len(parents) > 0 {
+			result += fmt.Sprintf(" (overrides %s)", parents[0].Name)
+		}
+		result += "\n"
+	}
+
+	if includeInherited {
+		result += fmt.Sprintf("\nInherited parameters (%d):\n", len(inherited))
+		if len(inherited) == 0 {
+			result += "  (none)\n"
+		}
+		for _, param := range inherited {
+			result += fmt.Sprintf("  %s = %s", param.Name, param.DisplayValue())
+			if origin, ok := origins[param.Name]; ok {
+				result += fmt.Sprintf(" (from %s [%s])", origin.Name, origin.ID)
+			}
+			result += "\n"
+		}
+	}
+
+	return result, nil
+}
+
+// annotateParameterOrigins records where each parameter of a configuration comes from (the
+// configuration itself, one of its templates or a project up the hierarchy) and what it overrides
+func (c *Client) annotateParameterOrigins(ctx context.Context, config *DetailedBuildType) error {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/templates?fields=buildType(id,name)", url.PathEscape(config.ID)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	var templates struct {
+		BuildType []BuildType `json:"buildType"`
+	}
+	if err := json.Unmarshal(respBody, &templates); err != nil {
+		return fmt.Errorf("failed to parse templates response: %w", err)
+	}
+
+	templateOrigins := make(map[string]string)
+	for _, template := range templates.BuildType {
+		params, err := c.getParameters(ctx, "buildTypes/id:"+template.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get parameters of template %s: %w", template.ID, err)
+		}
+		for _, param := range params {
+			if _, ok := templateOrigins[param.Name]; !ok && !param.Inherited {
+				templateOrigins[param.Name] = fmt.Sprintf("template %s", template.Name)
+			}
+		}
+	}
+
+	chain, err := c.getProjectChain(ctx, config.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to get project hierarchy: %w", err)
+	}
+	projectOrigins, _, err := c.parameterOrigins(ctx, chain)
+	if err != nil {
+		return err
+	}
+
+	for i := range config.Parameters {
+		param := &config.Parameters[i]
+
+		inheritedFrom := templateOrigins[param.Name]
+		if inheritedFrom == "" {
+			if project, ok := projectOrigins[param.Name]; ok {
+				inheritedFrom = fmt.Sprintf("project %s", project.Name)
+			}
+		}
+
+		if param.Inherited {
+			param.Origin = inheritedFrom
+			if param.Origin == "" {
+				param.Origin = "inherited"
+			}
+			continue
+		}
+
+		param.Origin = "own"
+		param.Overrides = inheritedFrom
+	}
+
+	return nil
+}
+
+// isSecretValue reports whether a parameter value must be masked in tool output: secure
+// parameter names, credentialsJSON references and TeamCity-scrambled ("zxx") values
+func isSecretValue(name, value string) bool {
+	return isSecureProperty(name) || strings.HasPrefix(value, "credentialsJSON:") || strings.HasPrefix(value, "zxx")
+}
+
+// GetBuildParameters returns the parameter values a build actually used (its resulting properties), with secrets masked
+func (c *Client) GetBuildParameters(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID string `json:"buildId"`
+		Name    string `json:"name"`
+		Prefix  string `json:"prefix"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildID == "" {
+		return "", fmt.Errorf("buildId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_build_parameters", "success", time.Since(start).Seconds())
+	}()
+
+	resulting, err := c.getResultingProperties(ctx, req.BuildID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build parameters: %w", err)
+	}
+
+	names := make([]string, 0, len(resulting))
+	for name := range resulting {
+		if req.Prefix != "" && !strings.HasPrefix(name, req.Prefix) {
+			continue
+		}
+		if req.Name != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(req.Name)) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return fmt.Sprintf("No parameters found for build %s matching the specified criteria.", req.BuildID), nil
+	}
+
+	result := fmt.Sprintf("Found %d parameters used by build %s:\n\n", len(names), req.BuildID)
+	masked := 0
+	for _, name := range names {
+		value := resulting[name]
+		if isSecretValue(name, value) {
+			value = maskedValue
+			masked++
+		}
+		result += fmt.Sprintf("  %s = %s\n", name, value)
+	}
+	if masked > 0 {
+		result += fmt.Sprintf("\n%d secret value(s) masked.\n", masked)
+	}
+
+	return result, nil
+}
+
+// maskSecrets returns a copy of params with secret values masked
+func maskSecrets(params Properties) Properties {
+	masked := make(Properties, len(params))
+	for name, value := range params {
+		if isSecretValue(name, value) {
+			value = maskedValue
+		}
+		masked[name] = value
+	}
+	return masked
+}
+
+// parameterKinds lists the parameter types set_build_config_parameter can declare
+var parameterKinds = []string{"text", "password", "checkbox", "select"}
+
+// parameterChange is a new parameter value with an optional type specification
+type parameterChange struct {
+	Name           string   `json:"name"`
+	Value          *string  `json:"value"`
+	Type           string   `json:"type"`
+	Options        []string `json:"options"`
+	Label          string   `json:"label"`
+	Description    string   `json:"description"`
+	Display        string   `json:"display"`
+	Required       bool     `json:"required"`
+	CheckedValue   string   `json:"checkedValue"`
+	UncheckedValue string   `json:"uncheckedValue"`
+}
+
+// validate checks the type specification of a parameter change
+func (p parameterChange) validate() error {
+	if p.Type != "" && !containsString(parameterKinds, p.Type) {
+		return fmt.Errorf("invalid type %q: must be one of %s", p.Type, strings.Join(parameterKinds, ", "))
+	}
+	if len(p.Options) > 0 && p.Type != "select" {
+		return fmt.Errorf("options require type select")
+	}
+	if p.Type == "select" && len(p.Options) == 0 {
+		return fmt.Errorf("type select requires options")
+	}
+	if p.Display != "" && p.Display != "normal" && p.Display != "prompt" && p.Display != "hidden" {
+		return fmt.Errorf("invalid display: must be 'normal', 'prompt' or 'hidden'")
+	}
+	if p.Type == "" && (p.Label != "" || p.Description != "" || p.Display != "" || p.Required || p.CheckedValue != "" || p.UncheckedValue != "") {
+		return fmt.Errorf("label, description, display, required, checkedValue and uncheckedValue require type")
+	}
+	return nil
+}
+
+// setParameter creates or updates a parameter of a project or build configuration ("projects/id:X"
+// or "buildTypes/id:X") and describes the change; without a type the parameter keeps its current
+// specification
+func (c *Client) setParameter(ctx context.Context, owner, ownerID string, change parameterChange) (string, error) {
+	endpoint := fmt.Sprintf("/%s/parameters/%s", owner, url.PathEscape(change.Name))
+
+	// The current parameter, if any, provides the previous value and the specification to keep
+	var previous *Parameter
+	if respBody, err := c.makeRequest(ctx, "GET", endpoint+"?fields=name,value,inherited,type(rawValue)", nil); err == nil {
+		var param Parameter
+		if err := json.Unmarshal(respBody, &param); err == nil {
+			previous = &param
+		}
+	}
+
+	param := Parameter{Name: change.Name, Value: *change.Value}
+	if change.Type != "" {
+		spec := ParameterSpec{
+			Kind:           change.Type,
+			Label:          change.Label,
+			Description:    change.Description,
+			Display:        change.Display,
+			Required:       change.Required,
+			Options:        change.Options,
+			CheckedValue:   change.CheckedValue,
+			UncheckedValue: change.UncheckedValue,
+		}
+		if raw := spec.Raw(); raw != "" {
+			param.Type = &ParameterType{RawValue: raw}
+		}
+	} else if previous != nil {
+		param.Type = previous.Type
+	}
+
+	reqBody, err := json.Marshal(param)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal parameter: %w", err)
+	}
+	if _, err := c.makeRequest(ctx, "PUT", endpoint, reqBody); err != nil {
+		return "", fmt.Errorf("failed to set parameter: %w", err)
+	}
+
+	result := fmt.Sprintf("Parameter %s of %s set to %s", change.Name, ownerID, parameterDisplayValue(param))
+	if previous != nil {
+		was := parameterDisplayValue(*previous)
+		if previous.Inherited {
+			was += ", inherited"
+		}
+		result += fmt.Sprintf(" (was %s)", was)
+	}
+	if param.Type != nil {
+		result += fmt.Sprintf("\n  Spec: %s", param.Spec())
+	}
+	return result, nil
+}
+
+// SetBuildConfigParameter creates or updates a build configuration parameter, optionally with a
+// type specification; without one the parameter keeps its current specification
+func (c *Client) SetBuildConfigParameter(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId"`
+		parameterChange
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" || req.Name == "" || req.Value == nil {
+		return "", fmt.Errorf("buildTypeId, name and value are required")
+	}
+	if err := req.validate(); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("set_build_config_parameter", "success", time.Since(start).Seconds())
+	}()
+
+	return c.setParameter(ctx, "buildTypes/id:"+req.BuildTypeID, req.BuildTypeID, req.parameterChange)
+}
+
+// DeleteBuildConfigParameter removes a parameter from a build configuration; a parameter
+// inherited from a template or project falls back to its inherited value
+func (c *Client) DeleteBuildConfigParameter(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId"`
+		Name        string `json:"name"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" || req.Name == "" {
+		return "", fmt.Errorf("buildTypeId and name are required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("delete_build_config_parameter", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := fmt.Sprintf("/buildTypes/id:%s/parameters/%s", req.BuildTypeID, url.PathEscape(req.Name))
+	if _, err := c.makeRequest(ctx, "DELETE", endpoint, nil); err != nil {
+		return "", fmt.Errorf("failed to delete parameter: %w", err)
+	}
+	return fmt.Sprintf("Parameter %s deleted from %s", req.Name, req.BuildTypeID), nil
+}
+
+// parameterDisplayValue returns a parameter value for output, masking passwords and secret values
+func parameterDisplayValue(param Parameter) string {
+	if isSecretValue(param.Name, param.Value) {
+		return maskedValue
+	}
+	if value := param.DisplayValue(); value != "" {
+		return value
+	}
+	return `""`
+}
+
+// ManageProjectParameters reads, sets or deletes a project parameter; reading shows the effective
+// value and every project up the hierarchy that defines the parameter
+func (c *Client) ManageProjectParameters(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action    string `json:"action"`
+		ProjectID string `json:"projectId"`
+		parameterChange
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" || req.Name == "" {
+		return "", fmt.Errorf("projectId and name are required")
+	}
+	if req.Action == "" {
+		req.Action = "get"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_project_parameters", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "get":
+		chain, err := c.getProjectChain(ctx, req.ProjectID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get project hierarchy: %w", err)
+		}
+
+		// The nearest definition wins; the ones further up are overridden
+		var definitions []string
+		var effective *Parameter
+		for _, project := range chain {
+			params, err := c.getParameters(ctx, "projects/id:"+project.ID)
+			if err != nil {
+				return "", fmt.Errorf("failed to get parameters of %s: %w", project.ID, err)
+			}
+			for _, param := range params {
+				if param.Name != req.Name || param.Inherited {
+					continue
+				}
+				state := "overridden"
+				if effective == nil {
+					effective = &param
+					state = "effective"
+				}
+				definitions = append(definitions, fmt.Sprintf("  %s [%s]: %s (%s)", project.Name, project.ID, parameterDisplayValue(param), state))
+			}
+		}
+
+		if effective == nil {
+			return fmt.Sprintf("Parameter %s is not defined in project %s or its parent projects.", req.Name, req.ProjectID), nil
+		}
+		result := fmt.Sprintf("Parameter %s of project %s = %s\n", req.Name, req.ProjectID, parameterDisplayValue(*effective))
+		if effective.Type != nil {
+			result += fmt.Sprintf("Spec: %s\n", effective.Spec())
+		}
+		result += "Defined in (nearest first):\n" + strings.Join(definitions, "\n") + "\n"
+		return result, nil
+
+	case "set":
+		if req.Value == nil {
+			return "", fmt.Errorf("value is required for set action")
+		}
+		if err := req.validate(); err != nil {
+			return "", err
+		}
+		return c.setParameter(ctx, "projects/id:"+req.ProjectID, req.ProjectID, req.parameterChange)
+
+	case "delete":
+		endpoint := fmt.Sprintf("/projects/id:%s/parameters/%s", req.ProjectID, url.PathEscape(req.Name))
+		if _, err := c.makeRequest(ctx, "DELETE", endpoint, nil); err != nil {
+			return "", fmt.Errorf("failed to delete parameter: %w", err)
+		}
+		return fmt.Sprintf("Parameter %s deleted from project %s; subprojects and build configurations now use the value inherited from its parent projects, if any", req.Name, req.ProjectID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected get, set or delete)", req.Action)
+	}
+}
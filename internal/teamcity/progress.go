@@ -0,0 +1,187 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// runningBuildFields is the field selection of get_running_build_progress
+const runningBuildFields = "id,number,state,status,statusText,branchName,buildTypeId,buildType(name),agent(name),startDate," +
+	"running-info(percentageComplete,elapsedSeconds,estimatedTotalSeconds,currentStageText,outdated,probablyHanging,lastActivityTime)"
+
+// defaultProgressLogLines is the number of log lines get_running_build_progress returns by default
+const defaultProgressLogLines = 10
+
+// runningBuild is a build with the fields of runningBuildFields
+type runningBuild struct {
+	Build
+	StatusText string `json:"statusText"`
+	Agent      *Agent `json:"agent"`
+	// RunningInfo is only returned for running builds
+	RunningInfo *struct {
+		PercentageComplete    int    `json:"percentageComplete"`
+		ElapsedSeconds        int64  `json:"elapsedSeconds"`
+		EstimatedTotalSeconds int64  `json:"estimatedTotalSeconds"`
+		CurrentStageText      string `json:"currentStageText"`
+		Outdated              bool   `json:"outdated"`
+		ProbablyHanging       bool   `json:"probablyHanging"`
+		LastActivityTime      string `json:"lastActivityTime"`
+	} `json:"running-info"`
+}
+
+// GetRunningBuildProgress returns the live progress of a running build, including the last lines
+// of its log, or of all running builds, optionally of one build configuration
+func (c *Client) GetRunningBuildProgress(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID     string `json:"buildId"`
+		BuildTypeID string `json:"buildTypeId"`
+		LogLines    *int   `json:"logLines"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &req); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	logLines := defaultProgressLogLines
+	if req.LogLines != nil {
+		logLines = *req.LogLines
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_running_build_progress", "success", time.Since(start).Seconds())
+	}()
+
+	if req.BuildID != "" {
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s?fields=%s", url.PathEscape(req.BuildID), url.QueryEscape(runningBuildFields)), nil)
+		if err != nil {
+			return "", fmt.Errorf("build not found: %w", err)
+		}
+
+		var build runningBuild
+		if err := json.Unmarshal(respBody, &build); err != nil {
+			return "", fmt.Errorf("failed to parse build: %w", err)
+		}
+
+		switch build.State {
+		case "queued":
+			return fmt.Sprintf("Build ID %d of %s is still queued; use list_queued_builds to see why it has not started.", build.ID, build.BuildTypeID), nil
+		case "finished":
+			return fmt.Sprintf("Build #%s (ID: %d) of %s has finished with status %s.", build.Number, build.ID, build.BuildTypeID, build.Status), nil
+		}
+
+		result := c.formatBuildProgress(build)
+		if logLines > 0 {
+			tail, err := c.buildLogTail(ctx, build.ID, logLines)
+			if err != nil {
+				return "", fmt.Errorf("failed to get build log: %w", err)
+			}
+			result += fmt.Sprintf("  Last %d log lines:\n", len(tail))
+			for _, line := range tail {
+				result += "    " + line + "\n"
+			}
+		}
+		return result, nil
+	}
+
+	locator := "state:running"
+	if req.BuildTypeID != "" {
+		locator += fmt.Sprintf(",buildType:(id:%s)", req.BuildTypeID)
+	}
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape("build("+runningBuildFields+")")), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get running builds: %w", err)
+	}
+
+	var response struct {
+		Build []runningBuild `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse builds response: %w", err)
+	}
+
+	if len(response.Build) == 0 {
+		return "No builds are running.", nil
+	}
+	result := fmt.Sprintf("%d running builds:\n\n", len(response.Build))
+	for _, build := range response.Build {
+		result += c.formatBuildProgress(build) + "\n"
+	}
+	return result, nil
+}
+
+// formatBuildProgress renders the progress of a running build
+func (c *Client) formatBuildProgress(build runningBuild) string {
+	name := build.BuildType.Name
+	if name == "" {
+		name = build.BuildTypeID
+	}
+	result := fmt.Sprintf("Build #%s (ID: %d) of %s (%s)", build.Number, build.ID, name, build.BuildTypeID)
+	if build.BranchName != "" {
+		result += ", branch " + build.BranchName
+	}
+	if build.Agent != nil && build.Agent.Name != "" {
+		result += ", on agent " + build.Agent.Name
+	}
+	result += "\n"
+
+	if info := build.RunningInfo; info != nil {
+		elapsed := time.Duration(info.ElapsedSeconds) * time.Second
+		result += fmt.Sprintf("  Progress: %d%% complete\n", info.PercentageComplete)
+		if info.EstimatedTotalSeconds > 0 {
+			estimated := time.Duration(info.EstimatedTotalSeconds) * time.Second
+			result += fmt.Sprintf("  Elapsed: %s of estimated %s", elapsed, estimated)
+			if left := estimated - elapsed; left > 0 {
+				result += fmt.Sprintf(" (%s left)", left)
+			} else {
+				result += fmt.Sprintf(" (overtime by %s)", -left)
+			}
+			result += "\n"
+		} else {
+			result += fmt.Sprintf("  Elapsed: %s (no estimate)\n", elapsed)
+		}
+		if info.CurrentStageText != "" {
+			result += fmt.Sprintf("  Current stage: %s\n", info.CurrentStageText)
+		}
+		if info.ProbablyHanging {
+			result += "  Warning: the build is probably hanging"
+			if info.LastActivityTime != "" {
+				result += fmt.Sprintf(" (last activity %s)", c.formatTeamCityDate(info.LastActivityTime))
+			}
+			result += "\n"
+		}
+		if info.Outdated {
+			result += "  Note: the build is outdated; a build with newer changes has already finished\n"
+		}
+	}
+	if build.Status != "" {
+		result += fmt.Sprintf("  Status: %s", build.Status)
+		if build.StatusText != "" {
+			result += fmt.Sprintf(" (%s)", build.StatusText)
+		}
+		result += "\n"
+	}
+	return result
+}
+
+// buildLogTail returns the last lines of a build's plain text log
+func (c *Client) buildLogTail(ctx context.Context, buildID, lines int) ([]string, error) {
+	respBody, err := c.makeRawRequest(ctx, "GET", fmt.Sprintf("/downloadBuildLog.html?buildId=%d&plain=true", buildID), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	all := strings.Split(strings.TrimRight(string(respBody), "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return all, nil
+}
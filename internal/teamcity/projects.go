@@ -0,0 +1,419 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// rootProjectID is the ID of the TeamCity root project
+const rootProjectID = "_Root"
+
+// CreateProject creates a project under a parent project, the root project by default
+func (c *Client) CreateProject(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Name            string `json:"name"`
+		ID              string `json:"id"`
+		ParentProjectID string `json:"parentProjectId"`
+		Description     string `json:"description"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if req.ParentProjectID == "" {
+		req.ParentProjectID = rootProjectID
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("create_project", "success", time.Since(start).Seconds())
+	}()
+
+	// TeamCity generates the ID from the parent's ID and the name when none is given
+	project := map[string]interface{}{
+		"name":          req.Name,
+		"parentProject": map[string]string{"locator": "id:" + req.ParentProjectID},
+	}
+	if req.ID != "" {
+		project["id"] = req.ID
+	}
+
+	reqBody, err := json.Marshal(project)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal project: %w", err)
+	}
+
+	respBody, err := c.makeRequest(ctx, "POST", "/projects", reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create project: %w", err)
+	}
+
+	var created Project
+	if err := json.Unmarshal(respBody, &created); err != nil {
+		return "", fmt.Errorf("failed to parse project response: %w", err)
+	}
+
+	result := fmt.Sprintf("Project %s (%s) created in %s", created.Name, created.ID, req.ParentProjectID)
+	if req.Description != "" {
+		// The description cannot be set on creation
+		if _, err := c.makeRawRequest(ctx, "PUT", fmt.Sprintf("/app/rest/projects/id:%s/description", url.PathEscape(created.ID)), []byte(req.Description), "text/plain"); err != nil {
+			return "", fmt.Errorf("project %s was created, but setting its description failed: %w", created.ID, err)
+		}
+	}
+	if created.WebURL != "" {
+		result += "\nURL: " + created.WebURL
+	}
+	return result, nil
+}
+
+// ProjectTree is the project hierarchy with the build configurations and templates of each project
+type ProjectTree struct {
+	Type           string       `json:"type"`
+	Timestamp      string       `json:"timestamp"`
+	ProjectCount   int          `json:"projectCount"`
+	BuildTypeCount int          `json:"buildTypeCount"`
+	Root           *ProjectNode `json:"root"`
+}
+
+// ProjectNode is a project in the project tree
+type ProjectNode struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Archived   bool            `json:"archived"`
+	BuildTypes []BuildTypeNode `json:"buildTypes"`
+	Templates  []BuildTypeNode `json:"templates"`
+	Projects   []*ProjectNode  `json:"projects"`
+}
+
+// BuildTypeNode is a build configuration or template in the project tree
+type BuildTypeNode struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Paused bool   `json:"paused,omitempty"`
+}
+
+// GetProjectTree returns the whole project hierarchy, loaded with a single request
+func (c *Client) GetProjectTree(ctx context.Context) (*ProjectTree, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_project_tree", "success", time.Since(start).Seconds())
+	}()
+
+	fields := "project(id,name,parentProjectId,archived,buildTypes(buildType(id,name,paused)),templates(buildType(id,name)))"
+	respBody, err := c.makeRequest(ctx, "GET", "/projects?fields="+url.QueryEscape(fields), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	var response struct {
+		Project []struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			ParentProjectID string `json:"parentProjectId"`
+			Archived        bool   `json:"archived"`
+			BuildTypes      struct {
+				BuildType []BuildTypeNode `json:"buildType"`
+			} `json:"buildTypes"`
+			Templates struct {
+				BuildType []BuildTypeNode `json:"buildType"`
+			} `json:"templates"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse projects response: %w", err)
+	}
+
+	tree := &ProjectTree{
+		Type:      "project-tree",
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	nodes := make(map[string]*ProjectNode, len(response.Project))
+	for _, project := range response.Project {
+		node := &ProjectNode{
+			ID:         project.ID,
+			Name:       project.Name,
+			Archived:   project.Archived,
+			BuildTypes: make([]BuildTypeNode, 0, len(project.BuildTypes.BuildType)),
+			Templates:  make([]BuildTypeNode, 0, len(project.Templates.BuildType)),
+			Projects:   make([]*ProjectNode, 0),
+		}
+		node.BuildTypes = append(node.BuildTypes, project.BuildTypes.BuildType...)
+		node.Templates = append(node.Templates, project.Templates.BuildType...)
+		nodes[project.ID] = node
+		tree.ProjectCount++
+		tree.BuildTypeCount += len(node.BuildTypes)
+	}
+
+	// Projects are attached to their parents in the order TeamCity lists them; projects whose
+	// parent the token cannot see are attached to the root project
+	tree.Root = nodes[rootProjectID]
+	for _, project := range response.Project {
+		if project.ID == rootProjectID {
+			continue
+		}
+		parent, ok := nodes[project.ParentProjectID]
+		if !ok {
+			parent = tree.Root
+		}
+		if parent != nil {
+			parent.Projects = append(parent.Projects, nodes[project.ID])
+		}
+	}
+	return tree, nil
+}
+
+// ManageProjectFeatures lists, adds, updates or deletes the features of a project: issue tracker
+// connections, custom report tabs, cloud profiles, connections and similar project-level settings
+func (c *Client) ManageProjectFeatures(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action     string            `json:"action"`
+		ProjectID  string            `json:"projectId"`
+		Type       string            `json:"type"`
+		Properties map[string]string `json:"properties"`
+		FeatureID  string            `json:"featureId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" {
+		return "", fmt.Errorf("projectId is required")
+	}
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_project_features", "success", time.Since(start).Seconds())
+	}()
+
+	featuresEndpoint := fmt.Sprintf("/projects/id:%s/projectFeatures", url.PathEscape(req.ProjectID))
+
+	switch req.Action {
+	case "list":
+		respBody, err := c.makeRequest(ctx, "GET", featuresEndpoint+"?fields="+url.QueryEscape("projectFeature(id,type,properties(property(name,value)))"), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get project features: %w", err)
+		}
+
+		var response struct {
+			ProjectFeature []BuildFeature `json:"projectFeature"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return "", fmt.Errorf("failed to parse project features response: %w", err)
+		}
+
+		features := make([]BuildFeature, 0, len(response.ProjectFeature))
+		for _, feature := range response.ProjectFeature {
+			if req.Type == "" || strings.EqualFold(feature.Type, req.Type) {
+				features = append(features, feature)
+			}
+		}
+		if len(features) == 0 {
+			if req.Type != "" {
+				return fmt.Sprintf("Project %s has no %s features.", req.ProjectID, req.Type), nil
+			}
+			return fmt.Sprintf("Project %s has no features.", req.ProjectID), nil
+		}
+
+		result := fmt.Sprintf("Features of project %s (%d):\n", req.ProjectID, len(features))
+		for _, feature := range features {
+			result += "\n" + formatProjectFeature(feature)
+		}
+		return result, nil
+
+	case "add":
+		if req.Type == "" {
+			return "", fmt.Errorf("type is required for add action")
+		}
+
+		reqBody, err := json.Marshal(map[string]interface{}{
+			"type":       req.Type,
+			"properties": propertiesPayload(req.Properties),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal project feature: %w", err)
+		}
+
+		respBody, err := c.makeRequest(ctx, "POST", featuresEndpoint, reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to add project feature: %w", err)
+		}
+
+		var created BuildFeature
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse project feature response: %w", err)
+		}
+		return fmt.Sprintf("Project feature added to %s:\n%s", req.ProjectID, formatProjectFeature(created)), nil
+
+	case "update":
+		if req.FeatureID == "" {
+			return "", fmt.Errorf("featureId is required for update action")
+		}
+		if len(req.Properties) == 0 {
+			return "", fmt.Errorf("properties is required for update action")
+		}
+
+		// Properties are changed one by one so that the secure ones the API does not return are kept
+		names := make([]string, 0, len(req.Properties))
+		for name := range req.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		featureEndpoint := fmt.Sprintf("/app/rest/projects/id:%s/projectFeatures/%s/properties/", req.ProjectID, url.PathEscape(req.FeatureID))
+		for _, name := range names {
+			if _, err := c.makeRawRequest(ctx, "PUT", featureEndpoint+url.PathEscape(name), []byte(req.Properties[name]), "text/plain"); err != nil {
+				return "", fmt.Errorf("failed to set property %s: %w", name, err)
+			}
+		}
+		return fmt.Sprintf("Project feature %s of %s updated: %s", req.FeatureID, req.ProjectID, strings.Join(names, ", ")), nil
+
+	case "delete":
+		if req.FeatureID == "" {
+			return "", fmt.Errorf("featureId is required for delete action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", featuresEndpoint+"/"+url.PathEscape(req.FeatureID), nil); err != nil {
+			return "", fmt.Errorf("failed to delete project feature: %w", err)
+		}
+		return fmt.Sprintf("Project feature %s deleted from %s", req.FeatureID, req.ProjectID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, add, update or delete)", req.Action)
+	}
+}
+
+// formatProjectFeature renders a project feature with its properties; secrets are masked
+func formatProjectFeature(feature BuildFeature) string {
+	result := fmt.Sprintf("[%s] %s\n", feature.ID, feature.Type)
+
+	properties := make([]Parameter, len(feature.Properties.Property))
+	copy(properties, feature.Properties.Property)
+	sort.Slice(properties, func(i, j int) bool { return properties[i].Name < properties[j].Name })
+	for _, prop := range properties {
+		value := prop.Value
+		if isSecretValue(prop.Name, value) {
+			value = maskedValue
+		}
+		result += fmt.Sprintf("  %s: %s\n", prop.Name, value)
+	}
+	return result
+}
+
+// SearchProjects finds projects by name substring, parent project and archived state
+func (c *Client) SearchProjects(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Name            string `json:"name"`
+		ParentProjectID string `json:"parentProjectId"`
+		Recursive       bool   `json:"recursive"`
+		Archived        *bool  `json:"archived"`
+		Count           int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Count == 0 {
+		req.Count = 100
+	}
+	if req.Count < 0 || req.Count > 1000 {
+		return "", fmt.Errorf("count must be between 1 and 1000")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("search_projects", "success", time.Since(start).Seconds())
+	}()
+
+	// One more project than asked for tells whether the results were cut off
+	var filters []string
+	fetch := req.Count + 1
+	if req.Name != "" {
+		filters = append(filters, fmt.Sprintf("name:(value:(%s),matchType:contains,ignoreCase:true)", req.Name))
+	}
+	if req.ParentProjectID != "" {
+		if req.Recursive {
+			filters = append(filters, fmt.Sprintf("affectedProject:(id:%s)", req.ParentProjectID))
+			fetch++
+		} else {
+			filters = append(filters, fmt.Sprintf("parentProject:(id:%s)", req.ParentProjectID))
+		}
+	}
+	if req.Archived != nil {
+		filters = append(filters, fmt.Sprintf("archived:%t", *req.Archived))
+	}
+	filters = append(filters, fmt.Sprintf("count:%d", fetch))
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/projects?locator=%s&fields=%s",
+		url.QueryEscape(strings.Join(filters, ",")), url.QueryEscape("project(id,name,parentProjectId,archived,description,webUrl)")), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to search projects: %w", err)
+	}
+
+	var response struct {
+		Project []struct {
+			Project
+			ParentProjectID string `json:"parentProjectId"`
+			Archived        bool   `json:"archived"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse projects response: %w", err)
+	}
+
+	projects := response.Project
+	if req.ParentProjectID != "" && req.Recursive {
+		// affectedProject includes the parent project itself
+		for i, project := range projects {
+			if project.ID == req.ParentProjectID {
+				projects = append(projects[:i:i], projects[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(projects) == 0 {
+		return "No projects found.", nil
+	}
+
+	truncated := len(projects) > req.Count
+	if truncated {
+		projects = projects[:req.Count]
+	}
+
+	result := fmt.Sprintf("Found %d projects:\n\n", len(projects))
+	for _, project := range projects {
+		result += fmt.Sprintf("%s (%s)", project.Name, project.ID)
+		if project.Archived {
+			result += " [ARCHIVED]"
+		}
+		result += "\n"
+		if project.ParentProjectID != "" {
+			result += fmt.Sprintf("  Parent: %s\n", project.ParentProjectID)
+		}
+		if project.Description != "" {
+			result += fmt.Sprintf("  Description: %s\n", project.Description)
+		}
+		if project.WebURL != "" {
+			result += fmt.Sprintf("  URL: %s\n", project.WebURL)
+		}
+	}
+	if truncated {
+		result += fmt.Sprintf("\nOnly the first %d projects are shown; narrow the search or raise count to see more.\n", req.Count)
+	}
+	return result, nil
+}
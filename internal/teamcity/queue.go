@@ -0,0 +1,396 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// noCompatiblePool groups queued builds that no agent can currently run
+const noCompatiblePool = "(no compatible agents)"
+
+// QueueStats is a snapshot of the build queue
+type QueueStats struct {
+	Type              string          `json:"type"`
+	Timestamp         string          `json:"timestamp"`
+	Length            int             `json:"length"`
+	OldestBuildID     int             `json:"oldestBuildId,omitempty"`
+	OldestBuildTypeID string          `json:"oldestBuildTypeId,omitempty"`
+	OldestQueuedDate  string          `json:"oldestQueuedDate,omitempty"`
+	OldestAgeSeconds  int64           `json:"oldestAgeSeconds"`
+	Pools             []PoolQueueStat `json:"pools"`
+}
+
+// PoolQueueStat is the number of queued builds that can run on an agent pool
+type PoolQueueStat struct {
+	Pool   string `json:"pool"`
+	Queued int    `json:"queued"`
+}
+
+// GetQueueStats returns the current queue length, the age of the oldest queued build and a
+// per-pool breakdown; a queued build counts towards every pool with a compatible agent
+func (c *Client) GetQueueStats(ctx context.Context) (*QueueStats, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_queue_stats", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", "/buildQueue?fields=build(id,buildTypeId,queuedDate,compatibleAgents(agent(id,pool(id,name))))", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build queue: %w", err)
+	}
+
+	var response struct {
+		Build []struct {
+			Build
+			CompatibleAgents struct {
+				Agent []Agent `json:"agent"`
+			} `json:"compatibleAgents"`
+		} `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse build queue response: %w", err)
+	}
+
+	now := time.Now()
+	stats := &QueueStats{
+		Type:      "queue-stats",
+		Timestamp: now.Format(time.RFC3339),
+		Length:    len(response.Build),
+		Pools:     make([]PoolQueueStat, 0),
+	}
+
+	var oldest time.Time
+	perPool := make(map[string]int)
+	for _, build := range response.Build {
+		if queued, err := parseTeamCityDate(build.QueuedDate); err == nil && (oldest.IsZero() || queued.Before(oldest)) {
+			oldest = queued
+			stats.OldestBuildID = build.ID
+			stats.OldestBuildTypeID = build.BuildTypeID
+			stats.OldestQueuedDate = queued.Format(time.RFC3339)
+		}
+
+		pools := make(map[string]bool)
+		for _, agent := range build.CompatibleAgents.Agent {
+			if agent.Pool != nil {
+				pools[agent.Pool.Name] = true
+			}
+		}
+		if len(pools) == 0 {
+			pools[noCompatiblePool] = true
+		}
+		for pool := range pools {
+			perPool[pool]++
+		}
+	}
+
+	if !oldest.IsZero() {
+		stats.OldestAgeSeconds = int64(now.Sub(oldest).Seconds())
+	}
+
+	for pool, queued := range perPool {
+		stats.Pools = append(stats.Pools, PoolQueueStat{Pool: pool, Queued: queued})
+	}
+	sort.Slice(stats.Pools, func(i, j int) bool {
+		if stats.Pools[i].Queued != stats.Pools[j].Queued {
+			return stats.Pools[i].Queued > stats.Pools[j].Queued
+		}
+		return stats.Pools[i].Pool < stats.Pools[j].Pool
+	})
+
+	return stats, nil
+}
+
+// queuedBuildFields is the field selection of list_queued_builds
+const queuedBuildFields = "build(id,buildTypeId,buildType(name,projectId),branchName,queuedDate,startEstimate,waitReason," +
+	"personal,plannedAgent(name),triggered(type,details,user(username)),compatibleAgents(count))"
+
+// queuedBuild is a build in the queue with the fields of queuedBuildFields
+type queuedBuild struct {
+	Build
+	StartEstimate string `json:"startEstimate"`
+	WaitReason    string `json:"waitReason"`
+	Personal      bool   `json:"personal"`
+	PlannedAgent  *Agent `json:"plannedAgent"`
+	Triggered     *struct {
+		Type    string   `json:"type"`
+		Details string   `json:"details"`
+		User    *userRef `json:"user"`
+	} `json:"triggered"`
+	CompatibleAgents *struct {
+		Count int `json:"count"`
+	} `json:"compatibleAgents"`
+}
+
+// ListQueuedBuilds lists the build queue in order with each build's queue position, estimated
+// start time and wait reason, optionally filtered by build configuration or project
+func (c *Client) ListQueuedBuilds(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId,omitempty"`
+		ProjectID   string `json:"projectId,omitempty"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &req); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("list_queued_builds", "success", time.Since(start).Seconds())
+	}()
+
+	// The whole queue is fetched so that positions are positions in the queue, not in the filtered list
+	respBody, err := c.makeRequest(ctx, "GET", "/buildQueue?fields="+url.QueryEscape(queuedBuildFields), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build queue: %w", err)
+	}
+
+	var response struct {
+		Build []queuedBuild `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse build queue response: %w", err)
+	}
+
+	var projects map[string]bool
+	if req.ProjectID != "" {
+		if projects, err = c.affectedProjects(ctx, req.ProjectID); err != nil {
+			return "", fmt.Errorf("failed to get subprojects of %s: %w", req.ProjectID, err)
+		}
+	}
+
+	var filter string
+	switch {
+	case req.BuildTypeID != "":
+		filter = " of build configuration " + req.BuildTypeID
+	case req.ProjectID != "":
+		filter = " of project " + req.ProjectID
+	}
+
+	now := time.Now()
+	result := ""
+	shown := 0
+	for i, build := range response.Build {
+		if req.BuildTypeID != "" && build.BuildTypeID != req.BuildTypeID {
+			continue
+		}
+		if projects != nil && !projects[build.BuildType.ProjectID] {
+			continue
+		}
+		shown++
+		result += c.formatQueuedBuild(i+1, build, now)
+	}
+
+	if shown == 0 {
+		if len(response.Build) == 0 {
+			return "The build queue is empty.", nil
+		}
+		return fmt.Sprintf("No queued builds%s (%d builds in the queue).", filter, len(response.Build)), nil
+	}
+	return fmt.Sprintf("%d queued builds%s (%d builds in the queue):\n\n", shown, filter, len(response.Build)) + result, nil
+}
+
+// formatQueuedBuild renders a queued build at a queue position
+func (c *Client) formatQueuedBuild(position int, build queuedBuild, now time.Time) string {
+	name := build.BuildType.Name
+	if name == "" {
+		name = build.BuildTypeID
+	}
+	result := fmt.Sprintf("#%d %s (%s), build ID %d", position, name, build.BuildTypeID, build.ID)
+	if build.BranchName != "" {
+		result += fmt.Sprintf(", branch %s", build.BranchName)
+	}
+	if build.Personal {
+		result += ", personal"
+	}
+	result += "\n"
+
+	if queued, err := parseTeamCityDate(build.QueuedDate); err == nil {
+		result += fmt.Sprintf("   Queued: %s (waiting %s)\n", c.formatTeamCityDate(build.QueuedDate), now.Sub(queued).Round(time.Second))
+	}
+	if build.StartEstimate != "" {
+		result += fmt.Sprintf("   Estimated start: %s", c.formatTeamCityDate(build.StartEstimate))
+		if estimate, err := parseTeamCityDate(build.StartEstimate); err == nil && estimate.After(now) {
+			result += fmt.Sprintf(" (in %s)", estimate.Sub(now).Round(time.Second))
+		}
+		result += "\n"
+	} else {
+		result += "   Estimated start: unknown\n"
+	}
+	if build.PlannedAgent != nil && build.PlannedAgent.Name != "" {
+		result += fmt.Sprintf("   Planned agent: %s\n", build.PlannedAgent.Name)
+	}
+	if build.WaitReason != "" {
+		result += fmt.Sprintf("   Wait reason: %s\n", build.WaitReason)
+	}
+	if build.CompatibleAgents != nil {
+		result += fmt.Sprintf("   Compatible agents: %d\n", build.CompatibleAgents.Count)
+	}
+	if t := build.Triggered; t != nil {
+		result += fmt.Sprintf("   Triggered by: %s\n", describeTrigger(t.Type, t.Details, t.User, nil))
+	}
+	return result + "\n"
+}
+
+// affectedProjects returns the IDs of a project and all its subprojects
+func (c *Client) affectedProjects(ctx context.Context, projectID string) (map[string]bool, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/projects?locator=affectedProject:(id:%s)&fields=project(id)", url.QueryEscape(projectID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Project []Project `json:"project"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse projects response: %w", err)
+	}
+
+	projects := map[string]bool{projectID: true}
+	for _, project := range response.Project {
+		projects[project.ID] = true
+	}
+	return projects, nil
+}
+
+// ManageBuildQueue removes a queued build, moves one to the top of the queue, or moves several
+// to the top in the given order
+func (c *Client) ManageBuildQueue(ctx context.Context, args json.RawMessage) (_ string, err error) {
+	var req struct {
+		Action   string   `json:"action"`
+		BuildID  string   `json:"buildId"`
+		BuildIDs []string `json:"buildIds"`
+		Comment  string   `json:"comment,omitempty"`
+		DryRun   bool     `json:"dryRun,omitempty"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	start := time.Now()
+	defer func() {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		metrics.RecordTeamCityRequest("manage_build_queue", status, time.Since(start).Seconds())
+	}()
+
+	var ids []int
+	switch req.Action {
+	case "remove", "move_to_top":
+		if req.BuildID == "" {
+			return "", fmt.Errorf("buildId is required for %s action", req.Action)
+		}
+		req.BuildIDs = []string{req.BuildID}
+	case "reorder":
+		if len(req.BuildIDs) == 0 {
+			return "", fmt.Errorf("buildIds is required for reorder action")
+		}
+	case "":
+		return "", fmt.Errorf("action is required")
+	default:
+		return "", fmt.Errorf("unknown action: %s (must be remove, move_to_top or reorder)", req.Action)
+	}
+	for _, raw := range req.BuildIDs {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid build ID %q: %w", raw, err)
+		}
+		ids = append(ids, id)
+	}
+
+	queue, err := c.queueOrder(ctx)
+	if err != nil {
+		return "", err
+	}
+	positions := make(map[int]int, len(queue))
+	for i, build := range queue {
+		positions[build.ID] = i
+	}
+	for _, id := range ids {
+		if _, ok := positions[id]; !ok {
+			return "", fmt.Errorf("build %d is not in the queue", id)
+		}
+	}
+
+	var method, endpoint string
+	var body interface{}
+	switch req.Action {
+	case "remove":
+		method, endpoint = "POST", fmt.Sprintf("/buildQueue/id:%d", ids[0])
+		body = map[string]interface{}{"comment": req.Comment, "readdIntoQueue": false}
+	case "move_to_top":
+		method, endpoint = "PUT", "/buildQueue/order/1"
+		body = map[string]interface{}{"id": ids[0]}
+	case "reorder":
+		builds := make([]map[string]interface{}, 0, len(ids))
+		for _, id := range ids {
+			builds = append(builds, map[string]interface{}{"id": id})
+		}
+		method, endpoint = "PUT", "/buildQueue/order"
+		body = map[string]interface{}{"build": builds}
+	}
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal queue request: %w", err)
+	}
+
+	describe := func(id int) string {
+		build := queue[positions[id]]
+		return fmt.Sprintf("Build ID %d of %s (queue position #%d)", id, build.BuildTypeID, positions[id]+1)
+	}
+
+	tense := ""
+	if req.DryRun {
+		tense = "would be "
+	}
+	var result string
+	switch req.Action {
+	case "remove":
+		result = fmt.Sprintf("%s %sremoved from the queue\n", describe(ids[0]), tense)
+	case "move_to_top":
+		result = fmt.Sprintf("%s %smoved to the top of the queue\n", describe(ids[0]), tense)
+	case "reorder":
+		result = fmt.Sprintf("%d builds %smoved to the top of the queue in this order:\n", len(ids), tense)
+		for i, id := range ids {
+			result += fmt.Sprintf("  #%d %s\n", i+1, describe(id))
+		}
+	}
+
+	if req.DryRun {
+		return fmt.Sprintf("Dry run: the queue was not changed.\n\n%s\nRequest: %s /app/rest%s %s", result, method, endpoint, reqBody), nil
+	}
+
+	if _, err := c.makeRequest(ctx, method, endpoint, reqBody); err != nil {
+		return "", fmt.Errorf("failed to %s: %w", strings.ReplaceAll(req.Action, "_", " "), err)
+	}
+	return strings.TrimSuffix(result, "\n"), nil
+}
+
+// queueOrder returns the builds in the queue in queue order
+func (c *Client) queueOrder(ctx context.Context) ([]Build, error) {
+	respBody, err := c.makeRequest(ctx, "GET", "/buildQueue?fields=build(id,buildTypeId)", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build queue: %w", err)
+	}
+
+	var response struct {
+		Build []Build `json:"build"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse build queue response: %w", err)
+	}
+	return response.Build, nil
+}
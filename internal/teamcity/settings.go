@@ -0,0 +1,180 @@
+package teamcity
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// kotlinExportPath is the endpoint behind the "Download settings in Kotlin format" project action
+const kotlinExportPath = "/admin/projectSettingsExport.html"
+
+// kotlinBuildTypeObject matches the start of a build configuration declaration in Kotlin DSL
+var kotlinBuildTypeObject = regexp.MustCompile(`(?m)^object\s+(\w+)\s*:\s*BuildType\(`)
+
+// ExportSettings returns the Kotlin DSL or XML settings representation of a project or build configuration
+func (c *Client) ExportSettings(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID   string `json:"projectId"`
+		BuildTypeID string `json:"buildTypeId"`
+		Format      string `json:"format"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" && req.BuildTypeID == "" {
+		return "", fmt.Errorf("projectId or buildTypeId is required")
+	}
+	if req.Format == "" {
+		req.Format = "kotlin"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("export_settings", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Format {
+	case "xml":
+		endpoint := fmt.Sprintf("/app/rest/projects/id:%s", url.PathEscape(req.ProjectID))
+		target := "project " + req.ProjectID
+		if req.BuildTypeID != "" {
+			endpoint = fmt.Sprintf("/app/rest/buildTypes/id:%s", url.PathEscape(req.BuildTypeID))
+			target = "build configuration " + req.BuildTypeID
+		}
+
+		respBody, err := c.makeRawRequest(ctx, "GET", endpoint, nil, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to export settings: %w", err)
+		}
+		if int64(len(respBody)) > c.maxInlineSize {
+			return "", fmt.Errorf("settings of %s are %s, which exceeds the inline limit of %s", target, formatBytes(int64(len(respBody))), formatBytes(c.maxInlineSize))
+		}
+
+		return fmt.Sprintf("XML settings of %s:\n\n%s", target, string(respBody)), nil
+
+	case "kotlin":
+		projectID := req.ProjectID
+		if projectID == "" {
+			// Kotlin DSL is generated per project; export the configuration's project
+			respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s?fields=projectId", url.PathEscape(req.BuildTypeID)), nil)
+			if err != nil {
+				return "", fmt.Errorf("build configuration not found: %w", err)
+			}
+			var buildType BuildType
+			if err := json.Unmarshal(respBody, &buildType); err != nil {
+				return "", fmt.Errorf("failed to parse build configuration: %w", err)
+			}
+			projectID = buildType.ProjectID
+		}
+
+		endpoint := fmt.Sprintf("%s?projectId=%s&format=kotlin", kotlinExportPath, url.QueryEscape(projectID))
+		respBody, err := c.makeRawRequest(ctx, "GET", endpoint, nil, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to export settings: %w", err)
+		}
+
+		files, err := readTextArchive(respBody, c.maxInlineSize)
+		if err != nil {
+			return "", fmt.Errorf("failed to read exported settings: %w", err)
+		}
+
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		// A build configuration is shown on its own when its declaration can be found
+		if req.BuildTypeID != "" && req.ProjectID == "" {
+			for _, path := range paths {
+				if name, block := kotlinBuildTypeBlock(files[path], req.BuildTypeID, projectID); block != "" {
+					return fmt.Sprintf("Kotlin DSL of build configuration %s (object %s in %s of project %s):\n\n%s\n\n"+
+						"Export with projectId %s for the settings of the whole project.", req.BuildTypeID, name, path, projectID, block, projectID), nil
+				}
+			}
+		}
+
+		result := fmt.Sprintf("Kotlin DSL settings of project %s (%d files):\n", projectID, len(files))
+		if req.BuildTypeID != "" && req.ProjectID == "" {
+			result += fmt.Sprintf("Build configuration %s is defined in these project settings.\n", req.BuildTypeID)
+		}
+
+		for _, path := range paths {
+			result += fmt.Sprintf("\n=== %s ===\n%s\n", path, strings.TrimRight(files[path], "\n"))
+		}
+
+		return result, nil
+
+	default:
+		return "", fmt.Errorf("format must be kotlin or xml")
+	}
+}
+
+// readTextArchive reads all files of a zip archive as text, failing if their total size exceeds limit
+func readTextArchive(data []byte, limit int64) (map[string]string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	var total int64
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		total += int64(file.UncompressedSize64)
+		if total > limit {
+			return nil, fmt.Errorf("archive content exceeds the inline limit of %s", formatBytes(limit))
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, limit))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[file.Name] = string(content)
+	}
+
+	return files, nil
+}
+
+// kotlinBuildTypeBlock returns the object name and declaration of a build configuration in a Kotlin
+// DSL file, or "" when the file does not declare it. The object is matched by its name, which is the
+// ID relative to the project, or by an explicit id(...) call.
+func kotlinBuildTypeBlock(content, buildTypeID, projectID string) (string, string) {
+	relativeID := strings.TrimPrefix(buildTypeID, projectID+"_")
+	for _, loc := range kotlinBuildTypeObject.FindAllStringSubmatchIndex(content, -1) {
+		name := content[loc[2]:loc[3]]
+
+		// The declaration ends with the first "})" at the start of a line
+		block := content[loc[0]:]
+		if end := strings.Index(block, "\n})"); end >= 0 {
+			block = block[:end+len("\n})")]
+		}
+
+		if name == relativeID || name == buildTypeID ||
+			strings.Contains(block, fmt.Sprintf(`id("%s")`, buildTypeID)) || strings.Contains(block, fmt.Sprintf(`id("%s")`, relativeID)) {
+			return name, block
+		}
+	}
+	return "", ""
+}
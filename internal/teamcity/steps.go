@@ -0,0 +1,427 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// parameterReference matches TeamCity parameter references such as %env.PATH% or %build.number%
+var parameterReference = regexp.MustCompile(`%([^%\s]+)%`)
+
+// maskedValue replaces values of secure properties in tool output
+const maskedValue = "*****"
+
+// resolveReferences substitutes %name% parameter references with their values.
+// "%%" is a literal percent sign; unknown references are kept and returned separately.
+func resolveReferences(value string, params map[string]string) (string, []string) {
+	unresolved := make([]string, 0)
+
+	parts := strings.Split(value, "%%")
+	for i, part := range parts {
+		parts[i] = parameterReference.ReplaceAllStringFunc(part, func(ref string) string {
+			name := ref[1 : len(ref)-1]
+			if resolved, ok := params[name]; ok {
+				return resolved
+			}
+			if !containsString(unresolved, name) {
+				unresolved = append(unresolved, name)
+			}
+			return ref
+		})
+	}
+
+	return strings.Join(parts, "%"), unresolved
+}
+
+// isSecureProperty reports whether a property holds a secret that must not be shown
+func isSecureProperty(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "secure:") || strings.Contains(lower, "password") || strings.Contains(lower, "token")
+}
+
+// getResultingProperties returns the parameter values a build actually used
+func (c *Client) getResultingProperties(ctx context.Context, buildID string) (Properties, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s/resulting-properties", url.PathEscape(buildID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resulting Properties
+	if err := json.Unmarshal(respBody, &resulting); err != nil {
+		return nil, fmt.Errorf("failed to parse resulting properties: %w", err)
+	}
+	return resulting, nil
+}
+
+// GetResolvedBuildSteps shows the steps of a build's configuration with parameter references
+// resolved to the values the build actually used (its resulting properties)
+func (c *Client) GetResolvedBuildSteps(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildID         string `json:"buildId"`
+		IncludeDisabled bool   `json:"includeDisabled"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildID == "" {
+		return "", fmt.Errorf("buildId is required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_resolved_build_steps", "success", time.Since(start).Seconds())
+	}()
+
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/builds/id:%s?fields=id,number,buildTypeId,status,state", url.PathEscape(req.BuildID)), nil)
+	if err != nil {
+		return "", fmt.Errorf("build not found: %w", err)
+	}
+
+	var build Build
+	if err := json.Unmarshal(respBody, &build); err != nil {
+		return "", fmt.Errorf("failed to parse build: %w", err)
+	}
+
+	resulting, err := c.getResultingProperties(ctx, req.BuildID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get resulting properties: %w", err)
+	}
+	// Never expand secrets into the resolved step properties
+	resulting = maskSecrets(resulting)
+
+	steps, err := c.getBuildSteps(ctx, build.BuildTypeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get build steps: %w", err)
+	}
+
+	result := fmt.Sprintf("Build steps of build #%s (ID: %d, %s)\n", build.Number, build.ID, build.BuildTypeID)
+	result += "Steps are taken from the current configuration; parameter references are resolved with the values used by this build.\n\n"
+
+	if len(steps) == 0 {
+		result += "No build steps defined.\n"
+		return result, nil
+	}
+
+	for i, step := range steps {
+		if step.Disabled && !req.IncludeDisabled {
+			continue
+		}
+
+		result += fmt.Sprintf("%d. %s [%s]", i+1, step.Name, step.Type)
+		if step.Disabled {
+			result += " (disabled)"
+		}
+		result += "\n"
+
+		names := make([]string, 0, len(step.Properties))
+		for name := range step.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		unresolved := make([]string, 0)
+		for _, name := range names {
+			raw := step.Properties[name]
+			if isSecureProperty(name) {
+				result += fmt.Sprintf("  %s: %s\n", name, maskedValue)
+				continue
+			}
+
+			value, missing := resolveReferences(raw, resulting)
+			for _, m := range missing {
+				if !containsString(unresolved, m) {
+					unresolved = append(unresolved, m)
+				}
+			}
+
+			if strings.Contains(value, "\n") {
+				result += fmt.Sprintf("  %s:\n", name)
+				for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+					result += fmt.Sprintf("    %s\n", line)
+				}
+			} else {
+				result += fmt.Sprintf("  %s: %s\n", name, value)
+			}
+			if value != raw && !strings.Contains(raw, "\n") {
+				result += fmt.Sprintf("    (configured as: %s)\n", raw)
+			}
+		}
+
+		if len(unresolved) > 0 {
+			result += fmt.Sprintf("  Unresolved references: %s\n", strings.Join(unresolved, ", "))
+		}
+		result += "\n"
+	}
+
+	return result, nil
+}
+
+// ManageBuildSteps lists, adds, updates, reorders or deletes the build steps of a build configuration
+func (c *Client) ManageBuildSteps(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action      string            `json:"action"`
+		BuildTypeID string            `json:"buildTypeId"`
+		StepID      string            `json:"stepId"`
+		StepIDs     []string          `json:"stepIds"`
+		Type        string            `json:"type"`
+		Name        *string           `json:"name"`
+		Script      string            `json:"script"`
+		Properties  map[string]string `json:"properties"`
+		Disabled    *bool             `json:"disabled"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_build_steps", "success", time.Since(start).Seconds())
+	}()
+
+	stepsEndpoint := fmt.Sprintf("/buildTypes/id:%s/steps", url.PathEscape(req.BuildTypeID))
+
+	switch req.Action {
+	case "list":
+		steps, err := c.getBuildSteps(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get build steps: %w", err)
+		}
+		if len(steps) == 0 {
+			return fmt.Sprintf("%s has no build steps.", req.BuildTypeID), nil
+		}
+		result := fmt.Sprintf("Build steps of %s (%d):\n\n", req.BuildTypeID, len(steps))
+		for i, step := range steps {
+			result += formatBuildStep(i+1, step) + "\n"
+		}
+		return result, nil
+
+	case "add":
+		// A script makes a command line step unless another runner type is given
+		properties := make(map[string]string)
+		if req.Script != "" {
+			if req.Type == "" {
+				req.Type = "simpleRunner"
+			}
+			properties["script.content"] = req.Script
+			properties["use.custom.script"] = "true"
+		}
+		if req.Type == "" {
+			return "", fmt.Errorf("type or script is required for add action")
+		}
+		for name, value := range req.Properties {
+			properties[name] = value
+		}
+
+		step := map[string]interface{}{
+			"type":       req.Type,
+			"properties": propertiesPayload(properties),
+		}
+		if req.Name != nil {
+			step["name"] = *req.Name
+		}
+		if req.Disabled != nil {
+			step["disabled"] = *req.Disabled
+		}
+
+		reqBody, err := json.Marshal(step)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal build step: %w", err)
+		}
+
+		respBody, err := c.makeRequest(ctx, "POST", stepsEndpoint, reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to add build step: %w", err)
+		}
+
+		var created BuildStep
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse build step response: %w", err)
+		}
+		return fmt.Sprintf("Build step %s [%s] added to %s (ID: %s)", created.Name, created.Type, req.BuildTypeID, created.ID), nil
+
+	case "update":
+		if req.StepID == "" {
+			return "", fmt.Errorf("stepId is required for update action")
+		}
+		if req.Name == nil && req.Disabled == nil && req.Script == "" && len(req.Properties) == 0 {
+			return "", fmt.Errorf("name, disabled, script or properties is required for update action")
+		}
+
+		// Fields are changed one by one so that secure properties the API does not return are kept
+		stepEndpoint := fmt.Sprintf("/app/rest/buildTypes/id:%s/steps/%s", req.BuildTypeID, url.PathEscape(req.StepID))
+		properties := make(map[string]string, len(req.Properties)+1)
+		for name, value := range req.Properties {
+			properties[name] = value
+		}
+		if req.Script != "" {
+			properties["script.content"] = req.Script
+		}
+
+		changed := make([]string, 0)
+		if req.Name != nil {
+			if _, err := c.makeRawRequest(ctx, "PUT", stepEndpoint+"/name", []byte(*req.Name), "text/plain"); err != nil {
+				return "", fmt.Errorf("failed to rename build step: %w", err)
+			}
+			changed = append(changed, "renamed to "+*req.Name)
+		}
+
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// An empty value removes the property
+			endpoint := stepEndpoint + "/parameters/" + url.PathEscape(name)
+			if properties[name] == "" {
+				if _, err := c.makeRawRequest(ctx, "DELETE", endpoint, nil, "text/plain"); err != nil {
+					return "", fmt.Errorf("failed to remove property %s: %w", name, err)
+				}
+				changed = append(changed, name+" removed")
+				continue
+			}
+			if _, err := c.makeRawRequest(ctx, "PUT", endpoint, []byte(properties[name]), "text/plain"); err != nil {
+				return "", fmt.Errorf("failed to set property %s: %w", name, err)
+			}
+			changed = append(changed, name+" set")
+		}
+
+		if req.Disabled != nil {
+			if _, err := c.makeRawRequest(ctx, "PUT", stepEndpoint+"/disabled", []byte(fmt.Sprint(*req.Disabled)), "text/plain"); err != nil {
+				return "", fmt.Errorf("failed to change the disabled state of the build step: %w", err)
+			}
+			if *req.Disabled {
+				changed = append(changed, "disabled")
+			} else {
+				changed = append(changed, "enabled")
+			}
+		}
+
+		return fmt.Sprintf("Build step %s of %s updated: %s", req.StepID, req.BuildTypeID, strings.Join(changed, ", ")), nil
+
+	case "reorder":
+		if len(req.StepIDs) == 0 {
+			return "", fmt.Errorf("stepIds is required for reorder action")
+		}
+
+		steps, err := c.getBuildSteps(ctx, req.BuildTypeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get build steps: %w", err)
+		}
+		if len(req.StepIDs) != len(steps) {
+			return "", fmt.Errorf("stepIds must list all %d build steps of %s in the new order", len(steps), req.BuildTypeID)
+		}
+
+		byID := make(map[string]BuildStep, len(steps))
+		for _, step := range steps {
+			byID[step.ID] = step
+		}
+		ordered := make([]map[string]interface{}, 0, len(steps))
+		for _, id := range req.StepIDs {
+			step, ok := byID[id]
+			if !ok {
+				return "", fmt.Errorf("unknown or repeated build step: %s", id)
+			}
+			delete(byID, id)
+			// Reordering replaces all steps, which would drop the values of secure properties
+			for name := range step.Properties {
+				if strings.HasPrefix(name, "secure:") {
+					return "", fmt.Errorf("build step %s has secure properties that reordering would reset; reorder the steps in the TeamCity UI", id)
+				}
+			}
+			ordered = append(ordered, map[string]interface{}{
+				"id":         step.ID,
+				"name":       step.Name,
+				"type":       step.Type,
+				"disabled":   step.Disabled,
+				"properties": propertiesPayload(step.Properties),
+			})
+		}
+
+		reqBody, err := json.Marshal(map[string]interface{}{"step": ordered})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal build steps: %w", err)
+		}
+		if _, err := c.makeRequest(ctx, "PUT", stepsEndpoint, reqBody); err != nil {
+			return "", fmt.Errorf("failed to reorder build steps: %w", err)
+		}
+		return fmt.Sprintf("Build steps of %s reordered: %s", req.BuildTypeID, strings.Join(req.StepIDs, ", ")), nil
+
+	case "delete":
+		if req.StepID == "" {
+			return "", fmt.Errorf("stepId is required for delete action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", stepsEndpoint+"/"+url.PathEscape(req.StepID), nil); err != nil {
+			return "", fmt.Errorf("failed to delete build step: %w", err)
+		}
+		return fmt.Sprintf("Build step %s deleted from %s", req.StepID, req.BuildTypeID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, add, update, reorder or delete)", req.Action)
+	}
+}
+
+// getBuildSteps returns the build steps of a build configuration in execution order
+func (c *Client) getBuildSteps(ctx context.Context, buildTypeID string) ([]BuildStep, error) {
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/steps", url.PathEscape(buildTypeID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Step []BuildStep `json:"step"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse build steps: %w", err)
+	}
+	return response.Step, nil
+}
+
+// formatBuildStep renders a build step with its properties; secure properties are masked
+func formatBuildStep(position int, step BuildStep) string {
+	result := fmt.Sprintf("%d. %s [%s] (ID: %s)", position, step.Name, step.Type, step.ID)
+	if step.Disabled {
+		result += " (disabled)"
+	}
+	result += "\n"
+
+	names := make([]string, 0, len(step.Properties))
+	for name := range step.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := step.Properties[name]
+		if isSecureProperty(name) {
+			value = maskedValue
+		}
+		if strings.Contains(value, "\n") {
+			result += fmt.Sprintf("  %s:\n", name)
+			for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+				result += fmt.Sprintf("    %s\n", line)
+			}
+			continue
+		}
+		result += fmt.Sprintf("  %s: %s\n", name, value)
+	}
+	return result
+}
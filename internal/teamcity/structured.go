@@ -0,0 +1,183 @@
+package teamcity
+
+// StructuredResult is tool output with a machine-readable form alongside its text rendering.
+// MCP clients receive Text as content and Data as structuredContent.
+type StructuredResult struct {
+	Text string
+	Data interface{}
+}
+
+// BuildSearchResult is the structured result of a build search
+type BuildSearchResult struct {
+	Count  int           `json:"count"`
+	Builds []BuildResult `json:"builds"`
+}
+
+// BuildResult is a build in structured search results
+type BuildResult struct {
+	ID            int    `json:"id"`
+	Number        string `json:"number"`
+	Status        string `json:"status"`
+	State         string `json:"state"`
+	BuildTypeID   string `json:"buildTypeId"`
+	BuildTypeName string `json:"buildTypeName,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	Composite     bool   `json:"composite"`
+	QueuedDate    string `json:"queuedDate,omitempty"`
+	StartDate     string `json:"startDate,omitempty"`
+	FinishDate    string `json:"finishDate,omitempty"`
+	WebURL        string `json:"webUrl,omitempty"`
+}
+
+// TestResults is the structured result of a build's test results
+type TestResults struct {
+	BuildID string       `json:"buildId"`
+	Count   int          `json:"count"`
+	Start   int          `json:"start"`
+	HasMore bool         `json:"hasMore"`
+	Tests   []TestResult `json:"tests"`
+}
+
+// TestResult is a test occurrence in structured test results
+type TestResult struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Duration int    `json:"durationMs"`
+	Muted    bool   `json:"muted"`
+	Ignored  bool   `json:"ignored"`
+	// NewFailure is set for tests that failed in this build but not in the previous one
+	NewFailure bool   `json:"newFailure,omitempty"`
+	Details    string `json:"details,omitempty"`
+}
+
+// BuildConfigurationSearchResult is the structured result of a build configuration search
+type BuildConfigurationSearchResult struct {
+	Count               int                        `json:"count"`
+	BuildConfigurations []BuildConfigurationResult `json:"buildConfigurations"`
+}
+
+// BuildConfigurationResult is a build configuration in structured search results.
+// Parameters, steps and VCS roots are only set when details were requested.
+type BuildConfigurationResult struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	ProjectID   string            `json:"projectId"`
+	ProjectName string            `json:"projectName,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Parameters  []ParameterResult `json:"parameters,omitempty"`
+	Steps       []StepResult      `json:"steps,omitempty"`
+	VcsRoots    []VCSRootResult   `json:"vcsRoots,omitempty"`
+}
+
+// ParameterResult is a parameter in structured results
+type ParameterResult struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Origin    string `json:"origin,omitempty"`
+	Overrides string `json:"overrides,omitempty"`
+}
+
+// StepResult is a build step in structured results
+type StepResult struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+// VCSRootResult is a VCS root in structured results
+type VCSRootResult struct {
+	Name    string `json:"name"`
+	VcsName string `json:"vcsName"`
+}
+
+// AgentSearchResult is the structured result of an agent search
+type AgentSearchResult struct {
+	Count   int           `json:"count"`
+	HasMore bool          `json:"hasMore"`
+	Agents  []AgentResult `json:"agents"`
+}
+
+// AgentResult is a build agent in structured search results
+type AgentResult struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Connected          bool   `json:"connected"`
+	Enabled            bool   `json:"enabled"`
+	Authorized         bool   `json:"authorized"`
+	PoolID             *int   `json:"poolId,omitempty"`
+	PoolName           string `json:"poolName,omitempty"`
+	RunningBuildID     int    `json:"runningBuildId,omitempty"`
+	RunningBuildTypeID string `json:"runningBuildTypeId,omitempty"`
+	WebURL             string `json:"webUrl,omitempty"`
+}
+
+// newBuildResult converts a build into its structured form
+func newBuildResult(build Build) BuildResult {
+	return BuildResult{
+		ID:            build.ID,
+		Number:        build.Number,
+		Status:        build.Status,
+		State:         build.State,
+		BuildTypeID:   build.BuildTypeID,
+		BuildTypeName: build.BuildType.Name,
+		Branch:        build.BranchName,
+		Composite:     build.Composite,
+		QueuedDate:    build.QueuedDate,
+		StartDate:     build.StartDate,
+		FinishDate:    build.FinishDate,
+		WebURL:        build.WebURL,
+	}
+}
+
+// newBuildConfigurationResult converts a build configuration into its structured form
+func newBuildConfigurationResult(config DetailedBuildType, includeDetails bool) BuildConfigurationResult {
+	result := BuildConfigurationResult{
+		ID:          config.ID,
+		Name:        config.Name,
+		ProjectID:   config.ProjectID,
+		ProjectName: config.Project.Name,
+		Description: config.Description,
+	}
+	if !includeDetails {
+		return result
+	}
+
+	for _, param := range config.Parameters {
+		result.Parameters = append(result.Parameters, ParameterResult{
+			Name:      param.Name,
+			Value:     param.DisplayValue(),
+			Origin:    param.Origin,
+			Overrides: param.Overrides,
+		})
+	}
+	for _, step := range config.Steps {
+		result.Steps = append(result.Steps, StepResult{Name: step.Name, Type: step.Type, Disabled: step.Disabled})
+	}
+	for _, vcs := range config.VcsRoots {
+		result.VcsRoots = append(result.VcsRoots, VCSRootResult{Name: vcs.Name, VcsName: vcs.VcsName})
+	}
+	return result
+}
+
+// newAgentResult converts an agent into its structured form
+func newAgentResult(agent Agent) AgentResult {
+	result := AgentResult{
+		ID:         agent.ID,
+		Name:       agent.Name,
+		Connected:  agent.Connected,
+		Enabled:    agent.Enabled,
+		Authorized: agent.Authorized,
+		WebURL:     agent.WebURL,
+	}
+	if agent.Pool != nil {
+		poolID := agent.Pool.ID
+		result.PoolID = &poolID
+		result.PoolName = agent.Pool.Name
+	}
+	if agent.Build != nil {
+		result.RunningBuildID = agent.Build.ID
+		result.RunningBuildTypeID = agent.Build.BuildTypeID
+	}
+	return result
+}
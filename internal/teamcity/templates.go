@@ -0,0 +1,147 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// ManageTemplates lists the build configuration templates of a project or build configuration and
+// attaches or detaches a template to one or more build configurations
+func (c *Client) ManageTemplates(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action       string   `json:"action"`
+		ProjectID    string   `json:"projectId"`
+		BuildTypeID  string   `json:"buildTypeId"`
+		BuildTypeIDs []string `json:"buildTypeIds"`
+		TemplateID   string   `json:"templateId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_templates", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "list":
+		if req.BuildTypeID != "" {
+			respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes/id:%s/templates?fields=buildType(id,name,projectId)", url.PathEscape(req.BuildTypeID)), nil)
+			if err != nil {
+				return "", fmt.Errorf("failed to get templates: %w", err)
+			}
+			var response struct {
+				BuildType []BuildType `json:"buildType"`
+			}
+			if err := json.Unmarshal(respBody, &response); err != nil {
+				return "", fmt.Errorf("failed to parse templates response: %w", err)
+			}
+			if len(response.BuildType) == 0 {
+				return fmt.Sprintf("%s is not based on a template.", req.BuildTypeID), nil
+			}
+			result := fmt.Sprintf("Templates of %s (%d, in order of precedence):\n", req.BuildTypeID, len(response.BuildType))
+			for _, template := range response.BuildType {
+				result += fmt.Sprintf("  - %s (%s) in project %s\n", template.Name, template.ID, template.ProjectID)
+			}
+			return result, nil
+		}
+		if req.ProjectID == "" {
+			return "", fmt.Errorf("projectId or buildTypeId is required for list action")
+		}
+
+		locator := fmt.Sprintf("affectedProject:(id:%s),templateFlag:true", req.ProjectID)
+		respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/buildTypes?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape("buildType(id,name,projectId)")), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get templates: %w", err)
+		}
+		var response struct {
+			BuildType []BuildType `json:"buildType"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return "", fmt.Errorf("failed to parse templates response: %w", err)
+		}
+		if len(response.BuildType) == 0 {
+			return fmt.Sprintf("No templates found in project %s or its subprojects.", req.ProjectID), nil
+		}
+
+		result := fmt.Sprintf("Templates in project %s and its subprojects (%d):\n", req.ProjectID, len(response.BuildType))
+		for _, template := range response.BuildType {
+			users, err := c.entityNames(ctx, "buildTypes", "buildType", fmt.Sprintf("template:(id:%s)", template.ID))
+			if err != nil {
+				return "", fmt.Errorf("failed to get build configurations based on %s: %w", template.ID, err)
+			}
+			result += fmt.Sprintf("\n%s (%s) in project %s, used by %d build configurations\n", template.Name, template.ID, template.ProjectID, len(users))
+			for _, name := range users {
+				result += "  - " + name + "\n"
+			}
+		}
+		return result, nil
+
+	case "attach", "detach":
+		if req.TemplateID == "" {
+			return "", fmt.Errorf("templateId is required for %s action", req.Action)
+		}
+		buildTypeIDs := req.BuildTypeIDs
+		if req.BuildTypeID != "" {
+			buildTypeIDs = append([]string{req.BuildTypeID}, buildTypeIDs...)
+		}
+		if len(buildTypeIDs) == 0 {
+			return "", fmt.Errorf("buildTypeId or buildTypeIds is required for %s action", req.Action)
+		}
+
+		templateRef, err := json.Marshal(map[string]string{"id": req.TemplateID})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal template: %w", err)
+		}
+
+		// Each configuration is changed on its own; a failure does not stop the others
+		var done, failed []string
+		for _, id := range buildTypeIDs {
+			if req.Action == "attach" {
+				_, err = c.makeRequest(ctx, "POST", fmt.Sprintf("/buildTypes/id:%s/templates", url.PathEscape(id)), templateRef)
+			} else {
+				_, err = c.makeRequest(ctx, "DELETE", fmt.Sprintf("/buildTypes/id:%s/templates/id:%s", url.PathEscape(id), url.PathEscape(req.TemplateID)), nil)
+			}
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("  - %s: %v", id, err))
+				continue
+			}
+			done = append(done, "  - "+id)
+		}
+
+		if len(done) == 0 {
+			return "", fmt.Errorf("failed to %s template %s:\n%s", req.Action, req.TemplateID, strings.Join(failed, "\n"))
+		}
+
+		verb := "attached to"
+		if req.Action == "detach" {
+			verb = "detached from"
+		}
+		result := fmt.Sprintf("Template %s %s %d of %d build configurations\n", req.TemplateID, verb, len(done), len(buildTypeIDs))
+		for _, line := range done {
+			result += line + "\n"
+		}
+		if len(failed) > 0 {
+			result += fmt.Sprintf("\nFailed (%d):\n", len(failed))
+			for _, line := range failed {
+				result += line + "\n"
+			}
+		}
+		return result, nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected list, attach or detach)", req.Action)
+	}
+}
@@ -0,0 +1,127 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// maxComparedTests bounds the number of test occurrences compare_test_results loads per build
+const maxComparedTests = 10000
+
+// CompareTestResults diffs the test occurrences of two builds and lists the newly failed, fixed,
+// newly ignored, added and removed tests
+func (c *Client) CompareTestResults(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		FromBuildID string `json:"fromBuildId"`
+		ToBuildID   string `json:"toBuildId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.FromBuildID == "" || req.ToBuildID == "" {
+		return "", fmt.Errorf("fromBuildId and toBuildId are required")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("compare_test_results", "success", time.Since(start).Seconds())
+	}()
+
+	from, err := c.buildTestStatuses(ctx, req.FromBuildID)
+	if err != nil {
+		return "", err
+	}
+	to, err := c.buildTestStatuses(ctx, req.ToBuildID)
+	if err != nil {
+		return "", err
+	}
+
+	var newlyFailed, fixed, newlyIgnored, added, removed []string
+	stillFailing := 0
+	for name, status := range to {
+		before, ok := from[name]
+		switch {
+		case !ok:
+			added = append(added, fmt.Sprintf("%s (%s)", name, status))
+		case status == "FAILURE" && before == "FAILURE":
+			stillFailing++
+		case status == "FAILURE":
+			newlyFailed = append(newlyFailed, fmt.Sprintf("%s (was %s)", name, before))
+		case before == "FAILURE" && status == "SUCCESS":
+			fixed = append(fixed, name)
+		case status == "IGNORED" && before != "IGNORED":
+			newlyIgnored = append(newlyIgnored, fmt.Sprintf("%s (was %s)", name, before))
+		}
+	}
+	for name, status := range from {
+		if _, ok := to[name]; !ok {
+			removed = append(removed, fmt.Sprintf("%s (was %s)", name, status))
+		}
+	}
+
+	result := fmt.Sprintf("Tests of build %s compared to build %s (%d vs %d tests): %d newly failed, %d fixed, %d newly ignored, %d added, %d removed\n",
+		req.ToBuildID, req.FromBuildID, len(to), len(from), len(newlyFailed), len(fixed), len(newlyIgnored), len(added), len(removed))
+	for _, group := range []struct {
+		title string
+		tests []string
+	}{
+		{"Newly failed", newlyFailed},
+		{"Fixed", fixed},
+		{"Newly ignored", newlyIgnored},
+		{"Added", added},
+		{"Removed", removed},
+	} {
+		if len(group.tests) == 0 {
+			continue
+		}
+		sort.Strings(group.tests)
+		result += fmt.Sprintf("\n%s (%d):\n", group.title, len(group.tests))
+		for _, test := range group.tests {
+			result += "  - " + test + "\n"
+		}
+	}
+
+	if stillFailing > 0 {
+		result += fmt.Sprintf("\nStill failing: %d tests failed in both builds\n", stillFailing)
+	}
+	if len(from) >= maxComparedTests || len(to) >= maxComparedTests {
+		result += fmt.Sprintf("\nOnly the first %d tests of each build were compared.\n", maxComparedTests)
+	}
+	return result, nil
+}
+
+// buildTestStatuses returns the status of every test of a build by test name; ignored tests have
+// status IGNORED
+func (c *Client) buildTestStatuses(ctx context.Context, buildID string) (map[string]string, error) {
+	locator := fmt.Sprintf("build:(id:%s),count:%d", buildID, maxComparedTests)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/testOccurrences?locator=%s&fields=%s",
+		url.QueryEscape(locator), url.QueryEscape("testOccurrence(name,status,ignored)")), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tests of build %s: %w", buildID, err)
+	}
+
+	var response struct {
+		TestOccurrence []TestOccurrence `json:"testOccurrence"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse test results response: %w", err)
+	}
+
+	statuses := make(map[string]string, len(response.TestOccurrence))
+	for _, test := range response.TestOccurrence {
+		status := test.Status
+		if test.Ignored {
+			status = "IGNORED"
+		}
+		statuses[test.Name] = status
+	}
+	return statuses, nil
+}
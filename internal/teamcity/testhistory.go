@@ -0,0 +1,157 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// testRun is a run of a test in a build, as returned by get_test_history
+type testRun struct {
+	Status   string `json:"status"`
+	Duration int    `json:"duration"`
+	Muted    bool   `json:"muted"`
+	Ignored  bool   `json:"ignored"`
+	Build    Build  `json:"build"`
+}
+
+// GetTestHistory returns the pass/fail history of a test across the latest builds of a build
+// configuration with durations, and tells a new regression from an ongoing failure
+func (c *Client) GetTestHistory(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		BuildTypeID string `json:"buildTypeId"`
+		TestName    string `json:"testName"`
+		TestID      string `json:"testId"`
+		Count       int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.BuildTypeID == "" {
+		return "", fmt.Errorf("buildTypeId is required")
+	}
+	if (req.TestName == "") == (req.TestID == "") {
+		return "", fmt.Errorf("exactly one of testName or testId is required")
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = 20
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("get_test_history", "success", time.Since(start).Seconds())
+	}()
+
+	test := Test{ID: req.TestID, Name: req.TestName}
+	if test.ID == "" {
+		found, err := c.findTest(ctx, req.TestName)
+		if err != nil {
+			return "", err
+		}
+		if found == nil {
+			return fmt.Sprintf("No test named '%s' found; use search_tests to find its full name.", req.TestName), nil
+		}
+		test = *found
+	}
+
+	locator := fmt.Sprintf("test:(id:%s),buildType:(id:%s),count:%d", test.ID, req.BuildTypeID, count)
+	fields := "testOccurrence(status,duration,muted,ignored,build(id,number,branchName,finishDate))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/testOccurrences?locator=%s&fields=%s", url.QueryEscape(locator), url.QueryEscape(fields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get test history: %w", err)
+	}
+
+	var response struct {
+		TestOccurrence []testRun `json:"testOccurrence"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse test occurrences response: %w", err)
+	}
+
+	name := test.Name
+	if name == "" {
+		name = "Test " + test.ID
+	}
+	runs := response.TestOccurrence
+	if len(runs) == 0 {
+		return fmt.Sprintf("%s has not run in the recent builds of %s.", name, req.BuildTypeID), nil
+	}
+
+	result := fmt.Sprintf("History of %s in %s (last %d runs, newest first):\n", name, req.BuildTypeID, len(runs))
+	failed, total := 0, 0
+	for _, run := range runs {
+		result += "  " + c.describeTestRun(run) + "\n"
+		total += run.Duration
+		if run.Status == "FAILURE" {
+			failed++
+		}
+	}
+	result += fmt.Sprintf("\nFailed in %d of %d runs, avg duration %s\n", failed, len(runs), formatMillis(total/len(runs)))
+	return result + "Verdict: " + testVerdict(runs) + "\n", nil
+}
+
+// describeTestRun renders a run of a test on one line
+func (c *Client) describeTestRun(run testRun) string {
+	status := run.Status
+	if run.Ignored {
+		status = "IGNORED"
+	}
+	line := fmt.Sprintf("#%s (ID: %d): %s, %s", run.Build.Number, run.Build.ID, status, formatMillis(run.Duration))
+	if run.Muted {
+		line += " [MUTED]"
+	}
+	if run.Build.BranchName != "" {
+		line += fmt.Sprintf(" [%s]", run.Build.BranchName)
+	}
+	if run.Build.FinishDate != "" {
+		line += ", finished " + c.formatTeamCityDate(run.Build.FinishDate)
+	}
+	return line
+}
+
+// testVerdict classifies the history of a test, given its runs newest first
+func testVerdict(runs []testRun) string {
+	// Ignored runs say nothing about whether the test passes
+	var judged []testRun
+	for _, run := range runs {
+		if !run.Ignored {
+			judged = append(judged, run)
+		}
+	}
+	if len(judged) == 0 {
+		return "the test was ignored in every analyzed run"
+	}
+
+	streak := 0
+	for streak < len(judged) && judged[streak].Status == "FAILURE" {
+		streak++
+	}
+	flips := 0
+	for i := 1; i < len(judged); i++ {
+		if (judged[i].Status == "FAILURE") != (judged[i-1].Status == "FAILURE") {
+			flips++
+		}
+	}
+
+	switch {
+	case streak == len(judged):
+		return fmt.Sprintf("ongoing failure; failing in all %d analyzed runs, so it started before build #%s", streak, judged[streak-1].Build.Number)
+	case streak > 0 && flips > 2:
+		return fmt.Sprintf("failing in the last %d runs, but flaky: the status changed %d times", streak, flips)
+	case streak > 0:
+		return fmt.Sprintf("new regression; failing since build #%s (ID: %d) after passing in build #%s (ID: %d)",
+			judged[streak-1].Build.Number, judged[streak-1].Build.ID, judged[streak].Build.Number, judged[streak].Build.ID)
+	case flips > 0:
+		return fmt.Sprintf("passing now, but failed before; the status changed %d times", flips)
+	default:
+		return fmt.Sprintf("passing in all %d analyzed runs", len(judged))
+	}
+}
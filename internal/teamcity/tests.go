@@ -0,0 +1,162 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// Test represents a TeamCity test, independent of its individual runs
+type Test struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// findTest returns the test with the given full name, or nil when there is none
+func (c *Client) findTest(ctx context.Context, name string) (*Test, error) {
+	locator := fmt.Sprintf("name:(value:(%s),matchType:equals),count:1", name)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/tests?locator=%s&fields=test(id,name)", url.QueryEscape(locator)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find test: %w", err)
+	}
+
+	var response struct {
+		Test []Test `json:"test"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse tests response: %w", err)
+	}
+	if len(response.Test) == 0 {
+		return nil, nil
+	}
+	return &response.Test[0], nil
+}
+
+// formatMillis formats a test duration in milliseconds
+func formatMillis(ms int) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%d ms", ms)
+	}
+	return fmt.Sprintf("%.2f s", float64(ms)/1000.0)
+}
+
+// SearchTests finds tests by (partial) name across a project and summarizes their recent runs
+func (c *Client) SearchTests(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID   string `json:"projectId"`
+		Name        string `json:"name"`
+		MaxTests    int    `json:"maxTests"`
+		Occurrences int    `json:"occurrences"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.ProjectID == "" {
+		return "", fmt.Errorf("projectId is required")
+	}
+	if req.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	maxTests := req.MaxTests
+	if maxTests == 0 {
+		maxTests = 10
+	}
+	occurrences := req.Occurrences
+	if occurrences == 0 {
+		occurrences = 10
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("search_tests", "success", time.Since(start).Seconds())
+	}()
+
+	locator := fmt.Sprintf("affectedProject:(id:%s),name:(value:(%s),matchType:contains,ignoreCase:true),count:%d",
+		req.ProjectID, url.QueryEscape(req.Name), maxTests)
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/tests?locator=%s&fields=test(id,name)", locator), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to search tests: %w", err)
+	}
+
+	var response struct {
+		Test []Test `json:"test"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse tests response: %w", err)
+	}
+
+	if len(response.Test) == 0 {
+		return fmt.Sprintf("No tests matching '%s' found in project %s.", req.Name, req.ProjectID), nil
+	}
+
+	result := fmt.Sprintf("Found %d test(s) matching '%s' in project %s:\n\n", len(response.Test), req.Name, req.ProjectID)
+	for _, test := range response.Test {
+		result += fmt.Sprintf("%s\n", test.Name)
+
+		endpoint := fmt.Sprintf("/testOccurrences?locator=test:(id:%s),count:%d&fields=testOccurrence(status,duration,muted,build(id,number,buildTypeId,branchName,buildType(name)))", test.ID, occurrences)
+		respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			c.logger.Warnw("Failed to get test occurrences", "testId", test.ID, "error", err)
+			result += "  Recent runs: unavailable\n\n"
+			continue
+		}
+
+		var runs struct {
+			TestOccurrence []struct {
+				Status   string `json:"status"`
+				Duration int    `json:"duration"`
+				Muted    bool   `json:"muted"`
+				Build    Build  `json:"build"`
+			} `json:"testOccurrence"`
+		}
+		if err := json.Unmarshal(respBody, &runs); err != nil {
+			return "", fmt.Errorf("failed to parse test occurrences response: %w", err)
+		}
+
+		if len(runs.TestOccurrence) == 0 {
+			result += "  No recent runs\n\n"
+			continue
+		}
+
+		// Occurrences are returned newest first
+		latest := runs.TestOccurrence[0]
+		status := latest.Status
+		if latest.Muted {
+			status += " [MUTED]"
+		}
+		result += fmt.Sprintf("  Current Status: %s (build #%s, ID: %d)\n", status, latest.Build.Number, latest.Build.ID)
+
+		configs := make(map[string]string)
+		durations := make([]string, 0, len(runs.TestOccurrence))
+		total, failed := 0, 0
+		for _, run := range runs.TestOccurrence {
+			configs[run.Build.BuildTypeID] = run.Build.BuildType.Name
+			durations = append(durations, formatMillis(run.Duration))
+			total += run.Duration
+			if run.Status == "FAILURE" {
+				failed++
+			}
+		}
+
+		runsIn := make([]string, 0, len(configs))
+		for id, name := range configs {
+			runsIn = append(runsIn, fmt.Sprintf("%s (%s)", name, id))
+		}
+		sort.Strings(runsIn)
+
+		result += fmt.Sprintf("  Runs In: %s\n", strings.Join(runsIn, ", "))
+		result += fmt.Sprintf("  Recent Runs: %d (%d failed)\n", len(runs.TestOccurrence), failed)
+		result += fmt.Sprintf("  Recent Durations: %s (avg %s)\n\n", strings.Join(durations, ", "), formatMillis(total/len(runs.TestOccurrence)))
+	}
+
+	return result, nil
+}
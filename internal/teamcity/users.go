@@ -0,0 +1,261 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// NotificationRule represents a TeamCity user notification rule
+type NotificationRule struct {
+	ID       string `json:"id,omitempty"`
+	Notifier struct {
+		Type string `json:"type"`
+	} `json:"notifier"`
+	WatchedBuilds struct {
+		Project    *Project `json:"project,omitempty"`
+		BuildTypes struct {
+			BuildType []BuildType `json:"buildType,omitempty"`
+		} `json:"buildTypes,omitempty"`
+		Branch string `json:"branchFilter,omitempty"`
+	} `json:"watchedBuilds"`
+	Events     map[string]bool `json:"events,omitempty"`
+	Properties struct {
+		Property []Parameter `json:"property,omitempty"`
+	} `json:"properties,omitempty"`
+}
+
+// notificationEvents lists the build events a notification rule can subscribe to
+var notificationEvents = []string{
+	"buildStarted",
+	"buildFailedToStart",
+	"buildFailed",
+	"firstFailureAfterSuccess",
+	"buildFinishedSuccessfully",
+	"firstSuccessAfterFailure",
+	"buildProbablyHanging",
+	"responsibilityChanged",
+}
+
+// userLocator converts a user reference (current, numeric ID or username) to a TeamCity locator
+func userLocator(user string) string {
+	if user == "" || user == "current" {
+		return "current"
+	}
+	if strings.Contains(user, ":") {
+		return user
+	}
+	if _, err := strconv.Atoi(user); err == nil {
+		return "id:" + user
+	}
+	return "username:" + user
+}
+
+// ManageNotificationRules lists, adds or removes notification rules of a user
+func (c *Client) ManageNotificationRules(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action       string   `json:"action"`
+		User         string   `json:"user"`
+		RuleID       string   `json:"ruleId"`
+		Notifier     string   `json:"notifier"`
+		BuildTypeIDs []string `json:"buildTypeIds"`
+		ProjectID    string   `json:"projectId"`
+		Branch       string   `json:"branch"`
+		Events       []string `json:"events"`
+		SlackChannel string   `json:"slackChannel"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Action == "" {
+		req.Action = "list"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_notification_rules", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := fmt.Sprintf("/users/%s/notificationRules", url.PathEscape(userLocator(req.User)))
+
+	switch req.Action {
+	case "list":
+		respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get notification rules: %w", err)
+		}
+
+		var response struct {
+			Count            int                `json:"count"`
+			NotificationRule []NotificationRule `json:"notificationRule"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil {
+			return "", fmt.Errorf("failed to parse notification rules response: %w", err)
+		}
+
+		return formatNotificationRules(req.User, response.NotificationRule), nil
+
+	case "add":
+		if req.Notifier == "" {
+			req.Notifier = "email"
+		}
+		if req.ProjectID == "" && len(req.BuildTypeIDs) == 0 {
+			return "", fmt.Errorf("projectId or buildTypeIds is required")
+		}
+		if len(req.Events) == 0 {
+			req.Events = []string{"buildFailed"}
+		}
+
+		notifier := map[string]interface{}{}
+		properties := make([]map[string]string, 0)
+		switch req.Notifier {
+		case "email":
+			notifier["type"] = "email"
+		case "slack":
+			if req.SlackChannel == "" {
+				return "", fmt.Errorf("slackChannel is required for slack notifier")
+			}
+			notifier["type"] = "slackNotifier"
+			properties = append(properties, map[string]string{
+				"name":  "channel",
+				"value": req.SlackChannel,
+			})
+		default:
+			return "", fmt.Errorf("invalid notifier: must be 'email' or 'slack'")
+		}
+
+		watchedBuilds := map[string]interface{}{}
+		if req.ProjectID != "" {
+			watchedBuilds["project"] = map[string]string{"id": req.ProjectID}
+		}
+		if len(req.BuildTypeIDs) > 0 {
+			buildTypes := make([]map[string]string, 0, len(req.BuildTypeIDs))
+			for _, id := range req.BuildTypeIDs {
+				buildTypes = append(buildTypes, map[string]string{"id": id})
+			}
+			watchedBuilds["buildTypes"] = map[string]interface{}{
+				"buildType": buildTypes,
+			}
+		}
+		if req.Branch != "" {
+			watchedBuilds["branchFilter"] = req.Branch
+		}
+
+		events := make(map[string]bool, len(req.Events))
+		for _, event := range req.Events {
+			if !isNotificationEvent(event) {
+				return "", fmt.Errorf("invalid event %q: must be one of %s", event, strings.Join(notificationEvents, ", "))
+			}
+			events[event] = true
+		}
+
+		rule := map[string]interface{}{
+			"notifier":      notifier,
+			"watchedBuilds": watchedBuilds,
+			"events":        events,
+		}
+		if len(properties) > 0 {
+			rule["properties"] = map[string]interface{}{
+				"property": properties,
+			}
+		}
+
+		reqBody, err := json.Marshal(rule)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal notification rule: %w", err)
+		}
+
+		respBody, err := c.makeRequest(ctx, "POST", endpoint, reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to add notification rule: %w", err)
+		}
+
+		var created NotificationRule
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse notification rule response: %w", err)
+		}
+
+		return fmt.Sprintf("Notification rule %s added (%s on %s)", created.ID, req.Notifier, strings.Join(req.Events, ", ")), nil
+
+	case "delete":
+		if req.RuleID == "" {
+			return "", fmt.Errorf("ruleId is required for delete action")
+		}
+
+		_, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("%s/id:%s", endpoint, url.PathEscape(req.RuleID)), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to delete notification rule: %w", err)
+		}
+
+		return fmt.Sprintf("Notification rule %s deleted", req.RuleID), nil
+
+	default:
+		return "", fmt.Errorf("invalid action: must be 'list', 'add' or 'delete'")
+	}
+}
+
+// isNotificationEvent reports whether event is a known notification event name
+func isNotificationEvent(event string) bool {
+	for _, e := range notificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNotificationRules formats notification rules for display
+func formatNotificationRules(user string, rules []NotificationRule) string {
+	if user == "" {
+		user = "current"
+	}
+
+	if len(rules) == 0 {
+		return fmt.Sprintf("No notification rules found for user %s.", user)
+	}
+
+	result := fmt.Sprintf("Found %d notification rules for user %s:\n\n", len(rules), user)
+	for _, rule := range rules {
+		result += fmt.Sprintf("Rule %s [%s]\n", rule.ID, rule.Notifier.Type)
+
+		if rule.WatchedBuilds.Project != nil {
+			result += fmt.Sprintf("  Project: %s\n", rule.WatchedBuilds.Project.ID)
+		}
+		if len(rule.WatchedBuilds.BuildTypes.BuildType) > 0 {
+			ids := make([]string, 0, len(rule.WatchedBuilds.BuildTypes.BuildType))
+			for _, bt := range rule.WatchedBuilds.BuildTypes.BuildType {
+				ids = append(ids, bt.ID)
+			}
+			result += fmt.Sprintf("  Build Configurations: %s\n", strings.Join(ids, ", "))
+		}
+		if rule.WatchedBuilds.Branch != "" {
+			result += fmt.Sprintf("  Branch Filter: %s\n", rule.WatchedBuilds.Branch)
+		}
+
+		events := make([]string, 0)
+		for _, event := range notificationEvents {
+			if rule.Events[event] {
+				events = append(events, event)
+			}
+		}
+		if len(events) > 0 {
+			result += fmt.Sprintf("  Events: %s\n", strings.Join(events, ", "))
+		}
+
+		for _, prop := range rule.Properties.Property {
+			result += fmt.Sprintf("  %s: %s\n", prop.Name, prop.Value)
+		}
+
+		result += "\n"
+	}
+
+	return result
+}
@@ -0,0 +1,384 @@
+package teamcity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+)
+
+// vcsRootKeyProperties are the VCS root properties shown when listing VCS roots, in display order
+var vcsRootKeyProperties = []struct{ name, title string }{
+	{"url", "URL"},
+	{"push_url", "Push URL"},
+	{"branch", "Default branch"},
+	{"teamcity:branchSpec", "Branch spec"},
+	{"authMethod", "Auth method"},
+	{"username", "Username"},
+	{"user", "Username"},
+}
+
+// ListVCSRoots lists VCS roots filtered by project, VCS type and fetch URL, optionally with the
+// build configurations using each of them
+func (c *Client) ListVCSRoots(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		ProjectID    string `json:"projectId"`
+		Type         string `json:"type"`
+		URL          string `json:"url"`
+		IncludeUsage bool   `json:"includeUsage"`
+		Count        int    `json:"count"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.Count == 0 {
+		req.Count = 100
+	}
+	if req.Count < 0 || req.Count > 1000 {
+		return "", fmt.Errorf("count must be between 1 and 1000")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("list_vcs_roots", "success", time.Since(start).Seconds())
+	}()
+
+	endpoint := "/vcs-roots?fields=" + url.QueryEscape("vcs-root(id,name,vcsName,project(id),properties(property(name,value)))")
+	if req.ProjectID != "" {
+		endpoint += "&locator=" + url.QueryEscape(fmt.Sprintf("affectedProject:(id:%s)", req.ProjectID))
+	}
+	respBody, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get VCS roots: %w", err)
+	}
+
+	var response struct {
+		VCSRoot []struct {
+			VCSRoot
+			Project Project `json:"project"`
+		} `json:"vcs-root"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse VCS roots response: %w", err)
+	}
+
+	// The type and URL are matched as substrings, so "git" matches jetbrains.git
+	result := ""
+	found, truncated := 0, false
+	for _, root := range response.VCSRoot {
+		if req.Type != "" && !strings.Contains(strings.ToLower(root.VcsName), strings.ToLower(req.Type)) {
+			continue
+		}
+		if req.URL != "" && !strings.Contains(strings.ToLower(root.Properties["url"]), strings.ToLower(req.URL)) {
+			continue
+		}
+		if found == req.Count {
+			truncated = true
+			break
+		}
+		found++
+
+		result += fmt.Sprintf("%s (%s)\n  Type: %s\n  Project: %s\n", root.Name, root.ID, root.VcsName, root.Project.ID)
+		shown := make(map[string]bool)
+		for _, key := range vcsRootKeyProperties {
+			value := root.Properties[key.name]
+			if value == "" || shown[key.title] {
+				continue
+			}
+			shown[key.title] = true
+			if isSecretValue(key.name, value) {
+				value = maskedValue
+			}
+			result += fmt.Sprintf("  %s: %s\n", key.title, value)
+		}
+
+		if req.IncludeUsage {
+			names, err := c.entityNames(ctx, "buildTypes", "buildType", fmt.Sprintf("vcsRoot:(id:%s)", root.ID))
+			if err != nil {
+				return "", fmt.Errorf("failed to list build configurations using %s: %w", root.ID, err)
+			}
+			result += formatEntityGroups([]entityGroup{{"Used by", names}})
+		}
+		result += "\n"
+	}
+
+	if found == 0 {
+		return "No VCS roots found.", nil
+	}
+	result = fmt.Sprintf("Found %d VCS roots:\n\n", found) + result
+	if truncated {
+		result += fmt.Sprintf("Only the first %d VCS roots are shown; narrow the search or raise count to see more.\n", req.Count)
+	}
+	return result, nil
+}
+
+// ManageVCSRoots creates a VCS root, changes its name and properties, or attaches it to or detaches
+// it from a build configuration
+func (c *Client) ManageVCSRoots(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action        string            `json:"action"`
+		VCSRootID     string            `json:"vcsRootId"`
+		ProjectID     string            `json:"projectId"`
+		Name          string            `json:"name"`
+		Type          string            `json:"type"`
+		Properties    map[string]string `json:"properties"`
+		BuildTypeID   string            `json:"buildTypeId"`
+		CheckoutRules string            `json:"checkoutRules"`
+
+		// Shorthands for the common VCS root properties
+		URL        *string `json:"url"`
+		Branch     *string `json:"branch"`
+		BranchSpec *string `json:"branchSpec"`
+		AuthMethod *string `json:"authMethod"`
+		Username   *string `json:"username"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	properties := make(map[string]string, len(req.Properties))
+	for name, value := range req.Properties {
+		properties[name] = value
+	}
+	for name, value := range map[string]*string{
+		"url":                 req.URL,
+		"branch":              req.Branch,
+		"teamcity:branchSpec": req.BranchSpec,
+		"authMethod":          req.AuthMethod,
+		"username":            req.Username,
+	} {
+		if value != nil {
+			properties[name] = *value
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("manage_vcs_roots", "success", time.Since(start).Seconds())
+	}()
+
+	switch req.Action {
+	case "create":
+		if req.ProjectID == "" || req.Name == "" {
+			return "", fmt.Errorf("projectId and name are required for create action")
+		}
+		if req.Type == "" {
+			req.Type = "jetbrains.git"
+		}
+
+		root := map[string]interface{}{
+			"name":       req.Name,
+			"vcsName":    req.Type,
+			"project":    map[string]string{"id": req.ProjectID},
+			"properties": propertiesPayload(properties),
+		}
+		if req.VCSRootID != "" {
+			root["id"] = req.VCSRootID
+		}
+
+		reqBody, err := json.Marshal(root)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal VCS root: %w", err)
+		}
+		respBody, err := c.makeRequest(ctx, "POST", "/vcs-roots", reqBody)
+		if err != nil {
+			return "", fmt.Errorf("failed to create VCS root: %w", err)
+		}
+
+		var created VCSRoot
+		if err := json.Unmarshal(respBody, &created); err != nil {
+			return "", fmt.Errorf("failed to parse VCS root response: %w", err)
+		}
+		return fmt.Sprintf("VCS root %s (%s) created in %s", created.Name, created.ID, req.ProjectID), nil
+
+	case "update":
+		if req.VCSRootID == "" {
+			return "", fmt.Errorf("vcsRootId is required for update action")
+		}
+		if req.Name == "" && len(properties) == 0 {
+			return "", fmt.Errorf("name, properties or a VCS root setting is required for update action")
+		}
+
+		endpoint := fmt.Sprintf("/app/rest/vcs-roots/id:%s", url.PathEscape(req.VCSRootID))
+		var changed []string
+		if req.Name != "" {
+			if _, err := c.makeRawRequest(ctx, "PUT", endpoint+"/name", []byte(req.Name), "text/plain"); err != nil {
+				return "", fmt.Errorf("failed to rename VCS root: %w", err)
+			}
+			changed = append(changed, "name")
+		}
+
+		// An empty value removes the property
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var err error
+			if properties[name] == "" {
+				_, err = c.makeRawRequest(ctx, "DELETE", endpoint+"/properties/"+url.PathEscape(name), nil, "text/plain")
+			} else {
+				_, err = c.makeRawRequest(ctx, "PUT", endpoint+"/properties/"+url.PathEscape(name), []byte(properties[name]), "text/plain")
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to set property %s: %w", name, err)
+			}
+			changed = append(changed, name)
+		}
+		return fmt.Sprintf("VCS root %s updated: %s", req.VCSRootID, strings.Join(changed, ", ")), nil
+
+	case "attach":
+		if req.BuildTypeID == "" || req.VCSRootID == "" {
+			return "", fmt.Errorf("buildTypeId and vcsRootId are required for attach action")
+		}
+
+		entry := map[string]interface{}{
+			"id":       req.VCSRootID,
+			"vcs-root": map[string]string{"id": req.VCSRootID},
+		}
+		if req.CheckoutRules != "" {
+			entry["checkout-rules"] = req.CheckoutRules
+		}
+		reqBody, err := json.Marshal(entry)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal VCS root entry: %w", err)
+		}
+		if _, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/buildTypes/id:%s/vcs-root-entries", url.PathEscape(req.BuildTypeID)), reqBody); err != nil {
+			return "", fmt.Errorf("failed to attach VCS root: %w", err)
+		}
+
+		result := fmt.Sprintf("VCS root %s attached to %s", req.VCSRootID, req.BuildTypeID)
+		if req.CheckoutRules != "" {
+			result += " with checkout rules:\n" + req.CheckoutRules
+		}
+		return result, nil
+
+	case "detach":
+		if req.BuildTypeID == "" || req.VCSRootID == "" {
+			return "", fmt.Errorf("buildTypeId and vcsRootId are required for detach action")
+		}
+
+		if _, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/buildTypes/id:%s/vcs-root-entries/%s", req.BuildTypeID, url.PathEscape(req.VCSRootID)), nil); err != nil {
+			return "", fmt.Errorf("failed to detach VCS root: %w", err)
+		}
+		return fmt.Sprintf("VCS root %s detached from %s", req.VCSRootID, req.BuildTypeID), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected create, update, attach or detach)", req.Action)
+	}
+}
+
+// CheckVCSRoot reports whether TeamCity can reach the repository of a VCS root, queues a check for
+// changes, or sends the commit hook notification a missed push webhook would have sent
+func (c *Client) CheckVCSRoot(ctx context.Context, args json.RawMessage) (string, error) {
+	var req struct {
+		Action    string `json:"action"`
+		VCSRootID string `json:"vcsRootId"`
+	}
+
+	if err := json.Unmarshal(args, &req); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if req.VCSRootID == "" {
+		return "", fmt.Errorf("vcsRootId is required")
+	}
+	if req.Action == "" {
+		req.Action = "status"
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordTeamCityRequest("check_vcs_root", "success", time.Since(start).Seconds())
+	}()
+
+	locator := url.QueryEscape(fmt.Sprintf("vcsRoot:(id:%s)", req.VCSRootID))
+	var result string
+	switch req.Action {
+	case "status":
+	case "checkForChanges":
+		// These endpoints answer in plain text
+		if _, err := c.makeRawRequest(ctx, "POST", "/app/rest/vcs-root-instances/checkingForChangesQueue?locator="+locator, nil, ""); err != nil {
+			return "", fmt.Errorf("failed to queue checking for changes: %w", err)
+		}
+		result = fmt.Sprintf("Checking for changes queued for VCS root %s; run the status action to see the result.\n\n", req.VCSRootID)
+	case "commitHook":
+		// Besides checking for changes, TeamCity relies on commit hooks for the root afterwards and
+		// polls it less often
+		if _, err := c.makeRawRequest(ctx, "POST", "/app/rest/vcs-root-instances/commitHookNotification?locator="+locator, nil, ""); err != nil {
+			return "", fmt.Errorf("failed to send commit hook notification: %w", err)
+		}
+		result = fmt.Sprintf("Commit hook notification sent for VCS root %s; changes will be collected shortly.\n\n", req.VCSRootID)
+	default:
+		return "", fmt.Errorf("unknown action: %s (expected status, checkForChanges or commitHook)", req.Action)
+	}
+
+	fields := "vcs-root-instance(id,name,lastVersion,commitHookMode,status(current(status,timestamp),previous(status,timestamp)))"
+	respBody, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/vcs-root-instances?locator=%s&fields=%s", locator, url.QueryEscape(fields)), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get VCS root instances: %w", err)
+	}
+
+	type checkStatus struct {
+		Status    string `json:"status"`
+		Timestamp string `json:"timestamp"`
+	}
+	var response struct {
+		VCSRootInstance []struct {
+			ID             string `json:"id"`
+			Name           string `json:"name"`
+			LastVersion    string `json:"lastVersion"`
+			CommitHookMode bool   `json:"commitHookMode"`
+			Status         struct {
+				Current  *checkStatus `json:"current"`
+				Previous *checkStatus `json:"previous"`
+			} `json:"status"`
+		} `json:"vcs-root-instance"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return "", fmt.Errorf("failed to parse VCS root instances response: %w", err)
+	}
+
+	// A VCS root has an instance per set of resolved parameters; it has none until a build
+	// configuration uses it
+	if len(response.VCSRootInstance) == 0 {
+		return result + fmt.Sprintf("VCS root %s is not used by any build configuration, so TeamCity does not check it for changes.", req.VCSRootID), nil
+	}
+
+	result += fmt.Sprintf("VCS root %s (%d instances):\n", req.VCSRootID, len(response.VCSRootInstance))
+	for _, instance := range response.VCSRootInstance {
+		result += fmt.Sprintf("\n%s (instance %s)\n", instance.Name, instance.ID)
+		if current := instance.Status.Current; current != nil {
+			result += fmt.Sprintf("  Checking for changes: %s", current.Status)
+			if current.Timestamp != "" {
+				result += " at " + c.formatTeamCityDate(current.Timestamp)
+			}
+			result += "\n"
+		}
+		if previous := instance.Status.Previous; previous != nil && previous.Status != "" {
+			result += fmt.Sprintf("  Previous check: %s", previous.Status)
+			if previous.Timestamp != "" {
+				result += " at " + c.formatTeamCityDate(previous.Timestamp)
+			}
+			result += "\n"
+		}
+		if instance.LastVersion != "" {
+			result += fmt.Sprintf("  Last revision: %s\n", instance.LastVersion)
+		} else {
+			result += "  Last revision: none; TeamCity has not reached the repository yet\n"
+		}
+		if instance.CommitHookMode {
+			result += "  Commit hooks: enabled, polling less often\n"
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestAgentsResourceFilters(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/agents", r.URL.Path)
+		assert.Equal(t, "defaultFilter:false,connected:true,enabled:false,pool:(name:Linux Pool),start:0,count:100", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"agent":[
+			{"id":1,"name":"linux-1","connected":true,"enabled":false,"authorized":true,"pool":{"id":1,"name":"Linux Pool"},
+			 "build":{"id":7,"number":"12","buildTypeId":"App_Build"}}]}`))
+	})
+
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "resources/list",
+		"params": {"uri": "teamcity://agents?connected=true&enabled=false&pool=Linux+Pool"}
+	}`))
+	require.NoError(t, err)
+
+	result := resp.(map[string]interface{})["result"].(map[string]interface{})
+	resources := result["resources"].([]interface{})
+	require.Len(t, resources, 1)
+
+	agent := resources[0].(map[string]interface{})
+	assert.Equal(t, "teamcity://agents/1", agent["uri"])
+	assert.Equal(t, "Connected: true, Enabled: false, Authorized: true, Pool: Linux Pool, Running: App_Build #12 (ID: 7)", agent["description"])
+
+	resp, err = handler.HandleRequest(context.Background(), json.RawMessage(`{
+		"jsonrpc": "2.0",
+		"id": 2,
+		"method": "resources/list",
+		"params": {"uri": "teamcity://agents?connected=maybe"}
+	}`))
+	require.NoError(t, err)
+	assert.Contains(t, resp.(map[string]interface{}), "error")
+}
+
+func TestManageAgent(t *testing.T) {
+	infos := map[string]map[string]interface{}{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && (r.URL.Path == "/app/rest/agents/name:linux-07" || r.URL.Path == "/app/rest/agents/id:7"):
+			w.Write([]byte(`{"id":7,"name":"linux-07"}`))
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/app/rest/agents/id:7/"):
+			var info map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&info))
+			infos[strings.TrimPrefix(r.URL.Path, "/app/rest/agents/id:7/")] = info
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	result, err := tc.ManageAgent(ctx, json.RawMessage(`{"action":"disable","agent":"linux-07","comment":"Disk replacement","reenableAfterMinutes":60}`))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(result, "Agent linux-07 (ID: 7) disabled: Disk replacement\nIt will be enabled again at "))
+	info := infos["enabledInfo"]
+	assert.Equal(t, false, info["status"])
+	assert.Equal(t, map[string]interface{}{"text": "Disk replacement"}, info["comment"])
+	switchTime, err := time.Parse("20060102T150405-0700", info["statusSwitchTime"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), switchTime, time.Minute)
+
+	result, err = tc.ManageAgent(ctx, json.RawMessage(`{"action":"authorize","agent":"7"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Agent linux-07 (ID: 7) authorized", result)
+	assert.Equal(t, map[string]interface{}{"status": true}, infos["authorizedInfo"])
+
+	_, err = tc.ManageAgent(ctx, json.RawMessage(`{"action":"enable","agent":"7","reenableAfterMinutes":5}`))
+	assert.EqualError(t, err, "reenableAfterMinutes is only supported by the disable action")
+}
+
+func TestManageAgentPools(t *testing.T) {
+	var requests []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/app/rest/agentPools":
+			w.Write([]byte(`{"agentPool":[
+				{"id":0,"name":"Default","maxAgents":-1,"agents":{"agent":[{"id":7,"name":"linux-07","connected":true,"enabled":false,"authorized":true}]},"projects":{"project":[{"id":"_Root","name":"<Root project>"}]}},
+				{"id":3,"name":"Mobile","maxAgents":4,"agents":{},"projects":{}}]}`))
+		case r.Method == "GET" && r.URL.Path == "/app/rest/agentPools/name:Mobile":
+			w.Write([]byte(`{"id":3,"name":"Mobile"}`))
+		case r.Method == "GET" && r.URL.Path == "/app/rest/agents/name:linux-07":
+			w.Write([]byte(`{"id":7,"name":"linux-07","pool":{"id":0,"name":"Default"}}`))
+		case r.Method == "POST" || r.Method == "DELETE":
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	result, err := tc.ManageAgentPools(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Default (ID: 0)\n  Agents (1):\n    - linux-07 (ID: 7) [disabled]\n  Projects (1):\n    - <Root project> (_Root)\n")
+	assert.Contains(t, result, "Mobile (ID: 3), at most 4 agents\n  Agents (0):\n  Projects (0):\n")
+
+	result, err = tc.ManageAgentPools(ctx, json.RawMessage(`{"action":"moveAgent","pool":"Mobile","agent":"linux-07"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Agent linux-07 (ID: 7) moved to pool Mobile (ID: 3) from pool Default (ID: 0)", result)
+
+	_, err = tc.ManageAgentPools(ctx, json.RawMessage(`{"action":"assignProject","pool":"Mobile","projectId":"Android"}`))
+	require.NoError(t, err)
+	_, err = tc.ManageAgentPools(ctx, json.RawMessage(`{"action":"unassignProject","pool":"Mobile","projectId":"Android"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`POST /app/rest/agentPools/id:3/agents {"id":7}`,
+		`POST /app/rest/agentPools/id:3/projects {"id":"Android"}`,
+		`DELETE /app/rest/agentPools/id:3/projects/id:Android `,
+	}, requests)
+
+	_, err = tc.ManageAgentPools(ctx, json.RawMessage(`{"action":"assignProject","projectId":"Android"}`))
+	assert.EqualError(t, err, "pool is required for assignProject action")
+}
+
+func TestGetAgentDetails(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app/rest/agents/name:linux-07" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		w.Write([]byte(`{"id":7,"name":"linux-07","connected":true,"enabled":false,"authorized":true,"uptodate":false,"version":"147512",
+			"pool":{"id":0,"name":"Default"},
+			"enabledInfo":{"comment":{"text":"Disk replacement","user":{"username":"alice"}}},
+			"environment":{"osName":"Linux","osType":"Linux"},
+			"properties":{"property":[
+				{"name":"teamcity.agent.hardware.cpuCount","value":"8"},
+				{"name":"teamcity.agent.jvm.os.arch","value":"amd64"},
+				{"name":"system.agent.name","value":"linux-07"},
+				{"name":"env.AWS_SECRET_ACCESS_KEY","value":"abc"},
+				{"name":"env.HOME","value":"/home/agent"}]}}`))
+	})
+
+	result, err := tc.GetAgentDetails(context.Background(), json.RawMessage(`{"agent":"linux-07"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Agent linux-07 (ID: 7)\n  Connected: true\n  Enabled: false (Disk replacement by alice)\n  Authorized: true\n  Pool: Default (ID: 0)\n  Running: idle\n")
+	assert.Contains(t, result, "  OS: Linux (amd64)\n  CPU count: 8\n  Version: 147512 (outdated)\n")
+	assert.Contains(t, result, "Configuration parameters (2):\n  teamcity.agent.hardware.cpuCount = 8\n")
+	assert.Contains(t, result, "System properties (1):\n  system.agent.name = linux-07\n")
+	assert.Contains(t, result, "Environment variables (2):\n  env.AWS_SECRET_ACCESS_KEY = *****\n  env.HOME = /home/agent\n")
+
+	result, err = tc.GetAgentDetails(context.Background(), json.RawMessage(`{"agent":"linux-07","prefix":"env."}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Configuration parameters (0):\n")
+	assert.NotContains(t, result, "system.agent.name")
+}
+
+func TestGetCompatibleAgents(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildQueue/id:55":
+			w.Write([]byte(`{"id":55,"buildTypeId":"App_Build","state":"queued","waitReason":"There are no idle compatible agents"}`))
+		case "/app/rest/agents":
+			// The locator arrives intact only when it is escaped as a whole
+			assert.NotContains(t, r.URL.RawQuery, "locator=compatible:(")
+			switch r.URL.Query().Get("locator") {
+			case "compatible:(build:(id:55)),defaultFilter:false":
+				w.Write([]byte(`{"agent":[
+					{"id":1,"name":"linux-1","connected":true,"enabled":true,"authorized":true,"pool":{"id":1,"name":"Linux"}},
+					{"id":2,"name":"linux-2","connected":false,"enabled":true,"authorized":true,"pool":{"id":1,"name":"Linux"}}]}`))
+			case "incompatible:(build:(id:55)),defaultFilter:false":
+				w.Write([]byte(`{"agent":[{"id":3,"name":"win-1","connected":true,"enabled":true,"authorized":true,"pool":{"id":2,"name":"Windows"}}]}`))
+			default:
+				t.Errorf("unexpected agent locator %q", r.URL.Query().Get("locator"))
+			}
+		case "/app/rest/agents/id:3/incompatibleBuildTypes":
+			w.Write([]byte(`{"compatibility":[
+				{"buildType":{"id":"Other_Build"},"unmetRequirements":{"description":"Unrelated"}},
+				{"buildType":{"id":"App_Build"},"unmetRequirements":{"requirement":[
+					{"id":"RQ_1","type":"equals","properties":{"property":[{"name":"property-name","value":"teamcity.agent.jvm.os.name"},{"name":"property-value","value":"Linux"}]}}]}}]}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.GetCompatibleAgents(context.Background(), json.RawMessage(`{"buildId":"55"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Agents for queued build 55 (App_Build)\nWait Reason: There are no idle compatible agents\n")
+	assert.Contains(t, result, "Compatible agents (2, 1 available):\n")
+	assert.Contains(t, result, "  - linux-1 (ID: 1) [pool: Linux]\n")
+	assert.Contains(t, result, "  - linux-2 (ID: 2) [pool: Linux] - not available: disconnected\n")
+	assert.Contains(t, result, "Incompatible agents (1):\n  - win-1 (ID: 3) [pool: Windows]\n"+
+		"      Unmet requirement: equals (property-name=teamcity.agent.jvm.os.name, property-value=Linux)\n")
+	assert.NotContains(t, result, "Unrelated")
+
+	_, err = tc.GetCompatibleAgents(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "buildId is required")
+
+	_, err = tc.GetCompatibleAgents(context.Background(), json.RawMessage(`{"buildId":"55),defaultFilter:false"}`))
+	assert.EqualError(t, err, `buildId must be a numeric build ID: "55),defaultFilter:false"`)
+}
@@ -0,0 +1,50 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestToolAnnotations(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	tc, err := teamcity.NewClient(config.TeamCityConfig{URL: "http://localhost:8111", Token: "test-token", Timeout: "5s"}, logger)
+	require.NoError(t, err)
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, logger)
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	require.NoError(t, err)
+
+	tools := resp.(map[string]interface{})["result"].(map[string]interface{})["tools"].([]map[string]interface{})
+	annotations := make(map[string]map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		annotation, ok := tool["annotations"].(map[string]interface{})
+		require.True(t, ok, "tool %s has no annotations", tool["name"])
+		assert.Contains(t, annotation, "readOnlyHint")
+		assert.Contains(t, annotation, "destructiveHint")
+		assert.Contains(t, annotation, "idempotentHint")
+		annotations[tool["name"].(string)] = annotation
+	}
+
+	for _, name := range []string{"search_builds", "fetch_build_log", "get_test_results"} {
+		assert.Equal(t, true, annotations[name]["readOnlyHint"], name)
+		assert.Equal(t, false, annotations[name]["destructiveHint"], name)
+	}
+	for _, name := range []string{"cancel_build", "trigger_build", "manage_notification_rules"} {
+		assert.Equal(t, false, annotations[name]["readOnlyHint"], name)
+		assert.Equal(t, true, annotations[name]["destructiveHint"], name)
+	}
+	assert.Equal(t, true, annotations["cancel_build"]["idempotentHint"])
+	assert.Equal(t, false, annotations["trigger_build"]["idempotentHint"])
+}
@@ -0,0 +1,225 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestArtifactSizeReport(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds", r.URL.Path)
+		assert.Contains(t, r.URL.Query().Get("locator"), "buildType:App_Build,state:finished")
+		w.Write([]byte(`{"build":[
+			{"id":3,"number":"3","statistics":{"property":[{"name":"ArtifactsSize","value":"3145728"}]}},
+			{"id":2,"number":"2","statistics":{"property":[{"name":"ArtifactsSize","value":"1048576"}]}},
+			{"id":1,"number":"1","statistics":{"property":[{"name":"ArtifactsSize","value":"1000000"}]}}]}`))
+	})
+
+	result, err := tc.GetArtifactSizeReport(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Build #3 (ID: 3): 3.0 MiB (+2.0 MiB, +200.0%) [GROWTH]")
+	assert.Contains(t, result, "Build #2 (ID: 2): 1.0 MiB (+47.4 KiB, +4.9%)\n")
+	assert.Contains(t, result, "Overall: 976.6 KiB -> 3.0 MiB between #1 and #3")
+	assert.Contains(t, result, "  - #3 (ID: 3): +2.0 MiB")
+
+	_, err = tc.GetArtifactSizeReport(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "buildTypeId is required")
+}
+
+func TestListBuildArtifacts(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:7/artifacts/children/", r.URL.Path)
+		assert.Equal(t, "recursive:true,browseArchives:true", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"file":[
+			{"name":"report.txt","fullName":"reports/report.txt","size":2048,"modificationTime":"20240101T120000+0000"},
+			{"name":"reports","fullName":"reports","children":{"count":1}},
+			{"name":"app.zip","fullName":"app.zip","size":1048576,"children":{"count":1}},
+			{"name":"main.bin","fullName":"app.zip!/main.bin","size":4096}]}`))
+	})
+
+	result, err := tc.ListBuildArtifacts(context.Background(), json.RawMessage(`{"buildId":"7","browseArchives":true}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Artifacts of build 7: 2 files, 1.0 MiB\n\n"+
+		"  app.zip  (archive, 1.0 MiB)\n"+
+		"    main.bin  4.0 KiB\n"+
+		"  reports/\n"+
+		"    report.txt  2.0 KiB  ")
+}
+
+func TestDownloadArtifact(t *testing.T) {
+	artifacts := map[string]string{
+		"/app/rest/builds/id:42/artifacts/content/reports/summary.txt": "All 12 tests passed\n",
+		"/app/rest/builds/id:42/artifacts/content/dist/app.bin":        "\x7fELF\x00\x01\x02",
+		"/app/rest/builds/id:42/artifacts/content/logs/build.log":      strings.Repeat("line\n", 100),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := artifacts[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	tc, err := teamcity.NewClient(config.TeamCityConfig{
+		URL: server.URL, Token: "test-token", Timeout: "5s", ArtifactDir: dir,
+	}, zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err)
+
+	t.Run("text", func(t *testing.T) {
+		content, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"42","artifactPath":"reports/summary.txt"}`))
+		require.NoError(t, err)
+		assert.Nil(t, content.Data)
+		assert.Equal(t, "Artifact reports/summary.txt of build 42 (20 B):\n\nAll 12 tests passed\n", content.Summary)
+	})
+
+	t.Run("text truncated to maxBytes", func(t *testing.T) {
+		content, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"42","artifactPath":"logs/build.log","maxBytes":10}`))
+		require.NoError(t, err)
+		assert.Equal(t, "Artifact logs/build.log of build 42 (first 10 B of 500 B, truncated):\n\nline\nline\n", content.Summary)
+	})
+
+	t.Run("binary inline", func(t *testing.T) {
+		content, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"42","artifactPath":"dist/app.bin"}`))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x7fELF\x00\x01\x02"), content.Data)
+		assert.Equal(t, "application/octet-stream", content.MimeType)
+		assert.Equal(t, "teamcity://builds/42/artifacts/content/dist/app.bin", content.URI)
+	})
+
+	t.Run("binary over maxBytes is saved to disk", func(t *testing.T) {
+		content, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"42","artifactPath":"../dist/app.bin","maxBytes":4}`))
+		require.NoError(t, err)
+		path := filepath.Join(dir, "build-42", "dist", "app.bin")
+		assert.Contains(t, content.Summary, "saved to "+path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x7fELF\x00\x01\x02"), data)
+	})
+
+	t.Run("non-numeric buildId is rejected", func(t *testing.T) {
+		_, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"../../tmp","artifactPath":"dist/app.bin","saveToDisk":true}`))
+		assert.EqualError(t, err, `buildId must be a numeric build ID: "../../tmp"`)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.True(t, strings.HasPrefix(entry.Name(), "build-"), entry.Name())
+		}
+	})
+
+	t.Run("missing artifact", func(t *testing.T) {
+		_, err := tc.DownloadArtifact(context.Background(), json.RawMessage(`{"buildId":"42","artifactPath":"missing.txt"}`))
+		assert.ErrorContains(t, err, "API error 404")
+	})
+}
+
+func TestDownloadArtifactArchiveWithoutContentLength(t *testing.T) {
+	archive := "PK\x03\x04" + strings.Repeat("z", 60)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing before the whole archive is written makes the response chunked
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write([]byte(archive[:8]))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(archive[8:]))
+	}))
+	t.Cleanup(server.Close)
+
+	newClient := func(dir string) *teamcity.Client {
+		tc, err := teamcity.NewClient(config.TeamCityConfig{
+			URL: server.URL, Token: "test-token", Timeout: "5s", ArtifactDir: dir, ArtifactMaxInlineSize: "16",
+		}, zaptest.NewLogger(t).Sugar())
+		require.NoError(t, err)
+		return tc
+	}
+
+	t.Run("saved to disk when over the inline limit", func(t *testing.T) {
+		dir := t.TempDir()
+		content, err := newClient(dir).DownloadArtifactArchive(context.Background(), json.RawMessage(`{"buildId":"42"}`))
+		require.NoError(t, err)
+
+		path := filepath.Join(dir, "build-42-artifacts.zip")
+		assert.Equal(t, "Artifacts of build 42 saved as zip archive to "+path+" (64 B)", content.Summary)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, archive, string(data))
+	})
+
+	t.Run("rejected without ARTIFACT_DIR", func(t *testing.T) {
+		_, err := newClient("").DownloadArtifactArchive(context.Background(), json.RawMessage(`{"buildId":"42"}`))
+		assert.EqualError(t, err, "artifact archive exceeds the inline limit of 16 B; configure ARTIFACT_DIR to save it to disk")
+	})
+}
+
+func TestCompareArtifacts(t *testing.T) {
+	trees := map[string]string{
+		"1": `{"file":[{"name":"dist","fullName":"dist","children":{"count":3}},
+			{"name":"app.jar","fullName":"dist/app.jar","size":1000},{"name":"config.yml","fullName":"dist/config.yml","size":10},
+			{"name":"old.txt","fullName":"dist/old.txt","size":5}]}`,
+		"2": `{"file":[{"name":"dist","fullName":"dist","children":{"count":3}},
+			{"name":"app.jar","fullName":"dist/app.jar","size":3048},{"name":"config.yml","fullName":"dist/config.yml","size":10},
+			{"name":"new.txt","fullName":"dist/new.txt","size":7}]}`,
+	}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/app/rest/builds/id:"), "/", 2)
+		switch parts[1] {
+		case "artifacts/children/dist":
+			w.Write([]byte(trees[parts[0]]))
+		case "artifacts/content/dist/config.yml":
+			w.Write([]byte("version: " + parts[0]))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := tc.CompareArtifacts(context.Background(), json.RawMessage(`{"fromBuildId":"1","toBuildId":"2","path":"dist"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Artifacts of build 2 compared to build 1 under dist: 1 added, 1 removed, 1 changed\n")
+	assert.Contains(t, result, "  + dist/new.txt  7 B\n")
+	assert.Contains(t, result, "  - dist/old.txt  5 B\n")
+	assert.Contains(t, result, "  ~ dist/app.jar  1000 B -> 3.0 KiB (+2.0 KiB)\n")
+	assert.Contains(t, result, "Unchanged: 1 files with the same size (contents not compared")
+
+	result, err = tc.CompareArtifacts(context.Background(), json.RawMessage(`{"fromBuildId":"1","toBuildId":"2","path":"dist","compareChecksums":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "2 changed")
+	assert.Contains(t, result, "  ~ dist/config.yml  10 B, content differs\n")
+	assert.Contains(t, result, "Unchanged: 0 files\n")
+}
+
+func TestDownloadArtifactArchive(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:42/artifacts/archived", r.URL.Path)
+		assert.Equal(t, "pattern:**/*.log", r.URL.Query().Get("locator"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write([]byte("PK\x03\x04zip"))
+	})
+
+	content, err := tc.DownloadArtifactArchive(context.Background(), json.RawMessage(`{"buildId":"42","pattern":"**/*.log"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "application/zip", content.MimeType)
+	assert.Equal(t, []byte("PK\x03\x04zip"), content.Data)
+	assert.Contains(t, content.Summary, "Artifacts of build 42 downloaded")
+
+	_, err = tc.DownloadArtifactArchive(context.Background(), json.RawMessage(`{"buildId":"42","saveToDisk":true}`))
+	assert.EqualError(t, err, "saving to disk requires ARTIFACT_DIR to be configured")
+
+	_, err = tc.DownloadArtifactArchive(context.Background(), json.RawMessage(`{"buildId":"x/../../../../tmp/evil"}`))
+	assert.EqualError(t, err, `buildId must be a numeric build ID: "x/../../../../tmp/evil"`)
+}
@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/audit"
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func readAuditEvents(t *testing.T, path string) []audit.Event {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []audit.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event audit.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestAuditToolCalls(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildQueue":
+			w.Write([]byte(`{"id":42,"number":"7","state":"queued"}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(audit.Config{Destination: path})
+	require.NoError(t, err)
+	handler.SetAuditLogger(auditLog)
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "ci", Method: auth.MethodHMAC})
+	call := func(ctx context.Context, params string) {
+		_, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+params+`}`))
+		require.NoError(t, err)
+	}
+	call(ctx, `{"name":"trigger_build","arguments":{"buildTypeId":"App_Build","properties":{"env.DB_PASSWORD":"hunter2"}}}`)
+	call(context.Background(), `{"name":"fetch_build_log","arguments":{"buildId":"9"}}`)
+	handler.SetPolicy(writePolicy(t, `{"rules":[{"subjects":["ci"],"tools":["search_builds"]}]}`))
+	call(ctx, `{"name":"cancel_build","arguments":{"buildId":"42"}}`)
+	require.NoError(t, auditLog.Close())
+
+	events := readAuditEvents(t, path)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "ci", events[0].Subject)
+	assert.Equal(t, auth.MethodHMAC, events[0].AuthMethod)
+	assert.Equal(t, "trigger_build", events[0].Tool)
+	assert.Equal(t, audit.StatusSuccess, events[0].Status)
+	assert.Equal(t, []string{"buildType:App_Build"}, events[0].Entities)
+	assert.Equal(t, "[REDACTED]", events[0].Arguments.(map[string]interface{})["properties"].(map[string]interface{})["env.DB_PASSWORD"])
+
+	assert.Equal(t, auth.Anonymous, events[1].Subject)
+	assert.Equal(t, audit.StatusError, events[1].Status)
+	assert.NotEmpty(t, events[1].Error)
+	assert.Equal(t, []string{"build:9"}, events[1].Entities)
+
+	assert.Equal(t, audit.StatusDenied, events[2].Status)
+	assert.Equal(t, "cancel_build", events[2].Tool)
+}
+
+func TestAuditFailedToolCalls(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rejected password=hunter2", http.StatusBadRequest)
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+	handler.SetConfirmDestructive(true)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(audit.Config{Destination: path})
+	require.NoError(t, err)
+	handler.SetAuditLogger(auditLog)
+
+	call := func(params string) {
+		_, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+params+`}`))
+		require.NoError(t, err)
+	}
+	call(`{"name":"fetch_build_log","arguments":{"buildId":"9"}}`)
+	call(`{"name":"cancel_build","arguments":{"buildId":"42","confirmationToken":7}}`)
+	require.NoError(t, auditLog.Close())
+
+	events := readAuditEvents(t, path)
+	require.Len(t, events, 2)
+
+	// Secrets quoted by TeamCity errors are masked
+	assert.Equal(t, audit.StatusError, events[0].Status)
+	assert.Contains(t, events[0].Error, "rejected password=")
+	assert.NotContains(t, events[0].Error, "hunter2")
+
+	// Calls with an invalid confirmation token are audited although they never run
+	assert.Equal(t, "cancel_build", events[1].Tool)
+	assert.Equal(t, audit.StatusError, events[1].Status)
+	assert.Equal(t, "confirmationToken must be a string", events[1].Error)
+	assert.Equal(t, []string{"build:42"}, events[1].Entities)
+}
+
+func TestAuditLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.Open(audit.Config{Destination: path, MaxSize: 200, MaxBackups: 2})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, auditLog.Record(audit.Event{Subject: "ci", Tool: "search_builds", Status: audit.StatusSuccess}))
+	}
+	require.NoError(t, auditLog.Close())
+
+	for _, name := range []string{"audit.log", "audit.log.1", "audit.log.2"} {
+		info, err := os.Stat(filepath.Join(filepath.Dir(path), name))
+		require.NoError(t, err, name)
+		assert.LessOrEqual(t, info.Size(), int64(200), name)
+		assert.NotEmpty(t, readAuditEvents(t, filepath.Join(filepath.Dir(path), name)), name)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), "only MaxBackups rotated files are kept")
+}
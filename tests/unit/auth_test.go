@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestTeamCityTokenPassthrough(t *testing.T) {
+	var mu sync.Mutex
+	var lastAuth string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastAuth = r.Header.Get("Authorization")
+		mu.Unlock()
+		w.Write([]byte(`{"count":0,"build":[]}`))
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	searchBuilds := func(ctx context.Context) string {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search_builds","arguments":{"count":1}}}`))
+		require.NoError(t, err)
+		require.Contains(t, resp, "result")
+		mu.Lock()
+		defer mu.Unlock()
+		return lastAuth
+	}
+
+	t.Run("configured token by default", func(t *testing.T) {
+		assert.Equal(t, "Bearer test-token", searchBuilds(context.Background()))
+	})
+
+	t.Run("request token", func(t *testing.T) {
+		ctx := teamcity.WithToken(context.Background(), "user-token")
+		assert.Equal(t, "Bearer user-token", searchBuilds(ctx))
+	})
+
+	t.Run("session token from initialize", func(t *testing.T) {
+		ctx := mcp.WithSession(context.Background(), mcp.NewSession(func(interface{}) error { return nil }))
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","teamcityToken":"session-token"}}`))
+		require.NoError(t, err)
+		require.Contains(t, resp, "result")
+
+		assert.Equal(t, "Bearer session-token", searchBuilds(ctx))
+
+		// A request token takes precedence over the session token
+		assert.Equal(t, "Bearer user-token", searchBuilds(teamcity.WithToken(ctx, "user-token")))
+	})
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestBatchRequests(t *testing.T) {
+	handler := newVersionTestHandler(t)
+	ctx := mcp.WithProtocolVersion(context.Background(), "2025-03-26")
+
+	t.Run("responses in request order without notifications", func(t *testing.T) {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(` [
+			{"jsonrpc":"2.0","id":1,"method":"ping"},
+			{"jsonrpc":"2.0","method":"notifications/initialized"},
+			{"jsonrpc":"2.0","id":"two","method":"unknown/method"},
+			{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_current_time","arguments":{}}}
+		]`))
+		require.NoError(t, err)
+
+		batch, ok := resp.([]interface{})
+		require.True(t, ok)
+		require.Len(t, batch, 3)
+
+		assert.Equal(t, float64(1), batch[0].(map[string]interface{})["id"])
+		assert.Contains(t, batch[0], "result")
+		assert.Equal(t, "two", batch[1].(map[string]interface{})["id"])
+		assert.Equal(t, -32601, batch[1].(map[string]interface{})["error"].(map[string]interface{})["code"])
+		assert.Equal(t, float64(3), batch[2].(map[string]interface{})["id"])
+		assert.Contains(t, batch[2], "result")
+	})
+
+	t.Run("batch of notifications has no response", func(t *testing.T) {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`))
+		require.NoError(t, err)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("invalid entries", func(t *testing.T) {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`[1,{"jsonrpc":"2.0","id":5,"method":"initialize","params":{}}]`))
+		require.NoError(t, err)
+
+		batch := resp.([]interface{})
+		require.Len(t, batch, 2)
+		assert.Equal(t, -32600, batch[0].(map[string]interface{})["error"].(map[string]interface{})["code"])
+		assert.Equal(t, float64(5), batch[1].(map[string]interface{})["id"])
+		assert.Equal(t, -32600, batch[1].(map[string]interface{})["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`[]`))
+		require.NoError(t, err)
+		assert.Equal(t, -32600, resp.(map[string]interface{})["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("rejected in 2025-06-18", func(t *testing.T) {
+		resp, err := handler.HandleRequest(mcp.WithProtocolVersion(context.Background(), "2025-06-18"), json.RawMessage(`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`))
+		require.NoError(t, err)
+		assert.Equal(t, -32600, resp.(map[string]interface{})["error"].(map[string]interface{})["code"])
+	})
+}
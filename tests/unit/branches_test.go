@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBranches(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/buildTypes/id:Shop_Api_Build/branches", r.URL.Path)
+		assert.Equal(t, "policy:ACTIVE_HISTORY_AND_ACTIVE_VCS_BRANCHES", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"branch":[
+			{"name":"feature/login","active":true,"builds":{"build":[{"id":7,"number":"14","status":"FAILURE","state":"finished","finishDate":"20250107T100000+0000"}]}},
+			{"name":"main","default":true,"active":true,"builds":{"build":[{"id":8,"number":"15","status":"SUCCESS","state":"running"}]}},
+			{"name":"feature/cart","active":true,"builds":{"count":0}}]}`))
+	})
+
+	result, err := tc.ListBranches(context.Background(), json.RawMessage(`{"buildTypeId":"Shop_Api_Build"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Branches of Shop_Api_Build (3):\n\n"+
+		"- main [default]: RUNNING #15 (ID: 8)\n"+
+		"- feature/login: FAILURE #14 (ID: 7), finished 2025-01-07 10:00:00\n"+
+		"- feature/cart: no builds\n", result)
+
+	result, err = tc.ListBranches(context.Background(), json.RawMessage(`{"buildTypeId":"Shop_Api_Build","name":"LOGIN"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Branches of Shop_Api_Build (1):\n\n- feature/login: FAILURE #14 (ID: 7), finished 2025-01-07 10:00:00\n", result)
+}
+
+func TestGetBranchHealth(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch r.URL.Path {
+		case "/app/rest/buildTypes":
+			assert.Equal(t, "affectedProject:(id:Shop),count:201", locator)
+			w.Write([]byte(`{"buildType":[{"id":"Shop_Api","name":"API"},{"id":"Shop_Web","name":"Web"}]}`))
+		case "/app/rest/buildTypes/id:Shop_Api/branches":
+			w.Write([]byte(`{"branch":[
+				{"name":"main","default":true,"builds":{"build":[{"id":20,"number":"20","status":"FAILURE","state":"finished"}]}},
+				{"name":"feature/login","builds":{"build":[{"id":21,"number":"21","status":"FAILURE","state":"finished"}]}}]}`))
+		case "/app/rest/buildTypes/id:Shop_Web/branches":
+			w.Write([]byte(`{"branch":[
+				{"name":"main","default":true,"builds":{"build":[{"id":30,"number":"30","status":"SUCCESS","state":"finished"}]}},
+				{"name":"feature/cart","builds":{"build":[{"id":31,"number":"31","status":"FAILURE","state":"finished"}]}}]}`))
+		case "/app/rest/builds":
+			switch locator {
+			case "buildType:(id:Shop_Api),branch:(name:main),status:SUCCESS,count:1":
+				w.Write([]byte(`{"build":[{"id":17}]}`))
+			case "buildType:(id:Shop_Api),branch:(name:main),sinceBuild:(id:17),count:1000":
+				w.Write([]byte(`{"build":[{"id":20,"number":"20"},{"id":19,"number":"19"},{"id":18,"number":"18","finishDate":"20250105T100000+0000"}]}`))
+			case "buildType:(id:Shop_Api),branch:(name:feature/login),status:SUCCESS,count:1":
+				w.Write([]byte(`{"count":0}`))
+			case "buildType:(id:Shop_Web),branch:(name:feature/cart),status:SUCCESS,count:1":
+				w.Write([]byte(`{"build":[{"id":29}]}`))
+			case "buildType:(id:Shop_Web),branch:(name:feature/cart),sinceBuild:(id:29),count:1000":
+				w.Write([]byte(`{"build":[{"id":31,"number":"31"}]}`))
+			default:
+				t.Errorf("unexpected builds locator: %s", locator)
+			}
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.GetBranchHealth(context.Background(), json.RawMessage(`{"projectId":"Shop"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Branch health of project Shop: 3 of 4 branch builds failing in 3 branches (2 build configurations checked)\n"+
+		"\nmain [default]\n  - API (Shop_Api): FAILURE #20 (ID: 20), failing since #18 (ID: 18) finished 2025-01-05 10:00:00\n"+
+		"\nfeature/cart\n  - Web (Shop_Web): FAILURE #31 (ID: 31), first failure\n"+
+		"\nfeature/login\n  - API (Shop_Api): FAILURE #21 (ID: 21), never succeeded\n", result)
+}
+
+func TestFindPullRequestBuilds(t *testing.T) {
+	var locators []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds", r.URL.Path)
+		locator := r.URL.Query().Get("locator")
+		locators = append(locators, locator)
+		switch locator {
+		case "affectedProject:(id:Shop),property:(name:teamcity.pullRequest.number,value:12),branch:default:any,state:any,count:20":
+			w.Write([]byte(`{"build":[{"id":40,"number":"7","status":"FAILURE","state":"finished","branchName":"pull/12","buildTypeId":"Shop_Api","buildType":{"name":"API"}}]}`))
+		case "affectedProject:(id:Shop),branch:(name:pull/12),state:any,count:20":
+			w.Write([]byte(`{"build":[
+				{"id":41,"number":"8","state":"running","branchName":"pull/12","buildTypeId":"Shop_Api","buildType":{"name":"API"}},
+				{"id":40,"number":"7","status":"FAILURE","state":"finished","branchName":"pull/12","buildTypeId":"Shop_Api","buildType":{"name":"API"}}]}`))
+		case "affectedProject:(id:Shop),branch:(name:refs/pull/12/merge),state:any,count:20":
+			w.Write([]byte(`{"build":[{"id":39,"number":"3","status":"SUCCESS","state":"finished","branchName":"refs/pull/12/merge","buildTypeId":"Shop_Web","buildType":{"name":"Web"}}]}`))
+		default:
+			w.Write([]byte(`{"count":0}`))
+		}
+	})
+
+	result, err := tc.FindPullRequestBuilds(context.Background(), json.RawMessage(`{"pullRequest":"#12","projectId":"Shop"}`))
+	require.NoError(t, err)
+	assert.Len(t, locators, 8)
+	assert.Equal(t, "Found 3 builds for pull request #12 on branches pull/12, refs/pull/12/merge:\n\n"+
+		"- API (Shop_Api) #8 (ID: 41): RUNNING [pull/12]\n"+
+		"- API (Shop_Api) #7 (ID: 40): FAILURE [pull/12]\n"+
+		"- Web (Shop_Web) #3 (ID: 39): SUCCESS [refs/pull/12/merge]\n", result)
+
+	result, err = tc.FindPullRequestBuilds(context.Background(), json.RawMessage(`{"branch":"refs/heads/feature/none"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "No builds found for branch feature/none.", result)
+
+	_, err = tc.FindPullRequestBuilds(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "exactly one of pullRequest or branch is required")
+}
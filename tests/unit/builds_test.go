@@ -0,0 +1,530 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRunPersonalBuild(t *testing.T) {
+	var uploaded string
+	var queueRequest map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /uploadDiffChanges.html":
+			assert.Equal(t, "Fix flaky test", r.URL.Query().Get("description"))
+			assert.Equal(t, "0", r.URL.Query().Get("commitType"))
+			assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			uploaded = string(body)
+			w.Write([]byte("12345\n"))
+		case "POST /app/rest/buildQueue":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&queueRequest))
+			w.Write([]byte(`{"id":77,"state":"queued"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	patch := "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+	args, err := json.Marshal(map[string]interface{}{
+		"buildTypeId": "App_Test", "patch": patch, "description": "Fix flaky test", "branchName": "feature",
+	})
+	require.NoError(t, err)
+
+	result, err := tc.RunPersonalBuild(context.Background(), args)
+	require.NoError(t, err)
+
+	assert.Equal(t, patch, uploaded)
+	assert.Equal(t, "Personal build queued successfully (ID: 77) with uploaded change 12345", result)
+	assert.Equal(t, true, queueRequest["personal"])
+	assert.Equal(t, "feature", queueRequest["branchName"])
+	assert.Equal(t, map[string]interface{}{"id": "App_Test"}, queueRequest["buildType"])
+	assert.Equal(t, map[string]interface{}{
+		"change": []interface{}{map[string]interface{}{"id": "12345", "personal": true}},
+	}, queueRequest["lastChanges"])
+}
+
+func TestRunPersonalBuildUploadFailure(t *testing.T) {
+	queued := false
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/uploadDiffChanges.html":
+			http.Error(w, "Access denied", http.StatusForbidden)
+		case "/app/rest/buildQueue":
+			queued = true
+		}
+	})
+
+	_, err := tc.RunPersonalBuild(context.Background(), json.RawMessage(`{"buildTypeId":"App_Test","patch":"--- a/x\n+++ b/x\n"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to upload patch")
+	assert.False(t, queued, "no build is queued when the upload fails")
+
+	_, err = tc.RunPersonalBuild(context.Background(), json.RawMessage(`{"buildTypeId":"App_Test","patch":"  "}`))
+	assert.EqualError(t, err, "patch is required")
+}
+
+func TestCompositeBuildSummary(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.URL.Path == "/app/rest/builds/id:100":
+			w.Write([]byte(`{"id":100,"number":"42","status":"FAILURE","state":"finished","composite":true,
+				"buildType":{"id":"App_All","name":"All","projectId":"App","project":{"name":"App"}}}`))
+		case strings.Contains(locator, "snapshotDependency:(to:(id:100)"):
+			w.Write([]byte(`{"build":[
+				{"id":101,"number":"5","status":"SUCCESS","state":"finished","buildTypeId":"App_Unit"},
+				{"id":102,"number":"6","status":"FAILURE","state":"finished","buildTypeId":"App_Checks","composite":true},
+				{"id":103,"number":"7","status":"SUCCESS","state":"running","buildTypeId":"App_Lint"}]}`))
+		case strings.Contains(locator, "snapshotDependency:(to:(id:102)"):
+			w.Write([]byte(`{"build":[
+				{"id":201,"number":"8","status":"SUCCESS","state":"finished","buildTypeId":"App_Docs"},
+				{"id":202,"number":"9","status":"FAILURE","state":"finished","buildTypeId":"App_E2E"}]}`))
+		default:
+			w.Write([]byte(`{"count":1,"build":[{"id":100,"number":"42","status":"FAILURE","state":"finished","buildTypeId":"App_All","composite":true}]}`))
+		}
+	})
+
+	t.Run("summary drills into nested failing part", func(t *testing.T) {
+		summary, err := tc.GetCompositeSummary(context.Background(), 100)
+		require.NoError(t, err)
+
+		assert.Len(t, summary.Parts, 3)
+		assert.Equal(t, 1, summary.ByStatus["SUCCESS"])
+		assert.Equal(t, 1, summary.ByStatus["FAILURE"])
+		assert.Equal(t, 1, summary.Unfinished)
+		require.NotNil(t, summary.FailingPart)
+		assert.Equal(t, 202, summary.FailingPart.ID)
+		assert.Equal(t, "App_E2E", summary.FailingPart.BuildTypeID)
+	})
+
+	t.Run("search output includes composite roll-up", func(t *testing.T) {
+		result, err := tc.SearchBuilds(context.Background(), json.RawMessage(`{"buildTypeId":"App_All"}`))
+		require.NoError(t, err)
+
+		assert.Contains(t, result.Text, "Composite: 3 parts (SUCCESS: 1, FAILURE: 1, unfinished: 1)")
+		assert.Contains(t, result.Text, "Failing Part: App_E2E #9 (ID: 202, FAILURE)")
+	})
+
+	t.Run("build details include composite roll-up", func(t *testing.T) {
+		result, err := tc.GetBuildDetails(context.Background(), json.RawMessage(`{"buildId":"100"}`))
+		require.NoError(t, err)
+
+		assert.Contains(t, result, "  Flags: composite\n")
+		assert.Contains(t, result, "  Composite: 3 parts (SUCCESS: 1, FAILURE: 1, unfinished: 1)\n")
+		assert.Contains(t, result, "  Failing Part: App_E2E #9 (ID: 202, FAILURE)\n")
+	})
+}
+
+func TestCompositeBuildSummaryDepthCap(t *testing.T) {
+	// Every composite build has a single failing composite part, nested deeper than the drill-down follows
+	requested := make([]string, 0)
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		var id int
+		_, err := fmt.Sscanf(locator, "snapshotDependency:(to:(id:%d)", &id)
+		require.NoError(t, err)
+		requested = append(requested, strconv.Itoa(id))
+		fmt.Fprintf(w, `{"build":[{"id":%d,"number":"1","status":"FAILURE","state":"finished","buildTypeId":"App_Level%d","composite":true}]}`, id+1, id+1)
+	})
+
+	summary, err := tc.GetCompositeSummary(context.Background(), 0)
+	require.NoError(t, err)
+
+	// The parts of the root build plus five nested levels are requested
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, requested)
+	require.NotNil(t, summary.FailingPart)
+	assert.Equal(t, 6, summary.FailingPart.ID)
+	assert.Equal(t, map[string]int{"FAILURE": 1}, summary.ByStatus)
+}
+
+func TestSearchBuildsByRevision(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		assert.Contains(t, locator, "change:(version:abc123def)")
+		assert.Contains(t, locator, "branch:default:any")
+		w.Write([]byte(`{"count":1,"build":[{"id":7,"number":"7","status":"SUCCESS","state":"finished","buildTypeId":"App_Deploy"}]}`))
+	})
+
+	result, err := tc.SearchBuilds(context.Background(), json.RawMessage(`{"revision":"abc123def"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "Build #7 (ID: 7)")
+}
+
+func TestPromoteBuild(t *testing.T) {
+	requests := make([]string, 0)
+	var deployRequest map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /app/rest/builds/id:10":
+			w.Write([]byte(`{"id":10,"number":"42","status":"SUCCESS","state":"finished","buildTypeId":"App_Build","branchName":"main"}`))
+		case "POST /app/rest/buildQueue":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deployRequest))
+			w.Write([]byte(`{"id":11,"state":"queued"}`))
+		}
+	})
+
+	result, err := tc.PromoteBuild(context.Background(), json.RawMessage(`{
+		"buildId":"10","tags":["released-1.2.3"],"comment":"Release 1.2.3","deployBuildTypeId":"App_Deploy"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /app/rest/builds/id:10",
+		"PUT /app/rest/builds/id:10/pin",
+		"POST /app/rest/builds/id:10/tags",
+		"PUT /app/rest/builds/id:10/comment",
+		"POST /app/rest/buildQueue",
+	}, requests)
+	assert.Contains(t, result, "Tags: released-1.2.3")
+	assert.Contains(t, result, "Deployment: App_Deploy queued (ID: 11)")
+	assert.Equal(t, "main", deployRequest["branchName"])
+	assert.Equal(t, map[string]interface{}{"build": []interface{}{map[string]interface{}{"id": float64(10)}}}, deployRequest["artifact-dependencies"])
+}
+
+func TestRerunBuild(t *testing.T) {
+	var queueRequest map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /app/rest/builds/id:10":
+			w.Write([]byte(`{"id":10,"number":"42","status":"FAILURE","state":"finished","buildTypeId":"App_Test","branchName":"feature",
+				"revisions":{"revision":[{"version":"abc123","vcsBranchName":"refs/heads/feature","vcs-root-instance":{"id":"7","name":"App"}}]},
+				"properties":{"property":[{"name":"env.MODE","value":"full"},{"name":"deployKey","value":"","type":{"rawValue":"password display='hidden'"}}]},
+				"snapshot-dependencies":{"build":[{"id":9,"number":"41","buildTypeId":"App_Build"}]}}`))
+		case "POST /app/rest/buildQueue":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&queueRequest))
+			w.Write([]byte(`{"id":11,"state":"queued"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.RerunBuild(context.Background(), json.RawMessage(`{"buildId":"10"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Re-run of build #42 queued successfully (ID: 11)")
+	assert.Contains(t, result, "Password parameters use their configured values: deployKey")
+
+	assert.Equal(t, "feature", queueRequest["branchName"])
+	assert.Equal(t, map[string]interface{}{"revision": []interface{}{map[string]interface{}{
+		"version": "abc123", "vcsBranchName": "refs/heads/feature", "vcs-root-instance": map[string]interface{}{"id": "7"},
+	}}}, queueRequest["revisions"])
+	assert.Equal(t, map[string]interface{}{"property": []interface{}{map[string]interface{}{"name": "env.MODE", "value": "full"}}}, queueRequest["properties"])
+	assert.Equal(t, map[string]interface{}{"build": []interface{}{map[string]interface{}{"id": float64(9)}}}, queueRequest["snapshot-dependencies"])
+
+	queueRequest = nil
+	result, err = tc.RerunBuild(context.Background(), json.RawMessage(`{"buildId":"10","rebuildDependencies":true,"dryRun":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Would re-run build #42 (ID: 10) of App_Test")
+	assert.Contains(t, result, "Dependencies: rebuilt")
+	assert.Contains(t, result, `"rebuildAllDependencies":true`)
+	assert.Nil(t, queueRequest)
+}
+
+func TestSetBuildStatus(t *testing.T) {
+	var update map[string]string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /app/rest/builds/id:10":
+			w.Write([]byte(`{"id":10,"number":"42","status":"FAILURE","state":"finished","buildTypeId":"App_Test"}`))
+		case "GET /app/rest/builds/id:11":
+			w.Write([]byte(`{"id":11,"number":"43","state":"running","buildTypeId":"App_Test"}`))
+		case "PUT /app/rest/builds/id:10/status":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.SetBuildStatus(context.Background(), json.RawMessage(`{"buildId":"10","status":"success","comment":"Agent disk full"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build #42 (ID: 10) of App_Test changed from FAILURE to SUCCESS: Agent disk full", result)
+	assert.Equal(t, map[string]string{"status": "SUCCESS", "comment": "Agent disk full"}, update)
+
+	_, err = tc.SetBuildStatus(context.Background(), json.RawMessage(`{"buildId":"10","status":"SUCCESS"}`))
+	assert.EqualError(t, err, "comment is required")
+
+	_, err = tc.SetBuildStatus(context.Background(), json.RawMessage(`{"buildId":"11","status":"SUCCESS","comment":"flaky"}`))
+	assert.EqualError(t, err, "only finished builds can change status (build #43 is running)")
+}
+
+func TestGetBuildDependencies(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:10", r.URL.Path)
+		w.Write([]byte(`{"id":10,"number":"42","state":"finished","status":"FAILURE","buildTypeId":"App_Deploy",
+			"snapshot-dependencies":{"build":[
+				{"id":8,"number":"7","state":"finished","status":"SUCCESS","buildTypeId":"App_Build","buildType":{"name":"Build"},"branchName":"main"},
+				{"id":9,"number":"3","state":"finished","status":"FAILURE","statusText":"Tests failed: 2","buildTypeId":"App_Test","buildType":{"name":"Test"}}]},
+			"artifact-dependencies":{"build":[
+				{"id":9,"number":"3","state":"finished","status":"FAILURE","buildTypeId":"App_Test","buildType":{"name":"Test"}}]}}`))
+	})
+
+	result, err := tc.GetBuildDependencies(context.Background(), json.RawMessage(`{"buildId":"10"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Dependencies of build #42 (ID: 10) of App_Deploy (finished, FAILURE)")
+	assert.Contains(t, result, "Snapshot dependencies (2):\n  #7 (ID: 8) Build (App_Build), branch main: finished, SUCCESS\n")
+	assert.Contains(t, result, "  #3 (ID: 9) Test (App_Test): finished, FAILURE\n    Tests failed: 2\n")
+	assert.Contains(t, result, "Artifact dependencies (1):")
+	assert.Contains(t, result, "Failed dependencies: #3 (ID: 9) of App_Test\n")
+}
+
+func TestManageDependencies(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildTypes/id:App_Deploy/snapshot-dependencies":
+			w.Write([]byte(`{"snapshot-dependency":[{"id":"App_Build","type":"snapshot_dependency","source-buildType":{"id":"App_Build","name":"Build"},
+				"properties":{"property":[{"name":"run-build-on-the-same-agent","value":"false"},{"name":"run-build-if-dependency-failed","value":"CANCEL"}]}}]}`))
+		case "/app/rest/buildTypes/id:App_Deploy/artifact-dependencies":
+			if r.Method == "POST" {
+				var dep teamcity.BuildTypeDependency
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&dep))
+				assert.Equal(t, "artifact_dependency", dep.Type)
+				assert.Equal(t, "App_Build", dep.SourceBuildType.ID)
+				assert.Equal(t, "buildTag", dep.Property("revisionName"))
+				assert.Equal(t, "release.tcbuildtag", dep.Property("revisionValue"))
+				assert.Equal(t, "app.zip!** => app", dep.Property("pathRules"))
+				w.Write([]byte(`{"id":"ARTIFACT_DEPENDENCY_2"}`))
+				return
+			}
+			w.Write([]byte(`{"artifact-dependency":[{"id":"ARTIFACT_DEPENDENCY_1","type":"artifact_dependency","source-buildType":{"id":"App_Build","name":"Build"},
+				"properties":{"property":[{"name":"revisionName","value":"lastSuccessful"},{"name":"revisionValue","value":"latest.lastSuccessful"},
+				{"name":"pathRules","value":"app.zip!** => app\nreport.txt"}]}}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.ManageDependencies(context.Background(), json.RawMessage(`{"buildTypeId":"App_Deploy"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Dependencies of App_Deploy:\n"+
+		"\nSnapshot dependencies (1):\n  - Build (App_Build), ID: App_Build\n"+
+		"      run-build-if-dependency-failed=CANCEL\n      run-build-on-the-same-agent=false\n"+
+		"\nArtifact dependencies (1):\n  - Build (App_Build), ID: ARTIFACT_DEPENDENCY_1\n"+
+		"      Revision: lastSuccessful (latest.lastSuccessful)\n      Artifact rules:\n        app.zip!** => app\n        report.txt\n", result)
+
+	result, err = tc.ManageDependencies(context.Background(), json.RawMessage(`{"action":"add","buildTypeId":"App_Deploy","kind":"artifact",
+		"sourceBuildTypeId":"App_Build","revisionRule":"buildTag","revisionValue":"release","pathRules":"app.zip!** => app"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Artifact dependency of App_Deploy on App_Build added (ID: ARTIFACT_DEPENDENCY_2)", result)
+
+	_, err = tc.ManageDependencies(context.Background(), json.RawMessage(`{"action":"add","buildTypeId":"App_Deploy","kind":"artifact","sourceBuildTypeId":"App_Build"}`))
+	assert.EqualError(t, err, "pathRules is required for artifact dependencies")
+	_, err = tc.ManageDependencies(context.Background(), json.RawMessage(`{"action":"remove","buildTypeId":"App_Deploy","dependencyId":"App_Build"}`))
+	assert.EqualError(t, err, "kind must be snapshot or artifact")
+}
+
+func TestGetBuildChain(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.URL.Path == "/app/rest/builds" && strings.HasPrefix(locator, "snapshotDependency:(to:(id:10)"):
+			w.Write([]byte(`{"build":[
+				{"id":10,"number":"5","state":"queued","buildTypeId":"App_Deploy","buildType":{"name":"Deploy"},"snapshot-dependencies":{"build":[{"id":8},{"id":9}]}},
+				{"id":9,"number":"3","state":"finished","status":"FAILURE","buildTypeId":"App_Test","buildType":{"name":"Test"},"snapshot-dependencies":{"build":[{"id":8}]}},
+				{"id":8,"number":"7","state":"finished","status":"SUCCESS","buildTypeId":"App_Build","buildType":{"name":"Build"}}]}`))
+		case r.URL.Path == "/app/rest/buildTypes" && strings.HasPrefix(locator, "snapshotDependency:(from:(id:App_Build)"):
+			w.Write([]byte(`{"buildType":[
+				{"id":"App_Build","name":"Build"},
+				{"id":"App_Test","name":"Test","snapshot-dependencies":{"snapshot-dependency":[{"source-buildType":{"id":"App_Build"}}]}}]}`))
+		case r.URL.Path == "/app/rest/builds" && strings.HasPrefix(locator, "buildType:(id:App_Build)"):
+			w.Write([]byte(`{"build":[{"id":8,"number":"7","state":"finished","status":"SUCCESS","buildTypeId":"App_Build"}]}`))
+		case r.URL.Path == "/app/rest/builds" && strings.HasPrefix(locator, "buildType:(id:App_Test)"):
+			w.Write([]byte(`{"build":[{"id":11,"number":"4","state":"running","status":"SUCCESS","buildTypeId":"App_Test"}]}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.GetBuildChain(context.Background(), json.RawMessage(`{"buildId":"10","direction":"upstream"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build chain of #5 (ID: 10) Deploy (App_Deploy): queued\n\n"+
+		"Upstream (dependencies) (2):\n"+
+		"  - #7 (ID: 8) Build (App_Build): finished, SUCCESS\n"+
+		"  - #3 (ID: 9) Test (App_Test): finished, FAILURE\n"+
+		"    - #7 (ID: 8) Build (App_Build): finished, SUCCESS\n"+
+		"  Failed: #3 (ID: 9) of App_Test\n", result)
+
+	result, err = tc.GetBuildChain(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","direction":"downstream"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Build chain of Build (App_Build): latest #7 (ID: 8): finished, SUCCESS\n")
+	assert.Contains(t, result, "  - Test (App_Test): latest #4 (ID: 11): running, SUCCESS\n  Running: App_Test\n")
+
+	_, err = tc.GetBuildChain(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "exactly one of buildId or buildTypeId is required")
+}
+
+func TestTriggerBuildOptions(t *testing.T) {
+	var queueRequest map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /app/rest/buildQueue":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&queueRequest))
+			w.Write([]byte(`{"id":12,"number":"N/A","state":"queued"}`))
+		case "GET /app/rest/buildTypes/id:App_Build":
+			w.Write([]byte(`{"id":"App_Build","name":"Build","projectId":"App","project":{"name":"App"}}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	_, err := tc.TriggerBuild(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","agentId":"3","agentPoolId":"2",
+		"personal":true,"cleanSources":true,"queueAtTop":true,"rebuildDependencies":true,"revision":"abc123"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": float64(3)}, queueRequest["agent"])
+	assert.Equal(t, map[string]interface{}{"id": float64(2)}, queueRequest["agentPool"])
+	assert.Equal(t, true, queueRequest["personal"])
+	assert.Equal(t, map[string]interface{}{"cleanSources": true, "queueAtTop": true, "rebuildAllDependencies": true}, queueRequest["triggeringOptions"])
+	assert.Equal(t, map[string]interface{}{"change": []interface{}{
+		map[string]interface{}{"locator": "version:abc123,buildType:(id:App_Build)"},
+	}}, queueRequest["lastChanges"])
+
+	result, err := tc.TriggerBuild(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","changeId":"55","queueAtTop":true,"dryRun":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "  Options:\n    put at the top of the queue\n    build change ID 55\n")
+
+	_, err = tc.TriggerBuild(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","changeId":"55","revision":"abc123"}`))
+	assert.EqualError(t, err, "changeId and revision cannot be combined")
+}
+
+func TestGetRunningBuildProgress(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/builds/id:10":
+			w.Write([]byte(`{"id":10,"number":"42","state":"running","status":"SUCCESS","statusText":"Tests passed: 120",
+				"buildTypeId":"App_Build","buildType":{"name":"Build"},"branchName":"main","agent":{"name":"linux-1"},
+				"running-info":{"percentageComplete":45,"elapsedSeconds":300,"estimatedTotalSeconds":660,
+					"currentStageText":"Step 2/3: Running tests (Maven)","probablyHanging":true}}`))
+		case "/app/rest/builds/id:11":
+			w.Write([]byte(`{"id":11,"number":"43","state":"finished","status":"FAILURE","buildTypeId":"App_Build"}`))
+		case "/downloadBuildLog.html":
+			assert.Equal(t, "10", r.URL.Query().Get("buildId"))
+			w.Write([]byte("line 1\nline 2\nline 3\n"))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.GetRunningBuildProgress(context.Background(), json.RawMessage(`{"buildId":"10","logLines":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build #42 (ID: 10) of Build (App_Build), branch main, on agent linux-1\n"+
+		"  Progress: 45% complete\n"+
+		"  Elapsed: 5m0s of estimated 11m0s (6m0s left)\n"+
+		"  Current stage: Step 2/3: Running tests (Maven)\n"+
+		"  Warning: the build is probably hanging\n"+
+		"  Status: SUCCESS (Tests passed: 120)\n"+
+		"  Last 2 log lines:\n    line 2\n    line 3\n", result)
+
+	result, err = tc.GetRunningBuildProgress(context.Background(), json.RawMessage(`{"buildId":"11"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build #43 (ID: 11) of App_Build has finished with status FAILURE.", result)
+}
+
+func TestBuildsResourceLocatorView(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds", r.URL.Path)
+		assert.Equal(t, "count:100,buildType:App_Build,status:FAILURE", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"count":1,"build":[{"id":7,"number":"7","status":"FAILURE","state":"finished","buildTypeId":"App_Build"}]}`))
+	})
+
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "resources/read",
+		"params": {"uri": "teamcity://builds?locator=buildType:App_Build,status:FAILURE"}
+	}`))
+	require.NoError(t, err)
+
+	result := resp.(map[string]interface{})["result"].(map[string]interface{})
+	contents := result["contents"].([]interface{})
+	require.Len(t, contents, 1)
+
+	view := contents[0].(map[string]interface{})
+	assert.Equal(t, "teamcity://builds?locator=buildType:App_Build,status:FAILURE", view["uri"])
+	assert.Equal(t, "text/plain", view["mimeType"])
+	assert.Contains(t, view["text"], "Build #7 (ID: 7)\n  Status: FAILURE")
+}
+
+func TestBuildsResourceWindow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds", r.URL.Path)
+		assert.Equal(t, "count:20,state:finished,branch:default:any,affectedProject:(id:App)", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"build":[{"id":7,"number":"7","status":"SUCCESS"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tc, err := teamcity.NewClient(config.TeamCityConfig{
+		URL:                   server.URL,
+		Token:                 "test-token",
+		Timeout:               "5s",
+		BuildsResourceCount:   "20",
+		BuildsResourceState:   "finished",
+		BuildsResourceBranch:  "default:any",
+		BuildsResourceProject: "App",
+	}, zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err)
+
+	builds, _, err := tc.ListBuilds(context.Background(), teamcity.Page{})
+	require.NoError(t, err)
+	require.Len(t, builds, 1)
+	assert.Equal(t, "teamcity://builds/7", builds[0].(map[string]interface{})["uri"])
+}
+
+func TestGetBuildDetails(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:42", r.URL.Path)
+		assert.Contains(t, r.URL.Query().Get("fields"), "triggered(")
+		w.Write([]byte(`{"id":42,"number":"108","status":"FAILURE","state":"finished","statusText":"Tests failed: 2 (1 new), passed: 98",
+			"branchName":"main","defaultBranch":true,"pinned":true,"webUrl":"https://tc/build/42",
+			"startDate":"20250101T100000+0000","finishDate":"20250101T101500+0000",
+			"buildType":{"id":"App_Build","name":"Build","projectId":"App","project":{"name":"App"}},
+			"triggered":{"type":"user","date":"20250101T095900+0000","user":{"username":"alice","name":"Alice"}},
+			"agent":{"id":3,"name":"linux-1"},
+			"revisions":{"revision":[{"version":"abc123","vcsBranchName":"refs/heads/main","vcs-root-instance":{"name":"App Git"}}]},
+			"tags":{"tag":[{"name":"release"}]},
+			"snapshot-dependencies":{"count":1,"build":[{"id":41,"number":"55","status":"SUCCESS","state":"finished","buildTypeId":"App_Compile"}]},
+			"artifacts":{"count":4},
+			"statistics":{"property":[{"name":"TimeSpentInQueue","value":"65000"},{"name":"TotalTestCount","value":"100"},
+				{"name":"PassedTestCount","value":"98"},{"name":"FailedTestCount","value":"2"},{"name":"ArtifactsSize","value":"2097152"}]}}`))
+	})
+
+	result, err := tc.GetBuildDetails(context.Background(), json.RawMessage(`{"buildId":"42"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Build #108 (ID: 42)\n")
+	assert.Contains(t, result, "  Build configuration: Build (App_Build) in project App (App)\n")
+	assert.Contains(t, result, "  State: finished, status: FAILURE\n")
+	assert.Contains(t, result, "  Status text: Tests failed: 2 (1 new), passed: 98\n")
+	assert.Contains(t, result, "  Branch: main (default)\n")
+	assert.Contains(t, result, "  Flags: pinned\n")
+	assert.Contains(t, result, "  Triggered by: user alice (Alice)\n")
+	assert.Contains(t, result, "  Finished: 2025-01-01 10:15:00 (took 15m)\n")
+	assert.Contains(t, result, "  Agent: linux-1 (ID: 3)\n")
+	assert.Contains(t, result, "  Tags: release\n")
+	assert.Contains(t, result, "    App Git: abc123 (refs/heads/main)\n")
+	assert.Contains(t, result, "  Snapshot dependencies (1):\n    #55 (ID: 41) of App_Compile: finished, SUCCESS\n")
+	assert.Contains(t, result, "  Artifacts: 4\n")
+	assert.Contains(t, result, "  Statistics: 1m5s in queue; 100 tests, 98 passed, 2 failed; artifacts 2.0 MiB\n")
+	assert.Contains(t, result, "  URL: https://tc/build/42\n")
+
+	_, err = tc.GetBuildDetails(context.Background(), json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageFailureConditions(t *testing.T) {
+	t.Run("list conditions", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/app/rest/buildTypes/id:App_Build/settings":
+				w.Write([]byte(`{"property":[{"name":"shouldFailBuildOnBadExitCode","value":"false"},{"name":"executionTimeoutMin","value":"30"}]}`))
+			case "/app/rest/buildTypes/id:App_Build/features":
+				w.Write([]byte(`{"feature":[
+					{"id":"BUILD_EXT_1","type":"BuildFailureOnMessage","properties":{"property":[
+						{"name":"buildFailureOnMessage.conditionText","value":"ERROR"},
+						{"name":"buildFailureOnMessage.conditionType","value":"contains"},
+						{"name":"buildFailureOnMessage.reverse","value":"false"}]}},
+					{"id":"BUILD_EXT_2","type":"BuildFailureOnMetric","disabled":true,"properties":{"property":[
+						{"name":"metricKey","value":"testCount"},{"name":"moreOrLess","value":"less"},{"name":"metricThreshold","value":"10"}]}},
+					{"id":"swabra","type":"swabra"}]}`))
+			}
+		})
+
+		result, err := tc.ManageFailureConditions(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+		require.NoError(t, err)
+
+		assert.Contains(t, result, "Fail on non-zero exit code (failOnExitCode): disabled")
+		assert.Contains(t, result, "Fail if tests failed (failOnTestFailure): enabled")
+		assert.Contains(t, result, "Execution timeout (executionTimeoutMin): 30 min")
+		assert.Contains(t, result, "Additional conditions (2):")
+		assert.Contains(t, result, "[BUILD_EXT_1] fail if build log contains 'ERROR'")
+		assert.Contains(t, result, "[BUILD_EXT_2] fail if metric testCount is less than 10 (disabled)")
+	})
+
+	t.Run("set common condition", func(t *testing.T) {
+		var body string
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "/app/rest/buildTypes/id:App_Build/settings/shouldFailBuildOnBadExitCode", r.URL.Path)
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		})
+
+		_, err := tc.ManageFailureConditions(context.Background(), json.RawMessage(`{"action":"set","buildTypeId":"App_Build","settings":{"failOnExitCode":"true"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "true", body)
+
+		_, err = tc.ManageFailureConditions(context.Background(), json.RawMessage(`{"action":"set","buildTypeId":"App_Build","settings":{"failFast":"true"}}`))
+		assert.EqualError(t, err, "unknown failure condition setting: failFast")
+	})
+}
+
+func TestManageBuildSettings(t *testing.T) {
+	t.Run("get settings", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/app/rest/buildTypes/id:App_Build/settings":
+				w.Write([]byte(`{"property":[{"name":"artifactRules","value":"build/*.jar\nlogs => logs.zip"},{"name":"buildNumberPattern","value":"1.%build.counter%"}]}`))
+			case "/app/rest/buildTypes/id:App_Build/vcs-root-entries":
+				w.Write([]byte(`{"vcs-root-entry":[{"id":"App_Repo","checkout-rules":"+:service-a\n-:docs","vcs-root":{"id":"App_Repo","name":"App repository"}}]}`))
+			}
+		})
+
+		result, err := tc.ManageBuildSettings(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+		require.NoError(t, err)
+
+		assert.Contains(t, result, "Build Number Format (buildNumberFormat): 1.%build.counter%")
+		assert.Contains(t, result, "Checkout Mode (checkoutMode): ON_AGENT")
+		assert.Contains(t, result, "Artifact Paths (artifactRules):\n    build/*.jar\n    logs => logs.zip\n")
+		assert.Contains(t, result, "  - App repository (App_Repo)\n      Checkout Rules:\n        +:service-a\n        -:docs\n")
+	})
+
+	t.Run("set settings and checkout rules", func(t *testing.T) {
+		updates := make(map[string]string)
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "PUT", r.Method)
+			data, _ := io.ReadAll(r.Body)
+			updates[r.URL.Path] = string(data)
+		})
+
+		result, err := tc.ManageBuildSettings(context.Background(), json.RawMessage(`{"action":"set","buildTypeId":"App_Build",
+			"settings":{"checkoutMode":"ON_SERVER"},"checkoutRules":{"App_Repo":"+:service-a"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "Settings of App_Build updated: checkoutMode, checkout rules of App_Repo", result)
+		assert.Equal(t, map[string]string{
+			"/app/rest/buildTypes/id:App_Build/settings/checkoutMode":                    "ON_SERVER",
+			"/app/rest/buildTypes/id:App_Build/vcs-root-entries/App_Repo/checkout-rules": "+:service-a",
+		}, updates)
+
+		_, err = tc.ManageBuildSettings(context.Background(), json.RawMessage(`{"action":"set","buildTypeId":"App_Build","settings":{"checkoutMode":"SOMEWHERE"}}`))
+		assert.EqualError(t, err, "checkoutMode must be one of ON_AGENT, ON_SERVER, MANUAL")
+	})
+}
+
+func TestSetCheckoutRules(t *testing.T) {
+	var updated string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(`{"vcs-root-entry":[{"id":"App_Repo","checkout-rules":"+:service-a","vcs-root":{"id":"App_Repo","name":"App repository"}}]}`))
+		case "PUT":
+			assert.Equal(t, "/app/rest/buildTypes/id:App_Build/vcs-root-entries/App_Repo/checkout-rules", r.URL.Path)
+			data, _ := io.ReadAll(r.Body)
+			updated = string(data)
+		}
+	})
+
+	result, err := tc.SetCheckoutRules(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","rules":["+:service-b","+:service-a"],"append":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "+:service-a\n+:service-b", updated)
+	assert.Contains(t, result, "Before:\n  Checkout Rules:\n    +:service-a\nAfter:\n  Checkout Rules:\n    +:service-a\n    +:service-b\n")
+
+	_, err = tc.SetCheckoutRules(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","vcsRootId":"Other","rules":[]}`))
+	assert.EqualError(t, err, "VCS root Other is not attached to App_Build")
+}
+
+func TestCreateBuildConfiguration(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/app/rest/buildTypes", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"name":"Build","project":{"id":"Shop_Payments"},
+			"templates":{"buildType":[{"id":"Shop_GoService"}]},
+			"parameters":{"property":[{"name":"env.TOKEN","value":"s3cret"}]},
+			"vcs-root-entries":{"vcs-root-entry":[{"id":"Shop_PaymentsGit","vcs-root":{"id":"Shop_PaymentsGit"},"checkout-rules":"+:src"}]}}`, string(body))
+		w.Write([]byte(`{"id":"Shop_Payments_Build","name":"Build"}`))
+	})
+
+	result, err := tc.CreateBuildConfiguration(context.Background(), json.RawMessage(`{"projectId":"Shop_Payments","name":"Build",
+		"templateId":"Shop_GoService","parameters":{"env.TOKEN":"s3cret"},"vcsRootId":"Shop_PaymentsGit","checkoutRules":"+:src"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build configuration Build (Shop_Payments_Build) created in project Shop_Payments\n"+
+		"  Template: Shop_GoService\n  Parameters: env.TOKEN\n  VCS root: Shop_PaymentsGit\n", result)
+
+	_, err = tc.CreateBuildConfiguration(context.Background(), json.RawMessage(`{"projectId":"Shop","name":"Build","checkoutRules":"+:src"}`))
+	assert.EqualError(t, err, "checkoutRules requires vcsRootId")
+}
+
+func TestPauseBuildConfiguration(t *testing.T) {
+	var puts []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"id":"Shop_Deploy","name":"Deploy","paused":false}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		puts = append(puts, r.URL.Path+" "+string(body))
+	})
+
+	result, err := tc.PauseBuildConfiguration(context.Background(), json.RawMessage(`{"buildTypeId":"Shop_Deploy","comment":"Staging is down"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/app/rest/buildTypes/id:Shop_Deploy/paused true", "/app/rest/buildTypes/id:Shop_Deploy/pauseComment Staging is down"}, puts)
+	assert.Equal(t, "Deploy (Shop_Deploy) paused: Staging is down\nAutomatic triggers are suspended; running and queued builds are not affected.", result)
+
+	// Unpausing a configuration that is not paused changes nothing
+	puts = nil
+	result, err = tc.PauseBuildConfiguration(context.Background(), json.RawMessage(`{"buildTypeId":"Shop_Deploy","paused":false}`))
+	require.NoError(t, err)
+	assert.Empty(t, puts)
+	assert.Equal(t, "Deploy (Shop_Deploy) is already unpaused", result)
+}
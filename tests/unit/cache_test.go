@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+)
+
+func TestCacheResourceType(t *testing.T) {
+	assert.Equal(t, "builds", cache.ResourceType("teamcity://builds/123"))
+	assert.Equal(t, "builds", cache.ResourceType("teamcity://builds?locator=count:10"))
+	assert.Equal(t, "projects", cache.ResourceType("teamcity://projects"))
+	assert.Equal(t, "agents", cache.ResourceType("agents:42"))
+	assert.Equal(t, "unknown", cache.ResourceType("something"))
+}
+
+func TestCacheStats(t *testing.T) {
+	c, err := cache.New(config.CacheConfig{TTL: "50ms"})
+	require.NoError(t, err)
+
+	c.Set("teamcity://builds/1", "abcd")
+	c.Set("teamcity://builds/2", "ef")
+	c.Set("teamcity://builds/2", "efgh")
+	c.Set("teamcity://projects/App", map[string]string{"id": "App"})
+
+	_, ok := c.Get("teamcity://builds/1")
+	assert.True(t, ok)
+	_, ok = c.Get("teamcity://builds/1")
+	assert.True(t, ok)
+	_, ok = c.Get("teamcity://builds/3")
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats["builds"].Entries)
+	assert.Equal(t, 8, stats["builds"].Bytes)
+	assert.Equal(t, 2, stats["builds"].Hits)
+	assert.Equal(t, 1, stats["builds"].Misses)
+	assert.InDelta(t, 2.0/3.0, stats["builds"].HitRatio(), 0.001)
+	assert.Equal(t, 1, stats["projects"].Entries)
+	assert.Equal(t, len(`{"id":"App"}`), stats["projects"].Bytes)
+
+	c.Delete("teamcity://projects/App")
+	assert.Equal(t, 0, c.Stats()["projects"].Entries)
+	assert.Equal(t, 0, c.Stats()["projects"].Bytes)
+
+	// Expired entries are evicted by the periodic cleanup
+	assert.Eventually(t, func() bool {
+		return c.Stats()["builds"].Evictions == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, c.Stats()["builds"].Entries)
+	assert.Equal(t, 0, c.Stats()["builds"].Bytes)
+}
+
+func TestResourceReadsAreCached(t *testing.T) {
+	var requests atomic.Int32
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/rest/projects/id:Cached" {
+			requests.Add(1)
+			w.Write([]byte(`{"id":"Cached","name":"Cached"}`))
+		}
+	}, nil)
+	handler, err := s.Handler(context.Background(), "http")
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	read := func(token string) {
+		req, err := http.NewRequest("POST", ts.URL+"/mcp", strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"teamcity://projects/Cached"}}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("X-TeamCity-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `\"name\":\"Cached\"`)
+	}
+
+	read("")
+	read("")
+	assert.Equal(t, int32(1), requests.Load(), "the second read is served from the cache")
+
+	// Reads with a per-request token always go to TeamCity
+	read("user-token")
+	assert.Equal(t, int32(2), requests.Load())
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `cache_hits_total{resource_type="projects"}`)
+	assert.Contains(t, string(body), "mcp_requests_total")
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestCancelledRequestIsAborted(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+			t.Error("TeamCity request was not aborted")
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	session := mcp.NewSession(func(interface{}) error { return nil })
+	ctx := mcp.WithSession(context.Background(), session)
+
+	type result struct {
+		resp interface{}
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{
+			"jsonrpc": "2.0", "id": 7, "method": "tools/call",
+			"params": {"name": "fetch_build_log", "arguments": {"buildId": "42"}}
+		}`))
+		results <- result{resp, err}
+	}()
+
+	<-started
+
+	// A cancellation from another session does not affect the request
+	other := mcp.WithSession(context.Background(), mcp.NewSession(func(interface{}) error { return nil }))
+	resp, err := handler.HandleRequest(other, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`))
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	select {
+	case <-aborted:
+		t.Fatal("request aborted by another session's cancellation")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resp, err = handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user cancelled"}}`))
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("TeamCity request was not aborted")
+	}
+
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		assert.Nil(t, r.resp, "no response is sent for cancelled requests")
+	case <-time.After(time.Second):
+		t.Fatal("cancelled request did not return")
+	}
+}
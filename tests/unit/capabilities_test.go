@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestInitializeCapabilities(t *testing.T) {
+	request := func(t *testing.T, handler *mcp.Handler, msg string) map[string]interface{} {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(msg))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+
+	t.Run("all capabilities by default", func(t *testing.T) {
+		handler := newVersionTestHandler(t)
+
+		result := request(t, handler, initialize)["result"].(map[string]interface{})
+		capabilities := result["capabilities"].(map[string]interface{})
+		for _, name := range []string{"resources", "tools", "prompts", "logging"} {
+			assert.Contains(t, capabilities, name)
+		}
+
+		instructions := result["instructions"].(string)
+		assert.Contains(t, instructions, "search_builds")
+		assert.Contains(t, instructions, "nextCursor")
+	})
+
+	t.Run("restricted capabilities", func(t *testing.T) {
+		handler := newVersionTestHandler(t)
+		require.NoError(t, handler.SetCapabilities([]string{"tools", " logging"}))
+
+		result := request(t, handler, initialize)["result"].(map[string]interface{})
+		capabilities := result["capabilities"].(map[string]interface{})
+		assert.Contains(t, capabilities, "tools")
+		assert.Contains(t, capabilities, "logging")
+		assert.NotContains(t, capabilities, "resources")
+		assert.NotContains(t, capabilities, "prompts")
+		assert.NotContains(t, result["instructions"], "teamcity://")
+
+		rpcErr := request(t, handler, `{"jsonrpc":"2.0","id":2,"method":"resources/list","params":{}}`)["error"].(map[string]interface{})
+		assert.Equal(t, -32601, rpcErr["code"])
+		rpcErr = request(t, handler, `{"jsonrpc":"2.0","id":3,"method":"prompts/list"}`)["error"].(map[string]interface{})
+		assert.Equal(t, -32601, rpcErr["code"])
+		assert.Contains(t, request(t, handler, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`), "result")
+	})
+
+	t.Run("unknown capability", func(t *testing.T) {
+		handler := newVersionTestHandler(t)
+		assert.Error(t, handler.SetCapabilities([]string{"completions"}))
+	})
+}
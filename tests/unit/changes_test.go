@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBuildsForChange(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/changes":
+			assert.Equal(t, "version:abc123", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"change":[{"id":55,"version":"abc123","username":"alice","comment":"Fix login\n\nDetails"}]}`))
+		case "/app/rest/builds":
+			assert.Contains(t, r.URL.Query().Get("locator"), "change:(version:abc123),branch:default:any")
+			w.Write([]byte(`{"build":[
+				{"id":3,"number":"3","status":"SUCCESS","state":"finished","buildTypeId":"App_Deploy","buildType":{"name":"Deploy","type":"deployment"}},
+				{"id":2,"number":"12","status":"FAILURE","state":"finished","buildTypeId":"App_Build","buildType":{"name":"Build"},"branchName":"main"},
+				{"id":1,"number":"11","status":"SUCCESS","state":"running","buildTypeId":"App_Build","buildType":{"name":"Build"}}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.GetBuildsForChange(context.Background(), json.RawMessage(`{"revision":"abc123"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Change 55 (abc123) by alice\n  Fix login\n")
+	assert.Contains(t, result, "Found 3 builds in 2 configurations (FAILURE: 1, RUNNING: 1, SUCCESS: 1)")
+	assert.Contains(t, result, "Build (App_Build)\n  - #12 (ID: 2): FAILURE [main]\n  - #11 (ID: 1): running\n")
+	assert.Contains(t, result, "Deploy (App_Deploy) [deployment]\n")
+	assert.Contains(t, result, "Deployed by: Deploy (#3, ID: 3)\n")
+
+	_, err = tc.GetBuildsForChange(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "changeId or revision is required")
+}
+
+func TestGetBuildsForChangeByBuildRevision(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/changes":
+			w.Write([]byte(`{"change":[]}`))
+		case "/app/rest/builds":
+			assert.Contains(t, r.URL.Query().Get("locator"), "revision:def456,branch:default:any")
+			w.Write([]byte(`{"build":[{"id":7,"number":"1","status":"SUCCESS","state":"finished","buildTypeId":"App_Build","buildType":{"name":"Build","type":"regular"}}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.GetBuildsForChange(context.Background(), json.RawMessage(`{"revision":"def456"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "No change found for revision def456; builds of this revision:\n")
+	assert.Contains(t, result, "Build (App_Build)\n  - #1 (ID: 7): SUCCESS\n")
+	assert.Contains(t, result, "No successful build of a deployment configuration included it yet.")
+}
+
+func TestFindBuildsByRevision(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds", r.URL.Path)
+		assert.Equal(t, "revision:3f2a9c1,branch:default:any,state:any,count:100", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"build":[
+			{"id":9,"number":"4","status":"SUCCESS","state":"finished","buildTypeId":"App_Deploy","buildType":{"name":"Deploy","type":"deployment"}},
+			{"id":8,"number":"20","status":"SUCCESS","state":"finished","buildTypeId":"App_Build","buildType":{"name":"Build","type":"regular"}}]}`))
+	})
+
+	result, err := tc.FindBuildsByRevision(context.Background(), json.RawMessage(`{"revision":"3f2a9c1"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Builds of revision 3f2a9c1:\n")
+	assert.Contains(t, result, "Found 2 builds in 2 configurations (SUCCESS: 2)")
+	assert.Contains(t, result, "Deployed by: Deploy (#4, ID: 9)\n")
+
+	_, err = tc.FindBuildsByRevision(context.Background(), json.RawMessage(`{}`))
+	assert.EqualError(t, err, "revision is required")
+}
+
+func TestRevisionLocatorInjection(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s", r.URL)
+	})
+
+	for _, call := range []func(context.Context, json.RawMessage) (string, error){tc.FindBuildsByRevision, tc.GetBuildsForChange, tc.GetChange} {
+		_, err := call(context.Background(), json.RawMessage(`{"revision":"abc,project:(id:Billing)"}`))
+		assert.EqualError(t, err, `invalid revision "abc,project:(id:Billing)": only letters, digits, '.', '_' and '-' are allowed`)
+	}
+}
+
+func TestSearchChanges(t *testing.T) {
+	var locators []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/changes", r.URL.Path)
+		locators = append(locators, r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"change":[
+			{"id":12,"version":"ccc","username":"alice","date":"20250110T090000+0000","comment":"Too new"},
+			{"id":11,"version":"bbb","username":"alice","date":"20250107T100000+0000","comment":"Fix checkout\n\nDetails","webUrl":"https://tc/change/11","vcsRootInstance":{"name":"API"}},
+			{"id":10,"version":"aaa","username":"alice","date":"20250103T100000+0000","comment":"Too old"}]}`))
+	})
+
+	result, err := tc.SearchChanges(context.Background(), json.RawMessage(`{"username":"alice","projectId":"Shop","sinceDate":"20250106T000000+0000","untilDate":"20250108T000000+0000"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"username:alice,project:(id:Shop),start:0,count:100"}, locators)
+	assert.Equal(t, "Found 1 changes:\n\nChange 11 (bbb) by alice, 2025-01-07 10:00:00\n  Fix checkout\n  VCS root: API\n  URL: https://tc/change/11\n", result)
+
+	result, err = tc.SearchChanges(context.Background(), json.RawMessage(`{"vcsRootId":"Shop_Api","count":2}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Found 2 changes:")
+	assert.Contains(t, result, "Showing the first 2 changes")
+
+	_, err = tc.SearchChanges(context.Background(), json.RawMessage(`{"sinceDate":"last week"}`))
+	assert.EqualError(t, err, "invalid sinceDate: expected YYYYMMDDTHHMMSS+HHMM")
+}
+
+func TestGetChange(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/changes":
+			assert.Equal(t, "version:abc123", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"change":[
+				{"id":55,"version":"abc123","username":"alice","date":"20250107T100000+0000","comment":"Fix login\n\nHandle expired sessions","user":{"username":"alice.smith"},
+				 "vcsRootInstance":{"name":"API"},"files":{"count":2,"file":[{"relative-file":"auth/login.go","changeType":"edited"},{"relative-file":"auth/session.go","changeType":"added"}]}},
+				{"id":56,"version":"abc123","username":"alice"}]}`))
+		case "/app/rest/builds":
+			assert.Contains(t, r.URL.Query().Get("locator"), "change:(id:55),branch:default:any")
+			w.Write([]byte(`{"build":[{"id":2,"number":"12","status":"FAILURE","state":"finished","buildTypeId":"App_Build","buildType":{"name":"Build"}}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.GetChange(context.Background(), json.RawMessage(`{"revision":"abc123"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Change 55 (abc123)\n  Author: alice (TeamCity user alice.smith)\n  Date: 2025-01-07 10:00:00\n  VCS root: API\n"+
+		"  Also collected in other VCS roots as changes 56\n")
+	assert.Contains(t, result, "\nCommit message:\n  Fix login\n  \n  Handle expired sessions\n")
+	assert.Contains(t, result, "\nChanged files (2):\n  EDITED auth/login.go\n  ADDED auth/session.go\n")
+	assert.Contains(t, result, "Found 1 builds in 1 configurations (FAILURE: 1)")
+}
+
+func TestGetBuildsForChangeDownstream(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.URL.Path == "/app/rest/changes":
+			w.Write([]byte(`{"change":[{"id":55,"version":"abc123","username":"alice"}]}`))
+		case r.URL.Path == "/app/rest/builds" && locator == "change:(id:55),branch:default:any,state:any,count:100":
+			w.Write([]byte(`{"build":[{"id":2,"number":"12","status":"SUCCESS","state":"finished","buildTypeId":"App_Build","buildType":{"name":"Build"}}]}`))
+		case r.URL.Path == "/app/rest/builds" && locator == "snapshotDependency:(from:(id:2),recursive:true,includeInitial:false),defaultFilter:false,count:100":
+			w.Write([]byte(`{"build":[
+				{"id":3,"number":"5","status":"SUCCESS","state":"finished","buildTypeId":"App_Staging","buildType":{"name":"Staging","type":"deployment"}},
+				{"id":4,"number":"2","status":"SUCCESS","state":"finished","buildTypeId":"App_Production","buildType":{"name":"Production","type":"deployment"}}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.URL.Path, locator)
+		}
+	})
+
+	result, err := tc.GetBuildsForChange(context.Background(), json.RawMessage(`{"changeId":"55","includeDownstream":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Found 3 builds, 2 of them downstream, in 3 configurations (SUCCESS: 3)")
+	assert.Contains(t, result, "Production (App_Production) [deployment]\n  - #2 (ID: 4): SUCCESS, downstream of Build #12 (ID: 2)\n")
+	assert.Contains(t, result, "Deployed by: Staging (#5, ID: 3), Production (#2, ID: 4)\n")
+}
@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func newDestructiveTestHandler(t *testing.T, queued *atomic.Int32) *mcp.Handler {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/app/rest/buildQueue":
+			queued.Add(1)
+			w.Write([]byte(`{"id":5,"number":"12","state":"queued"}`))
+		case r.URL.Path == "/app/rest/buildTypes/id:App_Build":
+			w.Write([]byte(`{"id":"App_Build","name":"Build","projectId":"App","project":{"name":"App"}}`))
+		case r.URL.Path == "/app/rest/buildTypes/id:App_Build/steps":
+			w.Write([]byte(`{"count":0}`))
+		case r.URL.Path == "/app/rest/builds/id:5":
+			w.Write([]byte(`{"id":5,"number":"12","state":"running","buildTypeId":"App_Build"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	return mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+}
+
+func callToolText(t *testing.T, handler *mcp.Handler, ctx context.Context, params string) (string, map[string]interface{}) {
+	resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+params+`}`))
+	require.NoError(t, err)
+	response := resp.(map[string]interface{})
+	if result, ok := response["result"].(map[string]interface{}); ok {
+		return result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), response
+	}
+	return "", response
+}
+
+func TestDryRun(t *testing.T) {
+	var queued atomic.Int32
+	handler := newDestructiveTestHandler(t, &queued)
+
+	text, _ := callToolText(t, handler, context.Background(), `{"name":"trigger_build","arguments":{"buildTypeId":"App_Build","branchName":"main","properties":{"env.DB_PASSWORD":"hunter2"},"dryRun":true}}`)
+	assert.Contains(t, text, "Dry run: no build was queued")
+	assert.Contains(t, text, "Would queue a build of Build (App_Build) in project App (App)")
+	assert.Contains(t, text, "Request: POST /app/rest/buildQueue")
+	assert.NotContains(t, text, "hunter2")
+	assert.Equal(t, int32(0), queued.Load())
+
+	text, _ = callToolText(t, handler, context.Background(), `{"name":"cancel_build","arguments":{"buildId":"5","dryRun":true}}`)
+	assert.Contains(t, text, "Build #12 (ID: 5) of App_Build is running and would be stopped")
+}
+
+func TestDestructiveToolConfirmation(t *testing.T) {
+	var queued atomic.Int32
+	handler := newDestructiveTestHandler(t, &queued)
+	handler.SetConfirmDestructive(true)
+	tokenPattern := regexp.MustCompile(`"confirmationToken": "([0-9a-f]+)"`)
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "alice"})
+	trigger := `{"name":"trigger_build","arguments":{"buildTypeId":"App_Build","comment":"release"}}`
+
+	text, _ := callToolText(t, handler, ctx, trigger)
+	assert.Contains(t, text, "Dry run: no build was queued")
+	assert.Contains(t, text, "Confirmation required")
+	match := tokenPattern.FindStringSubmatch(text)
+	require.Len(t, match, 2)
+	assert.Equal(t, int32(0), queued.Load())
+
+	t.Run("token is bound to the arguments", func(t *testing.T) {
+		text, _ := callToolText(t, handler, ctx, trigger)
+		token := tokenPattern.FindStringSubmatch(text)[1]
+		_, resp := callToolText(t, handler, ctx, `{"name":"trigger_build","arguments":{"buildTypeId":"App_Build","comment":"other","confirmationToken":"`+token+`"}}`)
+		require.Contains(t, resp, "error")
+		assert.Equal(t, -32602, resp["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("token is bound to the client", func(t *testing.T) {
+		text, _ := callToolText(t, handler, ctx, trigger)
+		token := tokenPattern.FindStringSubmatch(text)[1]
+		_, resp := callToolText(t, handler, context.Background(), `{"name":"trigger_build","arguments":{"comment":"release","buildTypeId":"App_Build","confirmationToken":"`+token+`"}}`)
+		assert.Contains(t, resp, "error")
+	})
+
+	text, _ = callToolText(t, handler, ctx, `{"name":"trigger_build","arguments":{"comment":"release","buildTypeId":"App_Build","confirmationToken":"`+match[1]+`"}}`)
+	assert.Contains(t, text, "Build #12 queued successfully")
+	assert.Equal(t, int32(1), queued.Load())
+
+	// Tokens are single-use
+	_, resp := callToolText(t, handler, ctx, `{"name":"trigger_build","arguments":{"buildTypeId":"App_Build","comment":"release","confirmationToken":"`+match[1]+`"}}`)
+	assert.Contains(t, resp, "error")
+	assert.Equal(t, int32(1), queued.Load())
+
+	// Read-only tools and dry runs are not confirmed
+	text, _ = callToolText(t, handler, ctx, `{"name":"get_current_time","arguments":{}}`)
+	assert.NotContains(t, text, "Confirmation required")
+	text, _ = callToolText(t, handler, ctx, `{"name":"cancel_build","arguments":{"buildId":"5","dryRun":true}}`)
+	assert.NotContains(t, text, "Confirmation required")
+
+	// Read actions of destructive tools are not confirmed, their write actions are
+	text, _ = callToolText(t, handler, ctx, `{"name":"manage_build_steps","arguments":{"buildTypeId":"App_Build"}}`)
+	assert.Equal(t, "App_Build has no build steps.", text)
+	text, _ = callToolText(t, handler, ctx, `{"name":"manage_build_steps","arguments":{"action":"list","buildTypeId":"App_Build"}}`)
+	assert.Equal(t, "App_Build has no build steps.", text)
+	text, _ = callToolText(t, handler, ctx, `{"name":"manage_build_steps","arguments":{"action":"delete","buildTypeId":"App_Build","stepId":"RUNNER_1"}}`)
+	assert.Contains(t, text, "Confirmation required")
+}
@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteProject(t *testing.T) {
+	deleted := false
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.Method == "DELETE":
+			assert.Equal(t, "/app/rest/projects/id:Shop_Legacy", r.URL.Path)
+			deleted = true
+		case r.URL.Path == "/app/rest/projects/id:Shop_Legacy":
+			w.Write([]byte(`{"id":"Shop_Legacy","name":"Legacy"}`))
+		case r.URL.Path == "/app/rest/projects":
+			w.Write([]byte(`{"project":[{"id":"Shop_Legacy","name":"Legacy"},{"id":"Shop_Legacy_Old","name":"Old"}]}`))
+		case r.URL.Path == "/app/rest/buildTypes" && strings.HasSuffix(locator, "templateFlag:true"):
+			w.Write([]byte(`{"buildType":[]}`))
+		case r.URL.Path == "/app/rest/buildTypes":
+			w.Write([]byte(`{"buildType":[{"id":"Shop_Legacy_Build","name":"Build"},{"id":"Shop_Legacy_Old_Test","name":"Test"}]}`))
+		case r.URL.Path == "/app/rest/vcs-roots":
+			w.Write([]byte(`{"vcs-root":[{"id":"Shop_Legacy_Git","name":"git@example.com:shop/legacy.git"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	summary := "  Subprojects: 1\n    - Old (Shop_Legacy_Old)\n" +
+		"  Build configurations: 2\n    - Build (Shop_Legacy_Build)\n    - Test (Shop_Legacy_Old_Test)\n" +
+		"  Templates: 0\n" +
+		"  VCS roots: 1\n    - git@example.com:shop/legacy.git (Shop_Legacy_Git)\n"
+
+	result, err := tc.DeleteProject(context.Background(), json.RawMessage(`{"projectId":"Shop_Legacy","dryRun":true}`))
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.Equal(t, "Dry run: nothing was deleted.\n\nWould delete project Legacy (Shop_Legacy), along with:\n"+summary+
+		"\nRequest: DELETE /app/rest/projects/id:Shop_Legacy", result)
+
+	result, err = tc.DeleteProject(context.Background(), json.RawMessage(`{"projectId":"Shop_Legacy"}`))
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Equal(t, "Deleted project Legacy (Shop_Legacy), along with:\n"+summary, result)
+
+	_, err = tc.DeleteProject(context.Background(), json.RawMessage(`{"projectId":"_Root"}`))
+	assert.EqualError(t, err, "the root project cannot be deleted")
+}
+
+func TestDeleteProjectEscapesID(t *testing.T) {
+	paths := make([]string, 0)
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		http.Error(w, "No project found", http.StatusNotFound)
+	})
+
+	// A path traversal in the ID must not reach another project
+	_, err := tc.DeleteProject(context.Background(), json.RawMessage(`{"projectId":"Payments_x/../Billing"}`))
+	require.Error(t, err)
+	assert.Equal(t, []string{"GET /app/rest/projects/id:Payments_x%2F..%2FBilling"}, paths)
+
+	paths = paths[:0]
+	_, err = tc.DeleteBuildConfiguration(context.Background(), json.RawMessage(`{"buildTypeId":"Payments_Build/../../projects/id:Billing"}`))
+	require.Error(t, err)
+	assert.Equal(t, []string{"GET /app/rest/buildTypes/id:Payments_Build%2F..%2F..%2Fprojects%2Fid:Billing"}, paths)
+}
+
+func TestDeleteBuildConfiguration(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.URL.Path == "/app/rest/buildTypes/id:Shop_GoService":
+			w.Write([]byte(`{"id":"Shop_GoService","name":"Go Service","projectId":"Shop","templateFlag":true}`))
+		case r.URL.Path == "/app/rest/builds":
+			assert.Equal(t, "buildType:(id:Shop_GoService),defaultFilter:false", locator)
+			w.Write([]byte(`{"count":0}`))
+		case r.URL.Path == "/app/rest/buildTypes" && strings.HasPrefix(locator, "template:"):
+			w.Write([]byte(`{"buildType":[{"id":"Shop_Payments_Build","name":"Build"}]}`))
+		case r.URL.Path == "/app/rest/buildTypes":
+			w.Write([]byte(`{"count":0}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.DeleteBuildConfiguration(context.Background(), json.RawMessage(`{"buildTypeId":"Shop_GoService","dryRun":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Dry run: nothing was deleted.\n\nWould delete template Go Service (Shop_GoService) in project Shop\n"+
+		"Build history: 0 builds\n\nAffected build configurations:\n"+
+		"  Snapshot dependencies of: 0\n  Artifact dependencies of: 0\n"+
+		"  Build configurations based on it: 1\n    - Build (Shop_Payments_Build)\n"+
+		"\nRequest: DELETE /app/rest/buildTypes/id:Shop_GoService", result)
+}
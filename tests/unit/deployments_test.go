@@ -0,0 +1,39 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeployments(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildTypes":
+			assert.Equal(t, "type:deployment,affectedProject:(id:App)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"buildType":[
+				{"id":"App_DeployProd","name":"Deploy Prod","projectName":"App"},
+				{"id":"App_DeployStaging","name":"Deploy Staging","projectName":"App"}]}`))
+		case "/app/rest/builds":
+			locator := r.URL.Query().Get("locator")
+			assert.Contains(t, locator, "status:SUCCESS")
+			if strings.HasPrefix(locator, "buildType:(id:App_DeployProd)") {
+				w.Write([]byte(`{"build":[{"id":90,"number":"17","artifact-dependencies":{"build":[
+					{"id":80,"number":"1.2.3","buildTypeId":"App_Build","branchName":"main","buildType":{"name":"Build"}}]}}]}`))
+				return
+			}
+			w.Write([]byte(`{"build":[]}`))
+		}
+	})
+
+	result, err := tc.GetDeployments(context.Background(), json.RawMessage(`{"projectId":"App"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "App / Deploy Prod (App_DeployProd)\n  Deployment: #17 (ID: 90)\n  Deployed: Build #1.2.3 (ID: 80) [main]\n")
+	assert.Contains(t, result, "App / Deploy Staging (App_DeployStaging)\n  Nothing deployed yet\n")
+}
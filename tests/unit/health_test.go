@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/health"
+)
+
+func TestHealthReportsVersions(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/projects":
+			w.Write([]byte(`{"project":[]}`))
+		case "/app/rest/server":
+			w.Write([]byte(`{"version":"2024.12 (build 174331)"}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+	checker := health.New(tc, zaptest.NewLogger(t).Sugar(), health.BuildInfo{Version: "1.2.3", Commit: "abc123"})
+
+	get := func(handler http.HandlerFunc) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	// Before the first readiness check the TeamCity version is unknown
+	code, body := get(checker.LivenessHandler)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1.2.3", body["version"])
+	assert.Equal(t, "abc123", body["commit"])
+	assert.NotContains(t, body, "teamcityVersion")
+
+	code, body = get(checker.ReadinessHandler)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1.2.3", body["version"])
+	assert.Equal(t, "abc123", body["commit"])
+	teamcity := body["checks"].(map[string]interface{})["teamcity"].(map[string]interface{})
+	assert.Equal(t, "ok", teamcity["status"])
+	assert.Equal(t, "2024.12 (build 174331)", teamcity["version"])
+	assert.Contains(t, teamcity, "latencyMs")
+
+	_, body = get(checker.LivenessHandler)
+	assert.Equal(t, "2024.12 (build 174331)", body["teamcityVersion"])
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageInvestigations(t *testing.T) {
+	var posted map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/app/rest/investigations":
+			assert.Equal(t, "buildType:(id:App_Build)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"investigation":[{"id":"buildType:(id:App_Build)","state":"TAKEN",
+				"assignee":{"username":"alice"},"assignment":{"user":{"username":"bob"},"text":"Flaky DB"},
+				"resolution":{"type":"whenFixed"},"scope":{"buildTypes":{"buildType":[{"id":"App_Build","name":"Build"}]}},
+				"target":{"anyProblem":true}}]}`))
+		case r.Method == "GET" && r.URL.Path == "/app/rest/tests":
+			w.Write([]byte(`{"test":[{"id":"-100","name":"LoginTest"}]}`))
+		case r.Method == "POST" && r.URL.Path == "/app/rest/investigations":
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &posted))
+			w.Write([]byte(`{}`))
+		case r.Method == "DELETE":
+			assert.Equal(t, "/app/rest/investigations/test:(id:-100)", r.URL.Path)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.ManageInvestigations(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "TAKEN: any problem in Build (App_Build)\n  Assignee: alice\n  Assigned by: bob\n  Comment: Flaky DB\n  Resolve: whenFixed\n")
+
+	result, err = tc.ManageInvestigations(context.Background(), json.RawMessage(`{"action":"assign","projectId":"App","testName":"LoginTest","assignee":"alice","comment":"on it"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Investigation of test LoginTest in project App assigned to alice (resolve whenFixed): on it", result)
+	assert.Equal(t, map[string]interface{}{"id": "App"}, posted["scope"].(map[string]interface{})["project"])
+	assert.Equal(t, "-100", posted["target"].(map[string]interface{})["tests"].(map[string]interface{})["test"].([]interface{})[0].(map[string]interface{})["id"])
+
+	result, err = tc.ManageInvestigations(context.Background(), json.RawMessage(`{"action":"remove","investigationId":"test:(id:-100)"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Investigation test:(id:-100) removed", result)
+
+	_, err = tc.ManageInvestigations(context.Background(), json.RawMessage(`{"action":"assign","buildTypeId":"App_Build"}`))
+	assert.EqualError(t, err, "assignee is required for assign action")
+}
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signed + "." + b64(signature)
+}
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+				{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			},
+		})
+	}))
+	t.Cleanup(jwks.Close)
+
+	validator, err := auth.NewJWTValidator(auth.JWTConfig{
+		JWKSURL:  jwks.URL,
+		Issuer:   "https://sso.example.com",
+		Audience: "teamcity-mcp",
+	}, jwks.Client())
+	require.NoError(t, err)
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   "https://sso.example.com",
+			"aud":   []string{"other", "teamcity-mcp"},
+			"sub":   "alice",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "builds:read builds:write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	t.Run("valid RS256 token", func(t *testing.T) {
+		identity, err := validator.Validate(context.Background(), signRS256(t, rsaKey, "rsa-1", claims(nil)))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", identity.Subject)
+		assert.Equal(t, auth.MethodJWT, identity.Method)
+		assert.Equal(t, []string{"builds:read", "builds:write"}, identity.Scopes)
+		assert.True(t, identity.HasScope("builds:write"))
+	})
+
+	t.Run("valid ES256 token", func(t *testing.T) {
+		identity, err := validator.Validate(context.Background(), signES256(t, ecKey, "ec-1", claims(map[string]interface{}{"scope": nil, "scp": []string{"admin"}})))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, identity.Scopes)
+	})
+
+	t.Run("keys are cached", func(t *testing.T) {
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	rejected := map[string]string{
+		"expired":        signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"no expiry":      signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"exp": nil})),
+		"not yet valid":  signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"wrong issuer":   signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"wrong audience": signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"aud": "other"})),
+		"no subject":     signRS256(t, rsaKey, "rsa-1", claims(map[string]interface{}{"sub": nil})),
+		"wrong key":      signRS256(t, otherKey, "rsa-1", claims(nil)),
+		"unknown kid":    signRS256(t, rsaKey, "rsa-2", claims(nil)),
+		"alg none":       b64([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." + b64([]byte(`{"sub":"alice"}`)) + ".",
+		"malformed":      "not.a.jwt",
+	}
+	for name, token := range rejected {
+		t.Run(name, func(t *testing.T) {
+			_, err := validator.Validate(context.Background(), token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLooksLikeJWT(t *testing.T) {
+	assert.True(t, auth.LooksLikeJWT("a.b.c"))
+	assert.False(t, auth.LooksLikeJWT("0123456789abcdef"))
+}
@@ -0,0 +1,37 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/itcaat/teamcity-mcp/internal/logging"
+)
+
+func TestRedactArguments(t *testing.T) {
+	redacted := logging.RedactArguments(json.RawMessage(`{
+		"buildTypeId": "App_Build",
+		"token": "abc",
+		"properties": {"env.DB_PASSWORD": "hunter2", "env.REGION": "eu"},
+		"parameters": [
+			{"name": "env.API_TOKEN", "value": "xyz"},
+			{"name": "env.COLOR", "value": "blue"},
+			{"name": "vcs.auth", "value": "credentialsJSON:1234"}
+		]
+	}`))
+
+	assert.Equal(t, map[string]interface{}{
+		"buildTypeId": "App_Build",
+		"token":       "[REDACTED]",
+		"properties":  map[string]interface{}{"env.DB_PASSWORD": "[REDACTED]", "env.REGION": "eu"},
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "env.API_TOKEN", "value": "[REDACTED]"},
+			map[string]interface{}{"name": "env.COLOR", "value": "blue"},
+			map[string]interface{}{"name": "vcs.auth", "value": "[REDACTED]"},
+		},
+	}, redacted)
+
+	assert.Nil(t, logging.RedactArguments(nil))
+	assert.Equal(t, "[REDACTED]", logging.RedactArguments(json.RawMessage(`{"token": "abc"`)))
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestLoggingNotifications(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":0,"build":[]}`))
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	var mu sync.Mutex
+	messages := make([]map[string]interface{}, 0)
+	session := mcp.NewSession(func(notification interface{}) error {
+		n := notification.(map[string]interface{})
+		if n["method"] == "notifications/message" {
+			mu.Lock()
+			messages = append(messages, n["params"].(map[string]interface{}))
+			mu.Unlock()
+		}
+		return nil
+	})
+	ctx := mcp.WithSession(context.Background(), session)
+
+	request := func(ctx context.Context, msg string) map[string]interface{} {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(msg))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+	searchBuilds := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search_builds","arguments":{"count":5}}}`
+
+	t.Run("no messages before setLevel", func(t *testing.T) {
+		request(ctx, searchBuilds)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("debug messages after setLevel", func(t *testing.T) {
+		resp := request(ctx, `{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"debug"}}`)
+		require.Contains(t, resp, "result")
+
+		request(ctx, searchBuilds)
+
+		mu.Lock()
+		defer mu.Unlock()
+		var teamcityRequest map[string]interface{}
+		for _, msg := range messages {
+			if msg["data"].(map[string]interface{})["message"] == "TeamCity request" {
+				teamcityRequest = msg
+			}
+		}
+		require.NotNil(t, teamcityRequest, "expected a TeamCity request log message")
+		assert.Equal(t, "debug", teamcityRequest["level"])
+		assert.Equal(t, "teamcity-mcp", teamcityRequest["logger"])
+		data := teamcityRequest["data"].(map[string]interface{})
+		assert.Equal(t, "GET", data["method"])
+		assert.Equal(t, int64(200), data["status"])
+	})
+
+	t.Run("higher level filters debug messages", func(t *testing.T) {
+		request(ctx, `{"jsonrpc":"2.0","id":3,"method":"logging/setLevel","params":{"level":"warning"}}`)
+		mu.Lock()
+		messages = messages[:0]
+		mu.Unlock()
+
+		request(ctx, searchBuilds)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		resp := request(ctx, `{"jsonrpc":"2.0","id":4,"method":"logging/setLevel","params":{"level":"verbose"}}`)
+		assert.Equal(t, -32602, resp["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("requests without session", func(t *testing.T) {
+		resp := request(context.Background(), `{"jsonrpc":"2.0","id":5,"method":"logging/setLevel","params":{"level":"debug"}}`)
+		assert.Equal(t, -32602, resp["error"].(map[string]interface{})["code"])
+	})
+}
@@ -0,0 +1,24 @@
+package unit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+)
+
+func TestCertificateIdentity(t *testing.T) {
+	assert.Nil(t, auth.CertificateIdentity(nil))
+	assert.Nil(t, auth.CertificateIdentity(&tls.ConnectionState{}), "no verified client certificate")
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"Acme"}}}
+	identity := auth.CertificateIdentity(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}})
+	require.NotNil(t, identity)
+	assert.Equal(t, "CN=alice,O=Acme", identity.Subject)
+	assert.Equal(t, auth.MethodMTLS, identity.Method)
+}
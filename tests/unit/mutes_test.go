@@ -0,0 +1,40 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestGetMutes(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/mutes", r.URL.Path)
+		assert.Equal(t, "affectedProject:(id:App)", r.URL.Query().Get("locator"))
+		w.Write([]byte(`{"mute":[
+			{"id":5,"assignment":{"user":{"username":"alice"},"timestamp":"20240101T120000+0000","text":"Flaky on Windows"},
+				"resolution":{"type":"whenFixed"},"scope":{"project":{"id":"App"}},
+				"target":{"tests":{"test":[{"id":"-1","name":"LoginTest"},{"id":"-2","name":"LogoutTest"}]}}},
+			{"id":6,"resolution":{"type":"atTime","time":"20240201T000000+0000"},"scope":{"buildTypes":{"buildType":[{"id":"App_Build"}]}},
+				"target":{"problems":{"problem":[{"id":"p1","type":"TC_EXIT_CODE","identity":"exit code 1"}]}}}]}`))
+	})
+
+	mutes, err := tc.GetMutes(context.Background(), "App")
+	require.NoError(t, err)
+
+	assert.Equal(t, "mutes", mutes.Type)
+	assert.Equal(t, 2, mutes.MutedTests)
+	assert.Equal(t, 1, mutes.MutedProblems)
+	require.Len(t, mutes.Mutes, 3)
+	assert.Equal(t, teamcity.MutedEntry{
+		MuteID: 5, Kind: "test", Name: "LogoutTest", Scope: []string{"App"},
+		MutedBy: "alice", MutedAt: "2024-01-01T12:00:00Z", Comment: "Flaky on Windows", Unmute: "whenFixed",
+	}, mutes.Mutes[1])
+	assert.Equal(t, teamcity.MutedEntry{
+		MuteID: 6, Kind: "problem", Name: "exit code 1 (TC_EXIT_CODE)", Scope: []string{"App_Build"}, Unmute: "2024-02-01T00:00:00Z",
+	}, mutes.Mutes[2])
+}
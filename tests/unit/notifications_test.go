@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+// newTestClient creates a TeamCity client backed by a fake TeamCity server
+func newTestClient(t *testing.T, handler http.HandlerFunc) *teamcity.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	tc, err := teamcity.NewClient(config.TeamCityConfig{
+		URL:     server.URL,
+		Token:   "test-token",
+		Timeout: "5s",
+	}, zaptest.NewLogger(t).Sugar())
+	require.NoError(t, err)
+
+	return tc
+}
+
+func TestManageNotificationRules(t *testing.T) {
+	t.Run("list rules of current user", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/app/rest/users/current/notificationRules", r.URL.Path)
+			w.Write([]byte(`{"count":1,"notificationRule":[{"id":"3","notifier":{"type":"email"},
+				"watchedBuilds":{"buildTypes":{"buildType":[{"id":"App_Build"}]}},
+				"events":{"buildFailed":true,"buildStarted":false}}]}`))
+		})
+
+		result, err := tc.ManageNotificationRules(context.Background(), json.RawMessage(`{}`))
+		require.NoError(t, err)
+		assert.Contains(t, result, "Rule 3 [email]")
+		assert.Contains(t, result, "App_Build")
+		assert.Contains(t, result, "Events: buildFailed")
+		assert.NotContains(t, result, "buildStarted")
+	})
+
+	t.Run("add slack rule for a user", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/app/rest/users/username:jdoe/notificationRules", r.URL.Path)
+
+			body, _ := io.ReadAll(r.Body)
+			var rule map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &rule))
+			assert.Equal(t, map[string]interface{}{"type": "slackNotifier"}, rule["notifier"])
+			assert.Equal(t, map[string]interface{}{"buildFailed": true}, rule["events"])
+
+			w.Write([]byte(`{"id":"7"}`))
+		})
+
+		result, err := tc.ManageNotificationRules(context.Background(), json.RawMessage(`{
+			"action": "add",
+			"user": "jdoe",
+			"notifier": "slack",
+			"slackChannel": "#ci",
+			"buildTypeIds": ["App_Build"]
+		}`))
+		require.NoError(t, err)
+		assert.Contains(t, result, "Notification rule 7 added")
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		})
+
+		tests := []struct {
+			name     string
+			args     string
+			expected string
+		}{
+			{"missing scope", `{"action":"add"}`, "projectId or buildTypeIds is required"},
+			{"slack without channel", `{"action":"add","notifier":"slack","projectId":"App"}`, "slackChannel is required"},
+			{"unknown event", `{"action":"add","projectId":"App","events":["exploded"]}`, "invalid event"},
+			{"delete without id", `{"action":"delete"}`, "ruleId is required"},
+			{"unknown action", `{"action":"mute"}`, "invalid action"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := tc.ManageNotificationRules(context.Background(), json.RawMessage(tt.args))
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expected)
+			})
+		}
+	})
+}
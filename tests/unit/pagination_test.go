@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestResourcesListPagination(t *testing.T) {
+	// 150 build configurations, served in pages selected by start and count locator dimensions
+	const total = 150
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/buildTypes", r.URL.Path)
+		start, count := 0, total
+		for _, dimension := range strings.Split(r.URL.Query().Get("locator"), ",") {
+			name, value, _ := strings.Cut(dimension, ":")
+			n, _ := strconv.Atoi(value)
+			switch name {
+			case "start":
+				start = n
+			case "count":
+				count = n
+			}
+		}
+		end := min(start+count, total)
+
+		buildTypes := make([]string, 0)
+		for i := start; i < end; i++ {
+			buildTypes = append(buildTypes, fmt.Sprintf(`{"id":"BT_%d","name":"Config %d"}`, i, i))
+		}
+		nextHref := ""
+		if end < total {
+			nextHref = `,"nextHref":"/app/rest/buildTypes?locator=start:` + strconv.Itoa(end) + `"`
+		}
+		fmt.Fprintf(w, `{"count":%d,"buildType":[%s]%s}`, end-start, strings.Join(buildTypes, ","), nextHref)
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	list := func(params string) map[string]interface{} {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/list","params":`+params+`}`))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+
+	first := list(`{"uri":"teamcity://buildTypes"}`)["result"].(map[string]interface{})
+	assert.Len(t, first["resources"], 100)
+	cursor, ok := first["nextCursor"].(string)
+	require.True(t, ok)
+
+	second := list(`{"uri":"teamcity://buildTypes","cursor":"` + cursor + `"}`)["result"].(map[string]interface{})
+	resources := second["resources"].([]interface{})
+	assert.Len(t, resources, 50)
+	assert.Equal(t, "teamcity://buildTypes/BT_100", resources[0].(map[string]interface{})["uri"])
+	assert.NotContains(t, second, "nextCursor")
+
+	t.Run("cursor of another listing", func(t *testing.T) {
+		rpcErr := list(`{"uri":"teamcity://projects","cursor":"` + cursor + `"}`)["error"].(map[string]interface{})
+		assert.Equal(t, -32602, rpcErr["code"])
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		rpcErr := list(`{"uri":"teamcity://buildTypes","cursor":"not a cursor"}`)["error"].(map[string]interface{})
+		assert.Equal(t, -32602, rpcErr["code"])
+	})
+}
+
+func TestToolsListPagination(t *testing.T) {
+	handler := newVersionTestHandler(t)
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`))
+	require.NoError(t, err)
+	result := resp.(map[string]interface{})["result"].(map[string]interface{})
+	assert.NotEmpty(t, result["tools"])
+	assert.NotContains(t, result, "nextCursor")
+
+	resp, err = handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{"cursor":"bogus"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, -32602, resp.(map[string]interface{})["error"].(map[string]interface{})["code"])
+}
@@ -0,0 +1,238 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestParseParameterSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected teamcity.ParameterSpec
+		rendered string
+	}{
+		{
+			name:     "Empty spec is plain text",
+			raw:      "",
+			expected: teamcity.ParameterSpec{Kind: "text"},
+			rendered: "text",
+		},
+		{
+			name: "Select with prompt",
+			raw:  "select data_1='dev' data_2='staging' data_10='prod' display='prompt' label='Environment'",
+			expected: teamcity.ParameterSpec{
+				Kind:    "select",
+				Label:   "Environment",
+				Display: "prompt",
+				Options: []string{"dev", "staging", "prod"},
+			},
+			rendered: `select, label: "Environment", options: dev | staging | prod, display: prompt`,
+		},
+		{
+			name: "Checkbox values",
+			raw:  "checkbox checkedValue='true' uncheckedValue='false'",
+			expected: teamcity.ParameterSpec{
+				Kind:           "checkbox",
+				CheckedValue:   "true",
+				UncheckedValue: "false",
+			},
+			rendered: `checkbox, checked: "true", unchecked: "false"`,
+		},
+		{
+			name:     "Password",
+			raw:      "password display='hidden'",
+			expected: teamcity.ParameterSpec{Kind: "password", Display: "hidden"},
+			rendered: "password, display: hidden",
+		},
+		{
+			name: "Required text with escaped quote",
+			raw:  "text validationMode='not_empty' description='Don|'t leave empty' display='normal'",
+			expected: teamcity.ParameterSpec{
+				Kind:        "text",
+				Description: "Don't leave empty",
+				Display:     "normal",
+				Required:    true,
+			},
+			rendered: `text, required, description: "Don't leave empty"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := teamcity.ParseParameterSpec(tt.raw)
+			assert.Equal(t, tt.expected, spec)
+			assert.Equal(t, tt.rendered, spec.String())
+		})
+	}
+}
+
+func TestListProjectParameters(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/projects/id:App_Backend/parameters":
+			w.Write([]byte(`{"property":[
+				{"name":"env.REGION","value":"eu"},
+				{"name":"deploy.token","value":"","type":{"rawValue":"password display='hidden'"}},
+				{"name":"env.JAVA_HOME","value":"/opt/jdk17","inherited":true},
+				{"name":"teamcity.ui.settings.readOnly","value":"false","inherited":true}]}`))
+		case "/app/rest/projects/id:App_Backend":
+			w.Write([]byte(`{"id":"App_Backend","name":"Backend","parentProjectId":"App"}`))
+		case "/app/rest/projects/id:App":
+			w.Write([]byte(`{"id":"App","name":"App","parentProjectId":"_Root"}`))
+		case "/app/rest/projects/id:_Root":
+			w.Write([]byte(`{"id":"_Root","name":"<Root project>"}`))
+		case "/app/rest/projects/id:App/parameters":
+			w.Write([]byte(`{"property":[{"name":"env.JAVA_HOME","value":"/opt/jdk17"},{"name":"env.REGION","value":"us"}]}`))
+		case "/app/rest/projects/id:_Root/parameters":
+			w.Write([]byte(`{"property":[{"name":"teamcity.ui.settings.readOnly","value":"false"},{"name":"env.JAVA_HOME","value":"/opt/jdk11"}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.ListProjectParameters(context.Background(), json.RawMessage(`{"projectId":"App_Backend"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Hierarchy: <Root project> > App > Backend\n")
+	assert.Contains(t, result, "  env.REGION = eu (overrides App)\n")
+	assert.Contains(t, result, "  deploy.token = *****\n")
+	assert.Contains(t, result, "  env.JAVA_HOME = /opt/jdk17 (from App [App])\n")
+	assert.Contains(t, result, "  teamcity.ui.settings.readOnly = false (from <Root project> [_Root])\n")
+}
+
+func TestSearchBuildConfigurationsParameterOrigins(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildTypes":
+			w.Write([]byte(`{"buildType":[{"id":"App_Build","name":"Build","projectId":"App"}]}`))
+		case "/app/rest/buildTypes/id:App_Build":
+			w.Write([]byte(`{"id":"App_Build","name":"Build","projectId":"App"}`))
+		case "/app/rest/buildTypes/id:App_Build/parameters":
+			w.Write([]byte(`{"property":[
+				{"name":"env.JAVA_HOME","value":"/opt/jdk21"},
+				{"name":"gradle.tasks","value":"build","inherited":true},
+				{"name":"env.REGION","value":"eu","inherited":true}]}`))
+		case "/app/rest/buildTypes/id:App_Build/templates":
+			w.Write([]byte(`{"buildType":[{"id":"App_Gradle","name":"Gradle"}]}`))
+		case "/app/rest/buildTypes/id:App_Gradle/parameters":
+			w.Write([]byte(`{"property":[{"name":"gradle.tasks","value":"build"},{"name":"env.REGION","value":"eu","inherited":true}]}`))
+		case "/app/rest/projects/id:App":
+			w.Write([]byte(`{"id":"App","name":"App Project"}`))
+		case "/app/rest/projects/id:App/parameters":
+			w.Write([]byte(`{"property":[{"name":"env.JAVA_HOME","value":"/opt/jdk17"},{"name":"env.REGION","value":"eu"}]}`))
+		}
+	})
+
+	result, err := tc.SearchBuildConfigurations(context.Background(), json.RawMessage(`{"includeDetails":true}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Text, "    env.JAVA_HOME = /opt/jdk21 (own, overrides project App Project)\n")
+	assert.Contains(t, result.Text, "    gradle.tasks = build (template Gradle)\n")
+	assert.Contains(t, result.Text, "    env.REGION = eu (project App Project)\n")
+}
+
+func TestGetBuildParameters(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:5/resulting-properties", r.URL.Path)
+		w.Write([]byte(`{"property":[
+			{"name":"env.TARGET","value":"prod"},
+			{"name":"env.DEPLOY_TOKEN","value":"s3cr3t"},
+			{"name":"env.DB","value":"credentialsJSON:1234"},
+			{"name":"system.debug","value":"false"}]}`))
+	})
+
+	result, err := tc.GetBuildParameters(context.Background(), json.RawMessage(`{"buildId":"5","prefix":"env."}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Found 3 parameters used by build 5:")
+	assert.Contains(t, result, "  env.TARGET = prod\n")
+	assert.Contains(t, result, "  env.DEPLOY_TOKEN = *****\n")
+	assert.Contains(t, result, "  env.DB = *****\n")
+	assert.NotContains(t, result, "s3cr3t")
+	assert.NotContains(t, result, "system.debug")
+}
+
+func TestParameterSpecRaw(t *testing.T) {
+	spec := teamcity.ParameterSpec{Kind: "select", Options: []string{"dev", "it's prod"}, Label: "Target", Display: "prompt", Required: true}
+	raw := spec.Raw()
+	assert.Equal(t, "select data_1='dev' data_2='it|'s prod' label='Target' display='prompt' validationMode='not_empty'", raw)
+	assert.Equal(t, spec, teamcity.ParseParameterSpec(raw))
+
+	assert.Equal(t, "", teamcity.ParameterSpec{Kind: "text"}.Raw())
+}
+
+func TestSetBuildConfigParameter(t *testing.T) {
+	var put map[string]interface{}
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/app/rest/buildTypes/id:App_Build/parameters/env.TOKEN":
+			w.Write([]byte(`{"name":"env.TOKEN","value":"old","inherited":true,"type":{"rawValue":"password display='hidden'"}}`))
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "PUT":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&put))
+			w.Write([]byte(`{}`))
+		case r.Method == "DELETE":
+			assert.Equal(t, "/app/rest/buildTypes/id:App_Build/parameters/env.TARGET", r.URL.Path)
+		}
+	})
+
+	// Without a type, the current password spec is kept and values stay masked
+	result, err := tc.SetBuildConfigParameter(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","name":"env.TOKEN","value":"new"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Parameter env.TOKEN of App_Build set to ***** (was *****, inherited)\n  Spec: password, display: hidden", result)
+	assert.Equal(t, map[string]interface{}{"rawValue": "password display='hidden'"}, put["type"])
+
+	result, err = tc.SetBuildConfigParameter(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","name":"env.TARGET","value":"dev","type":"select","options":["dev","prod"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Parameter env.TARGET of App_Build set to dev\n  Spec: select, options: dev | prod", result)
+	assert.Equal(t, map[string]interface{}{"rawValue": "select data_1='dev' data_2='prod'"}, put["type"])
+
+	_, err = tc.SetBuildConfigParameter(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","name":"env.TARGET","value":"dev","type":"select"}`))
+	assert.EqualError(t, err, "type select requires options")
+
+	result, err = tc.DeleteBuildConfigParameter(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","name":"env.TARGET"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Parameter env.TARGET deleted from App_Build", result)
+}
+
+func TestManageProjectParameters(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/projects/id:Shop_Payments":
+			w.Write([]byte(`{"id":"Shop_Payments","name":"Payments","parentProjectId":"Shop"}`))
+		case "/app/rest/projects/id:Shop":
+			w.Write([]byte(`{"id":"Shop","name":"Shop","parentProjectId":"_Root"}`))
+		case "/app/rest/projects/id:_Root":
+			w.Write([]byte(`{"id":"_Root","name":"<Root project>"}`))
+		case "/app/rest/projects/id:Shop_Payments/parameters":
+			w.Write([]byte(`{"property":[{"name":"env.TARGET","value":"staging"},{"name":"env.REGION","value":"eu","inherited":true}]}`))
+		case "/app/rest/projects/id:Shop/parameters":
+			w.Write([]byte(`{"property":[{"name":"env.TARGET","value":"prod","type":{"rawValue":"select data_1='staging' data_2='prod'"}},{"name":"env.REGION","value":"eu"}]}`))
+		case "/app/rest/projects/id:_Root/parameters":
+			w.Write([]byte(`{"property":[]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.ManageProjectParameters(context.Background(), json.RawMessage(`{"projectId":"Shop_Payments","name":"env.TARGET"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Parameter env.TARGET of project Shop_Payments = staging\n"+
+		"Defined in (nearest first):\n  Payments [Shop_Payments]: staging (effective)\n  Shop [Shop]: prod (overridden)\n", result)
+
+	result, err = tc.ManageProjectParameters(context.Background(), json.RawMessage(`{"projectId":"Shop_Payments","name":"env.MISSING"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Parameter env.MISSING is not defined in project Shop_Payments or its parent projects.", result)
+
+	_, err = tc.ManageProjectParameters(context.Background(), json.RawMessage(`{"action":"set","projectId":"Shop_Payments","name":"env.TARGET"}`))
+	assert.EqualError(t, err, "value is required for set action")
+}
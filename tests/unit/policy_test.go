@@ -0,0 +1,220 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func writePolicy(t *testing.T, policy string) *auth.Policy {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(policy), 0o600))
+	p, err := auth.LoadPolicy(path)
+	require.NoError(t, err)
+	return p
+}
+
+func TestPolicyGrant(t *testing.T) {
+	policy := writePolicy(t, `{"rules":[
+		{"subjects":["ci"]},
+		{"subjects":["payments"],"tools":["search_builds"],"projects":["Payments"]},
+		{"subjects":["*"],"tools":["get_current_time"],"projects":[]}
+	]}`)
+
+	ci := policy.Grant(&auth.Identity{Subject: "ci"})
+	assert.True(t, ci.AllowsTool("trigger_build"))
+	assert.False(t, ci.ProjectScoped())
+
+	payments := policy.Grant(&auth.Identity{Subject: "payments"})
+	assert.True(t, payments.AllowsTool("search_builds"))
+	assert.False(t, payments.AllowsTool("trigger_build"))
+	assert.True(t, payments.ProjectScoped())
+	assert.True(t, payments.AllowsProject("Payments"))
+	assert.True(t, payments.AllowsProject("Payments_Backend"))
+	assert.False(t, payments.AllowsProject("PaymentsLegacy"), "siblings sharing the ID prefix are not subprojects")
+	assert.False(t, payments.AllowsProject("PaymentsX"))
+	assert.False(t, payments.AllowsProject("Billing"))
+
+	anonymous := policy.Grant(nil)
+	assert.Equal(t, auth.Anonymous, anonymous.Subject)
+	assert.True(t, anonymous.AllowsTool("get_current_time"))
+	assert.False(t, anonymous.AllowsProject("Payments"))
+
+	_, err := auth.LoadPolicy(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules":[{"tools":["*"]}]}`), 0o600))
+	_, err = auth.LoadPolicy(path)
+	assert.Error(t, err, "rules without subjects are rejected")
+}
+
+func TestPolicyEnforcement(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildTypes/id:Payments_Build":
+			w.Write([]byte(`{"id":"Payments_Build","projectId":"Payments"}`))
+		case "/app/rest/buildTypes/id:Billing_Build":
+			w.Write([]byte(`{"id":"Billing_Build","projectId":"Billing"}`))
+		case "/app/rest/builds/id:1":
+			w.Write([]byte(`{"id":1,"buildType":{"projectId":"Payments"}}`))
+		case "/app/rest/builds/id:2":
+			w.Write([]byte(`{"id":2,"buildType":{"projectId":"Billing"}}`))
+		case "/app/rest/vcs-roots/id:Billing_Git":
+			w.Write([]byte(`{"id":"Billing_Git","project":{"id":"Billing"}}`))
+		case "/app/rest/projects":
+			w.Write([]byte(`{"count":2,"project":[{"id":"Payments","name":"Payments"},{"id":"Billing","name":"Billing"}]}`))
+		case "/app/rest/buildTypes":
+			w.Write([]byte(`{"count":2,"buildType":[{"id":"Payments_Build","projectId":"Payments"},{"id":"Billing_Build","projectId":"Billing"}]}`))
+		default:
+			w.Write([]byte(`{"count":0,"build":[]}`))
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+	handler.SetPolicy(writePolicy(t, `{"rules":[{"subjects":["payments"],"tools":["search_builds","fetch_build_log","get_current_time"],"projects":["Payments"]}]}`))
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "payments", Method: auth.MethodHMAC})
+	request := func(ctx context.Context, method, params string) map[string]interface{} {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"`+method+`","params":`+params+`}`))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+	forbidden := func(t *testing.T, resp map[string]interface{}) {
+		t.Helper()
+		require.Contains(t, resp, "error")
+		assert.Equal(t, -32001, resp["error"].(map[string]interface{})["code"])
+	}
+
+	t.Run("tools/list hides other tools", func(t *testing.T) {
+		resp := request(ctx, "tools/list", `{}`)
+		var names []string
+		for _, tool := range resp["result"].(map[string]interface{})["tools"].([]map[string]interface{}) {
+			names = append(names, tool["name"].(string))
+		}
+		assert.ElementsMatch(t, []string{"search_builds", "fetch_build_log", "get_current_time"}, names)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		assert.Contains(t, request(ctx, "tools/call", `{"name":"search_builds","arguments":{"buildTypeId":"Payments_Build"}}`), "result")
+		assert.Contains(t, request(ctx, "tools/call", `{"name":"get_current_time","arguments":{}}`), "result")
+
+		forbidden(t, request(ctx, "tools/call", `{"name":"trigger_build","arguments":{"buildTypeId":"Payments_Build"}}`))
+		forbidden(t, request(ctx, "tools/call", `{"name":"search_builds","arguments":{"buildTypeId":"Billing_Build"}}`))
+		forbidden(t, request(ctx, "tools/call", `{"name":"search_builds","arguments":{"count":5}}`))
+		forbidden(t, request(ctx, "tools/call", `{"name":"fetch_build_log","arguments":{"buildId":"2"}}`))
+		forbidden(t, request(ctx, "tools/call", `{"name":"search_builds","arguments":{"buildTypeId":"Payments_Build","vcsRootId":"Billing_Git"}}`))
+	})
+
+	t.Run("resource reads", func(t *testing.T) {
+		forbidden(t, request(ctx, "resources/read", `{"uri":"teamcity://projects/Billing"}`))
+		forbidden(t, request(ctx, "resources/read", `{"uri":"teamcity://buildTypes/Billing_Build"}`))
+		forbidden(t, request(ctx, "resources/read", `{"uri":"teamcity://builds/2"}`))
+		forbidden(t, request(ctx, "resources/read", `{"uri":"teamcity://builds?locator=status:FAILURE"}`))
+		forbidden(t, request(ctx, "resources/read", `{"uri":"teamcity://projects/tree"}`))
+		assert.Contains(t, request(ctx, "resources/read", `{"uri":"teamcity://builds/1"}`), "result")
+	})
+
+	t.Run("resource lists are filtered", func(t *testing.T) {
+		uris := func(resp map[string]interface{}) []string {
+			var result []string
+			for _, r := range resp["result"].(map[string]interface{})["resources"].([]interface{}) {
+				result = append(result, r.(map[string]interface{})["uri"].(string))
+			}
+			return result
+		}
+		assert.Equal(t, []string{"teamcity://projects/Payments"}, uris(request(ctx, "resources/list", `{"uri":"teamcity://projects"}`)))
+		assert.Equal(t, []string{"teamcity://buildTypes/Payments_Build"}, uris(request(ctx, "resources/list", `{"uri":"teamcity://buildTypes"}`)))
+		forbidden(t, request(ctx, "resources/list", `{"uri":"teamcity://builds"}`))
+	})
+
+	t.Run("unmatched clients can do nothing", func(t *testing.T) {
+		forbidden(t, request(context.Background(), "tools/call", `{"name":"get_current_time","arguments":{}}`))
+	})
+
+	t.Run("no policy allows everything", func(t *testing.T) {
+		handler.SetPolicy(nil)
+		assert.Contains(t, request(ctx, "tools/call", `{"name":"search_builds","arguments":{"count":5}}`), "result")
+	})
+}
+
+func TestPolicyToolArguments(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/app/rest/changes" && r.URL.Query().Get("locator") == "id:10":
+			w.Write([]byte(`{"change":[{"id":10,"vcsRootInstance":{"vcs-root":{"id":"Billing_Git","project":{"id":"Billing"}}}}]}`))
+		case r.URL.Path == "/app/rest/changes" && r.URL.Query().Get("locator") == "id:11":
+			w.Write([]byte(`{"change":[{"id":11,"vcsRootInstance":{"vcs-root":{"id":"Payments_Git","project":{"id":"Payments"}}}}]}`))
+		case r.URL.Path == "/app/rest/changes" && r.URL.Query().Get("locator") == "version:abc":
+			w.Write([]byte(`{"change":[{"id":11,"vcsRootInstance":{"vcs-root":{"id":"Payments_Git","project":{"id":"Payments"}}}},
+				{"id":10,"vcsRootInstance":{"vcs-root":{"id":"Billing_Git","project":{"id":"Billing"}}}}]}`))
+		case r.URL.Path == "/app/rest/changes" && r.URL.Query().Get("locator") == "version:unknown":
+			w.Write([]byte(`{"count":0}`))
+		case r.URL.Path == "/app/rest/investigations/inv-1":
+			w.Write([]byte(`{"scope":{"buildTypes":{"buildType":[{"id":"Billing_Build","projectId":"Billing"}]}}}`))
+		case r.URL.Path == "/app/rest/changes" || r.URL.Path == "/app/rest/builds":
+			w.Write([]byte(`{"count":0}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+	handler.SetPolicy(writePolicy(t, `{"rules":[{"subjects":["payments"],"tools":["*"],"projects":["Payments"]}]}`))
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "payments", Method: auth.MethodHMAC})
+	call := func(name, args string) map[string]interface{} {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+	forbidden := func(t *testing.T, resp map[string]interface{}) {
+		t.Helper()
+		require.Contains(t, resp, "error")
+		assert.Equal(t, -32001, resp["error"].(map[string]interface{})["code"])
+	}
+
+	t.Run("server tools are denied and hidden", func(t *testing.T) {
+		forbidden(t, call("manage_agent", `{"action":"disable","agent":"5","projectId":"Payments"}`))
+		forbidden(t, call("manage_agent_pools", `{"action":"assignProject","pool":"Default","projectId":"Payments"}`))
+		forbidden(t, call("get_agent_details", `{"agent":"5","projectId":"Payments"}`))
+		forbidden(t, call("search_agents", `{"projectId":"Payments"}`))
+		forbidden(t, call("manage_notification_rules", `{"action":"delete","user":"alice","ruleId":"3","projectId":"Payments"}`))
+
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`))
+		require.NoError(t, err)
+		for _, tool := range resp.(map[string]interface{})["result"].(map[string]interface{})["tools"].([]map[string]interface{}) {
+			assert.NotContains(t, []string{"manage_agent", "manage_agent_pools", "get_agent_details", "search_agents", "manage_notification_rules"}, tool["name"])
+		}
+	})
+
+	t.Run("entities named besides an allowed projectId are checked", func(t *testing.T) {
+		forbidden(t, call("manage_investigations", `{"action":"remove","investigationId":"inv-1","projectId":"Payments"}`))
+		forbidden(t, call("get_change", `{"changeId":"10","projectId":"Payments"}`))
+		forbidden(t, call("get_builds_for_change", `{"changeId":"10"}`))
+		forbidden(t, call("get_change", `{"revision":"abc","projectId":"Payments"}`))
+		forbidden(t, call("get_builds_for_change", `{"revision":"unknown","projectId":"Payments"}`))
+
+		assert.NotContains(t, call("get_change", `{"changeId":"11"}`), "error")
+	})
+
+	t.Run("IDs escaping an allowed project are rejected", func(t *testing.T) {
+		forbidden(t, call("delete_project", `{"projectId":"Payments_x/../Billing"}`))
+		forbidden(t, call("delete_build_configuration", `{"buildTypeId":"Payments_Build/../../projects/id:Billing"}`))
+		forbidden(t, call("search_builds", `{"projectId":"Payments_x,project:(id:Billing)"}`))
+		forbidden(t, call("manage_vcs_roots", `{"action":"get","vcsRootId":"Payments_Git/../Billing_Git"}`))
+	})
+}
@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateProject(t *testing.T) {
+	var description string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/app/rest/projects":
+			assert.JSONEq(t, `{"name":"Payments","id":"Shop_Payments","parentProject":{"locator":"id:Shop"}}`, string(body))
+			w.Write([]byte(`{"id":"Shop_Payments","name":"Payments","webUrl":"https://tc/project/Shop_Payments"}`))
+		case r.Method == "PUT" && r.URL.Path == "/app/rest/projects/id:Shop_Payments/description":
+			description = string(body)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.CreateProject(context.Background(), json.RawMessage(`{"name":"Payments","id":"Shop_Payments","parentProjectId":"Shop","description":"Payment services"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Project Payments (Shop_Payments) created in Shop\nURL: https://tc/project/Shop_Payments", result)
+	assert.Equal(t, "Payment services", description)
+
+	_, err = tc.CreateProject(context.Background(), json.RawMessage(`{"parentProjectId":"Shop"}`))
+	assert.EqualError(t, err, "name is required")
+}
+
+func TestGetProjectTree(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/projects", r.URL.Path)
+		w.Write([]byte(`{"project":[
+			{"id":"_Root","name":"<Root project>","buildTypes":{"count":0}},
+			{"id":"Shop","name":"Shop","parentProjectId":"_Root","templates":{"buildType":[{"id":"Shop_GoService","name":"Go Service"}]}},
+			{"id":"Shop_Payments","name":"Payments","parentProjectId":"Shop","buildTypes":{"buildType":[{"id":"Shop_Payments_Build","name":"Build","paused":true}]}},
+			{"id":"Legacy","name":"Legacy","parentProjectId":"Hidden","archived":true}]}`))
+	})
+
+	tree, err := tc.GetProjectTree(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, tree.ProjectCount)
+	assert.Equal(t, 1, tree.BuildTypeCount)
+	require.Len(t, tree.Root.Projects, 2)
+
+	shop := tree.Root.Projects[0]
+	assert.Equal(t, "Shop", shop.ID)
+	assert.Equal(t, []teamcity.BuildTypeNode{{ID: "Shop_GoService", Name: "Go Service"}}, shop.Templates)
+	require.Len(t, shop.Projects, 1)
+	assert.Equal(t, []teamcity.BuildTypeNode{{ID: "Shop_Payments_Build", Name: "Build", Paused: true}}, shop.Projects[0].BuildTypes)
+
+	// The parent of Legacy is not visible, so it is attached to the root project
+	assert.Equal(t, "Legacy", tree.Root.Projects[1].ID)
+	assert.True(t, tree.Root.Projects[1].Archived)
+}
+
+func TestManageProjectFeatures(t *testing.T) {
+	var added string
+	updated := map[string]string{}
+	deleted := false
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/app/rest/projects/id:Shop/projectFeatures":
+			w.Write([]byte(`{"projectFeature":[
+				{"id":"PROJECT_EXT_1","type":"IssueTracker","properties":{"property":[{"name":"url","value":"https://jira"},{"name":"secure:password","value":""},{"name":"type","value":"jira"}]}},
+				{"id":"PROJECT_EXT_2","type":"ReportTab","properties":{"property":[{"name":"title","value":"Coverage"}]}}]}`))
+		case r.Method == "POST" && r.URL.Path == "/app/rest/projects/id:Shop/projectFeatures":
+			added = string(body)
+			w.Write([]byte(`{"id":"PROJECT_EXT_3","type":"ReportTab","properties":{"property":[{"name":"title","value":"Docs"}]}}`))
+		case r.Method == "PUT" && r.URL.Path == "/app/rest/projects/id:Shop/projectFeatures/PROJECT_EXT_1/properties/url":
+			updated["url"] = string(body)
+		case r.Method == "DELETE" && r.URL.Path == "/app/rest/projects/id:Shop/projectFeatures/PROJECT_EXT_2":
+			deleted = true
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	result, err := tc.ManageProjectFeatures(ctx, json.RawMessage(`{"projectId":"Shop","type":"issuetracker"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Features of project Shop (1):\n\n[PROJECT_EXT_1] IssueTracker\n  secure:password: *****\n  type: jira\n  url: https://jira\n", result)
+
+	result, err = tc.ManageProjectFeatures(ctx, json.RawMessage(`{"action":"add","projectId":"Shop","type":"ReportTab","properties":{"title":"Docs"}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"ReportTab","properties":{"property":[{"name":"title","value":"Docs"}]}}`, added)
+	assert.Equal(t, "Project feature added to Shop:\n[PROJECT_EXT_3] ReportTab\n  title: Docs\n", result)
+
+	result, err = tc.ManageProjectFeatures(ctx, json.RawMessage(`{"action":"update","projectId":"Shop","featureId":"PROJECT_EXT_1","properties":{"url":"https://jira.example.com"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"url": "https://jira.example.com"}, updated)
+	assert.Equal(t, "Project feature PROJECT_EXT_1 of Shop updated: url", result)
+
+	_, err = tc.ManageProjectFeatures(ctx, json.RawMessage(`{"action":"delete","projectId":"Shop","featureId":"PROJECT_EXT_2"}`))
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = tc.ManageProjectFeatures(ctx, json.RawMessage(`{"action":"add","projectId":"Shop"}`))
+	assert.EqualError(t, err, "type is required for add action")
+}
+
+func TestSearchProjects(t *testing.T) {
+	var locator string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/projects", r.URL.Path)
+		locator = r.URL.Query().Get("locator")
+		w.Write([]byte(`{"project":[
+			{"id":"Shop","name":"Shop","parentProjectId":"_Root"},
+			{"id":"Shop_Payments","name":"Payments","parentProjectId":"Shop","description":"Payment services"},
+			{"id":"Shop_PaymentsLegacy","name":"Payments Legacy","parentProjectId":"Shop_Payments","archived":true},
+			{"id":"Shop_PaymentsGateway","name":"Payments Gateway","parentProjectId":"Shop_Payments"}]}`))
+	})
+
+	result, err := tc.SearchProjects(context.Background(), json.RawMessage(`{"name":"payments","parentProjectId":"Shop","recursive":true,"count":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, "name:(value:(payments),matchType:contains,ignoreCase:true),affectedProject:(id:Shop),count:4", locator)
+	assert.Equal(t, "Found 2 projects:\n\n"+
+		"Payments (Shop_Payments)\n  Parent: Shop\n  Description: Payment services\n"+
+		"Payments Legacy (Shop_PaymentsLegacy) [ARCHIVED]\n  Parent: Shop_Payments\n"+
+		"\nOnly the first 2 projects are shown; narrow the search or raise count to see more.\n", result)
+
+	_, err = tc.SearchProjects(context.Background(), json.RawMessage(`{"parentProjectId":"Shop","archived":false}`))
+	require.NoError(t, err)
+	assert.Equal(t, "parentProject:(id:Shop),archived:false,count:101", locator)
+
+	_, err = tc.SearchProjects(context.Background(), json.RawMessage(`{"count":5000}`))
+	assert.EqualError(t, err, "count must be between 1 and 1000")
+}
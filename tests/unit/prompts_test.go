@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func newPromptsHandler(t *testing.T) *mcp.Handler {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("prompts must not call TeamCity: %s", r.URL.Path)
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	return mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+}
+
+func TestPromptsList(t *testing.T) {
+	handler := newPromptsHandler(t)
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+	require.NoError(t, err)
+
+	result := resp.(map[string]interface{})["result"].(map[string]interface{})
+	names := make([]string, 0)
+	for _, p := range result["prompts"].([]interface{}) {
+		names = append(names, p.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"diagnose_failed_build", "summarize_pipeline_health", "investigate_flaky_test"}, names)
+}
+
+func TestPromptsGet(t *testing.T) {
+	handler := newPromptsHandler(t)
+
+	t.Run("renders arguments into tool calls", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+			"jsonrpc": "2.0", "id": 1, "method": "prompts/get",
+			"params": {"name": "diagnose_failed_build", "arguments": {"buildId": "12345"}}
+		}`))
+		require.NoError(t, err)
+
+		result := resp.(map[string]interface{})["result"].(map[string]interface{})
+		messages := result["messages"].([]interface{})
+		require.Len(t, messages, 1)
+
+		message := messages[0].(map[string]interface{})
+		assert.Equal(t, "user", message["role"])
+		text := message["content"].(map[string]interface{})["text"].(string)
+		assert.Contains(t, text, `fetch_build_log with {"buildId": "12345", "severity": "error", "tailLines": 200}`)
+		assert.Contains(t, text, `get_resolved_build_steps with {"buildId": "12345"}`)
+	})
+
+	t.Run("optional arguments", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+			"jsonrpc": "2.0", "id": 2, "method": "prompts/get",
+			"params": {"name": "summarize_pipeline_health", "arguments": {"buildTypeId": "App_Build"}}
+		}`))
+		require.NoError(t, err)
+
+		result := resp.(map[string]interface{})["result"].(map[string]interface{})
+		text := result["messages"].([]interface{})[0].(map[string]interface{})["content"].(map[string]interface{})["text"].(string)
+		assert.Contains(t, text, `search_builds with {"buildTypeId": "App_Build", "state": "finished", "count": 20}`)
+		assert.NotContains(t, text, "get_deployments")
+	})
+
+	t.Run("missing required argument", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+			"jsonrpc": "2.0", "id": 3, "method": "prompts/get",
+			"params": {"name": "investigate_flaky_test", "arguments": {"projectId": "App"}}
+		}`))
+		require.NoError(t, err)
+
+		rpcErr := resp.(map[string]interface{})["error"].(map[string]interface{})
+		assert.Equal(t, -32602, rpcErr["code"])
+		assert.Equal(t, "missing required argument: testName", rpcErr["data"])
+	})
+
+	t.Run("unknown prompt", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{
+			"jsonrpc": "2.0", "id": 4, "method": "prompts/get",
+			"params": {"name": "nope"}
+		}`))
+		require.NoError(t, err)
+
+		rpcErr := resp.(map[string]interface{})["error"].(map[string]interface{})
+		assert.Equal(t, "unknown prompt: nope", rpcErr["data"])
+	})
+}
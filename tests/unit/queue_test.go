@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/metrics"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestGetQueueStats(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/buildQueue", r.URL.Path)
+		w.Write([]byte(`{"build":[
+			{"id":3,"buildTypeId":"App_Test","queuedDate":"20240101T120500+0000","compatibleAgents":{"agent":[
+				{"id":1,"pool":{"id":0,"name":"Default"}},{"id":2,"pool":{"id":1,"name":"Linux"}},{"id":3,"pool":{"id":1,"name":"Linux"}}]}},
+			{"id":2,"buildTypeId":"App_Build","queuedDate":"20240101T120000+0000","compatibleAgents":{"agent":[{"id":2,"pool":{"id":1,"name":"Linux"}}]}},
+			{"id":4,"buildTypeId":"App_Mac","queuedDate":"20240101T121000+0000","compatibleAgents":{}}]}`))
+	})
+
+	stats, err := tc.GetQueueStats(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.Length)
+	assert.Equal(t, 2, stats.OldestBuildID)
+	assert.Equal(t, "App_Build", stats.OldestBuildTypeID)
+	assert.Greater(t, stats.OldestAgeSeconds, int64(0))
+	assert.Equal(t, []teamcity.PoolQueueStat{
+		{Pool: "Linux", Queued: 2},
+		{Pool: "(no compatible agents)", Queued: 1},
+		{Pool: "Default", Queued: 1},
+	}, stats.Pools)
+}
+
+func TestListQueuedBuilds(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/buildQueue":
+			w.Write([]byte(`{"build":[
+				{"id":2,"buildTypeId":"Other_Build","buildType":{"name":"Other","projectId":"Other"},"queuedDate":"20240101T120000+0000"},
+				{"id":3,"buildTypeId":"App_Test","buildType":{"name":"Test","projectId":"App_Sub"},"branchName":"main",
+					"queuedDate":"20240101T120500+0000","waitReason":"There are no idle compatible agents which can run this build",
+					"triggered":{"type":"user","user":{"username":"alice"}},"compatibleAgents":{"count":2}},
+				{"id":4,"buildTypeId":"App_Build","buildType":{"name":"Build","projectId":"App"},"queuedDate":"20240101T121000+0000",
+					"startEstimate":"20240101T123000+0000","plannedAgent":{"name":"linux-1"}}]}`))
+		case "/app/rest/projects":
+			assert.Equal(t, "affectedProject:(id:App)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"project":[{"id":"App"},{"id":"App_Sub"}]}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	result, err := tc.ListQueuedBuilds(context.Background(), []byte(`{"projectId":"App"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "2 queued builds of project App (3 builds in the queue)")
+	assert.Contains(t, result, "#2 Test (App_Test), build ID 3, branch main")
+	assert.Contains(t, result, "Wait reason: There are no idle compatible agents which can run this build")
+	assert.Contains(t, result, "Triggered by: user alice")
+	assert.Contains(t, result, "Compatible agents: 2")
+	assert.Contains(t, result, "#3 Build (App_Build), build ID 4")
+	assert.Contains(t, result, "Planned agent: linux-1")
+	assert.NotContains(t, result, "Other_Build")
+
+	result, err = tc.ListQueuedBuilds(context.Background(), []byte(`{"buildTypeId":"Missing"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "No queued builds of build configuration Missing (3 builds in the queue).", result)
+}
+
+func TestManageBuildQueue(t *testing.T) {
+	var requests []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/app/rest/buildQueue" {
+			w.Write([]byte(`{"build":[{"id":2,"buildTypeId":"App_Build"},{"id":3,"buildTypeId":"App_Test"},{"id":4,"buildTypeId":"App_Deploy"}]}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+	})
+
+	result, err := tc.ManageBuildQueue(context.Background(), []byte(`{"action":"move_to_top","buildId":"4"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build ID 4 of App_Deploy (queue position #3) moved to the top of the queue", result)
+
+	result, err = tc.ManageBuildQueue(context.Background(), []byte(`{"action":"reorder","buildIds":["4","3"]}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "2 builds moved to the top of the queue in this order:\n  #1 Build ID 4 of App_Deploy (queue position #3)\n  #2 Build ID 3")
+
+	result, err = tc.ManageBuildQueue(context.Background(), []byte(`{"action":"remove","buildId":"2","comment":"obsolete"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build ID 2 of App_Build (queue position #1) removed from the queue", result)
+
+	assert.Equal(t, []string{
+		`PUT /app/rest/buildQueue/order/1 {"id":4}`,
+		`PUT /app/rest/buildQueue/order {"build":[{"id":4},{"id":3}]}`,
+		`POST /app/rest/buildQueue/id:2 {"comment":"obsolete","readdIntoQueue":false}`,
+	}, requests)
+
+	t.Run("dry run", func(t *testing.T) {
+		requests = nil
+		result, err := tc.ManageBuildQueue(context.Background(), []byte(`{"action":"remove","buildId":"3","dryRun":true}`))
+		require.NoError(t, err)
+		assert.Contains(t, result, "Dry run: the queue was not changed")
+		assert.Contains(t, result, "Build ID 3 of App_Test (queue position #2) would be removed from the queue")
+		assert.Empty(t, requests)
+	})
+
+	t.Run("build not in the queue", func(t *testing.T) {
+		failures := testutil.ToFloat64(metrics.TeamCityRequestsTotal.WithLabelValues("manage_build_queue", "error"))
+		_, err := tc.ManageBuildQueue(context.Background(), []byte(`{"action":"move_to_top","buildId":"9"}`))
+		assert.EqualError(t, err, "build 9 is not in the queue")
+		assert.Equal(t, failures+1, testutil.ToFloat64(metrics.TeamCityRequestsTotal.WithLabelValues("manage_build_queue", "error")), "failed calls are recorded as errors")
+	})
+}
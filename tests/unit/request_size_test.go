@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+)
+
+// oversizedPing is a ping request padded beyond a 200 byte limit
+var oversizedPing = `{"jsonrpc":"2.0","id":2,"method":"ping","params":{"padding":"` + strings.Repeat("x", 500) + `"}}`
+
+// limitRequestSize sets the maximum request size to 200 bytes
+func limitRequestSize(cfg *config.ServerConfig) {
+	cfg.MaxRequestSize = "200"
+}
+
+// assertRequestTooLarge checks a JSON-RPC error response for an oversized request
+func assertRequestTooLarge(t *testing.T, message map[string]interface{}) {
+	assert.Nil(t, message["id"])
+	rpcError, ok := message["error"].(map[string]interface{})
+	require.True(t, ok, "expected an error response, got %v", message)
+	assert.Equal(t, float64(-32600), rpcError["code"])
+	assert.Equal(t, "Request too large", rpcError["message"])
+	assert.Equal(t, "request exceeds the maximum size of 200 bytes", rpcError["data"])
+}
+
+func TestHTTPMaxRequestSize(t *testing.T) {
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {}, limitRequestSize)
+	handler, err := s.Handler(context.Background(), "http")
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(oversizedPing))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var message map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&message))
+	assertRequestTooLarge(t, message)
+
+	resp, err = http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWebSocketMaxRequestSize(t *testing.T) {
+	conn := dialWebSocket(t, limitRequestSize)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(oversizedPing)))
+	var message map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&message))
+	assertRequestTooLarge(t, message)
+
+	// The connection stays usable after an oversized message
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "ping"}))
+	message = nil
+	require.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, float64(3), message["id"])
+	assert.Contains(t, message, "result")
+}
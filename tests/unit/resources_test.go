@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestResourceTemplates(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/builds/id:42":
+			w.Write([]byte(`{"id":42,"number":"7","status":"SUCCESS"}`))
+		case "/app/rest/projects/id:App/buildTypes":
+			w.Write([]byte(`{"count":1,"buildType":[{"id":"App_Build"}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/templates/list"}`))
+	require.NoError(t, err)
+
+	result := resp.(map[string]interface{})["result"].(map[string]interface{})
+	templates := make([]string, 0)
+	for _, tmpl := range result["resourceTemplates"].([]interface{}) {
+		templates = append(templates, tmpl.(map[string]interface{})["uriTemplate"].(string))
+	}
+	assert.Contains(t, templates, "teamcity://builds/{buildId}")
+	assert.Contains(t, templates, "teamcity://projects/{projectId}/buildTypes")
+	assert.Contains(t, templates, "teamcity://mutes/{projectId}")
+
+	// URIs expanded from the templates are readable
+	read := func(uri string) map[string]interface{} {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"`+uri+`"}}`))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+
+	contents := read("teamcity://builds/42")["result"].(map[string]interface{})["contents"].([]interface{})
+	require.Len(t, contents, 1)
+	assert.Equal(t, "application/json", contents[0].(map[string]interface{})["mimeType"])
+	assert.JSONEq(t, `{"id":42,"number":"7","status":"SUCCESS"}`, contents[0].(map[string]interface{})["text"].(string))
+
+	contents = read("teamcity://projects/App/buildTypes")["result"].(map[string]interface{})["contents"].([]interface{})
+	assert.Contains(t, contents[0].(map[string]interface{})["text"], "App_Build")
+
+	rpcErr := read("teamcity://builds/")["error"].(map[string]interface{})
+	assert.Contains(t, rpcErr["data"], "unsupported resource URI")
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+)
+
+func TestServerSecrets(t *testing.T) {
+	t.Run("named secrets with expiry and the default secret", func(t *testing.T) {
+		cfg := config.ServerConfig{
+			ServerSecret: "legacy",
+			NamedSecrets: "ci:secret1, alice:secret2:2026-12-31,bob:secret3:2026-06-01T12:00:00Z",
+		}
+		secrets, err := cfg.ServerSecrets()
+		require.NoError(t, err)
+		require.Len(t, secrets, 4)
+
+		assert.Equal(t, config.NamedSecret{Name: "ci", Secret: "secret1"}, secrets[0])
+		assert.Equal(t, "secret2", secrets[1].Secret)
+		assert.Equal(t, time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), secrets[1].Expires)
+		assert.Equal(t, time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC), secrets[2].Expires.UTC())
+		assert.Equal(t, config.NamedSecret{Name: "default", Secret: "legacy"}, secrets[3])
+
+		assert.False(t, secrets[0].Expired(time.Now()))
+		assert.False(t, secrets[1].Expired(time.Date(2026, 12, 30, 23, 59, 0, 0, time.UTC)))
+		assert.True(t, secrets[1].Expired(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("no secrets", func(t *testing.T) {
+		secrets, err := config.ServerConfig{}.ServerSecrets()
+		require.NoError(t, err)
+		assert.Empty(t, secrets)
+	})
+
+	invalid := map[string]string{
+		"missing secret": "ci",
+		"empty secret":   "ci:",
+		"empty name":     ":secret",
+		"bad expiry":     "ci:secret:tomorrow",
+		"duplicate name": "ci:secret1,ci:secret2",
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := config.ServerConfig{NamedSecrets: value}.ServerSecrets()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSettings(t *testing.T) {
+	t.Run("kotlin dsl of a build configuration's project", func(t *testing.T) {
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		for name, content := range map[string]string{
+			".teamcity/settings.kts": "version = \"2024.03\"\nproject {}\n",
+			".teamcity/pom.xml":      "<project/>",
+		} {
+			f, err := zw.Create(name)
+			require.NoError(t, err)
+			f.Write([]byte(content))
+		}
+		require.NoError(t, zw.Close())
+
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/app/rest/buildTypes/id:App_Build":
+				w.Write([]byte(`{"projectId":"App"}`))
+			case "/admin/projectSettingsExport.html":
+				assert.Equal(t, "App", r.URL.Query().Get("projectId"))
+				assert.Equal(t, "kotlin", r.URL.Query().Get("format"))
+				w.Write(archive.Bytes())
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		})
+
+		result, err := tc.ExportSettings(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+		require.NoError(t, err)
+		assert.Contains(t, result, "Kotlin DSL settings of project App (2 files):")
+		assert.Contains(t, result, "=== .teamcity/settings.kts ===\nversion = \"2024.03\"\nproject {}\n")
+	})
+
+	t.Run("kotlin dsl of a build configuration declared in its project", func(t *testing.T) {
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		f, err := zw.Create(".teamcity/settings.kts")
+		require.NoError(t, err)
+		f.Write([]byte("project {\n    buildType(Build)\n    buildType(Deploy)\n}\n\n" +
+			"object Build : BuildType({\n    name = \"Build\"\n})\n\n" +
+			"object Deploy : BuildType({\n    name = \"Deploy\"\n    dependencies {\n        snapshot(Build) {}\n    }\n})\n"))
+		require.NoError(t, zw.Close())
+
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/app/rest/buildTypes/id:App_Deploy":
+				w.Write([]byte(`{"projectId":"App"}`))
+			case "/admin/projectSettingsExport.html":
+				w.Write(archive.Bytes())
+			default:
+				t.Errorf("unexpected request: %s", r.URL.Path)
+			}
+		})
+
+		result, err := tc.ExportSettings(context.Background(), json.RawMessage(`{"buildTypeId":"App_Deploy"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "Kotlin DSL of build configuration App_Deploy (object Deploy in .teamcity/settings.kts of project App):\n\n"+
+			"object Deploy : BuildType({\n    name = \"Deploy\"\n    dependencies {\n        snapshot(Build) {}\n    }\n})\n\n"+
+			"Export with projectId App for the settings of the whole project.", result)
+	})
+
+	t.Run("xml of a build configuration", func(t *testing.T) {
+		tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/app/rest/buildTypes/id:App_Build", r.URL.Path)
+			w.Write([]byte(`<buildType id="App_Build"/>`))
+		})
+
+		result, err := tc.ExportSettings(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build","format":"xml"}`))
+		require.NoError(t, err)
+		assert.Equal(t, "XML settings of build configuration App_Build:\n\n<buildType id=\"App_Build\"/>", result)
+	})
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readSSEEvent reads the next event from an event stream, skipping keep-alive comments
+func readSSEEvent(t *testing.T, stream *bufio.Reader) (event, data string) {
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestSSETransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {}, nil)
+	handler, err := s.Handler(ctx, "sse")
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/sse")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	stream := bufio.NewReader(resp.Body)
+	event, endpoint := readSSEEvent(t, stream)
+	require.Equal(t, "endpoint", event)
+	require.True(t, strings.HasPrefix(endpoint, "/messages?sessionId="), endpoint)
+	assert.Greater(t, len(endpoint), len("/messages?sessionId="))
+
+	t.Run("responses to posted messages are sent on the stream", func(t *testing.T) {
+		post, err := http.Post(ts.URL+endpoint, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"ping"}`))
+		require.NoError(t, err)
+		post.Body.Close()
+		assert.Equal(t, http.StatusAccepted, post.StatusCode)
+
+		event, data := readSSEEvent(t, stream)
+		assert.Equal(t, "message", event)
+
+		var message map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &message))
+		assert.Equal(t, float64(7), message["id"])
+		assert.Contains(t, message, "result")
+	})
+
+	t.Run("unknown session is rejected", func(t *testing.T) {
+		post, err := http.Post(ts.URL+"/messages?sessionId=unknown", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":8,"method":"ping"}`))
+		require.NoError(t, err)
+		post.Body.Close()
+		assert.Equal(t, http.StatusNotFound, post.StatusCode)
+
+		post, err = http.Post(ts.URL+"/messages", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"ping"}`))
+		require.NoError(t, err)
+		post.Body.Close()
+		assert.Equal(t, http.StatusNotFound, post.StatusCode)
+	})
+
+	t.Run("stream requires GET", func(t *testing.T) {
+		post, err := http.Post(ts.URL+"/sse", "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		post.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, post.StatusCode)
+	})
+
+	t.Run("cancelling the context ends the stream", func(t *testing.T) {
+		cancel()
+		_, err := stream.ReadString('\n')
+		assert.Error(t, err)
+	})
+}
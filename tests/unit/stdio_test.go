@@ -0,0 +1,214 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/health"
+	"github.com/itcaat/teamcity-mcp/internal/server"
+)
+
+// newTestServer creates a server talking to a fake TeamCity; configure adjusts its server settings
+func newTestServer(t *testing.T, teamcity http.HandlerFunc, configure func(*config.ServerConfig)) *server.Server {
+	tc := httptest.NewServer(teamcity)
+	t.Cleanup(tc.Close)
+
+	cfg := &config.Config{
+		TeamCity: config.TeamCityConfig{URL: tc.URL, Token: "test-token", Timeout: "5s"},
+		Server: config.ServerConfig{
+			ListenAddr:     "127.0.0.1:0",
+			MaxRequestSize: "1048576",
+			Capabilities:   "resources,tools,prompts,logging",
+		},
+		Cache: config.CacheConfig{TTL: "10s"},
+	}
+	if configure != nil {
+		configure(&cfg.Server)
+	}
+
+	s, err := server.New(cfg, zaptest.NewLogger(t).Sugar(), health.BuildInfo{})
+	require.NoError(t, err)
+	return s
+}
+
+// contentLengthFrame frames a message with a Content-Length header
+func contentLengthFrame(message string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(message), message)
+}
+
+// readContentLengthFrames decodes all Content-Length framed messages of output
+func readContentLengthFrames(t *testing.T, output []byte) []map[string]interface{} {
+	headers := textproto.NewReader(bufio.NewReader(bytes.NewReader(output)))
+	var messages []map[string]interface{}
+	for {
+		header, err := headers.ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(t, err)
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+		body := make([]byte, length)
+		_, err = io.ReadFull(headers.R, body)
+		require.NoError(t, err)
+
+		var message map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &message))
+		messages = append(messages, message)
+	}
+}
+
+func TestSTDIOContentLengthFraming(t *testing.T) {
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {}, func(cfg *config.ServerConfig) {
+		cfg.MaxRequestSize = "100"
+	})
+	require.NoError(t, s.SetSTDIOFraming("content-length"))
+
+	input := contentLengthFrame(`{"jsonrpc":"2.0","id":1,"method":"ping"}`) +
+		contentLengthFrame(`{"jsonrpc":"2.0","id":2,"method":"ping","params":{"padding":"`+strings.Repeat("x", 200)+`"}}`) +
+		"Content-Length: abc\r\n\r\n" +
+		contentLengthFrame(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	var output bytes.Buffer
+	require.NoError(t, s.ServeSTDIO(context.Background(), strings.NewReader(input), &output))
+
+	// Requests are handled concurrently, so responses may come in any order
+	results := map[float64]bool{}
+	errors := map[float64]interface{}{}
+	for _, message := range readContentLengthFrames(t, output.Bytes()) {
+		if id, ok := message["id"].(float64); ok {
+			results[id] = message["result"] != nil
+			continue
+		}
+		assert.Nil(t, message["id"])
+		rpcError := message["error"].(map[string]interface{})
+		errors[rpcError["code"].(float64)] = rpcError["data"]
+	}
+
+	assert.Equal(t, map[float64]bool{1: true, 3: true}, results, "the oversized message is answered without its ID")
+	assert.Equal(t, map[float64]interface{}{
+		-32600: "request exceeds the maximum size of 100 bytes",
+		-32700: "missing or invalid Content-Length header",
+	}, errors)
+}
+
+// syncBuffer is a buffer safe for writes from abandoned request goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSTDIOShutdownDrain(t *testing.T) {
+	// serve starts the STDIO transport with a slow get_build_details request in flight, then cancels
+	// the server context; TeamCity answers the build request once release is closed
+	serve := func(t *testing.T, drainTimeout string, release <-chan struct{}) (output *syncBuffer, done <-chan error) {
+		started := make(chan struct{})
+		s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/app/rest/builds/id:1" {
+				return
+			}
+			close(started)
+			select {
+			case <-release:
+				w.Write([]byte(`{"id":1,"number":"7","state":"finished","status":"SUCCESS"}`))
+			case <-r.Context().Done():
+			}
+		}, func(cfg *config.ServerConfig) {
+			cfg.STDIOShutdownTimeout = drainTimeout
+		})
+
+		input, stdin := io.Pipe()
+		t.Cleanup(func() { stdin.Close() })
+		output = &syncBuffer{}
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- s.ServeSTDIO(ctx, input, output)
+		}()
+
+		_, err := io.WriteString(stdin, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_build_details","arguments":{"buildId":"1"}}}`+"\n")
+		require.NoError(t, err)
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("request did not reach TeamCity")
+		}
+		cancel()
+		return output, errc
+	}
+
+	t.Run("in-flight request finishes before exit", func(t *testing.T) {
+		release := make(chan struct{})
+		output, done := serve(t, "5s", release)
+
+		select {
+		case <-done:
+			t.Fatal("returned while a request was in flight")
+		case <-time.After(200 * time.Millisecond):
+		}
+		close(release)
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("did not return after the in-flight request finished")
+		}
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output.String()), &response))
+		assert.Equal(t, float64(1), response["id"])
+		assert.Contains(t, response, "result")
+		assert.Contains(t, output.String(), "Build #7 (ID: 1)")
+	})
+
+	t.Run("request still running after the drain timeout is abandoned", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		start := time.Now()
+		output, done := serve(t, "100ms", release)
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("did not return after the drain timeout")
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		assert.NotContains(t, output.String(), "Build #7")
+
+		// The abandoned request is cancelled; let it answer before the test logger goes away
+		assert.Eventually(t, func() bool {
+			return strings.Contains(output.String(), `"id":1`)
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.NotContains(t, output.String(), "Build #7")
+	})
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetResolvedBuildSteps(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/builds/id:5":
+			w.Write([]byte(`{"id":5,"number":"42","buildTypeId":"App_Build"}`))
+		case "/app/rest/builds/id:5/resulting-properties":
+			w.Write([]byte(`{"count":2,"property":[{"name":"env.TARGET","value":"prod"},{"name":"build.number","value":"42"}]}`))
+		case "/app/rest/buildTypes/id:App_Build/steps":
+			w.Write([]byte(`{"count":2,"step":[
+				{"id":"RUNNER_1","name":"Deploy","type":"simpleRunner","properties":{"property":[
+					{"name":"script.content","value":"deploy.sh %env.TARGET% %build.number% 100%% %missing.param%"},
+					{"name":"secure:password","value":"credentialsJSON:abc"}]}},
+				{"id":"RUNNER_2","name":"Old","type":"simpleRunner","disabled":true,"properties":{"count":0}}]}`))
+		}
+	})
+
+	result, err := tc.GetResolvedBuildSteps(context.Background(), json.RawMessage(`{"buildId":"5"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "1. Deploy [simpleRunner]\n")
+	assert.Contains(t, result, "  script.content: deploy.sh prod 42 100% %missing.param%\n")
+	assert.Contains(t, result, "    (configured as: deploy.sh %env.TARGET% %build.number% 100%% %missing.param%)\n")
+	assert.Contains(t, result, "  secure:password: *****\n")
+	assert.Contains(t, result, "  Unresolved references: missing.param\n")
+	assert.NotContains(t, result, "Old")
+}
+
+func TestManageBuildSteps(t *testing.T) {
+	var requests []string
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		if r.Method == "GET" {
+			w.Write([]byte(`{"step":[
+				{"id":"RUNNER_1","name":"Compile","type":"simpleRunner","properties":{"property":[{"name":"script.content","value":"make\nmake test"}]}},
+				{"id":"RUNNER_2","name":"Publish","type":"simpleRunner","disabled":true,"properties":{"property":[{"name":"secure:token","value":""}]}}]}`))
+		}
+	})
+
+	result, err := tc.ManageBuildSteps(context.Background(), json.RawMessage(`{"buildTypeId":"App_Build"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Build steps of App_Build (2):\n\n"+
+		"1. Compile [simpleRunner] (ID: RUNNER_1)\n  script.content:\n    make\n    make test\n\n"+
+		"2. Publish [simpleRunner] (ID: RUNNER_2) (disabled)\n  secure:token: *****\n\n", result)
+
+	requests = nil
+	result, err = tc.ManageBuildSteps(context.Background(), json.RawMessage(`{"action":"update","buildTypeId":"App_Build","stepId":"RUNNER_2",
+		"disabled":false,"properties":{"teamcity.step.mode":"execute_always","script.content":""}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /app/rest/buildTypes/id:App_Build/steps/RUNNER_2/parameters/script.content ",
+		"PUT /app/rest/buildTypes/id:App_Build/steps/RUNNER_2/parameters/teamcity.step.mode execute_always",
+		"PUT /app/rest/buildTypes/id:App_Build/steps/RUNNER_2/disabled false",
+	}, requests)
+	assert.Equal(t, "Build step RUNNER_2 of App_Build updated: script.content removed, teamcity.step.mode set, enabled", result)
+
+	// Replacing the steps would reset the secure property of RUNNER_2
+	requests = nil
+	_, err = tc.ManageBuildSteps(context.Background(), json.RawMessage(`{"action":"reorder","buildTypeId":"App_Build","stepIds":["RUNNER_2","RUNNER_1"]}`))
+	assert.EqualError(t, err, "build step RUNNER_2 has secure properties that reordering would reset; reorder the steps in the TeamCity UI")
+	assert.Len(t, requests, 1)
+
+	_, err = tc.ManageBuildSteps(context.Background(), json.RawMessage(`{"action":"reorder","buildTypeId":"App_Build","stepIds":["RUNNER_1"]}`))
+	assert.EqualError(t, err, "stepIds must list all 2 build steps of App_Build in the new order")
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+)
+
+func TestStructuredToolResults(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/builds":
+			w.Write([]byte(`{"count":1,"build":[{"id":7,"number":"12","status":"FAILURE","state":"finished",
+				"buildTypeId":"App_Build","buildType":{"name":"Build"},"branchName":"main","finishDate":"20241226T143022+0300"}]}`))
+		case "/app/rest/testOccurrences":
+			w.Write([]byte(`{"count":1,"testOccurrence":[{"id":"t1","name":"LoginTest","status":"FAILURE","duration":1500,"details":"expected 200"}]}`))
+		case "/app/rest/agents":
+			assert.Equal(t, "defaultFilter:false,connected:true,pool:(name:Linux)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"count":3,"agent":[{"id":7,"name":"linux-07","connected":true,"enabled":true,"authorized":true,"pool":{"id":2,"name":"Linux"},
+				"build":{"id":42,"number":"12","buildTypeId":"App_Build"}},{"id":8,"name":"linux-08","connected":true,"enabled":true,"authorized":true},
+				{"id":9,"name":"mac-09","connected":true,"enabled":true,"authorized":true}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+
+	// call runs a tool and returns the JSON-decoded result
+	call := func(name, args string) map[string]interface{} {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`))
+		require.NoError(t, err)
+
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		var decoded struct {
+			Result map[string]interface{} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.NotNil(t, decoded.Result)
+		return decoded.Result
+	}
+
+	t.Run("search_builds", func(t *testing.T) {
+		result := call("search_builds", `{"buildTypeId":"App_Build"}`)
+
+		content := result["content"].([]interface{})
+		assert.Contains(t, content[0].(map[string]interface{})["text"], "Build #12 (ID: 7)")
+
+		structured := result["structuredContent"].(map[string]interface{})
+		assert.Equal(t, float64(1), structured["count"])
+		build := structured["builds"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, float64(7), build["id"])
+		assert.Equal(t, "FAILURE", build["status"])
+		assert.Equal(t, "App_Build", build["buildTypeId"])
+		assert.Equal(t, "main", build["branch"])
+		assert.Equal(t, "20241226T143022+0300", build["finishDate"])
+	})
+
+	t.Run("get_test_results", func(t *testing.T) {
+		result := call("get_test_results", `{"buildId":"7","status":"FAILURE","includeDetails":true}`)
+
+		structured := result["structuredContent"].(map[string]interface{})
+		assert.Equal(t, "7", structured["buildId"])
+		test := structured["tests"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "LoginTest", test["name"])
+		assert.Equal(t, float64(1500), test["durationMs"])
+		assert.Equal(t, "expected 200", test["details"])
+	})
+
+	t.Run("search_agents", func(t *testing.T) {
+		result := call("search_agents", `{"connected":true,"pool":"Linux","name":"LINUX","count":1}`)
+
+		content := result["content"].([]interface{})
+		assert.Contains(t, content[0].(map[string]interface{})["text"], "- linux-07 (ID: 7)\n  Connected: true, Enabled: true, Authorized: true, Pool: Linux, Running: App_Build #12 (ID: 42)\n")
+
+		structured := result["structuredContent"].(map[string]interface{})
+		assert.Equal(t, float64(1), structured["count"])
+		assert.Equal(t, true, structured["hasMore"])
+		agent := structured["agents"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "linux-07", agent["name"])
+		assert.Equal(t, float64(2), agent["poolId"])
+		assert.Equal(t, float64(42), agent["runningBuildId"])
+	})
+
+	t.Run("text-only tools have no structured content", func(t *testing.T) {
+		result := call("get_current_time", `{}`)
+		assert.NotContains(t, result, "structuredContent")
+	})
+
+	t.Run("tools list declares output schemas", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+		require.NoError(t, err)
+
+		tools := resp.(map[string]interface{})["result"].(map[string]interface{})["tools"].([]map[string]interface{})
+		withSchema := make([]string, 0)
+		for _, tool := range tools {
+			if _, ok := tool["outputSchema"]; ok {
+				withSchema = append(withSchema, tool["name"].(string))
+			}
+		}
+		assert.ElementsMatch(t, []string{"search_builds", "search_build_configurations", "get_test_results", "search_agents"}, withSchema)
+	})
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/auth"
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestResourceSubscriptions(t *testing.T) {
+	var status atomic.Value
+	status.Store("RUNNING")
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/rest/builds/id:42", r.URL.Path)
+		w.Write([]byte(`{"id":42,"state":"` + status.Load().(string) + `"}`))
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+	handler.SetPollInterval(10 * time.Millisecond)
+
+	t.Run("requires a session", func(t *testing.T) {
+		resp, err := handler.HandleRequest(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"teamcity://builds/42"}}`))
+		require.NoError(t, err)
+		rpcErr := resp.(map[string]interface{})["error"].(map[string]interface{})
+		assert.Equal(t, "subscriptions require a WebSocket, SSE or STDIO connection or an HTTP session", rpcErr["data"])
+	})
+
+	t.Run("notifies on change", func(t *testing.T) {
+		notifications := make(chan interface{}, 10)
+		session := mcp.NewSession(func(notification interface{}) error {
+			notifications <- notification
+			return nil
+		})
+		defer handler.CloseSession(session)
+		ctx := mcp.WithSession(context.Background(), session)
+
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"teamcity://builds/42"}}`))
+		require.NoError(t, err)
+		require.Contains(t, resp.(map[string]interface{}), "result")
+
+		// Unchanged content does not notify
+		select {
+		case n := <-notifications:
+			t.Fatalf("unexpected notification: %v", n)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		status.Store("FINISHED")
+		select {
+		case n := <-notifications:
+			assert.Equal(t, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "notifications/resources/updated",
+				"params":  map[string]interface{}{"uri": "teamcity://builds/42"},
+			}, n)
+		case <-time.After(time.Second):
+			t.Fatal("no notification after the build changed")
+		}
+
+		_, err = handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"teamcity://builds/42"}}`))
+		require.NoError(t, err)
+
+		status.Store("RUNNING")
+		select {
+		case n := <-notifications:
+			t.Fatalf("unexpected notification after unsubscribe: %v", n)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestResourceSubscriptionsUseSubscriberAccess(t *testing.T) {
+	var status, project, authorization atomic.Value
+	status.Store("RUNNING")
+	project.Store("Payments")
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		if r.URL.Query().Get("fields") == "buildType(projectId)" {
+			w.Write([]byte(`{"buildType":{"projectId":"` + project.Load().(string) + `"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":42,"state":"` + status.Load().(string) + `"}`))
+	})
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	handler := mcp.NewHandler(tc, c, zaptest.NewLogger(t).Sugar())
+	handler.SetPollInterval(10 * time.Millisecond)
+	handler.SetPolicy(writePolicy(t, `{"rules":[{"subjects":["payments"],"projects":["Payments"]}]}`))
+
+	notifications := make(chan interface{}, 10)
+	session := mcp.NewSession(func(notification interface{}) error {
+		notifications <- notification
+		return nil
+	})
+	defer handler.CloseSession(session)
+	ctx := mcp.WithSession(context.Background(), session)
+	ctx = auth.WithIdentity(ctx, &auth.Identity{Subject: "payments", Method: auth.MethodHMAC})
+	ctx = teamcity.WithToken(ctx, "user-token")
+
+	resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"teamcity://builds/42"}}`))
+	require.NoError(t, err)
+	require.Contains(t, resp.(map[string]interface{}), "result")
+
+	// Polling uses the subscriber's token and grant
+	authorization.Store("")
+	status.Store("FINISHED")
+	select {
+	case <-notifications:
+	case <-time.After(time.Second):
+		t.Fatal("no notification after the build changed")
+	}
+	assert.Equal(t, "Bearer user-token", authorization.Load())
+
+	// Once the build no longer belongs to a granted project the subscriber is refused
+	project.Store("Billing")
+	status.Store("RUNNING")
+	select {
+	case n := <-notifications:
+		t.Fatalf("unexpected notification for a build outside the grant: %v", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageTemplates(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch {
+		case r.Method == "GET" && locator == "affectedProject:(id:Shop),templateFlag:true":
+			w.Write([]byte(`{"buildType":[{"id":"Shop_GoService","name":"Go Service","projectId":"Shop"}]}`))
+		case r.Method == "GET" && locator == "template:(id:Shop_GoService)":
+			w.Write([]byte(`{"buildType":[{"id":"Shop_Payments_Build","name":"Build"}]}`))
+		case r.Method == "POST" && r.URL.Path == "/app/rest/buildTypes/id:Shop_Payments_Build/templates":
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"id":"Shop_GoService"}`, string(body))
+			w.Write([]byte(`{"id":"Shop_GoService"}`))
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.ManageTemplates(context.Background(), json.RawMessage(`{"projectId":"Shop"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Templates in project Shop and its subprojects (1):\n\n"+
+		"Go Service (Shop_GoService) in project Shop, used by 1 build configurations\n  - Build (Shop_Payments_Build)\n", result)
+
+	// A configuration that cannot be changed does not stop the others
+	result, err = tc.ManageTemplates(context.Background(), json.RawMessage(`{"action":"attach","templateId":"Shop_GoService","buildTypeIds":["Shop_Payments_Build","Shop_Missing"]}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Template Shop_GoService attached to 1 of 2 build configurations\n  - Shop_Payments_Build\n\nFailed (1):\n  - Shop_Missing: ")
+
+	_, err = tc.ManageTemplates(context.Background(), json.RawMessage(`{"action":"detach","buildTypeId":"Shop_Payments_Build"}`))
+	assert.EqualError(t, err, "templateId is required for detach action")
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func TestSearchTests(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/tests":
+			assert.Equal(t, "affectedProject:(id:App),name:(value:(Login Test),matchType:contains,ignoreCase:true),count:10", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"test":[{"id":"-100","name":"com.example.LoginTest.testLogin"}]}`))
+		case "/app/rest/testOccurrences":
+			assert.Contains(t, r.URL.Query().Get("locator"), "test:(id:-100)")
+			w.Write([]byte(`{"testOccurrence":[
+				{"status":"FAILURE","duration":1500,"build":{"id":3,"number":"3","buildTypeId":"App_Test","buildType":{"name":"Tests"}}},
+				{"status":"SUCCESS","duration":500,"build":{"id":2,"number":"2","buildTypeId":"App_Test","buildType":{"name":"Tests"}}}]}`))
+		}
+	})
+
+	result, err := tc.SearchTests(context.Background(), json.RawMessage(`{"projectId":"App","name":"Login Test"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "com.example.LoginTest.testLogin\n  Current Status: FAILURE (build #3, ID: 3)\n")
+	assert.Contains(t, result, "Runs In: Tests (App_Test)")
+	assert.Contains(t, result, "Recent Runs: 2 (1 failed)")
+	assert.Contains(t, result, "Recent Durations: 1.50 s, 500 ms (avg 1.00 s)")
+
+	_, err = tc.SearchTests(context.Background(), json.RawMessage(`{"projectId":"App"}`))
+	assert.EqualError(t, err, "name is required")
+}
+
+func TestGetTestResults(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		locator := r.URL.Query().Get("locator")
+		switch locator {
+		case "build:(id:7),start:0,count:2":
+			assert.NotContains(t, r.URL.Query().Get("fields"), "details")
+			w.Write([]byte(`{"count":2,"nextHref":"/app/rest/testOccurrences?locator=build:(id:7),start:2,count:2","testOccurrence":[
+				{"id":"t1","name":"LoginTest","status":"SUCCESS","duration":200},
+				{"id":"t2","name":"LogoutTest","status":"FAILURE","duration":1500,"muted":true}]}`))
+		case "build:(id:7),start:2,count:2":
+			w.Write([]byte(`{"count":1,"testOccurrence":[{"id":"t3","name":"SlowTest","status":"UNKNOWN","ignored":true}]}`))
+		case "build:(id:7),ignored:true,start:0,count:100":
+			assert.Contains(t, r.URL.Query().Get("fields"), "details")
+			w.Write([]byte(`{"count":0}`))
+		default:
+			t.Errorf("unexpected locator: %s", locator)
+		}
+	})
+
+	result, err := tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","count":2}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "Found 2 test(s) for build 7, showing tests 1-2:\n\nFAILURE (1):\n  - LogoutTest (1.50 s) [MUTED]\n\nSUCCESS (1):\n  - LoginTest (200 ms)\n")
+	assert.Contains(t, result.Text, "call again with start=2 to see the next page")
+	assert.True(t, result.Data.(teamcity.TestResults).HasMore)
+
+	result, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","count":2,"start":2}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "showing tests 3-3:\n\nIGNORED (1):\n  - SlowTest\n")
+	assert.NotContains(t, result.Text, "next page")
+
+	result, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","status":"ignored","includeDetails":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "No tests found for build 7 with status: ignored.", result.Text)
+
+	_, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","start":-1}`))
+	assert.EqualError(t, err, "start must not be negative")
+}
+
+func TestGetTestHistory(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/tests":
+			assert.Equal(t, "name:(value:(LoginTest),matchType:equals),count:1", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"test":[{"id":"-100","name":"LoginTest"}]}`))
+		case "/app/rest/testOccurrences":
+			assert.Equal(t, "test:(id:-100),buildType:(id:App_Test),count:20", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"testOccurrence":[
+				{"status":"FAILURE","duration":1500,"build":{"id":3,"number":"3","branchName":"main"}},
+				{"status":"FAILURE","duration":1400,"muted":true,"build":{"id":2,"number":"2"}},
+				{"status":"SUCCESS","duration":500,"build":{"id":1,"number":"1"}}]}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	})
+
+	result, err := tc.GetTestHistory(context.Background(), json.RawMessage(`{"buildTypeId":"App_Test","testName":"LoginTest"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "History of LoginTest in App_Test (last 3 runs, newest first):\n")
+	assert.Contains(t, result, "  #3 (ID: 3): FAILURE, 1.50 s [main]\n  #2 (ID: 2): FAILURE, 1.40 s [MUTED]\n  #1 (ID: 1): SUCCESS, 500 ms\n")
+	assert.Contains(t, result, "Failed in 2 of 3 runs, avg duration 1.13 s")
+	assert.Contains(t, result, "Verdict: new regression; failing since build #2 (ID: 2) after passing in build #1 (ID: 1)")
+
+	_, err = tc.GetTestHistory(context.Background(), json.RawMessage(`{"buildTypeId":"App_Test"}`))
+	assert.EqualError(t, err, "exactly one of testName or testId is required")
+}
+
+func TestGetTestResultsStacktrace(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":1,"testOccurrence":[{"id":"t1","name":"LoginTest","status":"FAILURE","duration":20,
+			"details":"expected 200 but was 500\n\tat org.junit.Assert.fail\n\tat com.example.LoginTest.login\n\tat com.example.Client.post\n\tat java.base/Thread.run\n"}]}`))
+	})
+
+	result, err := tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","includeDetails":true,"stacktraceFilter":"com.example","maxStacktraceLines":1}`))
+	require.NoError(t, err)
+	details := "expected 200 but was 500\n\tat com.example.LoginTest.login\n... 3 more lines (showing lines containing \"com.example\")"
+	assert.Equal(t, details, result.Data.(teamcity.TestResults).Tests[0].Details)
+	assert.Contains(t, result.Text, "  - LoginTest (20 ms)\n    expected 200 but was 500\n    \tat com.example.LoginTest.login\n    ... 3 more lines")
+
+	result, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","includeDetails":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "\tat java.base/Thread.run\n")
+	assert.NotContains(t, result.Text, "more lines")
+}
+
+func TestCompareTestResults(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("locator") {
+		case "build:(id:1),count:10000":
+			w.Write([]byte(`{"testOccurrence":[
+				{"name":"A","status":"SUCCESS"},{"name":"B","status":"FAILURE"},{"name":"C","status":"SUCCESS"},
+				{"name":"D","status":"FAILURE"},{"name":"E","status":"SUCCESS"}]}`))
+		case "build:(id:2),count:10000":
+			w.Write([]byte(`{"testOccurrence":[
+				{"name":"A","status":"FAILURE"},{"name":"B","status":"SUCCESS"},{"name":"C","status":"UNKNOWN","ignored":true},
+				{"name":"D","status":"FAILURE"},{"name":"F","status":"SUCCESS"}]}`))
+		default:
+			t.Errorf("unexpected locator: %s", r.URL.Query().Get("locator"))
+		}
+	})
+
+	result, err := tc.CompareTestResults(context.Background(), json.RawMessage(`{"fromBuildId":"1","toBuildId":"2"}`))
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "Tests of build 2 compared to build 1 (5 vs 5 tests): 1 newly failed, 1 fixed, 1 newly ignored, 1 added, 1 removed\n")
+	assert.Contains(t, result, "Newly failed (1):\n  - A (was SUCCESS)\n")
+	assert.Contains(t, result, "Fixed (1):\n  - B\n")
+	assert.Contains(t, result, "Newly ignored (1):\n  - C (was SUCCESS)\n")
+	assert.Contains(t, result, "Added (1):\n  - F (SUCCESS)\n")
+	assert.Contains(t, result, "Removed (1):\n  - E (was SUCCESS)\n")
+	assert.Contains(t, result, "Still failing: 1 tests failed in both builds")
+
+	_, err = tc.CompareTestResults(context.Background(), json.RawMessage(`{"fromBuildId":"1"}`))
+	assert.EqualError(t, err, "fromBuildId and toBuildId are required")
+}
+
+func TestGetTestResultsNewFailures(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch locator := r.URL.Query().Get("locator"); locator {
+		case "build:(id:7),newFailure:true,start:0,count:100":
+			w.Write([]byte(`{"count":1,"testOccurrence":[{"id":"t1","name":"LoginTest","status":"FAILURE","newFailure":true}]}`))
+		case "build:(id:7),status:FAILURE,start:0,count:100":
+			w.Write([]byte(`{"count":2,"testOccurrence":[{"id":"t1","name":"LoginTest","status":"FAILURE","newFailure":true},
+				{"id":"t2","name":"OldTest","status":"FAILURE","firstFailed":{"build":{"id":3,"number":"41"}}}]}`))
+		case "build:(id:7),currentlyFailing:true,start:0,count:100":
+			w.Write([]byte(`{"count":0}`))
+		default:
+			t.Errorf("unexpected locator: %s", locator)
+		}
+	})
+
+	result, err := tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","newFailuresOnly":true}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "Found 1 test(s) for build 7 (new failures only):\n\nFAILURE (1):\n  - LoginTest [NEW]\n")
+	assert.True(t, result.Data.(teamcity.TestResults).Tests[0].NewFailure)
+
+	result, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","status":"FAILURE"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result.Text, "  - OldTest (failing since build #41, ID: 3)\n")
+
+	result, err = tc.GetTestResults(context.Background(), json.RawMessage(`{"buildId":"7","currentlyFailing":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "No tests found for build 7 with currently failing.", result.Text)
+}
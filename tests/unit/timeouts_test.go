@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/health"
+	"github.com/itcaat/teamcity-mcp/internal/server"
+)
+
+func TestHTTPServerTimeoutsConfig(t *testing.T) {
+	t.Setenv("TC_URL", "https://teamcity.example.com")
+	t.Setenv("TC_TOKEN", "test-token")
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := config.Load()
+		require.NoError(t, err)
+
+		s, err := server.New(cfg, zaptest.NewLogger(t).Sugar(), health.BuildInfo{})
+		require.NoError(t, err)
+		srv := s.HTTPServer(http.NotFoundHandler())
+
+		assert.Equal(t, 10*time.Second, srv.ReadHeaderTimeout)
+		assert.Equal(t, 60*time.Second, srv.ReadTimeout)
+		assert.Equal(t, 5*time.Minute, srv.WriteTimeout)
+		assert.Equal(t, 120*time.Second, srv.IdleTimeout)
+	})
+
+	t.Run("configured values are applied", func(t *testing.T) {
+		t.Setenv("HTTP_READ_HEADER_TIMEOUT", "2s")
+		t.Setenv("HTTP_READ_TIMEOUT", "15s")
+		t.Setenv("HTTP_WRITE_TIMEOUT", "0")
+		t.Setenv("HTTP_IDLE_TIMEOUT", "1m30s")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+
+		s, err := server.New(cfg, zaptest.NewLogger(t).Sugar(), health.BuildInfo{})
+		require.NoError(t, err)
+		srv := s.HTTPServer(http.NotFoundHandler())
+
+		assert.Equal(t, 2*time.Second, srv.ReadHeaderTimeout)
+		assert.Equal(t, 15*time.Second, srv.ReadTimeout)
+		assert.Zero(t, srv.WriteTimeout, "0 disables the timeout")
+		assert.Equal(t, 90*time.Second, srv.IdleTimeout)
+	})
+
+	t.Run("invalid values are rejected", func(t *testing.T) {
+		for _, name := range []string{"HTTP_READ_HEADER_TIMEOUT", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT"} {
+			for _, value := range []string{"soon", "-1s"} {
+				t.Setenv(name, value)
+				_, err := config.Load()
+				assert.ErrorContains(t, err, "invalid "+name, "%s=%s", name, value)
+			}
+			t.Setenv(name, "1s")
+		}
+	})
+}
+
+func TestHTTPServerReadHeaderTimeout(t *testing.T) {
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {}, func(cfg *config.ServerConfig) {
+		cfg.ReadHeaderTimeout = "200ms"
+	})
+	handler, err := s.Handler(context.Background(), "http")
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Config = s.HTTPServer(handler)
+	ts.Start()
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// A client that never finishes its headers is disconnected after the read header timeout
+	_, err = conn.Write([]byte("POST /mcp HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	require.Error(t, err)
+	var netErr net.Error
+	assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "the server must close the connection: %v", err)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListVCSRoots(t *testing.T) {
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/rest/vcs-roots":
+			assert.Equal(t, "affectedProject:(id:Shop)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"vcs-root":[
+				{"id":"Shop_Api","name":"API","vcsName":"jetbrains.git","project":{"id":"Shop"},"properties":{"property":[
+					{"name":"url","value":"https://git.old.example.com/shop/api.git"},{"name":"branch","value":"refs/heads/main"},
+					{"name":"authMethod","value":"PASSWORD"},{"name":"username","value":"ci"},{"name":"secure:password","value":""}]}},
+				{"id":"Shop_Web","name":"Web","vcsName":"jetbrains.git","project":{"id":"Shop"},"properties":{"property":[
+					{"name":"url","value":"https://git.example.com/shop/web.git"}]}},
+				{"id":"Shop_Legacy","name":"Legacy","vcsName":"svn","project":{"id":"Shop"},"properties":{"property":[
+					{"name":"url","value":"svn://git.old.example.com/legacy"}]}}]}`))
+		case "/app/rest/buildTypes":
+			assert.Equal(t, "vcsRoot:(id:Shop_Api)", r.URL.Query().Get("locator"))
+			w.Write([]byte(`{"buildType":[{"id":"Shop_Api_Build","name":"Build"}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.ListVCSRoots(context.Background(), json.RawMessage(`{"projectId":"Shop","type":"git","url":"GIT.OLD","includeUsage":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Found 1 VCS roots:\n\n"+
+		"API (Shop_Api)\n  Type: jetbrains.git\n  Project: Shop\n  URL: https://git.old.example.com/shop/api.git\n"+
+		"  Default branch: refs/heads/main\n  Auth method: PASSWORD\n  Username: ci\n"+
+		"  Used by: 1\n    - Build (Shop_Api_Build)\n\n", result)
+
+	result, err = tc.ListVCSRoots(context.Background(), json.RawMessage(`{"projectId":"Shop","count":1}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "Found 1 VCS roots:")
+	assert.Contains(t, result, "Only the first 1 VCS roots are shown")
+	assert.NotContains(t, result, "Used by")
+}
+
+func TestManageVCSRoots(t *testing.T) {
+	var created string
+	updated := map[string]string{}
+	var deletedProperty, attached string
+	detached := false
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/app/rest/vcs-roots":
+			created = string(body)
+			w.Write([]byte(`{"id":"Shop_Api","name":"API","vcsName":"jetbrains.git"}`))
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/app/rest/vcs-roots/id:Shop_Api/"):
+			updated[strings.TrimPrefix(r.URL.Path, "/app/rest/vcs-roots/id:Shop_Api/")] = string(body)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/app/rest/vcs-roots/id:Shop_Api/properties/"):
+			deletedProperty = strings.TrimPrefix(r.URL.Path, "/app/rest/vcs-roots/id:Shop_Api/properties/")
+		case r.Method == "POST" && r.URL.Path == "/app/rest/buildTypes/id:Shop_Api_Build/vcs-root-entries":
+			attached = string(body)
+			w.Write([]byte(`{"id":"Shop_Api"}`))
+		case r.Method == "DELETE" && r.URL.Path == "/app/rest/buildTypes/id:Shop_Api_Build/vcs-root-entries/Shop_Old":
+			detached = true
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	result, err := tc.ManageVCSRoots(ctx, json.RawMessage(`{"action":"create","projectId":"Shop","name":"API","url":"https://git.example.com/shop/api.git","branch":"refs/heads/main"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "VCS root API (Shop_Api) created in Shop", result)
+	var root struct {
+		Name       string              `json:"name"`
+		VcsName    string              `json:"vcsName"`
+		Project    map[string]string   `json:"project"`
+		Properties teamcity.Properties `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(created), &root))
+	assert.Equal(t, "jetbrains.git", root.VcsName)
+	assert.Equal(t, map[string]string{"id": "Shop"}, root.Project)
+	assert.Equal(t, teamcity.Properties{"url": "https://git.example.com/shop/api.git", "branch": "refs/heads/main"}, root.Properties)
+
+	result, err = tc.ManageVCSRoots(ctx, json.RawMessage(`{"action":"update","vcsRootId":"Shop_Api","name":"API (new server)","url":"git@git.example.com:shop/api.git","properties":{"secure:password":""}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "VCS root Shop_Api updated: name, secure:password, url", result)
+	assert.Equal(t, map[string]string{"name": "API (new server)", "properties/url": "git@git.example.com:shop/api.git"}, updated)
+	assert.Equal(t, "secure:password", deletedProperty)
+
+	result, err = tc.ManageVCSRoots(ctx, json.RawMessage(`{"action":"attach","buildTypeId":"Shop_Api_Build","vcsRootId":"Shop_Api","checkoutRules":"+:src"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"Shop_Api","vcs-root":{"id":"Shop_Api"},"checkout-rules":"+:src"}`, attached)
+	assert.Equal(t, "VCS root Shop_Api attached to Shop_Api_Build with checkout rules:\n+:src", result)
+
+	_, err = tc.ManageVCSRoots(ctx, json.RawMessage(`{"action":"detach","buildTypeId":"Shop_Api_Build","vcsRootId":"Shop_Old"}`))
+	require.NoError(t, err)
+	assert.True(t, detached)
+
+	_, err = tc.ManageVCSRoots(ctx, json.RawMessage(`{"action":"update","vcsRootId":"Shop_Api"}`))
+	assert.EqualError(t, err, "name, properties or a VCS root setting is required for update action")
+}
+
+func TestCheckVCSRoot(t *testing.T) {
+	notified := false
+	tc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/app/rest/vcs-root-instances/commitHookNotification":
+			assert.Equal(t, "vcsRoot:(id:Shop_Api)", r.URL.Query().Get("locator"))
+			notified = true
+			w.Write([]byte("Scheduled checking for changes for 1 VCS root instances"))
+		case r.Method == "GET" && r.URL.Path == "/app/rest/vcs-root-instances":
+			if r.URL.Query().Get("locator") == "vcsRoot:(id:Shop_Unused)" {
+				w.Write([]byte(`{"count":0}`))
+				return
+			}
+			w.Write([]byte(`{"vcs-root-instance":[
+				{"id":"101","name":"API","lastVersion":"abc123","commitHookMode":true,
+				 "status":{"current":{"status":"scheduled"},"previous":{"status":"finished","timestamp":"20250102T150405+0000"}}},
+				{"id":"102","name":"API (fork)","status":{"current":{"status":"finished"}}}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	result, err := tc.CheckVCSRoot(context.Background(), json.RawMessage(`{"vcsRootId":"Shop_Api","action":"commitHook"}`))
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Contains(t, result, "Commit hook notification sent for VCS root Shop_Api")
+	assert.Contains(t, result, "API (instance 101)\n  Checking for changes: scheduled\n  Previous check: finished at ")
+	assert.Contains(t, result, "  Last revision: abc123\n  Commit hooks: enabled, polling less often\n")
+	assert.Contains(t, result, "API (fork) (instance 102)\n  Checking for changes: finished\n  Last revision: none; TeamCity has not reached the repository yet\n")
+
+	result, err = tc.CheckVCSRoot(context.Background(), json.RawMessage(`{"vcsRootId":"Shop_Unused"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "VCS root Shop_Unused is not used by any build configuration, so TeamCity does not check it for changes.", result)
+}
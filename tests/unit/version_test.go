@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/itcaat/teamcity-mcp/internal/cache"
+	"github.com/itcaat/teamcity-mcp/internal/config"
+	"github.com/itcaat/teamcity-mcp/internal/mcp"
+	"github.com/itcaat/teamcity-mcp/internal/teamcity"
+)
+
+func newVersionTestHandler(t *testing.T) *mcp.Handler {
+	logger := zaptest.NewLogger(t).Sugar()
+	tc, err := teamcity.NewClient(config.TeamCityConfig{URL: "http://localhost:8111", Token: "test-token", Timeout: "5s"}, logger)
+	require.NoError(t, err)
+	c, err := cache.New(config.CacheConfig{TTL: "10s"})
+	require.NoError(t, err)
+	return mcp.NewHandler(tc, c, logger)
+}
+
+func TestProtocolVersionNegotiation(t *testing.T) {
+	handler := newVersionTestHandler(t)
+
+	initialize := func(ctx context.Context, version string) map[string]interface{} {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+version+`"}}`))
+		require.NoError(t, err)
+		return resp.(map[string]interface{})
+	}
+
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{requested: "2024-11-05", expected: "2024-11-05"},
+		{requested: "2025-03-26", expected: "2025-03-26"},
+		{requested: "2025-06-18", expected: "2025-06-18"},
+		{requested: "2030-01-01", expected: "2025-06-18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			result := initialize(context.Background(), tt.requested)["result"].(map[string]interface{})
+			assert.Equal(t, tt.expected, result["protocolVersion"])
+		})
+	}
+
+	for _, version := range []string{"2024-01-01", "1.0.0"} {
+		t.Run("rejects "+version, func(t *testing.T) {
+			rpcErr := initialize(context.Background(), version)["error"].(map[string]interface{})
+			assert.Equal(t, -32602, rpcErr["code"])
+			assert.Equal(t, "Unsupported protocol version", rpcErr["message"])
+			data := rpcErr["data"].(map[string]interface{})
+			assert.Equal(t, version, data["requested"])
+			assert.Contains(t, data["supported"], "2024-11-05")
+		})
+	}
+
+	t.Run("session remembers negotiated version", func(t *testing.T) {
+		session := mcp.NewSession(func(interface{}) error { return nil })
+		ctx := mcp.WithSession(context.Background(), session)
+		initialize(ctx, "2025-03-26")
+		assert.Equal(t, "2025-03-26", session.ProtocolVersion())
+	})
+}
+
+func TestProtocolVersionGatesFeatures(t *testing.T) {
+	handler := newVersionTestHandler(t)
+
+	// searchBuildsTool returns the search_builds definition from tools/list
+	searchBuildsTool := func(ctx context.Context) map[string]interface{} {
+		resp, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+		require.NoError(t, err)
+		for _, tool := range resp.(map[string]interface{})["result"].(map[string]interface{})["tools"].([]map[string]interface{}) {
+			if tool["name"] == "search_builds" {
+				return tool
+			}
+		}
+		t.Fatal("search_builds not listed")
+		return nil
+	}
+
+	tests := []struct {
+		version        string
+		hasAnnotations bool
+		hasSchema      bool
+	}{
+		{version: "2024-11-05", hasAnnotations: false, hasSchema: false},
+		{version: "2025-03-26", hasAnnotations: true, hasSchema: false},
+		{version: "2025-06-18", hasAnnotations: true, hasSchema: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			session := mcp.NewSession(func(interface{}) error { return nil })
+			ctx := mcp.WithSession(context.Background(), session)
+			_, err := handler.HandleRequest(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+tt.version+`"}}`))
+			require.NoError(t, err)
+
+			tool := searchBuildsTool(ctx)
+			assert.Equal(t, tt.hasAnnotations, tool["annotations"] != nil)
+			assert.Equal(t, tt.hasSchema, tool["outputSchema"] != nil)
+		})
+	}
+
+	t.Run("requests without session use the attached version", func(t *testing.T) {
+		tool := searchBuildsTool(mcp.WithProtocolVersion(context.Background(), mcp.HTTPDefaultProtocolVersion))
+		assert.NotNil(t, tool["annotations"])
+		assert.Nil(t, tool["outputSchema"])
+	})
+}
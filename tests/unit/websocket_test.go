@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/itcaat/teamcity-mcp/internal/config"
+)
+
+// dialWebSocket serves the HTTP transport of a test server and opens a WebSocket connection to it
+func dialWebSocket(t *testing.T, configure func(*config.ServerConfig)) *websocket.Conn {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {}, configure)
+	handler, err := s.Handler(ctx, "http")
+	require.NoError(t, err)
+
+	// Hijacked connections are not awaited by the test server; wait for their handlers so
+	// they do not log after the test has completed
+	var handlers sync.WaitGroup
+	t.Cleanup(handlers.Wait)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/mcp", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWebSocketIdleTimeout(t *testing.T) {
+	configure := func(cfg *config.ServerConfig) {
+		cfg.WSPingInterval = "50ms"
+		cfg.WSIdleTimeout = "300ms"
+	}
+
+	t.Run("connection not answering pings is closed", func(t *testing.T) {
+		conn := dialWebSocket(t, configure)
+
+		// Ignore the pings instead of answering them with pongs
+		conn.SetPingHandler(func(string) error { return nil })
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, _, err := conn.ReadMessage()
+		require.Error(t, err)
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+		assert.Equal(t, "idle timeout", closeErr.Text)
+	})
+
+	t.Run("connection answering pings stays open", func(t *testing.T) {
+		conn := dialWebSocket(t, configure)
+
+		var pings atomic.Int32
+		conn.SetPingHandler(func(data string) error {
+			pings.Add(1)
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+
+		// Reading answers the pings; the read loop ends when the connection is closed
+		messages := make(chan map[string]interface{})
+		readErr := make(chan error, 1)
+		go func() {
+			for {
+				var message map[string]interface{}
+				if err := conn.ReadJSON(&message); err != nil {
+					readErr <- err
+					return
+				}
+				messages <- message
+			}
+		}()
+
+		select {
+		case err := <-readErr:
+			t.Fatalf("connection closed while answering pings: %v", err)
+		case <-time.After(600 * time.Millisecond):
+		}
+		assert.Greater(t, pings.Load(), int32(2))
+
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "ping"}))
+		select {
+		case message := <-messages:
+			assert.Equal(t, float64(1), message["id"])
+			assert.Contains(t, message, "result")
+		case err := <-readErr:
+			t.Fatalf("connection closed while answering pings: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no response after the idle timeout")
+		}
+	})
+}